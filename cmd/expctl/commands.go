@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
+	"expense_tracker/internal/model"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// newFlagSet returns a flag set for a subcommand that prints its synopsis on -h.
+func newFlagSet(name, synopsis string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: expctl %s %s\n", name, synopsis)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses args with fs, allowing flags after positional arguments
+// as in "expctl add 12.50 food lunch -date 2024-03-01", and returns the
+// positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// dateFlag is an optional YYYY-MM-DD flag value.
+type dateFlag struct {
+	date *model.Date
+}
+
+func (f *dateFlag) String() string {
+	if f.date == nil {
+		return ""
+	}
+	return f.date.Format(time.DateOnly)
+}
+
+func (f *dateFlag) Set(s string) error {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return fmt.Errorf("invalid date %q, use YYYY-MM-DD", s)
+	}
+	f.date = &model.Date{Time: t}
+	return nil
+}
+
+// periodFlags registers -from and -to, which must be used together.
+func periodFlags(fs *flag.FlagSet) (from, to *dateFlag) {
+	from, to = &dateFlag{}, &dateFlag{}
+	fs.Var(from, "from", "first day, YYYY-MM-DD (requires -to)")
+	fs.Var(to, "to", "last day, YYYY-MM-DD (requires -from)")
+	return from, to
+}
+
+func checkPeriod(from, to *dateFlag) error {
+	if (from.date == nil) != (to.date == nil) {
+		return errors.New("-from and -to must be used together")
+	}
+	return nil
+}
+
+// tagsFlag collects repeated -tag flags.
+type tagsFlag []string
+
+func (f *tagsFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *tagsFlag) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
+
+func (c *cli) login(args []string) error {
+	fs := newFlagSet("login", "[-u USER]")
+	username := fs.String("u", c.cfg.Username, "username")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	if *username == "" {
+		fmt.Fprint(os.Stderr, "Username: ")
+		line, err := in.ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("can't read username: %w", err)
+		}
+		*username = strings.TrimSpace(line)
+	}
+
+	password, err := readPassword(in)
+	if err != nil {
+		return err
+	}
+
+	token, err := c.client.Login(context.Background(), *username, password)
+	if err != nil {
+		return err
+	}
+
+	c.cfg.Username, c.cfg.Token = *username, token
+	if err := c.cfg.save(c.path); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Logged in to %s as %s.\n", c.cfg.Server, *username)
+	return nil
+}
+
+// readPassword takes the password from EXPCTL_PASSWORD, the terminal without
+// echo, or the first line of a piped stdin, in that order.
+func readPassword(in *bufio.Reader) (string, error) {
+	if password, ok := os.LookupEnv("EXPCTL_PASSWORD"); ok {
+		return password, nil
+	}
+
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, "Password: ")
+		password, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("can't read password: %w", err)
+		}
+		return string(password), nil
+	}
+
+	line, err := in.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("can't read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (c *cli) logout(args []string) error {
+	fs := newFlagSet("logout", "")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	c.cfg.Token = ""
+	return c.cfg.save(c.path)
+}
+
+func (c *cli) add(args []string) error {
+	fs := newFlagSet("add", "AMOUNT CATEGORY [DESCRIPTION...] [-date YYYY-MM-DD] [-tag TAG]...")
+	date := &dateFlag{}
+	fs.Var(date, "date", "day of the expense, YYYY-MM-DD (default today)")
+	var tags tagsFlag
+	fs.Var(&tags, "tag", "tag the expense (repeatable)")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 2 {
+		fs.Usage()
+		return errors.New("add requires an amount and a category")
+	}
+
+	amount, err := strconv.ParseFloat(positional[0], 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount %q", positional[0])
+	}
+	expense := model.Expense{
+		Amount:      amount,
+		Category:    positional[1],
+		Description: strings.Join(positional[2:], " "),
+		Date:        model.Date{Time: today()},
+		Tags:        tags,
+	}
+	if date.date != nil {
+		expense.Date = *date.date
+	}
+
+	created, err := c.client.CreateExpense(context.Background(), expense)
+	if err != nil {
+		return err
+	}
+	return c.out.expense(created)
+}
+
+func (c *cli) list(args []string) error {
+	fs := newFlagSet("list", "[-category CATEGORY] [-from YYYY-MM-DD -to YYYY-MM-DD]")
+	category := fs.String("category", "", "only list this category")
+	from, to := periodFlags(fs)
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if err := checkPeriod(from, to); err != nil {
+		return err
+	}
+
+	expenses, err := c.listExpenses(*category, from.date, to.date)
+	if err != nil {
+		return err
+	}
+	return c.out.expenses(expenses)
+}
+
+func (c *cli) edit(args []string) error {
+	fs := newFlagSet("edit", "ID [-amount AMOUNT] [-category CATEGORY] [-description TEXT] [-date YYYY-MM-DD] [-tags A,B]")
+	amount := fs.Float64("amount", 0, "new amount")
+	category := fs.String("category", "", "new category")
+	description := fs.String("description", "", "new description")
+	date := &dateFlag{}
+	fs.Var(date, "date", "new day, YYYY-MM-DD")
+	tags := fs.String("tags", "", `new comma-separated tags, "" removes all`)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return errors.New("edit requires exactly one expense ID")
+	}
+	id, err := strconv.Atoi(positional[0])
+	if err != nil {
+		return fmt.Errorf("invalid expense ID %q", positional[0])
+	}
+
+	// Only the flags given on the command line are sent.
+	var input model.UpdateExpenseInput
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "amount":
+			input.Amount = amount
+		case "category":
+			input.Category = category
+		case "description":
+			input.Description = description
+		case "date":
+			input.Date = date.date
+		case "tags":
+			list := []string{}
+			for _, tag := range strings.Split(*tags, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					list = append(list, tag)
+				}
+			}
+			input.Tags = &list
+		}
+	})
+	if input == (model.UpdateExpenseInput{}) {
+		return errors.New("edit requires at least one field to change")
+	}
+
+	updated, err := c.client.UpdateExpense(context.Background(), id, input)
+	if err != nil {
+		return err
+	}
+	return c.out.expense(updated)
+}
+
+func (c *cli) delete(args []string) error {
+	fs := newFlagSet("delete", "ID...")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		fs.Usage()
+		return errors.New("delete requires at least one expense ID")
+	}
+
+	ids := make([]int, len(positional))
+	for i, arg := range positional {
+		if ids[i], err = strconv.Atoi(arg); err != nil {
+			return fmt.Errorf("invalid expense ID %q", arg)
+		}
+	}
+	for _, id := range ids {
+		if err := c.client.DeleteExpense(context.Background(), id); err != nil {
+			return fmt.Errorf("expense %d: %s", id, errorMessage(err))
+		}
+		fmt.Fprintf(os.Stderr, "Deleted expense %d.\n", id)
+	}
+	return nil
+}
+
+// listExpenses returns the expenses of a category, of a period or all of
+// them. from and to must be given together.
+func (c *cli) listExpenses(category string, from, to *model.Date) ([]model.Expense, error) {
+	ctx := context.Background()
+	switch {
+	case from != nil:
+		expenses, err := c.client.ListExpensesByPeriod(ctx, from.Time, to.Time)
+		if err != nil || category == "" {
+			return expenses, err
+		}
+		// The period endpoint can't filter by category as well.
+		filtered := expenses[:0]
+		for _, e := range expenses {
+			if strings.EqualFold(e.Category, category) {
+				filtered = append(filtered, e)
+			}
+		}
+		return filtered, nil
+	case category != "":
+		return c.client.ListExpensesByCategory(ctx, category)
+	default:
+		return c.client.ListExpenses(ctx)
+	}
+}
+
+// categoryTotal is a row of the summary command.
+type categoryTotal struct {
+	Category string  `json:"category"`
+	Count    int     `json:"count"`
+	Total    float64 `json:"total"`
+}
+
+func (c *cli) summary(args []string) error {
+	fs := newFlagSet("summary", "[-from YYYY-MM-DD -to YYYY-MM-DD]")
+	from, to := periodFlags(fs)
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if err := checkPeriod(from, to); err != nil {
+		return err
+	}
+
+	expenses, err := c.listExpenses("", from.date, to.date)
+	if err != nil {
+		return err
+	}
+
+	byCategory := map[string]*categoryTotal{}
+	var totals []*categoryTotal
+	all := categoryTotal{Category: "TOTAL"}
+	for _, e := range expenses {
+		t, ok := byCategory[e.Category]
+		if !ok {
+			t = &categoryTotal{Category: e.Category}
+			byCategory[e.Category] = t
+			totals = append(totals, t)
+		}
+		t.Count++
+		t.Total += e.Amount
+		all.Count++
+		all.Total += e.Amount
+	}
+	slices.SortFunc(totals, func(a, b *categoryTotal) int {
+		return cmp.Or(cmp.Compare(b.Total, a.Total), strings.Compare(a.Category, b.Category))
+	})
+
+	rows := make([][]string, 0, len(totals)+1)
+	result := make([]categoryTotal, 0, len(totals))
+	for _, t := range totals {
+		t.Total = roundCents(t.Total)
+		rows = append(rows, []string{t.Category, strconv.Itoa(t.Count), formatAmount(t.Total)})
+		result = append(result, *t)
+	}
+	all.Total = roundCents(all.Total)
+	if c.out.format == formatTable {
+		rows = append(rows, []string{all.Category, strconv.Itoa(all.Count), formatAmount(all.Total)})
+	}
+
+	return c.out.print(struct {
+		Categories []categoryTotal `json:"categories"`
+		Count      int             `json:"count"`
+		Total      float64         `json:"total"`
+	}{result, all.Count, all.Total}, []string{"category", "count", "total"}, rows)
+}
+
+// roundCents removes the floating point noise of summed amounts.
+func roundCents(x float64) float64 {
+	f, _ := strconv.ParseFloat(formatAmount(x), 64)
+	return f
+}
+
+// today returns the current local date at midnight UTC, the way the API stores dates.
+func today() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
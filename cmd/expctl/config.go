@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// defaultServer is used when neither -server, EXPCTL_SERVER nor the config file names one.
+const defaultServer = "http://localhost:8080"
+
+// config is persisted between runs in the user's config directory.
+type config struct {
+	Server   string `json:"server"`
+	Username string `json:"username,omitempty"`
+	Token    string `json:"token,omitempty"`
+}
+
+// configPath returns the config file location: EXPCTL_CONFIG if set, otherwise
+// expctl/config.json in the user's config directory.
+func configPath() (string, error) {
+	if path := os.Getenv("EXPCTL_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("can't locate the config directory: %w", err)
+	}
+	return filepath.Join(dir, "expctl", "config.json"), nil
+}
+
+// loadConfig reads the config file at path. A missing file yields an empty config.
+func loadConfig(path string) (*config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't read config: %w", err)
+	}
+
+	var cfg config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// save writes the config to path. The file holds an access token, so it is
+// only readable by the user.
+func (c *config) save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("can't encode config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("can't create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("can't write config: %w", err)
+	}
+	return nil
+}
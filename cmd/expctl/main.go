@@ -0,0 +1,150 @@
+// Command expctl is a command-line client for the expense tracker API.
+//
+// It logs in once, keeps the access token in its config file and offers
+// subcommands to add, list, edit, delete, summarize, import and export
+// expenses. Run "expctl help" for the list of commands.
+package main
+
+import (
+	"errors"
+	"expense_tracker/pkg/client"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const usage = `Usage: expctl [-server URL] [-o table|json|csv] command [flags] [arguments]
+
+Commands:
+  login [-u USER]                       log in and store the access token
+  logout                                forget the stored access token
+  add AMOUNT CATEGORY [DESCRIPTION...]  add an expense (-date, -tag)
+  list                                  list expenses (-category, -from, -to)
+  edit ID                               change an expense (-amount, -category,
+                                        -description, -date, -tags)
+  delete ID...                          delete expenses
+  summary                               totals per category (-from, -to)
+  import FILE                           create the expenses of a CSV or JSON file,
+                                        all or none of at most 500 (-best-effort)
+  export [FILE]                         write expenses as CSV or JSON (-from, -to)
+
+Global flags:
+  -server URL   API base URL (default from the config file, EXPCTL_SERVER or
+                http://localhost:8080)
+  -o FORMAT     output format: table (default), json or csv
+
+The token is stored in %s.
+`
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "expctl:", errorMessage(err))
+		}
+		os.Exit(1)
+	}
+}
+
+// errorMessage describes err for the user, listing the invalid fields of a
+// rejected request.
+func errorMessage(err error) string {
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		return err.Error()
+	}
+	if apiErr.StatusCode == http.StatusUnauthorized && apiErr.Instance != "/auth/login" {
+		return `not logged in or the session expired, run "expctl login"`
+	}
+
+	msg := apiErr.Detail
+	if msg == "" {
+		msg = apiErr.Title
+	}
+	for _, f := range apiErr.Errors {
+		msg += "\n  " + f.Field + ": " + f.Message
+	}
+	return msg
+}
+
+// run parses the global flags and dispatches to the subcommand in args.
+func run(args []string) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("expctl", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprintf(os.Stderr, usage, path) }
+	server := fs.String("server", "", "API base URL")
+	format := fs.String("o", "table", "output format: table, json or csv")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 || fs.Arg(0) == "help" {
+		fs.Usage()
+		return nil
+	}
+
+	out, err := newPrinter(os.Stdout, *format)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+	switch {
+	case *server != "":
+		cfg.Server = *server
+	case os.Getenv("EXPCTL_SERVER") != "":
+		cfg.Server = os.Getenv("EXPCTL_SERVER")
+	case cfg.Server == "":
+		cfg.Server = defaultServer
+	}
+	cfg.Server = strings.TrimRight(cfg.Server, "/")
+
+	c := &cli{
+		cfg:  cfg,
+		path: path,
+		client: client.New(cfg.Server,
+			client.WithToken(cfg.Token),
+			client.WithUserAgent("expctl"),
+		),
+		out: out,
+	}
+
+	command, rest := fs.Arg(0), fs.Args()[1:]
+	switch command {
+	case "login":
+		return c.login(rest)
+	case "logout":
+		return c.logout(rest)
+	case "add":
+		return c.add(rest)
+	case "list":
+		return c.list(rest)
+	case "edit":
+		return c.edit(rest)
+	case "delete":
+		return c.delete(rest)
+	case "summary":
+		return c.summary(rest)
+	case "import":
+		return c.importFile(rest)
+	case "export":
+		return c.export(rest)
+	default:
+		return fmt.Errorf("unknown command %q, run \"expctl help\"", command)
+	}
+}
+
+// cli holds the state shared by the subcommands.
+type cli struct {
+	cfg    *config
+	path   string
+	client *client.Client
+	out    *printer
+}
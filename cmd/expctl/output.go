@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"expense_tracker/internal/model"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Output formats selected with -o.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+// expenseHeader is the column layout of expense tables and CSV files.
+var expenseHeader = []string{"id", "date", "amount", "category", "description", "tags"}
+
+// printer writes command results in the selected format.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	switch format {
+	case formatTable, formatJSON, formatCSV:
+		return &printer{w: w, format: format}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q (use table, json or csv)", format)
+	}
+}
+
+// print writes v as indented JSON, or header and rows as a table or CSV.
+func (p *printer) print(v any, header []string, rows [][]string) error {
+	switch p.format {
+	case formatJSON:
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+
+	case formatCSV:
+		w := csv.NewWriter(p.w)
+		w.Write(header)
+		w.WriteAll(rows)
+		return w.Error()
+
+	default:
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(header, "\t")))
+		for _, row := range rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+func (p *printer) expenses(expenses []model.Expense) error {
+	if expenses == nil {
+		expenses = []model.Expense{}
+	}
+	rows := make([][]string, len(expenses))
+	for i, e := range expenses {
+		rows[i] = expenseRow(e)
+	}
+	return p.print(expenses, expenseHeader, rows)
+}
+
+func (p *printer) expense(e *model.Expense) error {
+	if p.format == formatJSON {
+		return p.print(e, nil, nil)
+	}
+	return p.expenses([]model.Expense{*e})
+}
+
+// expenseRow formats e in the columns of expenseHeader.
+func expenseRow(e model.Expense) []string {
+	return []string{
+		strconv.Itoa(e.ID),
+		e.Date.Format(time.DateOnly),
+		formatAmount(e.Amount),
+		e.Category,
+		e.Description,
+		strings.Join(e.Tags, ";"),
+	}
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
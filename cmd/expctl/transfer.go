@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"expense_tracker/internal/model"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// importChunk is the number of expenses sent per batch request. It matches
+// the operation limit of POST /expenses/batch, which is also the most an
+// atomic import can create, since every batch is committed on its own.
+const importChunk = 500
+
+func (c *cli) importFile(args []string) error {
+	fs := newFlagSet("import", "FILE [-best-effort]")
+	bestEffort := fs.Bool("best-effort", false, "create the valid expenses even if some are rejected")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return errors.New("import requires exactly one file")
+	}
+
+	expenses, err := readExpenses(positional[0])
+	if err != nil {
+		return err
+	}
+	if len(expenses) == 0 {
+		return errors.New("the file contains no expenses")
+	}
+
+	mode := model.BatchAtomic
+	if *bestEffort {
+		mode = model.BatchBestEffort
+	} else if len(expenses) > importChunk {
+		return fmt.Errorf("the file has %d expenses but an atomic import takes at most %d, split it or use -best-effort",
+			len(expenses), importChunk)
+	}
+
+	var created []model.Expense
+	var failed int
+	for start := 0; start < len(expenses); start += importChunk {
+		chunk := expenses[start:min(start+importChunk, len(expenses))]
+		req := model.BatchRequest{Mode: mode, Operations: make([]model.BatchOperation, len(chunk))}
+		for i := range chunk {
+			req.Operations[i] = model.BatchOperation{Op: model.BatchCreate, Expense: &chunk[i]}
+		}
+
+		resp, err := c.client.Batch(context.Background(), req)
+		if err != nil {
+			if start > 0 {
+				fmt.Fprintf(os.Stderr, "Imported %d of records 1-%d before the error.\n", len(created), start)
+			}
+			return fmt.Errorf("importing records %d-%d: %s", start+1, start+len(chunk), errorMessage(err))
+		}
+		for _, result := range resp.Results {
+			switch result.Status {
+			case model.BatchStatusOK:
+				created = append(created, *result.Expense)
+			case model.BatchStatusFailed:
+				failed++
+				fmt.Fprintf(os.Stderr, "record %d: %s\n", start+result.Index+1, result.Error)
+			}
+		}
+		if mode == model.BatchAtomic && failed > 0 {
+			return errors.New("nothing imported, fix the errors or use -best-effort")
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Imported %d of %d expenses.\n", len(created), len(expenses))
+	if failed > 0 {
+		return fmt.Errorf("%d expenses were rejected", failed)
+	}
+	return nil
+}
+
+// readExpenses reads a JSON array of expenses or a CSV file with a header row
+// naming at least the date, amount and category columns.
+func readExpenses(path string) ([]model.Expense, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't read %s: %w", path, err)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") || bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		var expenses []model.Expense
+		if err := json.Unmarshal(data, &expenses); err != nil {
+			return nil, fmt.Errorf("invalid JSON in %s: %w", path, err)
+		}
+		return expenses, nil
+	}
+
+	expenses, err := readCSV(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV in %s: %w", path, err)
+	}
+	return expenses, nil
+}
+
+func readCSV(r io.Reader) ([]model.Expense, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"date", "amount", "category"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing %q column", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var expenses []model.Expense
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return expenses, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		date, err := time.Parse(time.DateOnly, field(record, "date"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid date %q", line, field(record, "date"))
+		}
+		amount, err := strconv.ParseFloat(field(record, "amount"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid amount %q", line, field(record, "amount"))
+		}
+		var tags []string
+		for _, tag := range strings.Split(field(record, "tags"), ";") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+
+		expenses = append(expenses, model.Expense{
+			Amount:      amount,
+			Category:    field(record, "category"),
+			Description: field(record, "description"),
+			Date:        model.Date{Time: date},
+			Tags:        tags,
+		})
+	}
+}
+
+func (c *cli) export(args []string) error {
+	fs := newFlagSet("export", "[FILE] [-from YYYY-MM-DD -to YYYY-MM-DD] [-format csv|json]")
+	from, to := periodFlags(fs)
+	format := fs.String("format", "", "file format, csv or json (default from the file extension)")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		fs.Usage()
+		return errors.New("export takes at most one file")
+	}
+	if err := checkPeriod(from, to); err != nil {
+		return err
+	}
+
+	var path string
+	if len(positional) == 1 {
+		path = positional[0]
+	}
+	if *format == "" {
+		switch {
+		case strings.EqualFold(filepath.Ext(path), ".json"):
+			*format = formatJSON
+		case path == "" && c.out.format == formatJSON:
+			*format = formatJSON
+		default:
+			*format = formatCSV
+		}
+	}
+	if *format != formatCSV && *format != formatJSON {
+		return fmt.Errorf("unknown export format %q (use csv or json)", *format)
+	}
+
+	expenses, err := c.listExpenses("", from.date, to.date)
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("can't create %s: %w", path, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	p, err := newPrinter(w, *format)
+	if err != nil {
+		return err
+	}
+	if err := p.expenses(expenses); err != nil {
+		return fmt.Errorf("can't write expenses: %w", err)
+	}
+	if path != "" {
+		fmt.Fprintf(os.Stderr, "Exported %d expenses to %s.\n", len(expenses), path)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"expense_tracker/internal/model"
+	"expense_tracker/pkg/client"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeCSV writes a CSV file of n expenses and returns its path.
+func writeCSV(t *testing.T, n int) string {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString("date,amount,category\n")
+	for i := range n {
+		fmt.Fprintf(&b, "2024-03-15,%d,food\n", i+1)
+	}
+	path := filepath.Join(t.TempDir(), "expenses.csv")
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newBatchCLI returns a cli whose server creates every expense of a batch
+// and records the size of the batches.
+func newBatchCLI(t *testing.T) (*cli, *[]int) {
+	t.Helper()
+	var batches []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req model.BatchRequest
+		if r.URL.Path != "/expenses/batch" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		batches = append(batches, len(req.Operations))
+		resp := model.BatchResponse{Mode: req.Mode, Succeeded: len(req.Operations)}
+		for i, op := range req.Operations {
+			resp.Results = append(resp.Results, model.BatchResult{Index: i, Op: op.Op, Status: model.BatchStatusOK, Expense: op.Expense})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return &cli{client: client.New(server.URL, client.WithToken("token"))}, &batches
+}
+
+func TestImportFile(t *testing.T) {
+	t.Run("atomic", func(t *testing.T) {
+		c, batches := newBatchCLI(t)
+		if err := c.importFile([]string{writeCSV(t, importChunk)}); err != nil {
+			t.Fatal(err)
+		}
+		if len(*batches) != 1 || (*batches)[0] != importChunk {
+			t.Errorf("sent batches of %v", *batches)
+		}
+	})
+
+	t.Run("atomic over one batch", func(t *testing.T) {
+		c, batches := newBatchCLI(t)
+		err := c.importFile([]string{writeCSV(t, importChunk+1)})
+		if err == nil || !strings.Contains(err.Error(), "-best-effort") {
+			t.Fatalf("got %v, want the import refused", err)
+		}
+		if len(*batches) != 0 {
+			t.Errorf("sent batches of %v", *batches)
+		}
+	})
+
+	t.Run("best effort", func(t *testing.T) {
+		c, batches := newBatchCLI(t)
+		if err := c.importFile([]string{writeCSV(t, importChunk+1), "-best-effort"}); err != nil {
+			t.Fatal(err)
+		}
+		if len(*batches) != 2 || (*batches)[0] != importChunk || (*batches)[1] != 1 {
+			t.Errorf("sent batches of %v", *batches)
+		}
+	})
+}
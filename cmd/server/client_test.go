@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expense_tracker/internal/config"
+	"expense_tracker/lib"
+	"expense_tracker/pkg/client"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// faultyAPI forwards requests to the API, counting them by route, and answers
+// the requests of a route set up with failNext with errors instead.
+type faultyAPI struct {
+	api http.Handler
+
+	mu     sync.Mutex
+	calls  map[string]int
+	faults map[string][]int
+}
+
+// newFaultyAPI serves the API of newTestServer through a faultyAPI.
+func newFaultyAPI(t *testing.T, configure func(cfg *config.Config)) (*faultyAPI, *httptest.Server) {
+	t.Helper()
+	f := &faultyAPI{
+		api:    newTestServer(t, configure).Config.Handler,
+		calls:  map[string]int{},
+		faults: map[string][]int{},
+	}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+// failNext answers the next requests of route, e.g. "GET /user", with statuses in turn.
+func (f *faultyAPI) failNext(route string, statuses ...int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.faults[route] = append(f.faults[route], statuses...)
+}
+
+// count returns the number of requests of route so far.
+func (f *faultyAPI) count(route string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[route]
+}
+
+func (f *faultyAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route := r.Method + " " + r.URL.Path
+	f.mu.Lock()
+	f.calls[route]++
+	status := 0
+	if faults := f.faults[route]; len(faults) > 0 {
+		status, f.faults[route] = faults[0], faults[1:]
+	}
+	f.mu.Unlock()
+
+	if status != 0 {
+		lib.WriteJSONError(w, status, http.StatusText(status))
+		return
+	}
+	f.api.ServeHTTP(w, r)
+}
+
+// newClient returns a client of the API at url that retries without waiting long.
+func newClient(t *testing.T, url string, opts ...client.Option) *client.Client {
+	t.Helper()
+	opts = append([]client.Option{client.WithRetry(3, time.Millisecond, 5*time.Millisecond)}, opts...)
+	return client.New(url, opts...)
+}
+
+func TestClientLogin(t *testing.T) {
+	_, server := newFaultyAPI(t, nil)
+	ctx := context.Background()
+	c := newClient(t, server.URL)
+
+	if _, err := c.Register(ctx, "alice", "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Profile(ctx); !errors.Is(err, lib.ErrUnauthorized) {
+		t.Fatalf("profile before login: %v", err)
+	}
+	token, err := c.Login(ctx, "alice", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token == "" || c.Token() != token {
+		t.Fatalf("token %q not stored", token)
+	}
+
+	profile, err := c.Profile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Username != "alice" {
+		t.Errorf("profile of %q", profile.Username)
+	}
+
+	c.Logout()
+	if _, err := c.Profile(ctx); !errors.Is(err, lib.ErrUnauthorized) {
+		t.Errorf("profile after logout: %v", err)
+	}
+}
+
+func TestClientRefreshesToken(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rejected token", func(t *testing.T) {
+		api, server := newFaultyAPI(t, nil)
+		if _, err := newClient(t, server.URL).Register(ctx, "alice", "correct horse"); err != nil {
+			t.Fatal(err)
+		}
+
+		c := newClient(t, server.URL, client.WithToken("revoked"), client.WithCredentials("alice", "correct horse"))
+		if _, err := c.Profile(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if logins, calls := api.count("POST /auth/login"), api.count("GET /user"); logins != 1 || calls != 2 {
+			t.Errorf("%d logins and %d calls, want 1 and 2", logins, calls)
+		}
+		if c.Token() == "revoked" {
+			t.Error("token not replaced")
+		}
+	})
+
+	t.Run("expiring token", func(t *testing.T) {
+		// Tokens living less than the refresh margin are renewed before every call.
+		api, server := newFaultyAPI(t, func(cfg *config.Config) { cfg.TokenTTL = 10 * time.Second })
+		if _, err := newClient(t, server.URL).Register(ctx, "alice", "correct horse"); err != nil {
+			t.Fatal(err)
+		}
+
+		c := newClient(t, server.URL, client.WithCredentials("alice", "correct horse"))
+		for range 2 {
+			if _, err := c.Profile(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if logins, calls := api.count("POST /auth/login"), api.count("GET /user"); logins != 2 || calls != 2 {
+			t.Errorf("%d logins and %d calls, want 2 and 2", logins, calls)
+		}
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		api, server := newFaultyAPI(t, nil)
+		if _, err := newClient(t, server.URL).Register(ctx, "alice", "correct horse"); err != nil {
+			t.Fatal(err)
+		}
+
+		c := newClient(t, server.URL, client.WithCredentials("alice", "wrong"))
+		if _, err := c.Profile(ctx); !errors.Is(err, lib.ErrUnauthorized) {
+			t.Fatalf("got %v, want unauthorized", err)
+		}
+		if logins, calls := api.count("POST /auth/login"), api.count("GET /user"); logins != 1 || calls != 0 {
+			t.Errorf("%d logins and %d calls, want 1 and 0", logins, calls)
+		}
+	})
+}
+
+func TestClientRetries(t *testing.T) {
+	ctx := context.Background()
+	api, server := newFaultyAPI(t, nil)
+	c := newClient(t, server.URL)
+	if _, err := c.Register(ctx, "alice", "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Login(ctx, "alice", "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+
+	api.failNext("GET /user", http.StatusServiceUnavailable, http.StatusBadGateway)
+	if _, err := c.Profile(ctx); err != nil {
+		t.Fatalf("idempotent call not retried: %v", err)
+	}
+	if n := api.count("GET /user"); n != 3 {
+		t.Errorf("%d attempts, want 3", n)
+	}
+
+	api.failNext("GET /user", http.StatusServiceUnavailable, http.StatusServiceUnavailable,
+		http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	_, err := c.Profile(ctx)
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || !errors.Is(err, lib.ErrInternal) {
+		t.Fatalf("got %v after the last retry", err)
+	}
+	if n := api.count("GET /user"); n != 3+4 {
+		t.Errorf("%d attempts, want 4 more", n-3)
+	}
+
+	// A POST may have been processed before the gateway failed.
+	api.failNext("POST /expenses", http.StatusServiceUnavailable)
+	expense := client.Expense{Amount: 10, Category: "food", Date: client.Date{Time: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)}}
+	if _, err := c.CreateExpense(ctx, expense); !errors.Is(err, lib.ErrInternal) {
+		t.Fatalf("got %v", err)
+	}
+	if n := api.count("POST /expenses"); n != 1 {
+		t.Errorf("non-idempotent call sent %d times", n)
+	}
+
+	// Throttled requests weren't processed and are retried whatever the method.
+	api.failNext("POST /expenses", http.StatusTooManyRequests)
+	if _, err := c.CreateExpense(ctx, expense); err != nil {
+		t.Fatalf("throttled call not retried: %v", err)
+	}
+	if n := api.count("POST /expenses"); n != 3 {
+		t.Errorf("%d attempts, want 3", n)
+	}
+}
+
+func TestClientWaitsForRateLimit(t *testing.T) {
+	ctx := context.Background()
+	api, server := newFaultyAPI(t, func(cfg *config.Config) {
+		cfg.RateLimitEnabled = true
+		cfg.RateLimitDefault = "60/m:1"
+	})
+	c := newClient(t, server.URL, client.WithCredentials("alice", "correct horse"))
+	if _, err := c.Register(ctx, "alice", "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Profile(ctx); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := c.Profile(ctx); err != nil {
+		t.Fatalf("throttled call not retried: %v", err)
+	}
+	if n := api.count("GET /user"); n != 3 {
+		t.Errorf("%d calls, want 3", n)
+	}
+	if waited := time.Since(start); waited < 900*time.Millisecond {
+		t.Errorf("retried after %v, before Retry-After", waited)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	ctx := context.Background()
+	_, server := newFaultyAPI(t, nil)
+	c := newClient(t, server.URL)
+	if _, err := c.Register(ctx, "alice", "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Login(ctx, "alice", "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := c.GetExpense(ctx, 404)
+	if !errors.Is(err, lib.ErrNotFound) || errors.Is(err, lib.ErrValidation) {
+		t.Errorf("missing expense: %v", err)
+	}
+
+	_, err = c.CreateExpense(ctx, client.Expense{Amount: -1})
+	var apiErr *client.Error
+	if !errors.Is(err, lib.ErrValidation) || !errors.As(err, &apiErr) {
+		t.Fatalf("invalid expense: %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || len(apiErr.Errors) == 0 {
+		t.Errorf("invalid expense: %+v", apiErr)
+	}
+
+	if _, err := c.Register(ctx, "alice", "another one"); !errors.Is(err, lib.ErrConflict) {
+		t.Errorf("taken username: %v", err)
+	}
+
+	// lib.WriteJSONError writes no code; the status decides.
+	if _, err := c.ListExpensesByCategory(ctx, ""); !errors.Is(err, lib.ErrValidation) {
+		t.Errorf("missing category: %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"expense_tracker/internal/config"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// runConfig implements the "config" subcommand: it prints the effective
+// configuration as YAML with secrets redacted, followed by any validation
+// problems, which make the command fail.
+func runConfig(cfg *config.Config) error {
+	out, err := yaml.Marshal(cfg.Redacted())
+	if err != nil {
+		return fmt.Errorf("cmd: can't encode configuration: %w", err)
+	}
+
+	if cfg.ConfigFile != "" {
+		fmt.Printf("# loaded from %s\n", cfg.ConfigFile)
+	}
+	os.Stdout.Write(out)
+
+	return cfg.Validate()
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/tls"
+	"expense_tracker/internal/config"
+	"expense_tracker/internal/grpcserver"
+	"expense_tracker/internal/middleware"
+	"expense_tracker/internal/service"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// grpcPolicies assigns rate limit policies to gRPC methods the way newRouter
+// does for the matching HTTP routes.
+var grpcPolicies = map[string]string{
+	"/expense.v1.AuthService/Register":          policyAuth,
+	"/expense.v1.AuthService/Login":             policyAuth,
+	"/expense.v1.ExpenseService/SearchExpenses": policyHeavy,
+	"/expense.v1.ExpenseService/Batch":          policyHeavy,
+	"/expense.v1.ExpenseService/BulkUpdate":     policyHeavy,
+}
+
+// grpcPolicyFor returns the rate limit policy of a gRPC method. Health checks
+// and reflection are not limited.
+func grpcPolicyFor(method string) string {
+	if policy, ok := grpcPolicies[method]; ok {
+		return policy
+	}
+	if strings.HasPrefix(method, "/grpc.") {
+		return ""
+	}
+	return policyDefault
+}
+
+// newGRPCServer returns the gRPC server, or nil if neither grpc_port nor
+// grpc_on_http enables it. tlsConfig is used on the dedicated port.
+func newGRPCServer(cfg *config.Config, logger *slog.Logger, tlsConfig *tls.Config, limiter *middleware.RateLimiter,
+	authService *service.AuthService, userService *service.UserService, expenseService *service.ExpenseService,
+) *grpcserver.Server {
+	if cfg.GRPCPort == "" && !cfg.GRPCOnHTTP {
+		return nil
+	}
+
+	var opts []grpc.ServerOption
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	return grpcserver.New(authService, userService, expenseService, grpcserver.Options{
+		Logger:     logger,
+		Limiter:    limiter,
+		PolicyFor:  grpcPolicyFor,
+		Reflection: cfg.GRPCReflection,
+	}, opts...)
+}
+
+// grpcOrHTTP sends gRPC requests, which are HTTP/2 requests with an
+// application/grpc content type, to grpcServer and everything else to next.
+func grpcOrHTTP(grpcServer *grpcserver.Server, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"expense_tracker/internal/model"
+	"expense_tracker/lib"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestAuthHandlers(t *testing.T) {
+	server := newTestServer(t, nil)
+	credentials := map[string]string{"username": "alice", "password": "correct horse"}
+
+	if resp := call(t, server, "POST", "/auth/register", "", credentials, nil); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("register: status %d", resp.StatusCode)
+	}
+	var problem lib.Problem
+	if resp := call(t, server, "POST", "/auth/register", "", credentials, &problem); resp.StatusCode != http.StatusConflict {
+		t.Errorf("register twice: status %d", resp.StatusCode)
+	}
+	if problem.Code != "conflict" {
+		t.Errorf("register twice: code %q", problem.Code)
+	}
+
+	wrong := map[string]string{"username": "alice", "password": "wrong"}
+	if resp := call(t, server, "POST", "/auth/login", "", wrong, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("login with a wrong password: status %d", resp.StatusCode)
+	}
+	var login struct{ Token string }
+	if resp := call(t, server, "POST", "/auth/login", "", credentials, &login); resp.StatusCode != http.StatusOK || login.Token == "" {
+		t.Fatalf("login: status %d", resp.StatusCode)
+	}
+
+	var profile struct{ Username string }
+	if resp := call(t, server, "GET", "/user", login.Token, nil, &profile); resp.StatusCode != http.StatusOK {
+		t.Fatalf("profile: status %d", resp.StatusCode)
+	}
+	if profile.Username != "alice" {
+		t.Errorf("profile of %q", profile.Username)
+	}
+	if resp := call(t, server, "GET", "/user", "", nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("profile without a token: status %d", resp.StatusCode)
+	}
+	if resp := call(t, server, "GET", "/user", "not-a-token", nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("profile with an invalid token: status %d", resp.StatusCode)
+	}
+}
+
+func TestExpenseHandlers(t *testing.T) {
+	server := newTestServer(t, nil)
+	token := signUp(t, server, "alice")
+
+	var created model.Expense
+	expense := map[string]any{"amount": 12.345, "category": "food", "description": "lunch", "date": "2024-03-15", "tags": []string{"work"}}
+	resp := call(t, server, "POST", "/expenses", token, expense, &created)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: status %d", resp.StatusCode)
+	}
+	if created.ID == 0 || created.Category != "food" {
+		t.Fatalf("created %+v", created)
+	}
+	path := "/expenses/" + strconv.Itoa(created.ID)
+
+	var got model.Expense
+	if resp := call(t, server, "GET", path, token, nil, &got); resp.StatusCode != http.StatusOK {
+		t.Fatalf("get: status %d", resp.StatusCode)
+	}
+	if got.Amount != 12.35 || got.Date.Format("2006-01-02") != "2024-03-15" || len(got.Tags) != 1 {
+		t.Errorf("got %+v", got)
+	}
+
+	var updated model.Expense
+	if resp := call(t, server, "PUT", path, token, map[string]any{"amount": 20}, &updated); resp.StatusCode != http.StatusOK {
+		t.Fatalf("update: status %d", resp.StatusCode)
+	}
+	if updated.Amount != 20 || updated.Category != "food" {
+		t.Errorf("updated %+v", updated)
+	}
+
+	var list []model.Expense
+	if resp := call(t, server, "GET", "/expenses/category?category=food", token, nil, &list); resp.StatusCode != http.StatusOK || len(list) != 1 {
+		t.Errorf("by category: status %d, %d expenses", resp.StatusCode, len(list))
+	}
+	list = nil
+	if resp := call(t, server, "GET", "/expenses/period?start=2024-03-01&end=2024-03-31", token, nil, &list); resp.StatusCode != http.StatusOK || len(list) != 1 {
+		t.Errorf("by period: status %d, %d expenses", resp.StatusCode, len(list))
+	}
+
+	other := signUp(t, server, "bob")
+	if resp := call(t, server, "GET", path, other, nil, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("get another user's expense: status %d", resp.StatusCode)
+	}
+	if resp := call(t, server, "DELETE", path, other, nil, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("delete another user's expense: status %d", resp.StatusCode)
+	}
+
+	if resp := call(t, server, "DELETE", path, token, nil, nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete: status %d", resp.StatusCode)
+	}
+	if resp := call(t, server, "GET", path, token, nil, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("get after delete: status %d", resp.StatusCode)
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	server := newTestServer(t, nil)
+	token := signUp(t, server, "alice")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		status int
+		code   string
+		field  string
+	}{
+		{"invalid fields", "POST", "/expenses", map[string]any{"amount": -1, "date": "2024-03-15"}, http.StatusBadRequest, "validation_failed", "amount"},
+		{"unknown field", "POST", "/expenses", map[string]any{"amount": 1, "category": "food", "date": "2024-03-15", "colour": "red"}, http.StatusBadRequest, "validation_failed", ""},
+		{"invalid ID", "GET", "/expenses/abc", nil, http.StatusBadRequest, "", ""},
+		{"missing expense", "GET", "/expenses/999", nil, http.StatusNotFound, "not_found", ""},
+		{"invalid period", "GET", "/expenses/period?start=yesterday&end=2024-03-31", nil, http.StatusBadRequest, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var problem lib.Problem
+			resp := call(t, server, tt.method, tt.path, token, tt.body, &problem)
+			if resp.StatusCode != tt.status || problem.Status != tt.status {
+				t.Fatalf("status %d, problem status %d, want %d", resp.StatusCode, problem.Status, tt.status)
+			}
+			if got := resp.Header.Get("Content-Type"); got != "application/problem+json" {
+				t.Errorf("content type %q", got)
+			}
+			if problem.Code != tt.code {
+				t.Errorf("code %q, want %q", problem.Code, tt.code)
+			}
+			if tt.field != "" && (len(problem.Errors) == 0 || problem.Errors[0].Field != tt.field) {
+				t.Errorf("field errors %+v, want one for %s", problem.Errors, tt.field)
+			}
+		})
+	}
+}
+
+func TestHealthHandlers(t *testing.T) {
+	server := newTestServer(t, nil)
+	for _, path := range []string{"/healthz", "/readyz"} {
+		if resp := call(t, server, "GET", path, "", nil, nil); resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: status %d", path, resp.StatusCode)
+		}
+	}
+}
@@ -1,116 +1,109 @@
 package main
 
 import (
-	"context"
+	"errors"
 	"expense_tracker/internal/config"
-	"expense_tracker/internal/handler"
-	"expense_tracker/internal/middleware"
-	"expense_tracker/internal/repository"
-	"expense_tracker/internal/service"
+	"expense_tracker/lib"
+	"flag"
 	"fmt"
-	"log"
-	"net/http"
+	"log/slog"
 	"os"
-	"syscall"
-	"time"
+	"strings"
+)
 
-	"os/signal"
+// version is the build version, set with -ldflags "-X main.version=...".
+var version = "dev"
 
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+const usage = `Usage: server [command] [flags] [arguments]
 
-	"github.com/golang-migrate/migrate/v4"
-)
+Commands:
+  serve                    start the HTTP server (default)
+  migrate up               apply all pending migrations
+  migrate down [N]         roll back N migrations (default 1)
+  migrate to VERSION       migrate up or down to VERSION
+  migrate status           show the current and latest schema version
+  migrate force VERSION    set the schema version and clear the dirty flag
+  config                   print the effective configuration with secrets redacted
+  openapi                  print the OpenAPI document
+  openapi check            fail if the routes and the OpenAPI document differ
+  version                  print the build version
 
-func main() {
+Settings are read from the defaults, a config file (-config or CONFIG_FILE),
+environment variables and flags, each overriding the previous one.
+Run "server serve -h" to list the flags.
+`
 
-	cfg := config.Load()
+func main() {
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	switch command {
+	case "version":
+		fmt.Println(version)
+		return
+	case "help":
+		fmt.Print(usage)
+		return
+	case "openapi":
+		if err := runOpenAPI(args); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	case "serve", "migrate", "config":
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
 
-	db, err := repository.NewDB(ctx, cfg)
+	cfg, args, err := config.Load(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		log.Fatalf("cmd: failed to connect to database: %v", err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
-	defer db.Pool.Close()
 
-	if err := applyMigrations(cfg); err != nil {
-		log.Fatalf("cmd: migrations failed: %v", err)
+	if command == "config" {
+		err = runConfig(cfg)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	userRep := repository.NewUserRepository(db)
-	expenseRep := repository.NewExpenseRepository(db)
-
-	authService := service.NewAuthService(
-		userRep,
-		cfg.JWTSecret,
-		24*time.Hour,
-	)
-	userService := service.NewUserService(userRep)
-	expeneseService := service.NewExpenseService(expenseRep)
-
-	authHandler := handler.NewAuthHandler(authService)
-	userHandler := handler.NewUserHandler(userService)
-	expenseHandler := handler.NewExpenseHandler(expeneseService)
-
-	router := http.NewServeMux()
-	authMiddleware := middleware.AuthMiddleware(authService)
-
-	router.HandleFunc("POST /auth/register", authHandler.Register)
-	router.HandleFunc("POST /auth/login", authHandler.Login)
-
-	router.Handle("PUT /user/username", authMiddleware(http.HandlerFunc(userHandler.UpdateUsername)))
-	router.Handle("DELETE /user", authMiddleware(http.HandlerFunc(userHandler.DeleteUser)))
-	router.Handle("GET /user", authMiddleware(http.HandlerFunc(userHandler.GetProfile)))
-
-	router.Handle("POST /expenses", authMiddleware(http.HandlerFunc(expenseHandler.CreateExpense)))
-	router.Handle("GET /expenses/{id}", authMiddleware(http.HandlerFunc(expenseHandler.GetExpense)))
-	router.Handle("PUT /expenses/{id}", authMiddleware(http.HandlerFunc(expenseHandler.UpdateExpense)))
-	router.Handle("DELETE /expenses/{id}", authMiddleware(http.HandlerFunc(expenseHandler.DeleteExpense)))
-	router.Handle("GET /expenses", authMiddleware(http.HandlerFunc(expenseHandler.GetExpensesList)))
-	router.Handle("GET /expenses/period", authMiddleware(http.HandlerFunc(expenseHandler.GetExpensesByPeriod)))
-	router.Handle("GET /expenses/category", authMiddleware(http.HandlerFunc(expenseHandler.GetExpensesByCategory)))
-
-	server := &http.Server{
-		Addr:    ":" + cfg.Port,
-		Handler: router,
+	if err := cfg.Validate(); err != nil {
+		fatal("cmd: refusing to start", err)
 	}
 
-	go func() {
-		sigint := make(chan os.Signal, 1)
-		signal.Notify(sigint, syscall.SIGINT, syscall.SIGTERM)
-		<-sigint
-
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
-		if err := server.Shutdown(ctx); err != nil {
-			log.Printf("cmd: server shutdown error: %v", err)
-		}
-	}()
-
-	log.Printf("cmd: server starting on port %s", cfg.Port)
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("cmd: server failed: %v", err)
+	logger, err := lib.NewLogger(os.Stdout, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		fatal("cmd: invalid logger configuration", err)
 	}
-}
+	slog.SetDefault(logger)
 
-func applyMigrations(cfg *config.Config) error {
-	dsn := cfg.DBURL
+	if cfg.IsDevelopment() {
+		logger.Warn("cmd: running in development mode, insecure defaults are allowed")
+	}
 
-	m, err := migrate.New(
-		"file:///app/migrations",
-		dsn,
-	)
-	if err != nil {
-		return fmt.Errorf("cmd: migration init failed: %w", err)
+	switch command {
+	case "serve":
+		err = runServe(cfg, logger)
+	case "migrate":
+		err = runMigrate(cfg, args)
 	}
-	defer m.Close()
 
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		return fmt.Errorf("cmd: migration up failed: %w", err)
+	if err != nil {
+		fatal("cmd: "+command+" failed", err)
 	}
+}
 
-	return nil
+// fatal logs err and terminates the process.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
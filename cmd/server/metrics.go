@@ -0,0 +1,32 @@
+package main
+
+import (
+	"expense_tracker/internal/config"
+	"expense_tracker/internal/middleware"
+	"net/http"
+)
+
+// metricsHandlers returns the handler of GET /metrics on the API port and
+// the server of the separate metrics listener, nil unless metrics_addr is
+// set. Metrics include business figures such as the number of active users,
+// so the API port only serves them with a token or in development.
+func metricsHandlers(cfg *config.Config, metrics http.Handler) (http.Handler, *http.Server) {
+	if cfg.MetricsToken != "" {
+		metrics = middleware.StaticToken(cfg.MetricsToken)(metrics)
+	}
+
+	if cfg.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", metrics)
+		server := &http.Server{
+			Addr:              cfg.MetricsAddr,
+			Handler:           mux,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		}
+		return http.NotFoundHandler(), server
+	}
+	if cfg.MetricsToken == "" && !cfg.IsDevelopment() {
+		return http.NotFoundHandler(), nil
+	}
+	return metrics, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"expense_tracker/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMetricsHandlers(t *testing.T) {
+	metrics := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("expense_tracker_users_active 3\n"))
+	})
+	get := func(h http.Handler, token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	tests := []struct {
+		name      string
+		configure func(cfg *config.Config)
+		// public is the status of the API port without and with the token.
+		public, publicToken int
+		// separate is the status of the metrics listener, 0 without one.
+		separate, separateToken int
+	}{
+		{
+			name:      "production",
+			configure: func(cfg *config.Config) {},
+			public:    http.StatusNotFound, publicToken: http.StatusNotFound,
+		},
+		{
+			name:      "development",
+			configure: func(cfg *config.Config) { cfg.Environment = config.EnvDevelopment },
+			public:    http.StatusOK, publicToken: http.StatusOK,
+		},
+		{
+			name:      "token",
+			configure: func(cfg *config.Config) { cfg.MetricsToken = "scraper" },
+			public:    http.StatusUnauthorized, publicToken: http.StatusOK,
+		},
+		{
+			name:      "separate listener",
+			configure: func(cfg *config.Config) { cfg.MetricsAddr = "127.0.0.1:9100" },
+			public:    http.StatusNotFound, publicToken: http.StatusNotFound,
+			separate: http.StatusOK, separateToken: http.StatusOK,
+		},
+		{
+			name: "separate listener with token",
+			configure: func(cfg *config.Config) {
+				cfg.Environment = config.EnvDevelopment
+				cfg.MetricsAddr = "127.0.0.1:9100"
+				cfg.MetricsToken = "scraper"
+			},
+			public: http.StatusNotFound, publicToken: http.StatusNotFound,
+			separate: http.StatusUnauthorized, separateToken: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			tt.configure(cfg)
+			public, server := metricsHandlers(cfg, metrics)
+
+			if got, gotToken := get(public, ""), get(public, "scraper"); got != tt.public || gotToken != tt.publicToken {
+				t.Errorf("API port: %d and %d with the token, want %d and %d", got, gotToken, tt.public, tt.publicToken)
+			}
+			if (server != nil) != (tt.separate != 0) {
+				t.Fatalf("metrics server %v", server)
+			}
+			if server == nil {
+				return
+			}
+			if server.Addr != cfg.MetricsAddr {
+				t.Errorf("metrics server on %q", server.Addr)
+			}
+			if got, gotToken := get(server.Handler, ""), get(server.Handler, "scraper"); got != tt.separate || gotToken != tt.separateToken {
+				t.Errorf("metrics listener: %d and %d with the token, want %d and %d", got, gotToken, tt.separate, tt.separateToken)
+			}
+			if got := get(server.Handler, "wrong"); cfg.MetricsToken != "" && got != http.StatusUnauthorized {
+				t.Errorf("wrong token: %d", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"expense_tracker/internal/config"
+	"expense_tracker/internal/repository"
+	"expense_tracker/internal/repository/postgres"
+	"expense_tracker/internal/repository/sqlite"
+	"fmt"
+	"strconv"
+)
+
+// runMigrate implements the "migrate" subcommand.
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("cmd: migrate requires a subcommand (up, down, to, status, force)")
+	}
+
+	m, err := newMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch sub, rest := args[0], args[1:]; sub {
+	case "up":
+		if err := m.Up(); err != nil {
+			return err
+		}
+
+	case "down":
+		steps := 1
+		if len(rest) > 0 {
+			if steps, err = strconv.Atoi(rest[0]); err != nil {
+				return fmt.Errorf("cmd: invalid number of steps %q", rest[0])
+			}
+		}
+		if err := m.Down(steps); err != nil {
+			return err
+		}
+
+	case "to":
+		if len(rest) != 1 {
+			return fmt.Errorf("cmd: migrate to requires a version")
+		}
+		target, err := strconv.ParseUint(rest[0], 10, 32)
+		if err != nil {
+			return fmt.Errorf("cmd: invalid version %q", rest[0])
+		}
+		if err := m.To(uint(target)); err != nil {
+			return err
+		}
+
+	case "force":
+		if len(rest) != 1 {
+			return fmt.Errorf("cmd: migrate force requires a version")
+		}
+		target, err := strconv.Atoi(rest[0])
+		if err != nil {
+			return fmt.Errorf("cmd: invalid version %q", rest[0])
+		}
+		if err := m.Force(target); err != nil {
+			return err
+		}
+
+	case "status":
+
+	default:
+		return fmt.Errorf("cmd: unknown migrate subcommand %q", sub)
+	}
+
+	return printMigrationStatus(m)
+}
+
+// newMigrator returns the migrator of the configured database driver.
+func newMigrator(cfg *config.Config) (*repository.Migrator, error) {
+	switch cfg.DBDriver {
+	case config.DriverPostgres:
+		return postgres.NewMigrator(cfg.DBURL)
+	case config.DriverSQLite:
+		return sqlite.NewMigrator(cfg.SQLitePath)
+	default:
+		return nil, fmt.Errorf("cmd: migrations are not supported for the %s driver", cfg.DBDriver)
+	}
+}
+
+func printMigrationStatus(m *repository.Migrator) error {
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("version: %d\ndirty:   %t\nlatest:  %d\npending: %d\n",
+		status.Version, status.Dirty, status.Latest, status.Pending)
+	return nil
+}
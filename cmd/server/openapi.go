@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"expense_tracker/internal/openapi"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// runOpenAPI implements the "openapi" subcommand. Without arguments it prints
+// the OpenAPI document; "openapi check" fails if the registered routes and the
+// document differ, which is meant to run in CI.
+func runOpenAPI(args []string) error {
+	doc := openapi.New(version)
+
+	if len(args) == 0 {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	}
+
+	if args[0] != "check" {
+		return fmt.Errorf("cmd: unknown openapi subcommand %q", args[0])
+	}
+
+	patterns := routePatterns()
+	if err := doc.CheckRoutes(patterns); err != nil {
+		return err
+	}
+	fmt.Printf("openapi: %d routes documented\n", len(patterns))
+	return nil
+}
+
+// routePatterns returns the patterns of the routes registered by newRouter.
+func routePatterns() []string {
+	// Handlers are only registered, never called, so they need no dependencies.
+	_, patterns := newRouter(&api{
+		metrics:        http.NotFoundHandler(),
+		authMiddleware: func(h http.Handler) http.Handler { return h },
+	})
+	return patterns
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"expense_tracker/internal/openapi"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	if err := openapi.New(version).CheckRoutes(routePatterns()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenAPIReportsDrift(t *testing.T) {
+	doc := openapi.New(version)
+	patterns := routePatterns()
+	removed := patterns[len(patterns)-1]
+	patterns = append(slices.Clone(patterns[:len(patterns)-1]), "GET /undocumented")
+
+	err := doc.CheckRoutes(patterns)
+	if err == nil {
+		t.Fatal("drift not reported")
+	}
+	for _, want := range []string{`route "GET /undocumented" is not documented`, `documented operation "` + removed + `" is not registered`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't report %s", err, want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"expense_tracker/internal/config"
+	"expense_tracker/internal/middleware"
+	"expense_tracker/internal/ratelimit"
+	"fmt"
+)
+
+// Rate limit policies applied by newRouter.
+const (
+	policyAuth    = "auth"
+	policyDefault = "default"
+	policyHeavy   = "heavy"
+)
+
+// redisKeyPrefix namespaces the keys of the redis store.
+const redisKeyPrefix = "expense-tracker:"
+
+// openRateLimitStore opens the store shared by the rate limiter and the login lockout.
+func openRateLimitStore(ctx context.Context, cfg *config.Config) (ratelimit.Store, error) {
+	if cfg.RateLimitStore == config.StoreRedis {
+		store, err := ratelimit.NewRedisStore(ctx, cfg.RedisURL, redisKeyPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("cmd: failed to open rate limit store: %w", err)
+		}
+		return store, nil
+	}
+	return ratelimit.NewMemoryStore(), nil
+}
+
+// newLockout returns the login lockout configured by cfg, or nil if it is disabled.
+func newLockout(cfg *config.Config, store ratelimit.Store) *ratelimit.Lockout {
+	if cfg.LoginMaxFailures == 0 {
+		return nil
+	}
+	return ratelimit.NewLockout(store, cfg.LoginMaxFailures, cfg.LoginLockout, cfg.LoginLockoutMax)
+}
+
+// newRateLimiter returns the rate limiter configured by cfg, or nil if rate
+// limiting is disabled. The limits were checked by cfg.Validate.
+func newRateLimiter(cfg *config.Config, store ratelimit.Store) (*middleware.RateLimiter, error) {
+	if !cfg.RateLimitEnabled {
+		return nil, nil
+	}
+
+	var policies []middleware.RateLimitPolicy
+	for _, p := range []struct {
+		name, limit string
+		byUser      bool
+	}{
+		{policyAuth, cfg.RateLimitAuth, false},
+		{policyDefault, cfg.RateLimitDefault, true},
+		{policyHeavy, cfg.RateLimitHeavy, true},
+	} {
+		limit, err := ratelimit.ParseLimit(p.limit)
+		if err != nil {
+			return nil, fmt.Errorf("cmd: invalid %s rate limit: %w", p.name, err)
+		}
+		policies = append(policies, middleware.RateLimitPolicy{Name: p.name, Limit: limit, ByUser: p.byUser})
+	}
+	return middleware.NewRateLimiter(store, cfg.TrustProxy, policies...), nil
+}
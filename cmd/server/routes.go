@@ -0,0 +1,109 @@
+package main
+
+import (
+	"expense_tracker/internal/handler"
+	"expense_tracker/internal/middleware"
+	"expense_tracker/internal/openapi"
+	"net/http"
+
+	httpSwagger "github.com/swaggo/http-swagger"
+)
+
+// api bundles the handlers mounted by newRouter.
+type api struct {
+	auth           *handler.AuthHandler
+	user           *handler.UserHandler
+	expense        *handler.ExpenseHandler
+	health         *handler.HealthHandler
+	graphql        *handler.GraphQLHandler
+	graphiql       http.Handler // GraphQL playground, nil outside development
+	events         *handler.EventsHandler
+	webhook        *handler.WebhookHandler
+	metrics        http.Handler
+	authMiddleware func(http.Handler) http.Handler
+	// limiter throttles the API routes; nil disables rate limiting.
+	limiter *middleware.RateLimiter
+}
+
+// routes registers handlers on a ServeMux and records their patterns so they
+// can be compared with the OpenAPI document.
+type routes struct {
+	mux      *http.ServeMux
+	patterns []string
+}
+
+func (rt *routes) handle(pattern string, h http.Handler) {
+	rt.patterns = append(rt.patterns, pattern)
+	rt.mux.Handle(pattern, h)
+}
+
+func (rt *routes) handleFunc(pattern string, h http.HandlerFunc) {
+	rt.handle(pattern, h)
+}
+
+// newRouter registers every route of the server and returns the router with
+// the patterns of the documented routes. The OpenAPI document, Swagger UI and
+// GraphiQL are served as well but are not part of the document themselves.
+func newRouter(a *api) (*http.ServeMux, []string) {
+	rt := &routes{mux: http.NewServeMux()}
+	// Per-user limits run after authentication so the user ID is known.
+	auth := func(h http.HandlerFunc) http.Handler {
+		return a.authMiddleware(a.limiter.Limit(policyDefault)(h))
+	}
+	heavy := func(h http.HandlerFunc) http.Handler {
+		return a.authMiddleware(a.limiter.Limit(policyHeavy)(h))
+	}
+	public := func(h http.HandlerFunc) http.Handler {
+		return a.limiter.Limit(policyAuth)(h)
+	}
+
+	rt.handleFunc("GET /healthz", a.health.Liveness)
+	rt.handleFunc("GET /readyz", a.health.Readiness)
+	rt.handle("GET /metrics", a.metrics)
+
+	rt.handle("POST /auth/register", public(a.auth.Register))
+	rt.handle("POST /auth/login", public(a.auth.Login))
+
+	rt.handle("PUT /user/username", auth(a.user.UpdateUsername))
+	rt.handle("DELETE /user", auth(a.user.DeleteUser))
+	rt.handle("GET /user", auth(a.user.GetProfile))
+
+	rt.handle("POST /expenses", auth(a.expense.CreateExpense))
+	rt.handle("GET /expenses/{id}", auth(a.expense.GetExpense))
+	rt.handle("PUT /expenses/{id}", auth(a.expense.UpdateExpense))
+	rt.handle("DELETE /expenses/{id}", auth(a.expense.DeleteExpense))
+	rt.handle("GET /expenses", auth(a.expense.GetExpensesList))
+	rt.handle("GET /expenses/period", auth(a.expense.GetExpensesByPeriod))
+	rt.handle("GET /expenses/category", auth(a.expense.GetExpensesByCategory))
+	rt.handle("GET /expenses/search", heavy(a.expense.SearchExpenses))
+	rt.handle("POST /expenses/batch", heavy(a.expense.BatchExpenses))
+	rt.handle("POST /expenses/bulk-update", heavy(a.expense.BulkUpdateExpenses))
+	rt.handle("POST /expenses/import", heavy(a.expense.ImportExpenses))
+	rt.handle("POST /expenses/import/preview", heavy(a.expense.PreviewImport))
+	rt.handle("GET /expenses/export", heavy(a.expense.ExportExpenses))
+
+	rt.handle("GET /reports/monthly.pdf", heavy(a.expense.MonthlyReport))
+
+	rt.handle("POST /webhooks", auth(a.webhook.CreateWebhook))
+	rt.handle("GET /webhooks", auth(a.webhook.ListWebhooks))
+	rt.handle("GET /webhooks/{id}", auth(a.webhook.GetWebhook))
+	rt.handle("PUT /webhooks/{id}", auth(a.webhook.UpdateWebhook))
+	rt.handle("DELETE /webhooks/{id}", auth(a.webhook.DeleteWebhook))
+	rt.handle("GET /webhooks/{id}/deliveries", auth(a.webhook.ListDeliveries))
+	rt.handle("GET /webhooks/{id}/deliveries/{deliveryID}", auth(a.webhook.GetDelivery))
+	rt.handle("POST /webhooks/{id}/deliveries/{deliveryID}/redeliver", auth(a.webhook.Redeliver))
+
+	rt.handle("POST /graphql", heavy(a.graphql.Query))
+
+	// EventSource can't set headers, so browsers pass the token in the URL.
+	rt.handle("GET /events", middleware.QueryToken()(auth(a.events.Stream)))
+
+	rt.mux.Handle("GET /openapi.json", openapi.Handler(openapi.New(version)))
+	rt.mux.Handle("GET /docs", http.RedirectHandler("/docs/index.html", http.StatusMovedPermanently))
+	rt.mux.Handle("GET /docs/", httpSwagger.Handler(httpSwagger.URL("/openapi.json")))
+	if a.graphiql != nil {
+		rt.mux.Handle("GET /graphql", a.graphiql)
+	}
+
+	return rt.mux, rt.patterns
+}
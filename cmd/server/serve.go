@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"expense_tracker/internal/config"
+	"expense_tracker/internal/dispatch"
+	"expense_tracker/internal/events"
+	"expense_tracker/internal/graphqlapi"
+	"expense_tracker/internal/handler"
+	"expense_tracker/internal/metrics"
+	"expense_tracker/internal/middleware"
+	"expense_tracker/internal/service"
+	"expense_tracker/internal/telemetry"
+	"expense_tracker/internal/tlscert"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// runServe implements the "serve" subcommand: it connects to the database,
+// optionally applies migrations and serves the HTTP API until SIGINT or SIGTERM.
+func runServe(cfg *config.Config, logger *slog.Logger) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	shutdownTracing, err := telemetry.Setup(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("cmd: failed to set up tracing: %w", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("cmd: tracing shutdown error", "error", err)
+		}
+	}()
+
+	store, err := openStorage(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer store.close()
+
+	appMetrics := metrics.New(store.pool, store.stats)
+
+	limitStore, err := openRateLimitStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer limitStore.Close()
+
+	limiter, err := newRateLimiter(cfg, limitStore)
+	if err != nil {
+		return err
+	}
+
+	eventHub := events.NewHub(cfg.EventsBufferSize)
+	var streams service.EventPublisher = eventHub
+	if cfg.EventsPostgresNotify {
+		bridge := events.NewPostgresBridge(eventHub, store.pgPool, store.expenses)
+		bridgeCtx, stopBridge := context.WithCancel(context.Background())
+		defer stopBridge()
+		go bridge.Run(bridgeCtx)
+		streams = bridge
+	}
+
+	// Webhook deliveries are enqueued in the transaction of every change,
+	// so events relayed by the bridge are not enqueued again, and every
+	// instance dispatches whichever are due.
+	webhookService := service.NewWebhookService(store.webhooks)
+	dispatcher := dispatch.New(store.webhooks, dispatch.NewClient(cfg.WebhookTimeout, cfg.WebhookAllowPrivate), dispatch.Options{
+		Timeout:      cfg.WebhookTimeout,
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		RetryBackoff: cfg.WebhookRetryBackoff,
+		DisableAfter: cfg.WebhookDisableAfter,
+		LogRetention: cfg.WebhookLogRetention,
+	})
+	dispatchCtx, stopDispatch := context.WithCancel(context.Background())
+	dispatchDone := make(chan struct{})
+	go func() {
+		defer close(dispatchDone)
+		dispatcher.Run(dispatchCtx)
+	}()
+	// Deferred after store.close, so it runs first: attempts in flight are
+	// recorded before the database is closed.
+	defer func() {
+		stopDispatch()
+		<-dispatchDone
+	}()
+
+	authService := service.NewAuthService(
+		store.users,
+		cfg.JWTSecret,
+		cfg.TokenTTL,
+		newLockout(cfg, limitStore),
+	)
+	userService := service.NewUserService(store.users)
+	expeneseService := service.NewExpenseService(store.expenses, streams)
+	healthService := service.NewHealthService(store.health, store.schemaVersion)
+
+	var tlsConfig *tls.Config
+	if cfg.TLSCertFile != "" {
+		certs, err := tlscert.NewReloader(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return fmt.Errorf("cmd: failed to load TLS certificate: %w", err)
+		}
+		tlsConfig = certs.TLSConfig()
+	}
+
+	graphqlSchema := graphqlapi.New(userService, expeneseService, graphqlapi.Limits{
+		MaxComplexity: cfg.GraphQLMaxComplexity,
+		MaxDepth:      cfg.GraphQLMaxDepth,
+	})
+	publicMetrics, metricsServer := metricsHandlers(cfg, appMetrics.Handler())
+
+	var graphiql http.Handler
+	if cfg.IsDevelopment() {
+		graphiql = graphqlapi.GraphiQL("/graphql")
+	}
+
+	router, _ := newRouter(&api{
+		auth:           handler.NewAuthHandler(authService),
+		user:           handler.NewUserHandler(userService),
+		expense:        handler.NewExpenseHandler(expeneseService),
+		health:         handler.NewHealthHandler(healthService),
+		graphql:        handler.NewGraphQLHandler(graphqlSchema),
+		graphiql:       graphiql,
+		events:         handler.NewEventsHandler(eventHub),
+		webhook:        handler.NewWebhookHandler(webhookService),
+		metrics:        publicMetrics,
+		authMiddleware: middleware.AuthMiddleware(authService),
+		limiter:        limiter,
+	})
+
+	// CORS answers preflight requests itself, so it has to sit outside the
+	// router; Metrics has to wrap the router directly to see the route pattern.
+	handler := middleware.Metrics(appMetrics)(router)
+	handler = middleware.CORS(middleware.CORSOptions{
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
+		AllowedMethods:   cfg.CORSAllowedMethods,
+		AllowedHeaders:   cfg.CORSAllowedHeaders,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		MaxAge:           cfg.CORSMaxAge,
+	})(handler)
+	handler = middleware.SecurityHeaders(cfg.HSTSMaxAge, cfg.TrustProxy)(handler)
+	handler = middleware.Tracing()(middleware.RequestLogger(logger)(handler))
+
+	grpcServer := newGRPCServer(cfg, logger, tlsConfig, limiter, authService, userService, expeneseService)
+	if cfg.GRPCOnHTTP {
+		handler = grpcOrHTTP(grpcServer, handler)
+	}
+
+	server := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           handler,
+		TLSConfig:         tlsConfig,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+	// Event streams only end when their subscription does.
+	server.RegisterOnShutdown(eventHub.Close)
+	if cfg.GRPCOnHTTP && tlsConfig == nil {
+		// gRPC clients speak HTTP/2 with prior knowledge over cleartext.
+		server.Protocols = new(http.Protocols)
+		server.Protocols.SetHTTP1(true)
+		server.Protocols.SetHTTP2(true)
+		server.Protocols.SetUnencryptedHTTP2(true)
+	}
+
+	if cfg.GRPCPort != "" {
+		lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+		if err != nil {
+			return fmt.Errorf("cmd: failed to listen for gRPC: %w", err)
+		}
+		logger.Info("cmd: grpc server starting", "port", cfg.GRPCPort, "tls", tlsConfig != nil)
+		go func() {
+			if err := grpcServer.Serve(lis); err != nil {
+				logger.Error("cmd: grpc server failed", "error", err)
+			}
+		}()
+	}
+
+	if metricsServer != nil {
+		lis, err := net.Listen("tcp", metricsServer.Addr)
+		if err != nil {
+			return fmt.Errorf("cmd: failed to listen for metrics: %w", err)
+		}
+		logger.Info("cmd: metrics server starting", "addr", metricsServer.Addr)
+		go func() {
+			if err := metricsServer.Serve(lis); err != nil && err != http.ErrServerClosed {
+				logger.Error("cmd: metrics server failed", "error", err)
+			}
+		}()
+	}
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+
+		sigint := make(chan os.Signal, 1)
+		signal.Notify(sigint, syscall.SIGINT, syscall.SIGTERM)
+		<-sigint
+
+		// Fail readiness first and give load balancers time to notice
+		// before the listener is closed.
+		healthService.SetShuttingDown()
+		logger.Info("cmd: shutting down", "drain_delay", cfg.ShutdownDrainDelay)
+		time.Sleep(cfg.ShutdownDrainDelay)
+
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+
+		if grpcServer != nil {
+			grpcServer.Shutdown(ctx)
+		}
+		if err := server.Shutdown(ctx); err != nil {
+			logger.Error("cmd: server shutdown error", "error", err)
+		}
+		if metricsServer != nil {
+			metricsServer.Close()
+		}
+	}()
+
+	logger.Info("cmd: server starting", "port", cfg.Port, "tls", server.TLSConfig != nil, "grpc", cfg.GRPCOnHTTP, "version", version)
+	if server.TLSConfig != nil {
+		// The certificate comes from TLSConfig.GetCertificate.
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("cmd: server failed: %w", err)
+	}
+	<-shutdownDone
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"expense_tracker/internal/config"
+	"expense_tracker/internal/events"
+	"expense_tracker/internal/graphqlapi"
+	"expense_tracker/internal/handler"
+	"expense_tracker/internal/metrics"
+	"expense_tracker/internal/middleware"
+	"expense_tracker/internal/ratelimit"
+	"expense_tracker/internal/service"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestServer serves the routes of newRouter on an in-memory database,
+// wired like runServe but without background workers. Rate limiting is off
+// unless configure turns it on.
+func newTestServer(t *testing.T, configure func(cfg *config.Config)) *httptest.Server {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Environment = config.EnvDevelopment
+	cfg.DBDriver = config.DriverMemory
+	cfg.JWTSecret = "test-secret-that-is-long-enough-to-sign"
+	cfg.RateLimitEnabled = false
+	if configure != nil {
+		configure(cfg)
+	}
+
+	ctx := context.Background()
+	store, err := openStorage(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	limitStore := ratelimit.NewMemoryStore()
+	limiter, err := newRateLimiter(cfg, limitStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hub := events.NewHub(cfg.EventsBufferSize)
+	webhookService := service.NewWebhookService(store.webhooks)
+	authService := service.NewAuthService(store.users, cfg.JWTSecret, cfg.TokenTTL, newLockout(cfg, limitStore))
+	userService := service.NewUserService(store.users)
+	expenseService := service.NewExpenseService(store.expenses, hub)
+	healthService := service.NewHealthService(store.health, store.schemaVersion)
+	schema := graphqlapi.New(userService, expenseService, graphqlapi.Limits{
+		MaxComplexity: cfg.GraphQLMaxComplexity,
+		MaxDepth:      cfg.GraphQLMaxDepth,
+	})
+
+	router, _ := newRouter(&api{
+		auth:           handler.NewAuthHandler(authService),
+		user:           handler.NewUserHandler(userService),
+		expense:        handler.NewExpenseHandler(expenseService),
+		health:         handler.NewHealthHandler(healthService),
+		graphql:        handler.NewGraphQLHandler(schema),
+		events:         handler.NewEventsHandler(hub),
+		webhook:        handler.NewWebhookHandler(webhookService),
+		metrics:        metrics.New(nil, store.stats).Handler(),
+		authMiddleware: middleware.AuthMiddleware(authService),
+		limiter:        limiter,
+	})
+
+	server := httptest.NewServer(router)
+	t.Cleanup(func() {
+		hub.Close()
+		server.Close()
+		store.close()
+	})
+	return server
+}
+
+// call sends a request with a JSON body, unless body is nil, and the bearer
+// token, unless it is empty. It decodes a JSON response into out if not nil.
+func call(t *testing.T, server *httptest.Server, method, path, token string, body, out any) *http.Response {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, server.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: can't decode response: %v", method, path, err)
+		}
+	}
+	return resp
+}
+
+// signUp registers a user and returns a token for them.
+func signUp(t *testing.T, server *httptest.Server, username string) string {
+	t.Helper()
+	credentials := map[string]string{"username": username, "password": "correct horse"}
+	if resp := call(t, server, "POST", "/auth/register", "", credentials, nil); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("register: status %d", resp.StatusCode)
+	}
+	var login struct{ Token string }
+	if resp := call(t, server, "POST", "/auth/login", "", credentials, &login); resp.StatusCode != http.StatusOK {
+		t.Fatalf("login: status %d", resp.StatusCode)
+	}
+	return login.Token
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"expense_tracker/internal/config"
+	"expense_tracker/internal/metrics"
+	"expense_tracker/internal/repository"
+	"expense_tracker/internal/repository/memory"
+	"expense_tracker/internal/repository/postgres"
+	"expense_tracker/internal/repository/sqlite"
+	"expense_tracker/internal/service"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// storage bundles the repositories and infrastructure of the configured backend.
+type storage struct {
+	users    repository.UserRepository
+	expenses repository.ExpenseRepository
+	stats    repository.StatsRepository
+	webhooks repository.WebhookRepository
+
+	// health is checked by the readiness probe; schemaVersion is the version it expects.
+	health        service.DatabaseChecker
+	schemaVersion uint
+	// pool exposes connection pool metrics; nil for backends without a pool.
+	pool metrics.PoolStatter
+	// pgPool carries the events between instances; nil for other backends.
+	pgPool *pgxpool.Pool
+
+	close func()
+}
+
+// openStorage connects to the backend selected by cfg.DBDriver and, if enabled,
+// migrates its schema.
+func openStorage(ctx context.Context, cfg *config.Config) (*storage, error) {
+	switch cfg.DBDriver {
+	case config.DriverPostgres:
+		return openPostgres(ctx, cfg)
+	case config.DriverSQLite:
+		return openSQLite(ctx, cfg)
+	case config.DriverMemory:
+		db := memory.NewDB()
+		return &storage{
+			users:    memory.NewUserRepository(db),
+			expenses: memory.NewExpenseRepository(db),
+			stats:    memory.NewStatsRepository(db),
+			webhooks: memory.NewWebhookRepository(db),
+			health:   db,
+			close:    func() {},
+		}, nil
+	default:
+		return nil, fmt.Errorf("cmd: unknown database driver %q", cfg.DBDriver)
+	}
+}
+
+func openPostgres(ctx context.Context, cfg *config.Config) (*storage, error) {
+	db, err := postgres.NewDB(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("cmd: failed to connect to database: %w", err)
+	}
+
+	if cfg.AutoMigrate {
+		if err := applyMigrations(cfg); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("cmd: migrations failed: %w", err)
+		}
+	}
+
+	schemaVersion, err := postgres.LatestMigrationVersion()
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("cmd: can't read migrations: %w", err)
+	}
+
+	return &storage{
+		users:         postgres.NewUserRepository(db),
+		expenses:      postgres.NewExpenseRepository(db),
+		stats:         postgres.NewStatsRepository(db),
+		webhooks:      postgres.NewWebhookRepository(db),
+		health:        db,
+		schemaVersion: schemaVersion,
+		pool:          db,
+		pgPool:        db.Pool,
+		close:         db.Close,
+	}, nil
+}
+
+func openSQLite(ctx context.Context, cfg *config.Config) (*storage, error) {
+	db, err := sqlite.NewDB(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("cmd: failed to open database: %w", err)
+	}
+
+	if cfg.AutoMigrate {
+		if err := db.Migrate(); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("cmd: migrations failed: %w", err)
+		}
+	}
+
+	schemaVersion, err := sqlite.LatestMigrationVersion()
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("cmd: can't read migrations: %w", err)
+	}
+
+	return &storage{
+		users:         sqlite.NewUserRepository(db),
+		expenses:      sqlite.NewExpenseRepository(db),
+		stats:         sqlite.NewStatsRepository(db),
+		webhooks:      sqlite.NewWebhookRepository(db),
+		health:        db,
+		schemaVersion: schemaVersion,
+		close:         db.Close,
+	}, nil
+}
+
+func applyMigrations(cfg *config.Config) error {
+	m, err := postgres.NewMigrator(cfg.DBURL)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	return m.Up()
+}
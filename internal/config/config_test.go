@@ -0,0 +1,46 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadEnv(t *testing.T) {
+	t.Setenv("HTTP_READ_TIMEOUT", "3s")
+	t.Setenv("GRAPHQL_MAX_DEPTH", " 4 ")
+	t.Setenv("AUTO_MIGRATE", "false")
+	t.Setenv("TRACING_SAMPLE_RATIO", "0.25")
+	t.Setenv("DB_MAX_CONNS", "")
+
+	cfg, _, err := Load([]string{"-graphql-max-depth", "6"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ReadTimeout != 3*time.Second || cfg.AutoMigrate || cfg.TracingSampleRatio != 0.25 {
+		t.Errorf("environment not applied: %+v", cfg)
+	}
+	if cfg.GraphQLMaxDepth != 6 {
+		t.Errorf("flag didn't override the environment: depth %d", cfg.GraphQLMaxDepth)
+	}
+	if cfg.DBMaxConns != Default().DBMaxConns {
+		t.Errorf("empty variable not ignored: %d", cfg.DBMaxConns)
+	}
+}
+
+func TestLoadEnvErrors(t *testing.T) {
+	t.Setenv("HTTP_READ_TIMEOUT", "5")
+	t.Setenv("DB_MAX_CONNS", "ten")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "maybe")
+	t.Setenv("TRACING_SAMPLE_RATIO", "1/2")
+
+	_, _, err := Load(nil)
+	if err == nil {
+		t.Fatal("Load accepted invalid values")
+	}
+	for _, key := range []string{"HTTP_READ_TIMEOUT", "DB_MAX_CONNS", "CORS_ALLOW_CREDENTIALS", "TRACING_SAMPLE_RATIO"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("error doesn't name %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// loadFile overrides c with the settings of a YAML or TOML file, chosen by extension.
+// Unknown keys are rejected so that typos don't go unnoticed.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: can't read config file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		// An empty file yields io.EOF and leaves the defaults untouched.
+		if err := dec.Decode(c); err != nil && len(bytes.TrimSpace(data)) > 0 {
+			return fmt.Errorf("config: invalid config file %s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), c)
+		if err != nil {
+			return fmt.Errorf("config: invalid config file %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("config: invalid config file %s: unknown key %q", path, undecoded[0].String())
+		}
+	default:
+		return fmt.Errorf("config: unsupported config file format %q (use .yaml, .yml or .toml)", ext)
+	}
+	return nil
+}
+
+// loadEnv overrides c with the environment variables that are set. Values
+// that can't be parsed are reported together, naming their variables.
+func (c *Config) loadEnv() error {
+	var env envReader
+	c.Environment = env.string("ENVIRONMENT", c.Environment)
+
+	c.Port = env.string("PORT", c.Port)
+	c.ReadTimeout = env.duration("HTTP_READ_TIMEOUT", c.ReadTimeout)
+	c.ReadHeaderTimeout = env.duration("HTTP_READ_HEADER_TIMEOUT", c.ReadHeaderTimeout)
+	c.WriteTimeout = env.duration("HTTP_WRITE_TIMEOUT", c.WriteTimeout)
+	c.IdleTimeout = env.duration("HTTP_IDLE_TIMEOUT", c.IdleTimeout)
+	c.ShutdownTimeout = env.duration("SHUTDOWN_TIMEOUT", c.ShutdownTimeout)
+	c.ShutdownDrainDelay = env.duration("SHUTDOWN_DRAIN_DELAY", c.ShutdownDrainDelay)
+	c.TLSCertFile = env.string("TLS_CERT_FILE", c.TLSCertFile)
+	c.TLSKeyFile = env.string("TLS_KEY_FILE", c.TLSKeyFile)
+	c.CORSAllowedOrigins = env.list("CORS_ALLOWED_ORIGINS", c.CORSAllowedOrigins)
+	c.CORSAllowedMethods = env.list("CORS_ALLOWED_METHODS", c.CORSAllowedMethods)
+	c.CORSAllowedHeaders = env.list("CORS_ALLOWED_HEADERS", c.CORSAllowedHeaders)
+	c.CORSAllowCredentials = env.bool("CORS_ALLOW_CREDENTIALS", c.CORSAllowCredentials)
+	c.CORSMaxAge = env.duration("CORS_MAX_AGE", c.CORSMaxAge)
+	c.HSTSMaxAge = env.duration("HSTS_MAX_AGE", c.HSTSMaxAge)
+	c.MetricsAddr = env.string("METRICS_ADDR", c.MetricsAddr)
+	c.MetricsToken = env.string("METRICS_TOKEN", c.MetricsToken)
+	c.GRPCPort = env.string("GRPC_PORT", c.GRPCPort)
+	c.GRPCOnHTTP = env.bool("GRPC_ON_HTTP", c.GRPCOnHTTP)
+	c.GRPCReflection = env.bool("GRPC_REFLECTION", c.GRPCReflection)
+	c.GraphQLMaxComplexity = env.int("GRAPHQL_MAX_COMPLEXITY", c.GraphQLMaxComplexity)
+	c.GraphQLMaxDepth = env.int("GRAPHQL_MAX_DEPTH", c.GraphQLMaxDepth)
+	c.EventsBufferSize = env.int("EVENTS_BUFFER_SIZE", c.EventsBufferSize)
+	c.EventsPostgresNotify = env.bool("EVENTS_POSTGRES_NOTIFY", c.EventsPostgresNotify)
+	c.WebhookTimeout = env.duration("WEBHOOK_TIMEOUT", c.WebhookTimeout)
+	c.WebhookMaxAttempts = env.int("WEBHOOK_MAX_ATTEMPTS", c.WebhookMaxAttempts)
+	c.WebhookRetryBackoff = env.duration("WEBHOOK_RETRY_BACKOFF", c.WebhookRetryBackoff)
+	c.WebhookDisableAfter = env.int("WEBHOOK_DISABLE_AFTER", c.WebhookDisableAfter)
+	c.WebhookLogRetention = env.duration("WEBHOOK_LOG_RETENTION", c.WebhookLogRetention)
+	c.WebhookAllowPrivate = env.bool("WEBHOOK_ALLOW_PRIVATE", c.WebhookAllowPrivate)
+
+	c.DBDriver = env.string("DB_DRIVER", c.DBDriver)
+	c.DBURL = env.string("DB_URL", c.DBURL)
+	c.DBMaxConns = env.int("DB_MAX_CONNS", c.DBMaxConns)
+	c.DBMinConns = env.int("DB_MIN_CONNS", c.DBMinConns)
+	c.SQLitePath = env.string("SQLITE_PATH", c.SQLitePath)
+	c.AutoMigrate = env.bool("AUTO_MIGRATE", c.AutoMigrate)
+
+	c.JWTSecret = env.string("JWT_SECRET", c.JWTSecret)
+	c.TokenTTL = env.duration("TOKEN_TTL", c.TokenTTL)
+	c.LoginMaxFailures = env.int("LOGIN_MAX_FAILURES", c.LoginMaxFailures)
+	c.LoginLockout = env.duration("LOGIN_LOCKOUT", c.LoginLockout)
+	c.LoginLockoutMax = env.duration("LOGIN_LOCKOUT_MAX", c.LoginLockoutMax)
+
+	c.RateLimitEnabled = env.bool("RATE_LIMIT_ENABLED", c.RateLimitEnabled)
+	c.RateLimitStore = env.string("RATE_LIMIT_STORE", c.RateLimitStore)
+	c.RedisURL = env.string("REDIS_URL", c.RedisURL)
+	c.RateLimitAuth = env.string("RATE_LIMIT_AUTH", c.RateLimitAuth)
+	c.RateLimitDefault = env.string("RATE_LIMIT_DEFAULT", c.RateLimitDefault)
+	c.RateLimitHeavy = env.string("RATE_LIMIT_HEAVY", c.RateLimitHeavy)
+	c.TrustProxy = env.bool("TRUST_PROXY", c.TrustProxy)
+
+	c.LogLevel = env.string("LOG_LEVEL", c.LogLevel)
+	c.LogFormat = env.string("LOG_FORMAT", c.LogFormat)
+
+	c.ServiceName = env.string("OTEL_SERVICE_NAME", c.ServiceName)
+	c.TracingExporter = env.string("TRACING_EXPORTER", c.TracingExporter)
+	c.OTLPEndpoint = env.string("OTEL_EXPORTER_OTLP_ENDPOINT", c.OTLPEndpoint)
+	c.OTLPInsecure = env.bool("OTEL_EXPORTER_OTLP_INSECURE", c.OTLPInsecure)
+	c.TracingSampleRatio = env.float("TRACING_SAMPLE_RATIO", c.TracingSampleRatio)
+
+	if len(env.errs) > 0 {
+		return fmt.Errorf("config: invalid environment: %w", errors.Join(env.errs...))
+	}
+	return nil
+}
+
+// flagSet returns the command line flags bound to the fields of c, using the
+// current values as defaults so that only flags given explicitly override them.
+func (c *Config) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+
+	fs.StringVar(&c.ConfigFile, "config", c.ConfigFile, "path to a YAML or TOML config file")
+	fs.StringVar(&c.Environment, "environment", c.Environment, "production or development")
+
+	fs.StringVar(&c.Port, "port", c.Port, "HTTP listen port")
+	fs.DurationVar(&c.ReadTimeout, "read-timeout", c.ReadTimeout, "maximum duration for reading a request")
+	fs.DurationVar(&c.ReadHeaderTimeout, "read-header-timeout", c.ReadHeaderTimeout, "maximum duration for reading request headers")
+	fs.DurationVar(&c.WriteTimeout, "write-timeout", c.WriteTimeout, "maximum duration for writing a response")
+	fs.DurationVar(&c.IdleTimeout, "idle-timeout", c.IdleTimeout, "keep-alive idle timeout")
+	fs.DurationVar(&c.ShutdownTimeout, "shutdown-timeout", c.ShutdownTimeout, "time allowed for in-flight requests on shutdown")
+	fs.DurationVar(&c.ShutdownDrainDelay, "shutdown-drain-delay", c.ShutdownDrainDelay, "time readiness fails before the listener closes")
+	fs.StringVar(&c.TLSCertFile, "tls-cert-file", c.TLSCertFile, "PEM certificate file; enables HTTPS")
+	fs.StringVar(&c.TLSKeyFile, "tls-key-file", c.TLSKeyFile, "PEM private key file")
+	fs.Func("cors-allowed-origins", "comma-separated origins allowed to call the API", func(s string) error {
+		c.CORSAllowedOrigins = splitList(s)
+		return nil
+	})
+	fs.Func("cors-allowed-methods", "comma-separated methods allowed in cross-origin requests", func(s string) error {
+		c.CORSAllowedMethods = splitList(s)
+		return nil
+	})
+	fs.Func("cors-allowed-headers", "comma-separated headers allowed in cross-origin requests", func(s string) error {
+		c.CORSAllowedHeaders = splitList(s)
+		return nil
+	})
+	fs.BoolVar(&c.CORSAllowCredentials, "cors-allow-credentials", c.CORSAllowCredentials, "allow credentials in cross-origin requests")
+	fs.DurationVar(&c.CORSMaxAge, "cors-max-age", c.CORSMaxAge, "how long browsers cache preflight responses")
+	fs.DurationVar(&c.HSTSMaxAge, "hsts-max-age", c.HSTSMaxAge, "Strict-Transport-Security max-age (0 = disabled)")
+	fs.StringVar(&c.MetricsAddr, "metrics-addr", c.MetricsAddr, "separate listen address of GET /metrics, e.g. 127.0.0.1:9100")
+	fs.StringVar(&c.MetricsToken, "metrics-token", c.MetricsToken, "bearer token required by GET /metrics")
+	fs.StringVar(&c.GRPCPort, "grpc-port", c.GRPCPort, "gRPC listen port (empty = disabled)")
+	fs.BoolVar(&c.GRPCOnHTTP, "grpc-on-http", c.GRPCOnHTTP, "serve gRPC on the HTTP port as well")
+	fs.BoolVar(&c.GRPCReflection, "grpc-reflection", c.GRPCReflection, "enable gRPC server reflection")
+	fs.IntVar(&c.GraphQLMaxComplexity, "graphql-max-complexity", c.GraphQLMaxComplexity, "maximum estimated cost of a GraphQL query")
+	fs.IntVar(&c.GraphQLMaxDepth, "graphql-max-depth", c.GraphQLMaxDepth, "maximum nesting depth of a GraphQL query")
+	fs.IntVar(&c.EventsBufferSize, "events-buffer-size", c.EventsBufferSize, "recent events kept per user for resuming event streams")
+	fs.BoolVar(&c.EventsPostgresNotify, "events-postgres-notify", c.EventsPostgresNotify, "relay events between instances with PostgreSQL LISTEN/NOTIFY")
+	fs.DurationVar(&c.WebhookTimeout, "webhook-timeout", c.WebhookTimeout, "timeout of one webhook delivery attempt")
+	fs.IntVar(&c.WebhookMaxAttempts, "webhook-max-attempts", c.WebhookMaxAttempts, "attempts of a webhook delivery before it fails")
+	fs.DurationVar(&c.WebhookRetryBackoff, "webhook-retry-backoff", c.WebhookRetryBackoff, "delay before the first retry of a webhook delivery, doubled for every further one")
+	fs.IntVar(&c.WebhookDisableAfter, "webhook-disable-after", c.WebhookDisableAfter, "failed deliveries in a row that disable a webhook")
+	fs.DurationVar(&c.WebhookLogRetention, "webhook-log-retention", c.WebhookLogRetention, "how long completed webhook deliveries are kept (0 = forever)")
+	fs.BoolVar(&c.WebhookAllowPrivate, "webhook-allow-private", c.WebhookAllowPrivate, "allow webhook URLs on loopback and private networks")
+
+	fs.StringVar(&c.DBDriver, "db-driver", c.DBDriver, "database driver: postgres, sqlite or memory")
+	fs.StringVar(&c.DBURL, "db-url", c.DBURL, "PostgreSQL connection URL")
+	fs.IntVar(&c.DBMaxConns, "db-max-conns", c.DBMaxConns, "maximum PostgreSQL pool size (0 = driver default)")
+	fs.IntVar(&c.DBMinConns, "db-min-conns", c.DBMinConns, "minimum PostgreSQL pool size")
+	fs.StringVar(&c.SQLitePath, "sqlite-path", c.SQLitePath, "SQLite database file")
+	fs.BoolVar(&c.AutoMigrate, "auto-migrate", c.AutoMigrate, "apply pending migrations on startup")
+
+	fs.StringVar(&c.JWTSecret, "jwt-secret", c.JWTSecret, "secret used to sign access tokens")
+	fs.DurationVar(&c.TokenTTL, "token-ttl", c.TokenTTL, "lifetime of access tokens")
+	fs.IntVar(&c.LoginMaxFailures, "login-max-failures", c.LoginMaxFailures, "failed logins before an account is locked (0 = never)")
+	fs.DurationVar(&c.LoginLockout, "login-lockout", c.LoginLockout, "first lockout duration, doubled on every further failure")
+	fs.DurationVar(&c.LoginLockoutMax, "login-lockout-max", c.LoginLockoutMax, "longest lockout duration")
+
+	fs.BoolVar(&c.RateLimitEnabled, "rate-limit-enabled", c.RateLimitEnabled, "throttle requests per IP and per user")
+	fs.StringVar(&c.RateLimitStore, "rate-limit-store", c.RateLimitStore, "rate limit and lockout store: memory or redis")
+	fs.StringVar(&c.RedisURL, "redis-url", c.RedisURL, "Redis URL of the redis store, e.g. redis://localhost:6379/0")
+	fs.StringVar(&c.RateLimitAuth, "rate-limit-auth", c.RateLimitAuth, "per-IP limit of registration and login (RATE/PERIOD[:BURST])")
+	fs.StringVar(&c.RateLimitDefault, "rate-limit-default", c.RateLimitDefault, "per-user limit of API routes (RATE/PERIOD[:BURST])")
+	fs.StringVar(&c.RateLimitHeavy, "rate-limit-heavy", c.RateLimitHeavy, "per-user limit of search, batch and bulk updates (RATE/PERIOD[:BURST])")
+	fs.BoolVar(&c.TrustProxy, "trust-proxy", c.TrustProxy, "take the client IP from X-Forwarded-For")
+
+	fs.StringVar(&c.LogLevel, "log-level", c.LogLevel, "debug, info, warn or error")
+	fs.StringVar(&c.LogFormat, "log-format", c.LogFormat, "json or text")
+
+	fs.StringVar(&c.ServiceName, "service-name", c.ServiceName, "service name reported in traces")
+	fs.StringVar(&c.TracingExporter, "tracing-exporter", c.TracingExporter, "none or otlp")
+	fs.StringVar(&c.OTLPEndpoint, "otlp-endpoint", c.OTLPEndpoint, "OTLP/HTTP collector endpoint")
+	fs.BoolVar(&c.OTLPInsecure, "otlp-insecure", c.OTLPInsecure, "send traces without TLS")
+	fs.Float64Var(&c.TracingSampleRatio, "tracing-sample-ratio", c.TracingSampleRatio, "fraction of traces to sample")
+
+	return fs
+}
+
+// lookupFlag finds the value of flag name in args without parsing the others,
+// so the config file can be loaded before the flags override it. Like the flag
+// package it stops at the first non-flag argument or at "--".
+func lookupFlag(args []string, name string) (string, bool) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			break
+		}
+		arg = strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		if value, ok := strings.CutPrefix(arg, name+"="); ok {
+			return value, true
+		}
+		if arg == name && i+1 < len(args) {
+			return args[i+1], true
+		}
+	}
+	return "", false
+}
+
+// getEnv retrieves the value of the environment variable named by the key.
+// If the variable is not present, it returns the provided defaultValue.
+func getEnv(key, defaultValue string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return defaultValue
+}
+
+// envReader reads typed environment variables, collecting an error for every
+// value that can't be parsed instead of falling back to the default.
+type envReader struct {
+	errs []error
+}
+
+// string returns the value of the variable key, or defaultValue if it isn't set.
+func (e *envReader) string(key, defaultValue string) string {
+	return getEnv(key, defaultValue)
+}
+
+func (e *envReader) bool(key string, defaultValue bool) bool {
+	return parseEnv(e, key, defaultValue, strconv.ParseBool)
+}
+
+func (e *envReader) int(key string, defaultValue int) int {
+	return parseEnv(e, key, defaultValue, strconv.Atoi)
+}
+
+func (e *envReader) float(key string, defaultValue float64) float64 {
+	return parseEnv(e, key, defaultValue, func(s string) (float64, error) {
+		return strconv.ParseFloat(s, 64)
+	})
+}
+
+// duration reads durations such as "5s"; a number without a unit is an error.
+func (e *envReader) duration(key string, defaultValue time.Duration) time.Duration {
+	return parseEnv(e, key, defaultValue, time.ParseDuration)
+}
+
+// list reads comma-separated lists.
+func (e *envReader) list(key string, defaultValue []string) []string {
+	if value, exists := os.LookupEnv(key); exists {
+		return splitList(value)
+	}
+	return defaultValue
+}
+
+// parseEnv returns the value of the variable key parsed by parse, or
+// defaultValue if it isn't set or empty. Values that can't be parsed are
+// recorded in e.
+func parseEnv[T any](e *envReader, key string, defaultValue T, parse func(string) (T, error)) T {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return defaultValue
+	}
+	v, err := parse(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: invalid value %q", key, value))
+		return defaultValue
+	}
+	return v
+}
+
+// splitList splits a comma-separated list, trimming spaces and dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
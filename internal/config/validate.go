@@ -0,0 +1,193 @@
+package config
+
+import (
+	"errors"
+	"expense_tracker/internal/ratelimit"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minJWTSecretLength is the shortest JWT secret accepted outside development mode.
+const minJWTSecretLength = 32
+
+// Validate checks every setting and returns all problems at once.
+// Outside development mode it also refuses insecure defaults such as a
+// missing or short JWT secret and the docker-compose database credentials.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	switch c.Environment {
+	case EnvProduction, EnvDevelopment:
+	default:
+		fail("environment must be %q or %q, got %q", EnvProduction, EnvDevelopment, c.Environment)
+	}
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		fail("port must be a number between 1 and 65535, got %q", c.Port)
+	}
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"read_timeout", c.ReadTimeout},
+		{"read_header_timeout", c.ReadHeaderTimeout},
+		{"write_timeout", c.WriteTimeout},
+		{"idle_timeout", c.IdleTimeout},
+		{"shutdown_timeout", c.ShutdownTimeout},
+		{"shutdown_drain_delay", c.ShutdownDrainDelay},
+	} {
+		if d.value < 0 {
+			fail("%s must not be negative", d.name)
+		}
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		fail("tls_cert_file and tls_key_file must be set together")
+	}
+
+	for _, origin := range c.CORSAllowedOrigins {
+		if origin == "*" && c.CORSAllowCredentials {
+			fail("cors_allowed_origins: \"*\" can't be combined with cors_allow_credentials")
+		}
+		if origin != "*" && !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			fail("cors_allowed_origins: %q is not an http(s) origin", origin)
+		}
+		if strings.HasSuffix(origin, "/") {
+			fail("cors_allowed_origins: %q must not end with a slash", origin)
+		}
+	}
+	for _, method := range c.CORSAllowedMethods {
+		if method != strings.ToUpper(method) {
+			fail("cors_allowed_methods: %q must be upper case", method)
+		}
+	}
+	if c.CORSMaxAge < 0 || c.HSTSMaxAge < 0 {
+		fail("cors_max_age and hsts_max_age must not be negative")
+	}
+	if c.MetricsAddr != "" {
+		if _, port, err := net.SplitHostPort(c.MetricsAddr); err != nil || port == c.Port {
+			fail("metrics_addr must be a host:port address other than the API port, got %q", c.MetricsAddr)
+		}
+	}
+	if c.GRPCPort != "" {
+		if port, err := strconv.Atoi(c.GRPCPort); err != nil || port < 1 || port > 65535 {
+			fail("grpc_port must be a number between 1 and 65535, got %q", c.GRPCPort)
+		} else if c.GRPCPort == c.Port {
+			fail("grpc_port must differ from port, use grpc_on_http to share it")
+		}
+	}
+	if c.GraphQLMaxComplexity < 1 || c.GraphQLMaxDepth < 1 {
+		fail("graphql_max_complexity and graphql_max_depth must be positive")
+	}
+	if c.EventsBufferSize < 1 {
+		fail("events_buffer_size must be positive")
+	}
+	if c.EventsPostgresNotify && c.DBDriver != DriverPostgres {
+		fail("events_postgres_notify requires the %s driver", DriverPostgres)
+	}
+	if c.WebhookTimeout <= 0 || c.WebhookRetryBackoff <= 0 {
+		fail("webhook_timeout and webhook_retry_backoff must be positive")
+	}
+	if c.WebhookMaxAttempts < 1 || c.WebhookDisableAfter < 1 {
+		fail("webhook_max_attempts and webhook_disable_after must be positive")
+	}
+	if c.WebhookLogRetention < 0 {
+		fail("webhook_log_retention must not be negative")
+	}
+
+	switch c.DBDriver {
+	case DriverPostgres:
+		if c.DBURL == "" {
+			fail("db_url is required for the %s driver", DriverPostgres)
+		}
+	case DriverSQLite:
+		if c.SQLitePath == "" {
+			fail("sqlite_path is required for the %s driver", DriverSQLite)
+		}
+	case DriverMemory:
+	default:
+		fail("db_driver must be %q, %q or %q, got %q", DriverPostgres, DriverSQLite, DriverMemory, c.DBDriver)
+	}
+	if c.DBMaxConns < 0 || c.DBMinConns < 0 {
+		fail("db_max_conns and db_min_conns must not be negative")
+	}
+	if c.DBMaxConns > 0 && c.DBMinConns > c.DBMaxConns {
+		fail("db_min_conns (%d) must not exceed db_max_conns (%d)", c.DBMinConns, c.DBMaxConns)
+	}
+
+	if c.TokenTTL <= 0 {
+		fail("token_ttl must be positive")
+	}
+	if c.LoginMaxFailures < 0 {
+		fail("login_max_failures must not be negative")
+	}
+	if c.LoginMaxFailures > 0 && (c.LoginLockout <= 0 || c.LoginLockoutMax < c.LoginLockout) {
+		fail("login_lockout must be positive and not exceed login_lockout_max")
+	}
+
+	switch c.RateLimitStore {
+	case StoreMemory:
+	case StoreRedis:
+		if c.RedisURL == "" {
+			fail("redis_url is required for the %s rate limit store", StoreRedis)
+		}
+	default:
+		fail("rate_limit_store must be %q or %q, got %q", StoreMemory, StoreRedis, c.RateLimitStore)
+	}
+	for _, l := range []struct{ name, value string }{
+		{"rate_limit_auth", c.RateLimitAuth},
+		{"rate_limit_default", c.RateLimitDefault},
+		{"rate_limit_heavy", c.RateLimitHeavy},
+	} {
+		if _, err := ratelimit.ParseLimit(l.value); err != nil {
+			fail("%s: %v", l.name, err)
+		}
+	}
+
+	switch strings.ToLower(c.LogLevel) {
+	case "debug", "info", "warn", "error":
+	default:
+		fail("log_level must be debug, info, warn or error, got %q", c.LogLevel)
+	}
+	switch c.LogFormat {
+	case "json", "text":
+	default:
+		fail("log_format must be json or text, got %q", c.LogFormat)
+	}
+
+	switch c.TracingExporter {
+	case "none", "otlp":
+	default:
+		fail("tracing_exporter must be none or otlp, got %q", c.TracingExporter)
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		fail("tracing_sample_ratio must be between 0 and 1, got %g", c.TracingSampleRatio)
+	}
+
+	if !c.IsDevelopment() {
+		switch {
+		case c.JWTSecret == "":
+			fail("jwt_secret is required outside development mode")
+		case c.JWTSecret == devJWTSecret:
+			fail("jwt_secret must not be the built-in development secret")
+		case len(c.JWTSecret) < minJWTSecretLength:
+			fail("jwt_secret must be at least %d bytes long", minJWTSecretLength)
+		}
+		if c.DBDriver == DriverPostgres && c.DBURL == defaultDBURL {
+			fail("db_url must not use the default credentials outside development mode")
+		}
+		if c.DBDriver == DriverMemory {
+			fail("the %s driver loses all data on restart and is only allowed in development mode", DriverMemory)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("config: invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+// Package dispatch sends the webhook deliveries queued by
+// service.WebhookService. Deliveries are claimed from the outbox with a
+// lease, so several instances can dispatch from one database, and a delivery
+// whose instance stops before recording the attempt is retried once its
+// lease expires.
+package dispatch
+
+import (
+	"bytes"
+	"context"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"expense_tracker/lib"
+	"expense_tracker/pkg/webhook"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// pollInterval is how often the outbox is checked for due deliveries.
+	pollInterval = time.Second
+	// batchSize is how many deliveries are claimed and sent at once.
+	batchSize = 20
+	// leaseMargin is added to the attempt timeout for the claim lease.
+	leaseMargin = time.Minute
+	// maxBackoff caps the delay between two attempts.
+	maxBackoff = 6 * time.Hour
+	// pruneInterval is how often deliveries older than the retention are deleted.
+	pruneInterval = time.Hour
+	// maxResponseBody is how much of a response body is kept in the log.
+	maxResponseBody = 1024
+	// userAgent identifies the deliveries.
+	userAgent = "expense-tracker-webhooks"
+)
+
+// Options configure a Dispatcher.
+type Options struct {
+	// Timeout bounds one attempt, including reading the response.
+	Timeout time.Duration
+	// MaxAttempts is how many times a delivery is attempted before it fails.
+	MaxAttempts int
+	// RetryBackoff is the delay before the second attempt; it doubles with
+	// every further attempt.
+	RetryBackoff time.Duration
+	// DisableAfter is how many deliveries to a webhook may fail in a row
+	// before it is disabled.
+	DisableAfter int
+	// LogRetention is how long completed deliveries are kept; 0 keeps them forever.
+	LogRetention time.Duration
+}
+
+// Dispatcher sends the due deliveries of the outbox.
+type Dispatcher struct {
+	repo   repository.WebhookRepository
+	client *http.Client
+	opts   Options
+}
+
+// New creates a Dispatcher sending the deliveries of repo with client,
+// which should come from NewClient.
+func New(repo repository.WebhookRepository, client *http.Client, opts Options) *Dispatcher {
+	return &Dispatcher{
+		repo:   repo,
+		client: client,
+		opts:   opts,
+	}
+}
+
+// Run sends due deliveries until ctx is done. Deliveries being sent when ctx
+// is canceled are completed and recorded before Run returns.
+func (d *Dispatcher) Run(ctx context.Context) {
+	poll := time.NewTicker(pollInterval)
+	defer poll.Stop()
+
+	var lastPrune time.Time
+	for {
+		if d.opts.LogRetention > 0 && time.Since(lastPrune) >= pruneInterval {
+			d.prune(ctx)
+			lastPrune = time.Now()
+		}
+		// Full batches suggest more deliveries are due.
+		for d.dispatch(ctx) == batchSize && ctx.Err() == nil {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		}
+	}
+}
+
+// dispatch claims a batch of due deliveries, sends them concurrently and
+// returns how many there were.
+func (d *Dispatcher) dispatch(ctx context.Context) int {
+	claimed, err := d.repo.ClaimDeliveries(ctx, time.Now().UTC(), d.opts.Timeout+leaseMargin, batchSize)
+	if err != nil {
+		if ctx.Err() == nil {
+			lib.Logger(ctx).Error("dispatch: can't claim deliveries", "error", err)
+		}
+		return 0
+	}
+
+	// Claimed deliveries are sent even if ctx is canceled meanwhile.
+	sendCtx := context.WithoutCancel(ctx)
+	var wg sync.WaitGroup
+	for _, delivery := range claimed {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.deliver(sendCtx, &delivery)
+		}()
+	}
+	wg.Wait()
+	return len(claimed)
+}
+
+// deliver makes one attempt of a delivery and records it.
+func (d *Dispatcher) deliver(ctx context.Context, delivery *model.PendingDelivery) {
+	attempt := d.send(ctx, delivery)
+
+	status := model.DeliveryFailed
+	var next time.Time
+	switch {
+	case attempt.Error == "" && attempt.ResponseStatus >= 200 && attempt.ResponseStatus < 300:
+		status = model.DeliverySucceeded
+	case delivery.Attempts+1 < d.opts.MaxAttempts:
+		status = model.DeliveryPending
+		next = attempt.AttemptedAt.Add(d.backoff(delivery.Attempts + 1))
+	}
+
+	logger := lib.Logger(ctx).With("delivery_id", delivery.ID, "webhook_id", delivery.WebhookID)
+	level := slog.LevelDebug
+	if status == model.DeliveryFailed {
+		level = slog.LevelWarn
+	}
+	logger.Log(ctx, level, "webhook delivery attempted", "status", status, "attempt", delivery.Attempts+1,
+		"response_status", attempt.ResponseStatus, "error", attempt.Error)
+
+	if err := d.repo.RecordAttempt(ctx, attempt, status, next, d.opts.DisableAfter); err != nil {
+		logger.Error("dispatch: can't record attempt", "error", err)
+	}
+}
+
+// send POSTs the signed payload of a delivery.
+func (d *Dispatcher) send(ctx context.Context, delivery *model.PendingDelivery) model.WebhookAttempt {
+	start := time.Now()
+	attempt := model.WebhookAttempt{DeliveryID: delivery.ID, AttemptedAt: start.UTC()}
+	defer func() { attempt.DurationMS = time.Since(start).Milliseconds() }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	timestamp := start.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(webhook.HeaderEvent, string(delivery.EventType))
+	req.Header.Set(webhook.HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(webhook.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhook.HeaderSignature, webhook.Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	attempt.ResponseStatus = resp.StatusCode
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	attempt.ResponseBody = string(bytes.ToValidUTF8(body, nil))
+	if err != nil {
+		attempt.Error = "reading response: " + err.Error()
+	}
+	return attempt
+}
+
+// backoff returns the delay after the given attempt: RetryBackoff doubled
+// for every earlier attempt, capped at maxBackoff, with up to a quarter of
+// jitter so that the retries of many deliveries spread out.
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	// Doubling stops at the cap so that the delay can't overflow.
+	delay := min(d.opts.RetryBackoff, maxBackoff)
+	for range attempt - 1 {
+		if delay >= maxBackoff/2 {
+			delay = maxBackoff
+			break
+		}
+		delay *= 2
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay - rand.N(delay/4+1)
+}
+
+// prune deletes the completed deliveries older than the retention.
+func (d *Dispatcher) prune(ctx context.Context) {
+	n, err := d.repo.DeleteDeliveriesBefore(ctx, time.Now().UTC().Add(-d.opts.LogRetention))
+	if err != nil {
+		if ctx.Err() == nil {
+			lib.Logger(ctx).Error("dispatch: can't delete old deliveries", "error", err)
+		}
+		return
+	}
+	if n > 0 {
+		lib.Logger(ctx).Info("dispatch: deleted old deliveries", "count", n)
+	}
+}
@@ -0,0 +1,180 @@
+package dispatch
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository/memory"
+	"expense_tracker/pkg/webhook"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+const secret = "0123456789abcdef"
+
+// receiver answers deliveries with the given statuses in turn, then with 204,
+// and records the ones whose signature is valid.
+type receiver struct {
+	t *testing.T
+
+	mu       sync.Mutex
+	statuses []int
+	received []string
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	if err := webhook.Verify(secret, req.Header, body, time.Minute); err != nil {
+		r.t.Errorf("delivery %s: %v", req.Header.Get(webhook.HeaderDelivery), err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if event := req.Header.Get(webhook.HeaderEvent); event != string(model.EventExpenseCreated) {
+		r.t.Errorf("event header %q", event)
+	}
+
+	r.mu.Lock()
+	r.received = append(r.received, string(body))
+	status := http.StatusNoContent
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	r.mu.Unlock()
+	w.WriteHeader(status)
+}
+
+// store holds the webhooks and, for enqueuing deliveries, the expenses.
+type store struct {
+	*memory.WebhookRepository
+	expenses *memory.ExpenseRepository
+}
+
+// newDispatcher returns a dispatcher sending the deliveries of a webhook of
+// user 1 to a receiver answering with statuses.
+func newDispatcher(t *testing.T, opts Options, statuses ...int) (*Dispatcher, store, *model.Webhook, *receiver) {
+	t.Helper()
+	ctx := context.Background()
+	db := memory.NewDB()
+	if err := memory.NewUserRepository(db).CreateUser(ctx, &model.User{Username: "alice", Password: "hash"}); err != nil {
+		t.Fatal(err)
+	}
+
+	recv := &receiver{t: t, statuses: statuses}
+	server := httptest.NewServer(recv)
+	t.Cleanup(server.Close)
+
+	repo := memory.NewWebhookRepository(db)
+	hook, err := repo.CreateWebhook(ctx, model.Webhook{
+		UserID:     1,
+		URL:        server.URL,
+		Secret:     secret,
+		EventTypes: []model.EventType{model.EventExpenseCreated},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(repo, NewClient(time.Second, true), opts), store{repo, memory.NewExpenseRepository(db)}, hook, recv
+}
+
+// enqueue adds a delivery of payload to the webhooks of user 1.
+func enqueue(t *testing.T, repo store, payload string) {
+	t.Helper()
+	events := []model.OutboxEvent{{Type: model.EventExpenseCreated, Payload: []byte(payload)}}
+	if _, err := repo.expenses.EnqueueDeliveries(context.Background(), 1, events, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDispatchRetries(t *testing.T) {
+	ctx := context.Background()
+	d, repo, hook, recv := newDispatcher(t, Options{
+		Timeout:      time.Second,
+		MaxAttempts:  3,
+		RetryBackoff: time.Millisecond,
+		DisableAfter: 5,
+	}, http.StatusInternalServerError)
+	enqueue(t, repo, `{"id":1}`)
+
+	if n := d.dispatch(ctx); n != 1 {
+		t.Fatalf("dispatched %d deliveries, want 1", n)
+	}
+	deliveries, err := repo.ListDeliveries(ctx, hook.ID, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := deliveries[0]; got.Status != model.DeliveryPending || got.Attempts != 1 || got.ResponseStatus != http.StatusInternalServerError {
+		t.Fatalf("after a failed attempt: %+v", got)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if n := d.dispatch(ctx); n != 1 {
+		t.Fatalf("retried %d deliveries, want 1", n)
+	}
+	delivery, err := repo.GetDelivery(ctx, deliveries[0].ID, hook.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != model.DeliverySucceeded || len(delivery.Log) != 2 {
+		t.Errorf("after the retry: %+v", delivery)
+	}
+	if len(recv.received) != 2 || recv.received[1] != `{"id":1}` {
+		t.Errorf("received %q", recv.received)
+	}
+}
+
+func TestDispatchDisablesFailingWebhook(t *testing.T) {
+	ctx := context.Background()
+	d, repo, hook, recv := newDispatcher(t, Options{
+		Timeout:      time.Second,
+		MaxAttempts:  1,
+		RetryBackoff: time.Millisecond,
+		DisableAfter: 2,
+	}, http.StatusBadGateway, http.StatusBadGateway)
+
+	for range 2 {
+		enqueue(t, repo, `{}`)
+	}
+	if n := d.dispatch(ctx); n != 2 {
+		t.Fatalf("dispatched %d deliveries, want 2", n)
+	}
+	got, err := repo.GetWebhook(ctx, hook.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Active || got.DisabledAt == nil || got.ConsecutiveFailures != 2 {
+		t.Fatalf("webhook not disabled: %+v", got)
+	}
+
+	// Deliveries of a disabled webhook aren't sent.
+	enqueue(t, repo, `{}`)
+	if n := d.dispatch(ctx); n != 0 || len(recv.received) != 2 {
+		t.Errorf("dispatched %d deliveries to a disabled webhook", n)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := New(nil, nil, Options{RetryBackoff: time.Minute})
+	for attempt, want := range map[int]time.Duration{
+		1:       time.Minute,
+		2:       2 * time.Minute,
+		5:       16 * time.Minute,
+		20:      maxBackoff,
+		64:      maxBackoff,
+		1 << 20: maxBackoff,
+	} {
+		if got := d.backoff(attempt); got > want || got < want-want/4 {
+			t.Errorf("backoff(%d) = %v, want %v less up to a quarter", attempt, got, want)
+		}
+	}
+
+	// Delays close to the maximum can't overflow when doubled.
+	d = New(nil, nil, Options{RetryBackoff: 1 << 62})
+	for _, attempt := range []int{1, 2, 3, 40} {
+		if got := d.backoff(attempt); got <= 0 || got > maxBackoff {
+			t.Errorf("backoff(%d) of a huge delay = %v", attempt, got)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Package events distributes expense events to the clients streaming them
+// from GET /events. A Hub delivers the events published by one instance;
+// PostgresBridge relays them between instances sharing a database.
+package events
+
+import (
+	"cmp"
+	"context"
+	"expense_tracker/internal/model"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// subscriptionBuffer is how many events a subscriber may lag behind
+	// before it is dropped.
+	subscriptionBuffer = 64
+	// Events of users without subscribers are forgotten once they are older
+	// than retention; sweeps for them run at most every sweepInterval.
+	retention     = time.Hour
+	sweepInterval = time.Minute
+)
+
+// Hub fans out expense events to the subscribers of their user and keeps
+// the most recent events of every user, so that clients reconnecting with
+// the ID of the last event they saw can catch up.
+type Hub struct {
+	mu   sync.Mutex
+	size int
+	// startID is the first ID an event published to the hub could have;
+	// events before it were published to a previous process and are unknown.
+	startID int64
+	// forgotten is the ID of the newest event swept with its user. Users
+	// created later start from it, so resuming from an event before it is
+	// incomplete whichever user published it.
+	forgotten int64
+	users     map[int]*userEvents
+	lastSweep time.Time
+	closed    bool
+}
+
+type userEvents struct {
+	recent []model.ExpenseEvent // ordered by ID
+	// evicted is the ID of the newest event dropped from recent.
+	evicted int64
+	subs    map[*Subscription]struct{}
+}
+
+// Subscription receives the events of one user.
+type Subscription struct {
+	hub    *Hub
+	userID int
+	ch     chan model.ExpenseEvent
+}
+
+// NewHub creates a Hub keeping up to size recent events per user.
+func NewHub(size int) *Hub {
+	now := time.Now()
+	return &Hub{
+		size:      size,
+		startID:   now.UnixMicro(),
+		users:     map[int]*userEvents{},
+		lastSweep: now,
+	}
+}
+
+// Publish delivers event to the subscribers of its user.
+func (h *Hub) Publish(_ context.Context, event model.ExpenseEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+
+	u := h.user(event.UserID)
+	// Events relayed from other instances may arrive out of order.
+	i := len(u.recent)
+	for i > 0 && u.recent[i-1].ID > event.ID {
+		i--
+	}
+	u.recent = slices.Insert(u.recent, i, event)
+	if len(u.recent) > h.size {
+		u.evicted = max(u.evicted, u.recent[0].ID)
+		u.recent = slices.Delete(u.recent, 0, 1)
+	}
+
+	for sub := range u.subs {
+		select {
+		case sub.ch <- event:
+		default:
+			// The client can't keep up; closing the subscription ends its
+			// stream, and it catches up from the recent events on reconnect.
+			h.drop(u, sub)
+		}
+	}
+
+	h.sweep()
+}
+
+// Subscribe starts receiving the events of a user. If lastEventID is not 0,
+// it also returns the recent events published after it; complete is false
+// if some of them are no longer known, and the client has to reload its
+// state instead.
+func (h *Hub) Subscribe(userID int, lastEventID int64) (sub *Subscription, missed []model.ExpenseEvent, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub = &Subscription{hub: h, userID: userID, ch: make(chan model.ExpenseEvent, subscriptionBuffer)}
+	if h.closed {
+		close(sub.ch)
+		return sub, nil, true
+	}
+
+	u := h.user(userID)
+	u.subs[sub] = struct{}{}
+	if lastEventID == 0 {
+		return sub, nil, true
+	}
+	if lastEventID < h.startID || lastEventID < u.evicted {
+		return sub, nil, false
+	}
+
+	i, _ := slices.BinarySearchFunc(u.recent, lastEventID+1, func(e model.ExpenseEvent, id int64) int {
+		return cmp.Compare(e.ID, id)
+	})
+	return sub, slices.Clone(u.recent[i:]), true
+}
+
+// Close stops all subscriptions, ending their streams. It is called when
+// the server shuts down.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, u := range h.users {
+		for sub := range u.subs {
+			h.drop(u, sub)
+		}
+	}
+}
+
+func (h *Hub) user(userID int) *userEvents {
+	u, ok := h.users[userID]
+	if !ok {
+		u = &userEvents{evicted: h.forgotten, subs: map[*Subscription]struct{}{}}
+		h.users[userID] = u
+	}
+	return u
+}
+
+func (h *Hub) drop(u *userEvents, sub *Subscription) {
+	delete(u.subs, sub)
+	close(sub.ch)
+}
+
+// sweep forgets the users nobody is subscribed to whose events are all old,
+// or who have none. The ID of their newest event is kept in forgotten, so
+// resuming from an event before it is incomplete.
+func (h *Hub) sweep() {
+	now := time.Now()
+	if now.Sub(h.lastSweep) < sweepInterval {
+		return
+	}
+	h.lastSweep = now
+
+	for userID, u := range h.users {
+		n := len(u.recent)
+		if len(u.subs) > 0 || n > 0 && now.Sub(u.recent[n-1].Time) < retention {
+			continue
+		}
+		h.forgotten = max(h.forgotten, u.evicted)
+		if n > 0 {
+			h.forgotten = max(h.forgotten, u.recent[n-1].ID)
+		}
+		delete(h.users, userID)
+	}
+}
+
+// Events returns the channel delivering the events. It is closed when the
+// subscription ends: by Close, when the hub shuts down or when the
+// subscriber falls too far behind.
+func (s *Subscription) Events() <-chan model.ExpenseEvent {
+	return s.ch
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if u, ok := h.users[s.userID]; ok {
+		if _, ok := u.subs[s]; ok {
+			h.drop(u, s)
+		}
+	}
+	h.sweep()
+}
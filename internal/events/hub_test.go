@@ -0,0 +1,114 @@
+package events
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"testing"
+	"time"
+)
+
+// publish publishes an event of user at time t with the next ID of the hub.
+func publish(h *Hub, userID int, t time.Time) model.ExpenseEvent {
+	h.mu.Lock()
+	id := h.startID + 1
+	for _, u := range h.users {
+		if n := len(u.recent); n > 0 {
+			id = max(id, u.recent[n-1].ID+1)
+		}
+	}
+	id = max(id, h.forgotten+1)
+	h.mu.Unlock()
+
+	event := model.ExpenseEvent{ID: id, Type: model.EventExpenseCreated, UserID: userID, Time: t}
+	h.Publish(context.Background(), event)
+	return event
+}
+
+// expireSweep makes the next operation sweep the hub.
+func expireSweep(h *Hub) {
+	h.mu.Lock()
+	h.lastSweep = time.Now().Add(-2 * sweepInterval)
+	h.mu.Unlock()
+}
+
+func TestHubResume(t *testing.T) {
+	h := NewHub(2)
+	now := time.Now()
+	first := publish(h, 1, now)
+	second := publish(h, 1, now)
+	third := publish(h, 1, now)
+
+	sub, missed, complete := h.Subscribe(1, second.ID)
+	defer sub.Close()
+	if !complete || len(missed) != 1 || missed[0].ID != third.ID {
+		t.Errorf("resume from a recent event: complete %v, missed %v", complete, missed)
+	}
+	// The first event was evicted by the third.
+	if _, _, complete := h.Subscribe(1, first.ID-1); complete {
+		t.Error("resume from an evicted event reported complete")
+	}
+
+	fourth := publish(h, 1, now)
+	if event := <-sub.Events(); event.ID != fourth.ID {
+		t.Errorf("subscriber got event %d, want %d", event.ID, fourth.ID)
+	}
+}
+
+func TestHubSweepForgetsIdleUsers(t *testing.T) {
+	h := NewHub(10)
+	old := time.Now().Add(-2 * retention)
+	stale := publish(h, 1, old)
+
+	// A subscribed user is kept whatever the age of its events.
+	publish(h, 2, old)
+	sub, _, _ := h.Subscribe(2, 0)
+
+	// User 3 subscribed once and never published.
+	idle, _, _ := h.Subscribe(3, 0)
+	idle.Close()
+
+	expireSweep(h)
+	fresh := publish(h, 4, time.Now())
+
+	h.mu.Lock()
+	_, kept1 := h.users[1]
+	_, kept2 := h.users[2]
+	_, kept3 := h.users[3]
+	_, kept4 := h.users[4]
+	users := len(h.users)
+	h.mu.Unlock()
+	if kept1 || !kept2 || kept3 || !kept4 || users != 2 {
+		t.Fatalf("kept users 1: %v, 2: %v, 3: %v, 4: %v (%d users)", kept1, kept2, kept3, kept4, users)
+	}
+
+	// The forgotten events of user 1 can't be replayed.
+	resumed, missed, complete := h.Subscribe(1, stale.ID-1)
+	if complete || len(missed) != 0 {
+		t.Errorf("resume across forgotten events: complete %v, missed %v", complete, missed)
+	}
+	resumed.Close()
+	// Nothing was published after the newest forgotten event.
+	resumed, _, complete = h.Subscribe(1, stale.ID)
+	if !complete {
+		t.Error("resume after the forgotten events reported incomplete")
+	}
+	resumed.Close()
+
+	// Once unsubscribed, user 2 is forgotten as well.
+	sub.Close()
+	expireSweep(h)
+	publish(h, 4, time.Now())
+	h.mu.Lock()
+	_, kept2 = h.users[2]
+	h.mu.Unlock()
+	if kept2 {
+		t.Error("user 2 kept after unsubscribing")
+	}
+
+	// Recent events of other users are still replayed.
+	resumed, missed, complete = h.Subscribe(4, fresh.ID-1)
+	defer resumed.Close()
+	if !complete || len(missed) != 2 {
+		t.Errorf("resume user 4: complete %v, %d missed", complete, len(missed))
+	}
+}
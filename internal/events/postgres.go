@@ -0,0 +1,168 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"expense_tracker/internal/model"
+	"expense_tracker/lib"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// notifyChannel is the PostgreSQL channel the events are sent on.
+	notifyChannel = "expense_events"
+	// notifyTimeout bounds sending one notification.
+	notifyTimeout = 5 * time.Second
+	// reconnectDelay is how long Run waits before listening again after
+	// the connection failed.
+	reconnectDelay = 5 * time.Second
+)
+
+// PostgresBridge publishes events to the local Hub and, with PostgreSQL
+// LISTEN/NOTIFY, to the hubs of the other instances using the database.
+//
+// Notifications are not stored: instances miss the events sent while they
+// are reconnecting, and event IDs are only ordered across instances as far
+// as their clocks agree.
+//
+// Payloads of notifications are limited to 8000 bytes, which an expense with
+// a long description and many tags can exceed, so notifications leave the
+// expense out and the receiving instances load it from the database. They
+// send the expense as it is when they load it, which may include later
+// changes, and drop the events of expenses deleted in the meantime.
+type PostgresBridge struct {
+	hub      *Hub
+	pool     *pgxpool.Pool
+	expenses ExpenseLoader
+	// origin identifies the notifications of this instance, which its hub
+	// has received already.
+	origin string
+}
+
+// ExpenseLoader loads the expenses of the events received from other
+// instances. repository.ExpenseRepository implements it.
+type ExpenseLoader interface {
+	GetExpenseByID(ctx context.Context, id, userID int) (*model.Expense, error)
+}
+
+// notification is the payload of a NOTIFY. Its event has no expense.
+type notification struct {
+	Origin string             `json:"origin"`
+	Event  model.ExpenseEvent `json:"event"`
+}
+
+// NewPostgresBridge creates a PostgresBridge relaying events between hub
+// and the instances connected to the database of pool. The expenses of
+// received events are loaded from expenses.
+func NewPostgresBridge(hub *Hub, pool *pgxpool.Pool, expenses ExpenseLoader) *PostgresBridge {
+	origin := make([]byte, 8)
+	rand.Read(origin)
+	return &PostgresBridge{
+		hub:      hub,
+		pool:     pool,
+		expenses: expenses,
+		origin:   hex.EncodeToString(origin),
+	}
+}
+
+// Publish delivers event to the local hub and notifies the other instances.
+// Notification failures are only logged, since the change is committed and
+// the local subscribers have received it.
+func (b *PostgresBridge) Publish(ctx context.Context, event model.ExpenseEvent) {
+	b.hub.Publish(ctx, event)
+
+	event.Expense = nil
+	payload, err := json.Marshal(notification{Origin: b.origin, Event: event})
+	if err != nil {
+		lib.Logger(ctx).Error("events: can't encode notification", "error", err, "event_id", event.ID)
+		return
+	}
+
+	// The request may be canceled right after the change was committed.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyTimeout)
+	defer cancel()
+	if _, err := b.pool.Exec(ctx, "SELECT pg_notify($1, $2)", notifyChannel, string(payload)); err != nil {
+		lib.Logger(ctx).Error("events: can't notify other instances", "error", err, "event_id", event.ID)
+	}
+}
+
+// Run receives the events of the other instances until ctx is done,
+// reconnecting whenever the connection fails.
+func (b *PostgresBridge) Run(ctx context.Context) {
+	for {
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		lib.Logger(ctx).Error("events: listening for notifications failed", "error", err, "retry_in", reconnectDelay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (b *PostgresBridge) listen(ctx context.Context) error {
+	pooled, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("events: can't acquire connection: %w", err)
+	}
+	// The connection stays subscribed to the channel, so it must not go back
+	// to the pool.
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		return fmt.Errorf("events: can't listen: %w", err)
+	}
+	lib.Logger(ctx).Info("events: listening for notifications", "channel", notifyChannel)
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("events: can't receive notification: %w", err)
+		}
+
+		var msg notification
+		if err := json.Unmarshal([]byte(n.Payload), &msg); err != nil {
+			lib.Logger(ctx).Warn("events: ignoring invalid notification", "error", err)
+			continue
+		}
+		if msg.Origin == b.origin {
+			continue
+		}
+		if event, ok := b.load(ctx, msg.Event); ok {
+			b.hub.Publish(ctx, event)
+		}
+	}
+}
+
+// load adds the expense to an event received from another instance. It
+// reports false if the event can't be published.
+func (b *PostgresBridge) load(ctx context.Context, event model.ExpenseEvent) (model.ExpenseEvent, bool) {
+	if event.Type == model.EventExpenseDeleted {
+		return event, true
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	expense, err := b.expenses.GetExpenseByID(ctx, event.ExpenseID, event.UserID)
+	if errors.Is(err, lib.ErrNotFound) {
+		// The deletion is reported by an event of its own.
+		return event, false
+	}
+	if err != nil {
+		lib.Logger(ctx).Error("events: can't load expense of notification", "error", err, "event_id", event.ID)
+		return event, false
+	}
+	event.Expense = expense
+	return event, true
+}
@@ -0,0 +1,43 @@
+package events
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository/memory"
+	"testing"
+	"time"
+)
+
+func TestBridgeLoadsExpenses(t *testing.T) {
+	ctx := context.Background()
+	db := memory.NewDB()
+	if err := memory.NewUserRepository(db).CreateUser(ctx, &model.User{Username: "alice", Password: "hash"}); err != nil {
+		t.Fatal(err)
+	}
+	expenses := memory.NewExpenseRepository(db)
+	id, err := expenses.CreateExpense(ctx, model.Expense{
+		UserID:   1,
+		Amount:   12.5,
+		Category: "food",
+		Date:     model.Date{Time: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &PostgresBridge{hub: NewHub(10), expenses: expenses}
+
+	event, ok := b.load(ctx, model.ExpenseEvent{ID: 1, Type: model.EventExpenseUpdated, UserID: 1, ExpenseID: id})
+	if !ok || event.Expense == nil || event.Expense.ID != id || event.Expense.Category != "food" {
+		t.Errorf("updated expense: %+v, %v", event, ok)
+	}
+	if _, ok := b.load(ctx, model.ExpenseEvent{ID: 2, Type: model.EventExpenseCreated, UserID: 1, ExpenseID: id + 1}); ok {
+		t.Error("event of a missing expense published")
+	}
+	// Another user's expense isn't loaded.
+	if _, ok := b.load(ctx, model.ExpenseEvent{ID: 3, Type: model.EventExpenseUpdated, UserID: 2, ExpenseID: id}); ok {
+		t.Error("event with the expense of another user published")
+	}
+	if event, ok := b.load(ctx, model.ExpenseEvent{ID: 4, Type: model.EventExpenseDeleted, UserID: 1, ExpenseID: id + 1}); !ok || event.Expense != nil {
+		t.Errorf("deletion: %+v, %v", event, ok)
+	}
+}
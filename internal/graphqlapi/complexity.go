@@ -0,0 +1,159 @@
+package graphqlapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// complexity estimates the cost of a validated query before it runs.
+//
+// Every field costs 1. A field with a limit argument returns up to limit
+// items, so the cost of its selection is multiplied by the limit: with the
+// defaults, expenses { items { id amount } } costs 1 + 50 * (1 + 2) = 151.
+// Introspection is bounded by the size of the schema and costs nothing, so
+// that GraphiQL can load the schema under any limits.
+type complexity struct {
+	schema    *graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+}
+
+// checkComplexity returns an error if the operation to run exceeds limits.
+// It returns nil if the operation can't be determined, leaving the error to
+// the executor.
+func checkComplexity(schema *graphql.Schema, doc *ast.Document, operationName string, variables map[string]any, limits Limits) error {
+	c := &complexity{schema: schema, fragments: map[string]*ast.FragmentDefinition{}, variables: variables}
+
+	var operation *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			c.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operationName == "" && operation != nil {
+				return nil
+			}
+			if operationName == "" || def.Name != nil && def.Name.Value == operationName {
+				operation = def
+			}
+		}
+	}
+	if operation == nil || operation.Operation != ast.OperationTypeQuery {
+		return nil
+	}
+
+	cost, depth := c.selectionSet(operation.SelectionSet, schema.QueryType())
+	if depth > limits.MaxDepth {
+		return fmt.Errorf("query is nested %d levels deep, the maximum is %d", depth, limits.MaxDepth)
+	}
+	if cost > limits.MaxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the maximum of %d, request fewer fields or smaller limits", cost, limits.MaxComplexity)
+	}
+	return nil
+}
+
+// selectionSet returns the cost and depth of the fields selected on parent.
+func (c *complexity) selectionSet(set *ast.SelectionSet, parent *graphql.Object) (cost, depth int) {
+	if set == nil || parent == nil {
+		return 0, 0
+	}
+
+	for _, selection := range set.Selections {
+		var fieldCost, fieldDepth int
+		switch s := selection.(type) {
+		case *ast.Field:
+			fieldCost, fieldDepth = c.field(s, parent)
+		case *ast.InlineFragment:
+			fieldCost, fieldDepth = c.selectionSet(s.SelectionSet, c.typeCondition(s.TypeCondition, parent))
+		case *ast.FragmentSpread:
+			if f, ok := c.fragments[s.Name.Value]; ok {
+				fieldCost, fieldDepth = c.selectionSet(f.SelectionSet, c.typeCondition(f.TypeCondition, parent))
+			}
+		}
+		cost += fieldCost
+		depth = max(depth, fieldDepth)
+	}
+	return cost, depth
+}
+
+func (c *complexity) field(f *ast.Field, parent *graphql.Object) (cost, depth int) {
+	name := f.Name.Value
+	if strings.HasPrefix(name, "__") {
+		return 0, 0
+	}
+	def, ok := parent.Fields()[name]
+	if !ok {
+		return 0, 0
+	}
+
+	childCost, childDepth := c.selectionSet(f.SelectionSet, objectOf(def.Type))
+	return 1 + c.limit(f, def)*childCost, 1 + childDepth
+}
+
+// limit returns the value of the limit argument of a field, or 1 if it has none.
+func (c *complexity) limit(f *ast.Field, def *graphql.FieldDefinition) int {
+	var limit any
+	for _, arg := range def.Args {
+		if arg.Name() == "limit" {
+			limit = arg.DefaultValue
+		}
+	}
+	if limit == nil {
+		return 1
+	}
+
+	for _, arg := range f.Arguments {
+		if arg.Name.Value != "limit" {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			limit, _ = strconv.Atoi(v.Value)
+		case *ast.Variable:
+			if value, ok := c.variables[v.Name.Value]; ok {
+				limit = value
+			}
+		}
+	}
+
+	// Limits out of range are rejected by the resolvers; clamping them keeps
+	// the cost from overflowing.
+	switch v := limit.(type) {
+	case int:
+		return min(max(1, v), maxLimit)
+	case float64:
+		return min(max(1, int(v)), maxLimit)
+	default:
+		return 1
+	}
+}
+
+// typeCondition returns the object type named by a fragment's type condition.
+func (c *complexity) typeCondition(cond *ast.Named, parent *graphql.Object) *graphql.Object {
+	if cond == nil {
+		return parent
+	}
+	object, _ := c.schema.Type(cond.Name.Value).(*graphql.Object)
+	return object
+}
+
+// objectOf returns the object type of t after unwrapping lists and non-null
+// types, or nil for scalars and enums.
+func objectOf(t graphql.Type) *graphql.Object {
+	for {
+		switch w := t.(type) {
+		case *graphql.NonNull:
+			t = w.OfType
+		case *graphql.List:
+			t = w.OfType
+		case *graphql.Object:
+			return w
+		default:
+			return nil
+		}
+	}
+}
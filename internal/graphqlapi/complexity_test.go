@@ -0,0 +1,106 @@
+package graphqlapi
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// generous lets any query through that a test doesn't limit itself.
+var generous = Limits{MaxComplexity: 1 << 30, MaxDepth: 100}
+
+func check(t *testing.T, s *Schema, query string, variables map[string]any, limits Limits) error {
+	t.Helper()
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return checkComplexity(&s.schema, doc, "", variables, limits)
+}
+
+func TestComplexity(t *testing.T) {
+	s := New(nil, nil, generous)
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		cost      int
+	}{
+		{"scalar fields", `{ me { id username } }`, nil, 3},
+		{"default limit", `{ expenses { items { id amount } } }`, nil, 1 + 50*(1+2)},
+		{
+			name:  "nested limits multiply",
+			query: `{ categories(limit: 5) { name expenses(limit: 4) { id amount } } }`,
+			cost:  1 + 5*(1+(1+4*2)),
+		},
+		{
+			name:  "nested default limits multiply",
+			query: `{ categories { expenses { id } } }`,
+			cost:  1 + 50*(1+10*1),
+		},
+		{"limit clamped", `{ expenses(limit: 100000) { totalCount } }`, nil, 1 + 100},
+		{"limit variable", `query($n: Int) { expenses(limit: $n) { totalCount } }`, map[string]any{"n": float64(20)}, 1 + 20},
+		{"missing variable", `query($n: Int) { expenses(limit: $n) { totalCount } }`, nil, 1 + 50},
+		{
+			name:  "fragment spread",
+			query: `{ expenses(limit: 10) { items { ...fields } } } fragment fields on Expense { id amount date }`,
+			cost:  1 + 10*(1+3),
+		},
+		{"inline fragment", `{ me { ... on User { id username } } }`, nil, 3},
+		{"introspection", `{ __schema { types { name fields { name type { name } } } } }`, nil, 0},
+		{"typename", `{ me { __typename id } }`, nil, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := check(t, s, tt.query, tt.variables, Limits{MaxComplexity: tt.cost, MaxDepth: 100}); err != nil {
+				t.Errorf("rejected at a limit of %d: %v", tt.cost, err)
+			}
+			err := check(t, s, tt.query, tt.variables, Limits{MaxComplexity: tt.cost - 1, MaxDepth: 100})
+			if err == nil || !strings.Contains(err.Error(), "complexity") {
+				t.Errorf("accepted at a limit of %d: %v", tt.cost-1, err)
+			}
+		})
+	}
+}
+
+func TestDepth(t *testing.T) {
+	s := New(nil, nil, generous)
+	query := `{ categories { expenses { id } } }`
+
+	if err := check(t, s, query, nil, Limits{MaxComplexity: generous.MaxComplexity, MaxDepth: 3}); err != nil {
+		t.Errorf("rejected at depth 3: %v", err)
+	}
+	err := check(t, s, query, nil, Limits{MaxComplexity: generous.MaxComplexity, MaxDepth: 2})
+	if err == nil || !strings.Contains(err.Error(), "nested 3 levels") {
+		t.Errorf("accepted at depth 2: %v", err)
+	}
+
+	// Fragments count towards the depth of where they are spread.
+	fragment := `{ categories { ...c } } fragment c on Category { expenses { id } }`
+	if err := check(t, s, fragment, nil, Limits{MaxComplexity: generous.MaxComplexity, MaxDepth: 2}); err == nil {
+		t.Error("fragment hid the depth")
+	}
+
+	// Introspection is as deep as it needs to be.
+	introspection := `{ __schema { types { fields { type { ofType { ofType { name } } } } } } }`
+	if err := check(t, s, introspection, nil, Limits{MaxComplexity: 0, MaxDepth: 1}); err != nil {
+		t.Errorf("introspection rejected: %v", err)
+	}
+}
+
+func TestExecuteRejectsExpensiveQueries(t *testing.T) {
+	s := New(nil, nil, Limits{MaxComplexity: 100, MaxDepth: 8})
+	resp, ok := s.Execute(context.Background(), 1, &model.GraphQLRequest{
+		Query: `query Costly { categories(limit: 10) { expenses(limit: 10) { id } } }`,
+	})
+	if ok || len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != "validation_failed" {
+		t.Fatalf("got %+v, want a validation error", resp)
+	}
+	if !strings.Contains(resp.Errors[0].Message, "complexity 111") {
+		t.Errorf("message %q", resp.Errors[0].Message)
+	}
+}
@@ -0,0 +1,100 @@
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expense_tracker/internal/model"
+	"expense_tracker/lib"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// Execute runs a query for the user. It returns false if the query was
+// rejected before execution: it can't be parsed, is invalid against the
+// schema or exceeds the limits. Such errors, like those of the resolvers,
+// carry the code of the problem responses in their extensions.
+func (s *Schema) Execute(ctx context.Context, userID int, req *model.GraphQLRequest) (*model.GraphQLResponse, bool) {
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return &model.GraphQLResponse{Errors: formatErrors(ctx, gqlerrors.FormatErrors(err))}, false
+	}
+	if result := graphql.ValidateDocument(&s.schema, doc, nil); !result.IsValid {
+		return &model.GraphQLResponse{Errors: formatErrors(ctx, result.Errors)}, false
+	}
+	if err := checkComplexity(&s.schema, doc, req.OperationName, req.Variables, s.limits); err != nil {
+		return &model.GraphQLResponse{Errors: formatErrors(ctx, gqlerrors.FormatErrors(err))}, false
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       context.WithValue(ctx, requestKey{}, s.newRequest(userID)),
+	})
+	resp := &model.GraphQLResponse{Data: result.Data, Errors: formatErrors(ctx, result.Errors)}
+	if resp.Data == nil {
+		// A failed non-null root field nulls the whole result, which must
+		// still be sent to tell it apart from a rejected query.
+		resp.Data = json.RawMessage("null")
+	}
+	return resp, true
+}
+
+// formatErrors converts graphql-go errors for the response. Errors of the
+// resolvers expose their client-safe message and kind, internal errors are
+// logged and reported generically. Errors of graphql-go itself, such as
+// syntax errors or variables of the wrong type, are caused by the query and
+// reported as validation errors.
+func formatErrors(ctx context.Context, errs []gqlerrors.FormattedError) []model.GraphQLError {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	out := make([]model.GraphQLError, len(errs))
+	for i, err := range errs {
+		e := model.GraphQLError{
+			Message:    err.Message,
+			Path:       err.Path,
+			Extensions: map[string]any{"code": lib.KindValidation.String()},
+		}
+		for _, loc := range err.Locations {
+			e.Locations = append(e.Locations, model.GraphQLLocation{Line: loc.Line, Column: loc.Column})
+		}
+
+		var typed *lib.Error
+		if errors.As(originalError(err), &typed) {
+			if typed.Kind == lib.KindInternal {
+				lib.Logger(ctx).Error("internal error", "error", typed, "path", err.Path)
+			}
+			e.Message = typed.Message
+			e.Extensions["code"] = typed.Kind.String()
+			if len(typed.Fields) > 0 {
+				e.Extensions["fields"] = typed.Fields
+			}
+		}
+		out[i] = e
+	}
+	return out
+}
+
+// originalError returns the error a resolver returned, which graphql-go
+// wraps once for plain values and twice for thunks.
+func originalError(err error) error {
+	for {
+		var next error
+		switch e := err.(type) {
+		case gqlerrors.FormattedError:
+			next = e.OriginalError()
+		case *gqlerrors.Error:
+			next = e.OriginalError
+		}
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
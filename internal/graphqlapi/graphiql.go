@@ -0,0 +1,45 @@
+package graphqlapi
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// graphiqlPage loads GraphiQL from a CDN. The Authorization header is
+// prefilled in the headers editor; paste a token from POST /auth/login.
+var graphiqlPage = template.Must(template.New("graphiql").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<title>Expense Tracker GraphiQL</title>
+	<style>body { margin: 0; height: 100vh; } #graphiql { height: 100vh; }</style>
+	<link rel="stylesheet" href="https://unpkg.com/graphiql@3.8.3/graphiql.min.css">
+	<script crossorigin src="https://unpkg.com/react@18.3.1/umd/react.production.min.js"></script>
+	<script crossorigin src="https://unpkg.com/react-dom@18.3.1/umd/react-dom.production.min.js"></script>
+	<script crossorigin src="https://unpkg.com/graphiql@3.8.3/graphiql.min.js"></script>
+</head>
+<body>
+	<div id="graphiql">Loading…</div>
+	<script>
+		const fetcher = GraphiQL.createFetcher({ url: {{.Endpoint}} });
+		ReactDOM.createRoot(document.getElementById("graphiql")).render(
+			React.createElement(GraphiQL, {
+				fetcher: fetcher,
+				headers: JSON.stringify({ Authorization: "Bearer " }, null, 2),
+				shouldPersistHeaders: true,
+				defaultEditorToolsVisibility: "headers",
+			}),
+		);
+	</script>
+</body>
+</html>
+`))
+
+// GraphiQL returns a handler serving the GraphiQL playground for the GraphQL
+// API at endpoint. It is meant for development only.
+func GraphiQL(endpoint string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		graphiqlPage.Execute(w, struct{ Endpoint string }{endpoint})
+	})
+}
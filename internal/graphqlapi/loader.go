@@ -0,0 +1,63 @@
+package graphqlapi
+
+import (
+	"context"
+	"sync"
+)
+
+// loader batches and caches lookups by key for the duration of one request.
+//
+// load only records the key and returns a thunk. graphql-go resolves every
+// field of a level before it calls the thunks, so the first thunk called
+// fetches all keys recorded by sibling fields at once and the others are
+// answered from the cache. Keys missing from the fetched map yield the zero
+// value; a failed fetch fails all of its keys.
+type loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	results map[K]*loaded[V]
+}
+
+type loaded[V any] struct {
+	value V
+	err   error
+	done  bool
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{fetch: fetch, results: map[K]*loaded[V]{}}
+}
+
+// load schedules key to be fetched and returns a thunk yielding its value.
+func (l *loader[K, V]) load(ctx context.Context, key K) func() (V, error) {
+	l.mu.Lock()
+	if _, ok := l.results[key]; !ok {
+		l.results[key] = &loaded[V]{}
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		r := l.results[key]
+		if !r.done {
+			l.flush(ctx)
+		}
+		return r.value, r.err
+	}
+}
+
+// flush fetches every pending key. l.mu must be held.
+func (l *loader[K, V]) flush(ctx context.Context) {
+	keys := l.pending
+	l.pending = nil
+
+	values, err := l.fetch(ctx, keys)
+	for _, key := range keys {
+		r := l.results[key]
+		r.value, r.err, r.done = values[key], err, true
+	}
+}
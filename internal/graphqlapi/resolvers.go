@@ -0,0 +1,443 @@
+package graphqlapi
+
+import (
+	"cmp"
+	"context"
+	"expense_tracker/internal/model"
+	"expense_tracker/lib"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// request is the state of one query: the authenticated user and the loaders
+// that let fields share storage lookups.
+type request struct {
+	userID int
+	// byID batches the expense fields of a level into one lookup.
+	byID *loader[int, *model.Expense]
+	// byFilter loads the expenses matching a filter once, however many
+	// fields or aliases ask for them.
+	byFilter *loader[filterKey, []model.Expense]
+}
+
+type requestKey struct{}
+
+func (s *Schema) newRequest(userID int) *request {
+	return &request{
+		userID: userID,
+		byID: newLoader(func(ctx context.Context, ids []int) (map[int]*model.Expense, error) {
+			expenses, err := s.expenses.FindExpenses(ctx, userID, &model.ExpenseFilter{IDs: ids})
+			if err != nil {
+				return nil, err
+			}
+			found := make(map[int]*model.Expense, len(expenses))
+			for i := range expenses {
+				found[expenses[i].ID] = &expenses[i]
+			}
+			return found, nil
+		}),
+		byFilter: newLoader(func(ctx context.Context, keys []filterKey) (map[filterKey][]model.Expense, error) {
+			found := make(map[filterKey][]model.Expense, len(keys))
+			for _, key := range keys {
+				expenses, err := s.expenses.FindExpenses(ctx, userID, key.filter())
+				if err != nil {
+					return nil, err
+				}
+				found[key] = expenses
+			}
+			return found, nil
+		}),
+	}
+}
+
+func requestFrom(ctx context.Context) *request {
+	return ctx.Value(requestKey{}).(*request)
+}
+
+// resolver adapts fn to graphql-go and turns its errors, including those of
+// returned thunks, into typed errors: untyped errors become internal errors
+// and are never shown to clients, see formatErrors.
+func resolver(fn graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		v, err := fn(p)
+		if err != nil {
+			return nil, lib.AsError(err)
+		}
+		if thunk, ok := v.(func() (any, error)); ok {
+			return func() (any, error) {
+				v, err := thunk()
+				if err != nil {
+					return nil, lib.AsError(err)
+				}
+				return v, nil
+			}, nil
+		}
+		return v, nil
+	}
+}
+
+// then returns a thunk applying fn to the result of load.
+func then[V any](load func() (V, error), fn func(V) (any, error)) func() (any, error) {
+	return func() (any, error) {
+		v, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return fn(v)
+	}
+}
+
+func (s *Schema) resolveMe(p graphql.ResolveParams) (any, error) {
+	user, err := s.users.GetUserProfile(p.Context, requestFrom(p.Context).userID)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *Schema) resolveExpense(p graphql.ResolveParams) (any, error) {
+	id, err := parseID("id", p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	return then(requestFrom(p.Context).byID.load(p.Context, id), func(e *model.Expense) (any, error) {
+		if e == nil {
+			return nil, nil
+		}
+		return e, nil
+	}), nil
+}
+
+// expensePage is the value of the ExpensePage type.
+type expensePage struct {
+	Items      []model.Expense
+	TotalCount int
+	HasMore    bool
+}
+
+func (s *Schema) resolveExpenses(p graphql.ResolveParams) (any, error) {
+	key, err := parseFilter(p.Args["filter"])
+	if err != nil {
+		return nil, err
+	}
+	limit, err := limitArg(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	offset, _ := p.Args["offset"].(int)
+	if offset < 0 {
+		return nil, invalidArg("offset", "range", "offset must not be negative")
+	}
+
+	return then(requestFrom(p.Context).byFilter.load(p.Context, key), func(expenses []model.Expense) (any, error) {
+		sorted := sortExpenses(expenses, p.Args["orderBy"])
+		start := min(offset, len(sorted))
+		end := min(start+limit, len(sorted))
+		return expensePage{
+			Items:      sorted[start:end],
+			TotalCount: len(sorted),
+			HasMore:    end < len(sorted),
+		}, nil
+	}), nil
+}
+
+// category is the value of the Category type.
+type category struct {
+	Name    string
+	Total   float64
+	Count   int
+	Average float64
+	Share   float64
+	// Expenses are resolved by resolveCategoryExpenses without further lookups.
+	Expenses []model.Expense
+}
+
+func (s *Schema) resolveCategories(p graphql.ResolveParams) (any, error) {
+	key, err := parseFilter(p.Args["filter"])
+	if err != nil {
+		return nil, err
+	}
+	limit, err := limitArg(p.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	return then(requestFrom(p.Context).byFilter.load(p.Context, key), func(expenses []model.Expense) (any, error) {
+		categories := groupByCategory(expenses)
+		return categories[:min(limit, len(categories))], nil
+	}), nil
+}
+
+// groupByCategory totals expenses per category, largest total first.
+func groupByCategory(expenses []model.Expense) []category {
+	var (
+		byName = map[string]*category{}
+		names  []string
+		total  float64
+	)
+	for _, e := range expenses {
+		c, ok := byName[e.Category]
+		if !ok {
+			c = &category{Name: e.Category}
+			byName[e.Category] = c
+			names = append(names, e.Category)
+		}
+		c.Total += e.Amount
+		c.Count++
+		c.Expenses = append(c.Expenses, e)
+		total += e.Amount
+	}
+
+	categories := make([]category, 0, len(names))
+	for _, name := range names {
+		c := byName[name]
+		c.Average = roundCents(c.Total / float64(c.Count))
+		c.Share = math.Round(c.Total/total*10000) / 10000
+		c.Total = roundCents(c.Total)
+		categories = append(categories, *c)
+	}
+	slices.SortFunc(categories, func(a, b category) int {
+		return cmp.Or(cmp.Compare(b.Total, a.Total), strings.Compare(a.Name, b.Name))
+	})
+	return categories
+}
+
+func resolveCategoryExpenses(p graphql.ResolveParams) (any, error) {
+	limit, err := limitArg(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	expenses := sortExpenses(p.Source.(category).Expenses, p.Args["orderBy"])
+	return expenses[:min(limit, len(expenses))], nil
+}
+
+// summary is the value of the Summary type.
+type summary struct {
+	Total    float64
+	Count    int
+	Average  float64
+	Min, Max *float64
+	// Expenses are grouped by resolveByPeriod.
+	Expenses []model.Expense
+}
+
+func (s *Schema) resolveSummary(p graphql.ResolveParams) (any, error) {
+	key, err := parseFilter(p.Args["filter"])
+	if err != nil {
+		return nil, err
+	}
+	return then(requestFrom(p.Context).byFilter.load(p.Context, key), func(expenses []model.Expense) (any, error) {
+		return summarize(expenses), nil
+	}), nil
+}
+
+func summarize(expenses []model.Expense) summary {
+	sum := summary{Count: len(expenses), Expenses: expenses}
+	for i, e := range expenses {
+		sum.Total += e.Amount
+		if i == 0 || e.Amount < *sum.Min {
+			sum.Min = &expenses[i].Amount
+		}
+		if i == 0 || e.Amount > *sum.Max {
+			sum.Max = &expenses[i].Amount
+		}
+	}
+	if sum.Count > 0 {
+		sum.Average = roundCents(sum.Total / float64(sum.Count))
+	}
+	sum.Total = roundCents(sum.Total)
+	return sum
+}
+
+// periodTotal is the value of the PeriodTotal type.
+type periodTotal struct {
+	Start, End model.Date
+	Total      float64
+	Count      int
+}
+
+func resolveByPeriod(p graphql.ResolveParams) (any, error) {
+	limit, err := limitArg(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	interval, _ := p.Args["interval"].(string)
+
+	var periods []periodTotal
+	for _, e := range p.Source.(summary).Expenses {
+		start := periodStart(e.Date.Time, interval)
+		i := slices.IndexFunc(periods, func(pt periodTotal) bool { return pt.Start.Equal(start) })
+		if i < 0 {
+			end := periodEnd(start, interval)
+			periods = append(periods, periodTotal{Start: model.Date{Time: start}, End: model.Date{Time: end}})
+			i = len(periods) - 1
+		}
+		periods[i].Total += e.Amount
+		periods[i].Count++
+	}
+	for i := range periods {
+		periods[i].Total = roundCents(periods[i].Total)
+	}
+	slices.SortFunc(periods, func(a, b periodTotal) int { return a.Start.Compare(b.Start.Time) })
+	return periods[max(0, len(periods)-limit):], nil
+}
+
+// periodStart returns the first day of the interval containing d.
+func periodStart(d time.Time, interval string) time.Time {
+	switch interval {
+	case intervalWeek:
+		return d.AddDate(0, 0, -(int(d.Weekday())+6)%7)
+	case intervalMonth:
+		return time.Date(d.Year(), d.Month(), 1, 0, 0, 0, 0, d.Location())
+	case intervalYear:
+		return time.Date(d.Year(), time.January, 1, 0, 0, 0, 0, d.Location())
+	default:
+		return d
+	}
+}
+
+// periodEnd returns the last day of the interval starting on start.
+func periodEnd(start time.Time, interval string) time.Time {
+	switch interval {
+	case intervalWeek:
+		return start.AddDate(0, 0, 6)
+	case intervalMonth:
+		return start.AddDate(0, 1, -1)
+	case intervalYear:
+		return start.AddDate(1, 0, -1)
+	default:
+		return start
+	}
+}
+
+func resolveTags(p graphql.ResolveParams) (any, error) {
+	var tags []string
+	switch e := p.Source.(type) {
+	case model.Expense:
+		tags = e.Tags
+	case *model.Expense:
+		tags = e.Tags
+	}
+	if tags == nil {
+		return []string{}, nil
+	}
+	return tags, nil
+}
+
+// sortExpenses returns a sorted copy of expenses.
+func sortExpenses(expenses []model.Expense, order any) []model.Expense {
+	sorted := slices.Clone(expenses)
+	slices.SortStableFunc(sorted, func(a, b model.Expense) int {
+		switch order {
+		case orderDateAsc:
+			return cmp.Or(a.Date.Compare(b.Date.Time), cmp.Compare(a.ID, b.ID))
+		case orderAmountAsc:
+			return cmp.Or(cmp.Compare(a.Amount, b.Amount), cmp.Compare(a.ID, b.ID))
+		case orderAmountDesc:
+			return cmp.Or(cmp.Compare(b.Amount, a.Amount), cmp.Compare(b.ID, a.ID))
+		default:
+			return cmp.Or(b.Date.Compare(a.Date.Time), cmp.Compare(b.ID, a.ID))
+		}
+	})
+	return sorted
+}
+
+// filterKey is an ExpenseFilter in a comparable form usable as a loader key.
+// Empty fields are unset; IDs are sorted and comma-separated.
+type filterKey struct {
+	ids, category, tag, start, end string
+}
+
+// parseFilter converts the value of an ExpenseFilter argument.
+func parseFilter(value any) (filterKey, error) {
+	var key filterKey
+	args, _ := value.(map[string]any)
+	if args == nil {
+		return key, nil
+	}
+
+	if list, ok := args["ids"].([]any); ok {
+		ids := make([]int, 0, len(list))
+		for i, v := range list {
+			id, err := parseID(fmt.Sprintf("filter.ids[%d]", i), v)
+			if err != nil {
+				return key, err
+			}
+			ids = append(ids, id)
+		}
+		slices.Sort(ids)
+		parts := make([]string, len(ids))
+		for i, id := range ids {
+			parts[i] = strconv.Itoa(id)
+		}
+		key.ids = strings.Join(parts, ",")
+	}
+	key.category, _ = args["category"].(string)
+	key.tag, _ = args["tag"].(string)
+	if d, ok := args["start"].(model.Date); ok {
+		key.start = d.Format(time.DateOnly)
+	}
+	if d, ok := args["end"].(model.Date); ok {
+		key.end = d.Format(time.DateOnly)
+	}
+	return key, nil
+}
+
+// filter converts the key back; its values were validated by parseFilter.
+func (k filterKey) filter() *model.ExpenseFilter {
+	f := &model.ExpenseFilter{}
+	if k.ids != "" {
+		for _, s := range strings.Split(k.ids, ",") {
+			id, _ := strconv.Atoi(s)
+			f.IDs = append(f.IDs, id)
+		}
+	}
+	if k.category != "" {
+		f.Category = &k.category
+	}
+	if k.tag != "" {
+		f.Tag = &k.tag
+	}
+	if k.start != "" {
+		d := parseDate(k.start).(model.Date)
+		f.Start = &d
+	}
+	if k.end != "" {
+		d := parseDate(k.end).(model.Date)
+		f.End = &d
+	}
+	return f
+}
+
+// parseID converts an ID argument, which graphql-go passes as a string.
+func parseID(field string, value any) (int, error) {
+	s, _ := value.(string)
+	id, err := strconv.Atoi(s)
+	if err != nil || id <= 0 {
+		return 0, invalidArg(field, "invalid_type", field+" must be a positive integer")
+	}
+	return id, nil
+}
+
+// limitArg returns the limit argument of a list field.
+func limitArg(args map[string]any) (int, error) {
+	limit, _ := args["limit"].(int)
+	if limit < 0 || limit > maxLimit {
+		return 0, invalidArg("limit", "range", fmt.Sprintf("limit must be between 0 and %d", maxLimit))
+	}
+	return limit, nil
+}
+
+func invalidArg(field, code, message string) error {
+	return lib.Validation(message, lib.FieldError{Field: field, Code: code, Message: message})
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,272 @@
+// Package graphqlapi implements the GraphQL API served on /graphql. Queries
+// are resolved with the same services as the REST handlers and are checked
+// against Limits before they run.
+package graphqlapi
+
+import (
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/service"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits bound the cost of a query, see checkComplexity.
+type Limits struct {
+	// MaxComplexity is the highest accepted estimated cost of a query.
+	MaxComplexity int
+	// MaxDepth is the deepest accepted nesting of fields.
+	MaxDepth int
+}
+
+// Schema executes GraphQL queries on behalf of authenticated users.
+type Schema struct {
+	schema   graphql.Schema
+	users    *service.UserService
+	expenses *service.ExpenseService
+	limits   Limits
+}
+
+// New builds the schema backed by the given services.
+func New(users *service.UserService, expenses *service.ExpenseService, limits Limits) *Schema {
+	s := &Schema{users: users, expenses: expenses, limits: limits}
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: s.queryType()})
+	if err != nil {
+		panic("graphqlapi: invalid schema: " + err.Error())
+	}
+	s.schema = schema
+	return s
+}
+
+// Page sizes of the list fields, which all take a limit argument.
+const (
+	defaultLimit = 50
+	maxLimit     = 100
+)
+
+// Values of the ExpenseOrder enum.
+const (
+	orderDateAsc    = "DATE_ASC"
+	orderDateDesc   = "DATE_DESC"
+	orderAmountAsc  = "AMOUNT_ASC"
+	orderAmountDesc = "AMOUNT_DESC"
+)
+
+// Values of the Interval enum.
+const (
+	intervalDay   = "DAY"
+	intervalWeek  = "WEEK"
+	intervalMonth = "MONTH"
+	intervalYear  = "YEAR"
+)
+
+var dateScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Date",
+	Description: "A calendar date in YYYY-MM-DD format.",
+	Serialize: func(value any) any {
+		if d, ok := value.(model.Date); ok {
+			return d.Format(time.DateOnly)
+		}
+		return nil
+	},
+	ParseValue: func(value any) any {
+		s, _ := value.(string)
+		return parseDate(s)
+	},
+	ParseLiteral: func(value ast.Value) any {
+		if s, ok := value.(*ast.StringValue); ok {
+			return parseDate(s.Value)
+		}
+		return nil
+	},
+})
+
+// parseDate returns nil for invalid dates, which graphql-go reports as a
+// value of the wrong type.
+func parseDate(s string) any {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return nil
+	}
+	return model.Date{Time: t}
+}
+
+var orderEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name:        "ExpenseOrder",
+	Description: "Sort order of expenses. Ties are broken by ID.",
+	Values: graphql.EnumValueConfigMap{
+		orderDateAsc:    {Value: orderDateAsc, Description: "Oldest first."},
+		orderDateDesc:   {Value: orderDateDesc, Description: "Newest first."},
+		orderAmountAsc:  {Value: orderAmountAsc, Description: "Smallest first."},
+		orderAmountDesc: {Value: orderAmountDesc, Description: "Largest first."},
+	},
+})
+
+var intervalEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name:        "Interval",
+	Description: "Length of the periods expenses are grouped by. Weeks start on Monday.",
+	Values: graphql.EnumValueConfigMap{
+		intervalDay:   {Value: intervalDay},
+		intervalWeek:  {Value: intervalWeek},
+		intervalMonth: {Value: intervalMonth},
+		intervalYear:  {Value: intervalYear},
+	},
+})
+
+var filterInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name:        "ExpenseFilter",
+	Description: "Selects expenses matching every given field.",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"ids":      {Type: graphql.NewList(graphql.NewNonNull(graphql.ID))},
+		"category": {Type: graphql.String, Description: "Exact, case-sensitive category."},
+		"tag":      {Type: graphql.String},
+		"start":    {Type: dateScalar, Description: "First day, inclusive."},
+		"end":      {Type: dateScalar, Description: "Last day, inclusive."},
+	},
+})
+
+var userType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "User",
+	Fields: graphql.Fields{
+		"id":       {Type: graphql.NewNonNull(graphql.ID)},
+		"username": {Type: graphql.NewNonNull(graphql.String)},
+	},
+})
+
+var expenseType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Expense",
+	Fields: graphql.Fields{
+		"id":          {Type: graphql.NewNonNull(graphql.ID)},
+		"amount":      {Type: graphql.NewNonNull(graphql.Float)},
+		"category":    {Type: graphql.NewNonNull(graphql.String)},
+		"description": {Type: graphql.NewNonNull(graphql.String)},
+		"date":        {Type: graphql.NewNonNull(dateScalar)},
+		"tags":        {Type: nonNullList(graphql.String), Resolve: resolveTags},
+	},
+})
+
+var pageType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "ExpensePage",
+	Description: "A page of expenses and the number of expenses matching the filter.",
+	Fields: graphql.Fields{
+		"items":      {Type: nonNullList(expenseType)},
+		"totalCount": {Type: graphql.NewNonNull(graphql.Int)},
+		"hasMore":    {Type: graphql.NewNonNull(graphql.Boolean)},
+	},
+})
+
+var categoryType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Category",
+	Description: "Totals of the expenses of one category.",
+	Fields: graphql.Fields{
+		"name":    {Type: graphql.NewNonNull(graphql.String)},
+		"total":   {Type: graphql.NewNonNull(graphql.Float)},
+		"count":   {Type: graphql.NewNonNull(graphql.Int)},
+		"average": {Type: graphql.NewNonNull(graphql.Float)},
+		"share":   {Type: graphql.NewNonNull(graphql.Float), Description: "Fraction of the total of all categories, from 0 to 1."},
+		"expenses": {
+			Type:    nonNullList(expenseType),
+			Args:    listArgs(10, true),
+			Resolve: resolveCategoryExpenses,
+		},
+	},
+})
+
+var periodType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PeriodTotal",
+	Fields: graphql.Fields{
+		"start": {Type: graphql.NewNonNull(dateScalar)},
+		"end":   {Type: graphql.NewNonNull(dateScalar)},
+		"total": {Type: graphql.NewNonNull(graphql.Float)},
+		"count": {Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+var summaryType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Summary",
+	Description: "Aggregates over the expenses matching a filter.",
+	Fields: graphql.Fields{
+		"total":   {Type: graphql.NewNonNull(graphql.Float)},
+		"count":   {Type: graphql.NewNonNull(graphql.Int)},
+		"average": {Type: graphql.NewNonNull(graphql.Float)},
+		"min":     {Type: graphql.Float, Description: "Smallest amount, null without expenses."},
+		"max":     {Type: graphql.Float, Description: "Largest amount, null without expenses."},
+		"byPeriod": {
+			Type:        nonNullList(periodType),
+			Description: "Totals of the most recent periods with expenses, oldest first.",
+			Args: graphql.FieldConfigArgument{
+				"interval": {Type: intervalEnum, DefaultValue: intervalMonth},
+				"limit":    {Type: graphql.Int, DefaultValue: 12},
+			},
+			Resolve: resolveByPeriod,
+		},
+	},
+})
+
+// queryType is the root of every query. Its resolvers are methods of s so
+// they can reach the services.
+func (s *Schema) queryType() *graphql.Object {
+	filterArg := &graphql.ArgumentConfig{Type: filterInput}
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": {
+				Type:        graphql.NewNonNull(userType),
+				Description: "The authenticated user.",
+				Resolve:     resolver(s.resolveMe),
+			},
+			"expense": {
+				Type:        expenseType,
+				Description: "An expense by ID, null if it does not exist.",
+				Args: graphql.FieldConfigArgument{
+					"id": {Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: resolver(s.resolveExpense),
+			},
+			"expenses": {
+				Type: graphql.NewNonNull(pageType),
+				Args: withArgs(listArgs(defaultLimit, true), graphql.FieldConfigArgument{
+					"filter": filterArg,
+					"offset": {Type: graphql.Int, DefaultValue: 0},
+				}),
+				Resolve: resolver(s.resolveExpenses),
+			},
+			"categories": {
+				Type:        nonNullList(categoryType),
+				Description: "Categories with expenses matching the filter, largest total first.",
+				Args:        withArgs(listArgs(defaultLimit, false), graphql.FieldConfigArgument{"filter": filterArg}),
+				Resolve:     resolver(s.resolveCategories),
+			},
+			"summary": {
+				Type:    graphql.NewNonNull(summaryType),
+				Args:    graphql.FieldConfigArgument{"filter": filterArg},
+				Resolve: resolver(s.resolveSummary),
+			},
+		},
+	})
+}
+
+// listArgs returns the limit argument of a list field and, if ordered, its orderBy argument.
+func listArgs(limit int, ordered bool) graphql.FieldConfigArgument {
+	args := graphql.FieldConfigArgument{
+		"limit": {Type: graphql.Int, DefaultValue: limit, Description: "At most 100."},
+	}
+	if ordered {
+		args["orderBy"] = &graphql.ArgumentConfig{Type: orderEnum, DefaultValue: orderDateDesc}
+	}
+	return args
+}
+
+func withArgs(args, more graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	for name, arg := range more {
+		args[name] = arg
+	}
+	return args
+}
+
+func nonNullList(t graphql.Type) *graphql.NonNull {
+	return graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(t)))
+}
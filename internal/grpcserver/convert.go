@@ -0,0 +1,212 @@
+package grpcserver
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"expense_tracker/lib"
+	pb "expense_tracker/pkg/pb/expense/v1"
+	"strconv"
+	"time"
+)
+
+// userID returns the authenticated user stored in ctx by the auth interceptor.
+func userID(ctx context.Context) (int, error) {
+	id, ok := ctx.Value(lib.UserIDkey).(int)
+	if !ok {
+		return 0, lib.Unauthorized("user ID not found in context: unauthorized")
+	}
+	return id, nil
+}
+
+// parseDate parses a YYYY-MM-DD field. An empty value yields the zero date,
+// which the services reject where a date is required.
+func parseDate(field, value string) (model.Date, error) {
+	if value == "" {
+		return model.Date{}, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		msg := field + " must be a date in YYYY-MM-DD format"
+		return model.Date{}, lib.Validation(msg, lib.FieldError{Field: field, Code: "invalid_type", Message: msg})
+	}
+	return model.Date{Time: t}, nil
+}
+
+// parseOptionalDate is parseDate for optional fields.
+func parseOptionalDate(field string, value *string) (*model.Date, error) {
+	if value == nil {
+		return nil, nil
+	}
+	d, err := parseDate(field, *value)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func formatDate(d model.Date) string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(time.DateOnly)
+}
+
+func toProtoUser(u *model.User) *pb.User {
+	return &pb.User{Id: int64(u.ID), Username: u.Username}
+}
+
+func toProtoExpense(e *model.Expense) *pb.Expense {
+	if e == nil {
+		return nil
+	}
+	return &pb.Expense{
+		Id:          int64(e.ID),
+		Amount:      e.Amount,
+		Category:    e.Category,
+		Description: e.Description,
+		Date:        formatDate(e.Date),
+		Tags:        e.Tags,
+	}
+}
+
+func toProtoExpenses(expenses []model.Expense) []*pb.Expense {
+	out := make([]*pb.Expense, len(expenses))
+	for i := range expenses {
+		out[i] = toProtoExpense(&expenses[i])
+	}
+	return out
+}
+
+// fromProtoExpense converts an expense received from a client; field names
+// in errors are prefixed with prefix.
+func fromProtoExpense(prefix string, e *pb.Expense) (model.Expense, error) {
+	if e == nil {
+		return model.Expense{}, lib.Validation(prefix+" is required",
+			lib.FieldError{Field: prefix, Code: "required", Message: prefix + " is required"})
+	}
+	date, err := parseDate(prefix+".date", e.Date)
+	if err != nil {
+		return model.Expense{}, err
+	}
+	return model.Expense{
+		Amount:      e.Amount,
+		Category:    e.Category,
+		Description: e.Description,
+		Date:        date,
+		Tags:        e.Tags,
+	}, nil
+}
+
+// fromProtoUpdate converts the fields to change of an expense; field names
+// in errors are prefixed with prefix.
+func fromProtoUpdate(prefix string, u *pb.ExpenseUpdate) (*model.UpdateExpenseInput, error) {
+	input := &model.UpdateExpenseInput{}
+	if u == nil {
+		return input, nil
+	}
+	date, err := parseOptionalDate(prefix+".date", u.Date)
+	if err != nil {
+		return nil, err
+	}
+	input.Amount = u.Amount
+	input.Category = u.Category
+	input.Description = u.Description
+	input.Date = date
+	if u.Tags != nil {
+		tags := append([]string{}, u.Tags.Tags...)
+		input.Tags = &tags
+	}
+	return input, nil
+}
+
+var batchModes = map[pb.BatchMode]model.BatchMode{
+	pb.BatchMode_BATCH_MODE_UNSPECIFIED: "",
+	pb.BatchMode_BATCH_MODE_ATOMIC:      model.BatchAtomic,
+	pb.BatchMode_BATCH_MODE_BEST_EFFORT: model.BatchBestEffort,
+}
+
+var batchOps = map[pb.BatchOp]model.BatchOp{
+	pb.BatchOp_BATCH_OP_UNSPECIFIED: "",
+	pb.BatchOp_BATCH_OP_CREATE:      model.BatchCreate,
+	pb.BatchOp_BATCH_OP_UPDATE:      model.BatchUpdate,
+	pb.BatchOp_BATCH_OP_DELETE:      model.BatchDelete,
+}
+
+// protoEnum returns the key of m holding v.
+func protoEnum[K, V comparable](m map[K]V, v V) K {
+	for k, mv := range m {
+		if mv == v {
+			return k
+		}
+	}
+	var zero K
+	return zero
+}
+
+func fromProtoBatch(req *pb.BatchRequest) (*model.BatchRequest, error) {
+	batch := &model.BatchRequest{
+		Mode:       batchModes[req.Mode],
+		Operations: make([]model.BatchOperation, len(req.Operations)),
+	}
+	for i, op := range req.Operations {
+		prefix := "operations[" + strconv.Itoa(i) + "]"
+		out := model.BatchOperation{Op: batchOps[op.Op], ID: int(op.Id)}
+		if op.Expense != nil {
+			e, err := fromProtoExpense(prefix+".expense", op.Expense)
+			if err != nil {
+				return nil, err
+			}
+			out.Expense = &e
+		}
+		if op.Update != nil {
+			u, err := fromProtoUpdate(prefix+".update", op.Update)
+			if err != nil {
+				return nil, err
+			}
+			out.Update = u
+		}
+		batch.Operations[i] = out
+	}
+	return batch, nil
+}
+
+func toProtoBatch(resp *model.BatchResponse) *pb.BatchResponse {
+	out := &pb.BatchResponse{
+		Mode:      protoEnum(batchModes, resp.Mode),
+		Succeeded: int32(resp.Succeeded),
+		Failed:    int32(resp.Failed),
+		Results:   make([]*pb.BatchResult, len(resp.Results)),
+	}
+	for i, r := range resp.Results {
+		out.Results[i] = &pb.BatchResult{
+			Index:   int32(r.Index),
+			Op:      protoEnum(batchOps, r.Op),
+			Status:  r.Status,
+			Id:      int64(r.ID),
+			Expense: toProtoExpense(r.Expense),
+			Error:   r.Error,
+		}
+	}
+	return out
+}
+
+func fromProtoFilter(f *pb.ExpenseFilter) (model.ExpenseFilter, error) {
+	var filter model.ExpenseFilter
+	if f == nil {
+		return filter, nil
+	}
+	for _, id := range f.Ids {
+		filter.IDs = append(filter.IDs, int(id))
+	}
+	filter.Category = f.Category
+	filter.Tag = f.Tag
+
+	var err error
+	if filter.Start, err = parseOptionalDate("filter.start", f.Start); err != nil {
+		return filter, err
+	}
+	if filter.End, err = parseOptionalDate("filter.end", f.End); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
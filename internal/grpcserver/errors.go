@@ -0,0 +1,91 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"expense_tracker/lib"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// errorDomain identifies the service in ErrorInfo details.
+const errorDomain = "expense-tracker"
+
+// toStatus maps err to a gRPC status the way lib.WriteError maps it to an HTTP
+// problem: typed errors expose their client-safe message, field errors become
+// a BadRequest detail and throttling a RetryInfo detail. Any other error is
+// logged and reported as a generic Internal error.
+func toStatus(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	var e *lib.Error
+	if _, ok := status.FromError(err); ok && !errors.As(err, &e) {
+		return err
+	}
+
+	e = lib.AsError(err)
+	if e.Kind == lib.KindInternal {
+		lib.Logger(ctx).Error("internal error", "error", err)
+	}
+
+	st := status.New(codeOf(e.Kind), e.Message)
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: e.Kind.String(), Domain: errorDomain}}
+	if len(e.Fields) > 0 {
+		br := &errdetails.BadRequest{}
+		for _, f := range e.Fields {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       f.Field,
+				Description: f.Message,
+			})
+		}
+		details = append(details, br)
+	}
+	if e.RetryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(e.RetryAfter)})
+	}
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// codeOf returns the gRPC code for an error kind.
+func codeOf(kind lib.Kind) codes.Code {
+	switch kind {
+	case lib.KindValidation:
+		return codes.InvalidArgument
+	case lib.KindNotFound:
+		return codes.NotFound
+	case lib.KindConflict:
+		return codes.AlreadyExists
+	case lib.KindUnauthorized:
+		return codes.Unauthenticated
+	case lib.KindTooLarge:
+		return codes.InvalidArgument
+	case lib.KindTooManyRequests:
+		return codes.ResourceExhausted
+	default:
+		return codes.Internal
+	}
+}
+
+func unaryErrors(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	resp, err := handler(ctx, req)
+	return resp, toStatus(ctx, err)
+}
+
+func streamErrors(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return toStatus(ss.Context(), handler(srv, ss))
+}
@@ -0,0 +1,80 @@
+// Package grpcserver serves the gRPC API defined in proto/expense/v1 on top
+// of the same services as the HTTP handlers.
+package grpcserver
+
+import (
+	"context"
+	"expense_tracker/internal/middleware"
+	"expense_tracker/internal/service"
+	pb "expense_tracker/pkg/pb/expense/v1"
+	"log/slog"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Options configures a Server.
+type Options struct {
+	Logger *slog.Logger
+	// Limiter throttles calls; nil disables rate limiting.
+	Limiter *middleware.RateLimiter
+	// PolicyFor maps a full method name to the rate limit policy applied to it.
+	PolicyFor func(method string) string
+	// Reflection registers the server reflection service used by tools like grpcurl.
+	Reflection bool
+}
+
+// Server is a gRPC server exposing the auth, user and expense services, the
+// standard health service and optionally server reflection.
+type Server struct {
+	*grpc.Server
+	health *health.Server
+}
+
+// New creates a Server backed by the given services. serverOpts are passed to
+// grpc.NewServer, e.g. transport credentials.
+func New(auth *service.AuthService, users *service.UserService, expenses *service.ExpenseService, opts Options, serverOpts ...grpc.ServerOption) *Server {
+	logUnary, logStream := middleware.GRPCLogger(opts.Logger)
+	authUnary, authStream := middleware.GRPCAuth(auth)
+	policyFor := opts.PolicyFor
+	if policyFor == nil {
+		policyFor = func(string) string { return "" }
+	}
+
+	serverOpts = append(serverOpts,
+		grpc.ChainUnaryInterceptor(logUnary, unaryErrors, authUnary, opts.Limiter.GRPCLimit(policyFor)),
+		grpc.ChainStreamInterceptor(logStream, streamErrors, authStream),
+	)
+	s := &Server{
+		Server: grpc.NewServer(serverOpts...),
+		health: health.NewServer(),
+	}
+
+	pb.RegisterAuthServiceServer(s.Server, &authServer{auth: auth})
+	pb.RegisterUserServiceServer(s.Server, &userServer{users: users})
+	pb.RegisterExpenseServiceServer(s.Server, &expenseServer{expenses: expenses})
+	healthpb.RegisterHealthServer(s.Server, s.health)
+	if opts.Reflection {
+		reflection.Register(s.Server)
+	}
+	return s
+}
+
+// Shutdown reports the server as not serving, then waits for in-flight calls
+// to finish. If ctx expires first the remaining calls are cancelled.
+func (s *Server) Shutdown(ctx context.Context) {
+	s.health.Shutdown()
+
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.Stop()
+	}
+}
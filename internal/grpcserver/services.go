@@ -0,0 +1,261 @@
+package grpcserver
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/service"
+	"expense_tracker/lib"
+	pb "expense_tracker/pkg/pb/expense/v1"
+)
+
+// authServer implements pb.AuthServiceServer with AuthService.
+type authServer struct {
+	pb.UnimplementedAuthServiceServer
+	auth *service.AuthService
+}
+
+func (s *authServer) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	user := model.User{Username: req.Username, Password: req.Password}
+	if err := s.auth.Register(ctx, &user); err != nil {
+		return nil, err
+	}
+	return &pb.RegisterResponse{User: toProtoUser(&user)}, nil
+}
+
+func (s *authServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	token, err := s.auth.Login(ctx, &model.LoginInput{Username: req.Username, Password: req.Password})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.LoginResponse{Token: token}, nil
+}
+
+// userServer implements pb.UserServiceServer with UserService.
+type userServer struct {
+	pb.UnimplementedUserServiceServer
+	users *service.UserService
+}
+
+func (s *userServer) GetProfile(ctx context.Context, _ *pb.GetProfileRequest) (*pb.GetProfileResponse, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetUserProfile(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetProfileResponse{User: toProtoUser(user)}, nil
+}
+
+func (s *userServer) UpdateUsername(ctx context.Context, req *pb.UpdateUsernameRequest) (*pb.UpdateUsernameResponse, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.UpdateUsername(ctx, id, &model.UpdateUsernameInput{Username: req.Username})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateUsernameResponse{User: toProtoUser(user)}, nil
+}
+
+func (s *userServer) DeleteUser(ctx context.Context, _ *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.users.DeleteUser(ctx, id); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteUserResponse{}, nil
+}
+
+// expenseServer implements pb.ExpenseServiceServer with ExpenseService.
+type expenseServer struct {
+	pb.UnimplementedExpenseServiceServer
+	expenses *service.ExpenseService
+}
+
+func (s *expenseServer) CreateExpense(ctx context.Context, req *pb.CreateExpenseRequest) (*pb.CreateExpenseResponse, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	expense, err := fromProtoExpense("expense", req.Expense)
+	if err != nil {
+		return nil, err
+	}
+	created, err := s.expenses.CreateExpense(ctx, id, expense)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateExpenseResponse{Expense: toProtoExpense(created)}, nil
+}
+
+func (s *expenseServer) GetExpense(ctx context.Context, req *pb.GetExpenseRequest) (*pb.GetExpenseResponse, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	expense, err := s.expenses.GetExpense(ctx, id, int(req.Id))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetExpenseResponse{Expense: toProtoExpense(expense)}, nil
+}
+
+func (s *expenseServer) UpdateExpense(ctx context.Context, req *pb.UpdateExpenseRequest) (*pb.UpdateExpenseResponse, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	input, err := fromProtoUpdate("update", req.Update)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.expenses.UpdateExpense(ctx, int(req.Id), id, input)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateExpenseResponse{Expense: toProtoExpense(updated)}, nil
+}
+
+func (s *expenseServer) DeleteExpense(ctx context.Context, req *pb.DeleteExpenseRequest) (*pb.DeleteExpenseResponse, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.expenses.DeleteExpense(ctx, int(req.Id), id); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteExpenseResponse{}, nil
+}
+
+func (s *expenseServer) ListExpenses(ctx context.Context, _ *pb.ListExpensesRequest) (*pb.ListExpensesResponse, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	expenses, err := s.expenses.GetExpensesList(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListExpensesResponse{Expenses: toProtoExpenses(expenses)}, nil
+}
+
+func (s *expenseServer) ListExpensesByPeriod(ctx context.Context, req *pb.ListExpensesByPeriodRequest) (*pb.ListExpensesByPeriodResponse, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Start == "" || req.End == "" {
+		return nil, lib.Validation("start and end are required (use YYYY-MM-DD)")
+	}
+	start, err := parseDate("start", req.Start)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseDate("end", req.End)
+	if err != nil {
+		return nil, err
+	}
+	if end.Before(start.Time) {
+		return nil, lib.Validation("end time must be after start time")
+	}
+
+	expenses, err := s.expenses.GetExpensesByPeriod(ctx, id, start.Time, end.Time)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListExpensesByPeriodResponse{Expenses: toProtoExpenses(expenses)}, nil
+}
+
+func (s *expenseServer) ListExpensesByCategory(ctx context.Context, req *pb.ListExpensesByCategoryRequest) (*pb.ListExpensesByCategoryResponse, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Category == "" {
+		return nil, lib.Validation("category is required")
+	}
+	expenses, err := s.expenses.GetExpensesByCategory(ctx, id, req.Category)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListExpensesByCategoryResponse{Expenses: toProtoExpenses(expenses)}, nil
+}
+
+func (s *expenseServer) SearchExpenses(ctx context.Context, req *pb.SearchExpensesRequest) (*pb.SearchExpensesResponse, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Query == "" {
+		return nil, lib.Validation("query is required")
+	}
+
+	query := model.SearchQuery{
+		Query:    req.Query,
+		Category: req.Category,
+		Limit:    int(req.Limit),
+		Offset:   int(req.Offset),
+	}
+	if query.Start, err = parseOptionalDate("start", req.Start); err != nil {
+		return nil, err
+	}
+	if query.End, err = parseOptionalDate("end", req.End); err != nil {
+		return nil, err
+	}
+
+	results, err := s.expenses.SearchExpenses(ctx, id, &query)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.SearchExpensesResponse{Results: make([]*pb.SearchResult, len(results))}
+	for i := range results {
+		resp.Results[i] = &pb.SearchResult{
+			Expense: toProtoExpense(&results[i].Expense),
+			Rank:    results[i].Rank,
+			Snippet: results[i].Snippet,
+		}
+	}
+	return resp, nil
+}
+
+func (s *expenseServer) Batch(ctx context.Context, req *pb.BatchRequest) (*pb.BatchResponse, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	batch, err := fromProtoBatch(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.expenses.Batch(ctx, id, batch)
+	if err != nil {
+		return nil, err
+	}
+	return toProtoBatch(resp), nil
+}
+
+func (s *expenseServer) BulkUpdate(ctx context.Context, req *pb.BulkUpdateRequest) (*pb.BulkUpdateResponse, error) {
+	id, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := fromProtoFilter(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.expenses.BulkUpdate(ctx, id, &model.BulkUpdateInput{
+		Filter:      filter,
+		SetCategory: req.SetCategory,
+		AddTags:     req.AddTags,
+		RemoveTags:  req.RemoveTags,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.BulkUpdateResponse{Updated: result.Updated}, nil
+}
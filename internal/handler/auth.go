@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"expense_tracker/internal/model"
 	"expense_tracker/internal/service"
+	"expense_tracker/lib"
 	"net/http"
 )
 
@@ -20,16 +21,19 @@ func NewAuthHandler(authService *service.AuthService) *AuthHandler {
 // Register handles the HTTP request for user registration.
 // Possible HTTP responses:
 // - 201 Created: User registered successfully.
-// - 400 Bad Request: Invalid request body or registration error.
+// - 400 Bad Request: Invalid request body or username/password missing or too long.
+// - 409 Conflict: Username is already taken.
+// - 413 Payload Too Large: Request body exceeds the size limit.
+// - 429 Too Many Requests: Rate limit exceeded.
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var user model.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	if err := decodeJSON(w, r, &user, MaxBodyBytes); err != nil {
+		lib.WriteError(w, r, err)
 		return
 	}
 
 	if err := h.authService.Register(r.Context(), &user); err != nil {
-		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		lib.WriteError(w, r, err)
 		return
 	}
 
@@ -46,16 +50,19 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 // - 200 OK: Login successful, token returned.
 // - 400 Bad Request: Invalid request body.
 // - 401 Unauthorized: Invalid credentials.
+// - 413 Payload Too Large: Request body exceeds the size limit.
+// - 429 Too Many Requests: Rate limit exceeded or account locked after failed logins.
+// - 500 Internal Server Error: Failed to look up the user or sign the token.
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var input model.LoginInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	if err := decodeJSON(w, r, &input, MaxBodyBytes); err != nil {
+		lib.WriteError(w, r, err)
 		return
 	}
 
 	token, err := h.authService.Login(r.Context(), &input)
 	if err != nil {
-		http.Error(w, `{"error": "invalid credentials"}`, http.StatusUnauthorized)
+		lib.WriteError(w, r, err)
 		return
 	}
 
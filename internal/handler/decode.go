@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"expense_tracker/lib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Request body size limits.
+const (
+	// MaxBodyBytes limits the body of ordinary JSON requests.
+	MaxBodyBytes = 1 << 20
+	// MaxBatchBodyBytes limits batch requests, which carry up to service.MaxBatchOperations items.
+	MaxBatchBodyBytes = 8 << 20
+	// MaxStatementBodyBytes limits the bank statement files of imports.
+	MaxStatementBodyBytes = 8 << 20
+)
+
+// readBody reads a request body of at most limit bytes; oversized bodies yield lib.ErrTooLarge.
+func readBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return nil, fmt.Errorf("handler: %w", lib.TooLarge(
+			fmt.Sprintf("request body must not exceed %d bytes", maxBytesErr.Limit)))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("handler: can't read request body: %w", err)
+	}
+	return body, nil
+}
+
+// decodeJSON decodes a single JSON value of at most limit bytes from the request
+// body into dst. Unknown fields, type mismatches and trailing data are rejected with
+// a validation error naming the offending field; oversized bodies yield lib.ErrTooLarge.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any, limit int64) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit))
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err == nil {
+		if _, extra := dec.Token(); extra != io.EOF {
+			err = errors.New("trailing data")
+		}
+	}
+	if err == nil {
+		return nil
+	}
+
+	var (
+		maxBytesErr *http.MaxBytesError
+		syntaxErr   *json.SyntaxError
+		typeErr     *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return fmt.Errorf("handler: %w", lib.TooLarge(
+			fmt.Sprintf("request body must not exceed %d bytes", maxBytesErr.Limit)))
+
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		return fmt.Errorf("handler: %w", lib.Validation("invalid request body", lib.FieldError{
+			Field:   field,
+			Code:    "invalid_type",
+			Message: fmt.Sprintf("%s must be of type %s", field, jsonType(typeErr.Type.Kind().String())),
+		}))
+
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for unknown fields.
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return fmt.Errorf("handler: %w", lib.Validation("invalid request body", lib.FieldError{
+			Field:   field,
+			Code:    "unknown_field",
+			Message: fmt.Sprintf("%s is not a known field", field),
+		}))
+
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return fmt.Errorf("handler: %w", lib.Validation("request body is not valid JSON"))
+
+	case errors.Is(err, io.EOF):
+		return fmt.Errorf("handler: %w", lib.Validation("request body is empty"))
+
+	default:
+		// Custom unmarshalers such as model.Date report their own errors.
+		return fmt.Errorf("handler: %w", lib.Validation("invalid request body: "+err.Error()))
+	}
+}
+
+// jsonType names the JSON type corresponding to a Go kind.
+func jsonType(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "number"
+	case kind == "bool":
+		return "boolean"
+	case kind == "slice", kind == "array":
+		return "array"
+	case kind == "struct", kind == "map":
+		return "object"
+	default:
+		return kind
+	}
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"encoding/json"
+	"expense_tracker/internal/events"
+	"expense_tracker/internal/model"
+	"expense_tracker/lib"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// eventsHeartbeat is how often an idle stream sends a comment, so that
+	// proxies keep the connection open and dead clients are noticed.
+	eventsHeartbeat = 20 * time.Second
+	// eventsRetry is the reconnection delay suggested to EventSource clients.
+	eventsRetry = 3 * time.Second
+)
+
+// EventsHandler streams expense events as server-sent events.
+type EventsHandler struct {
+	hub *events.Hub
+}
+
+// NewEventsHandler creates a new EventsHandler streaming the events of hub.
+func NewEventsHandler(hub *events.Hub) *EventsHandler {
+	return &EventsHandler{
+		hub: hub,
+	}
+}
+
+// Stream handles the HTTP request to stream the expense events of the authenticated user.
+// Each event has its ID, its type (expense.created, expense.updated or expense.deleted)
+// and the event as JSON data. A client reconnecting with the Last-Event-ID header, or the
+// last_event_id query parameter, first receives the events it missed; if they are no longer
+// known, a reset event tells it to reload its expenses instead.
+// Possible HTTP responses:
+// - 200 OK: The stream of events, until the client disconnects or the server shuts down.
+// - 400 Bad Request: Invalid last event ID.
+// - 401 Unauthorized: User authentication failed.
+func (h *EventsHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	userID, err := lib.GetUserIDFromContext(r)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	var lastEventID int64
+	if lastID != "" {
+		if lastEventID, err = strconv.ParseInt(lastID, 10, 64); err != nil || lastEventID < 0 {
+			lib.WriteJSONError(w, http.StatusBadRequest, "invalid last event ID")
+			return
+		}
+	}
+
+	// The stream outlives the server's write timeout.
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		lib.WriteError(w, r, fmt.Errorf("handler: can't stream events: %w", err))
+		return
+	}
+
+	sub, missed, complete := h.hub.Subscribe(userID, lastEventID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Keeps nginx from buffering the stream.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", eventsRetry.Milliseconds())
+	if !complete {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for i := range missed {
+		writeEvent(w, &missed[i])
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			writeEvent(w, &event)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes event in the server-sent events format.
+func writeEvent(w http.ResponseWriter, event *model.ExpenseEvent) {
+	data, _ := json.Marshal(event)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
@@ -25,24 +25,26 @@ func NewExpenseHandler(expenseService *service.ExpenseService) *ExpenseHandler {
 // CreateExpense handles the HTTP request to create a new expense for the authenticated user.
 // Possible HTTP responses:
 // - 201 Created: Expense created successfully.
-// - 400 Bad Request: Invalid request body or creation error.
+// - 400 Bad Request: Invalid request body or validation error.
 // - 401 Unauthorized: User authentication failed.
+// - 413 Payload Too Large: Request body exceeds the size limit.
+// - 500 Internal Server Error: Failed to create the expense.
 func (h *ExpenseHandler) CreateExpense(w http.ResponseWriter, r *http.Request) {
 	userID, err := lib.GetUserIDFromContext(r)
 	if err != nil {
-		lib.WriteJSONError(w, http.StatusUnauthorized, err.Error())
+		lib.WriteError(w, r, err)
 		return
 	}
 
 	var expense model.Expense
-	if err := json.NewDecoder(r.Body).Decode(&expense); err != nil {
-		lib.WriteJSONError(w, http.StatusBadRequest, "invalid request body")
+	if err := decodeJSON(w, r, &expense, MaxBodyBytes); err != nil {
+		lib.WriteError(w, r, err)
 		return
 	}
 
 	creared, err := h.expenseService.CreateExpense(r.Context(), userID, expense)
 	if err != nil {
-		lib.WriteJSONError(w, http.StatusBadRequest, err.Error())
+		lib.WriteError(w, r, err)
 		return
 	}
 
@@ -57,10 +59,11 @@ func (h *ExpenseHandler) CreateExpense(w http.ResponseWriter, r *http.Request) {
 // - 400 Bad Request: Invalid expense ID.
 // - 401 Unauthorized: User authentication failed.
 // - 404 Not Found: Expense not found.
+// - 500 Internal Server Error: Failed to retrieve the expense.
 func (h *ExpenseHandler) GetExpense(w http.ResponseWriter, r *http.Request) {
 	userID, err := lib.GetUserIDFromContext(r)
 	if err != nil {
-		lib.WriteJSONError(w, http.StatusUnauthorized, err.Error())
+		lib.WriteError(w, r, err)
 		return
 	}
 
@@ -72,7 +75,7 @@ func (h *ExpenseHandler) GetExpense(w http.ResponseWriter, r *http.Request) {
 
 	expense, err := h.expenseService.GetExpense(r.Context(), userID, expenseID)
 	if err != nil {
-		lib.WriteJSONError(w, http.StatusNotFound, "expense not found")
+		lib.WriteError(w, r, err)
 		return
 	}
 
@@ -83,12 +86,15 @@ func (h *ExpenseHandler) GetExpense(w http.ResponseWriter, r *http.Request) {
 // UpdateExpense handles the HTTP request to update an existing expense by its ID for the authenticated user.
 // Possible HTTP responses:
 // - 200 OK: Expense updated successfully.
-// - 400 Bad Request: Invalid expense ID, request body, or update error.
+// - 400 Bad Request: Invalid expense ID, request body, or validation error.
 // - 401 Unauthorized: User authentication failed.
+// - 404 Not Found: Expense not found.
+// - 413 Payload Too Large: Request body exceeds the size limit.
+// - 500 Internal Server Error: Failed to update the expense.
 func (h *ExpenseHandler) UpdateExpense(w http.ResponseWriter, r *http.Request) {
 	userID, err := lib.GetUserIDFromContext(r)
 	if err != nil {
-		lib.WriteJSONError(w, http.StatusUnauthorized, err.Error())
+		lib.WriteError(w, r, err)
 		return
 	}
 
@@ -99,14 +105,14 @@ func (h *ExpenseHandler) UpdateExpense(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var input model.UpdateExpenseInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		lib.WriteJSONError(w, http.StatusBadRequest, "invalid request body")
+	if err := decodeJSON(w, r, &input, MaxBodyBytes); err != nil {
+		lib.WriteError(w, r, err)
 		return
 	}
 
 	updated, err := h.expenseService.UpdateExpense(r.Context(), expenseID, userID, &input)
 	if err != nil {
-		lib.WriteJSONError(w, http.StatusBadRequest, err.Error())
+		lib.WriteError(w, r, err)
 		return
 	}
 
@@ -117,12 +123,14 @@ func (h *ExpenseHandler) UpdateExpense(w http.ResponseWriter, r *http.Request) {
 // DeleteExpense handles the HTTP request to delete an expense by its ID for the authenticated user.
 // Possible HTTP responses:
 // - 204 No Content: Expense deleted successfully.
-// - 400 Bad Request: Invalid expense ID or deletion error.
+// - 400 Bad Request: Invalid expense ID.
 // - 401 Unauthorized: User authentication failed.
+// - 404 Not Found: Expense not found.
+// - 500 Internal Server Error: Failed to delete the expense.
 func (h *ExpenseHandler) DeleteExpense(w http.ResponseWriter, r *http.Request) {
 	userID, err := lib.GetUserIDFromContext(r)
 	if err != nil {
-		lib.WriteJSONError(w, http.StatusUnauthorized, err.Error())
+		lib.WriteError(w, r, err)
 		return
 	}
 
@@ -133,7 +141,7 @@ func (h *ExpenseHandler) DeleteExpense(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.expenseService.DeleteExpense(r.Context(), expenseID, userID); err != nil {
-		lib.WriteJSONError(w, http.StatusBadRequest, err.Error())
+		lib.WriteError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -147,13 +155,13 @@ func (h *ExpenseHandler) DeleteExpense(w http.ResponseWriter, r *http.Request) {
 func (h *ExpenseHandler) GetExpensesList(w http.ResponseWriter, r *http.Request) {
 	userID, err := lib.GetUserIDFromContext(r)
 	if err != nil {
-		lib.WriteJSONError(w, http.StatusUnauthorized, err.Error())
+		lib.WriteError(w, r, err)
 		return
 	}
 
 	expenses, err := h.expenseService.GetExpensesList(r.Context(), userID)
 	if err != nil {
-		lib.WriteJSONError(w, http.StatusInternalServerError, "internal server error")
+		lib.WriteError(w, r, err)
 		return
 	}
 
@@ -171,7 +179,7 @@ func (h *ExpenseHandler) GetExpensesList(w http.ResponseWriter, r *http.Request)
 func (h *ExpenseHandler) GetExpensesByPeriod(w http.ResponseWriter, r *http.Request) {
 	userID, err := lib.GetUserIDFromContext(r)
 	if err != nil {
-		lib.WriteJSONError(w, http.StatusUnauthorized, err.Error())
+		lib.WriteError(w, r, err)
 		return
 	}
 
@@ -193,7 +201,7 @@ func (h *ExpenseHandler) GetExpensesByPeriod(w http.ResponseWriter, r *http.Requ
 
 	expenses, err := h.expenseService.GetExpensesByPeriod(r.Context(), userID, start, end)
 	if err != nil {
-		lib.WriteJSONError(w, http.StatusInternalServerError, "internal server error")
+		lib.WriteError(w, r, err)
 		return
 	}
 
@@ -211,7 +219,7 @@ func (h *ExpenseHandler) GetExpensesByPeriod(w http.ResponseWriter, r *http.Requ
 func (h *ExpenseHandler) GetExpensesByCategory(w http.ResponseWriter, r *http.Request) {
 	userID, err := lib.GetUserIDFromContext(r)
 	if err != nil {
-		lib.WriteJSONError(w, http.StatusUnauthorized, err.Error())
+		lib.WriteError(w, r, err)
 		return
 	}
 	category := r.URL.Query().Get("category")
@@ -222,10 +230,155 @@ func (h *ExpenseHandler) GetExpensesByCategory(w http.ResponseWriter, r *http.Re
 
 	expenses, err := h.expenseService.GetExpensesByCategory(r.Context(), userID, category)
 	if err != nil {
-		lib.WriteJSONError(w, http.StatusInternalServerError, "internal server error")
+		lib.WriteError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(expenses)
 }
+
+// BatchExpenses handles the HTTP request to execute a batch of create, update and delete operations
+// for the authenticated user in a single transaction.
+// Possible HTTP responses:
+// - 200 OK: All operations succeeded.
+// - 207 Multi-Status: Best-effort batch where some operations failed.
+// - 400 Bad Request: Invalid request body, mode or operation count.
+// - 401 Unauthorized: User authentication failed.
+// - 413 Payload Too Large: Request body exceeds the size limit.
+// - 500 Internal Server Error: Failed to run the batch transaction.
+// - 422 Unprocessable Entity: Atomic batch rolled back because an operation failed.
+func (h *ExpenseHandler) BatchExpenses(w http.ResponseWriter, r *http.Request) {
+	userID, err := lib.GetUserIDFromContext(r)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	var req model.BatchRequest
+	if err := decodeJSON(w, r, &req, MaxBatchBodyBytes); err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	resp, err := h.expenseService.Batch(r.Context(), userID, &req)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	status := http.StatusOK
+	if resp.Failed > 0 {
+		status = http.StatusMultiStatus
+		if resp.Mode == model.BatchAtomic {
+			status = http.StatusUnprocessableEntity
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// BulkUpdateExpenses handles the HTTP request to recategorize and/or retag every expense
+// of the authenticated user matching a filter.
+// Possible HTTP responses:
+// - 200 OK: Expenses updated, the number of affected rows is returned.
+// - 400 Bad Request: Invalid request body, empty filter or nothing to change.
+// - 401 Unauthorized: User authentication failed.
+// - 413 Payload Too Large: Request body exceeds the size limit.
+// - 500 Internal Server Error: Failed to update expenses.
+func (h *ExpenseHandler) BulkUpdateExpenses(w http.ResponseWriter, r *http.Request) {
+	userID, err := lib.GetUserIDFromContext(r)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	var input model.BulkUpdateInput
+	if err := decodeJSON(w, r, &input, MaxBodyBytes); err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	result, err := h.expenseService.BulkUpdate(r.Context(), userID, &input)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// SearchExpenses handles the HTTP request to full-text search the authenticated user's expenses.
+// It expects a query parameter "q" and accepts optional "category", "start", "end" (YYYY-MM-DD),
+// "limit" and "offset" parameters. Bare words are prefix matched, "quoted phrases" match exactly.
+// Possible HTTP responses:
+// - 200 OK: Matching expenses ordered by relevance, with highlighted snippets.
+// - 400 Bad Request: Missing query or invalid filter parameters.
+// - 401 Unauthorized: User authentication failed.
+// - 500 Internal Server Error: Failed to search expenses.
+func (h *ExpenseHandler) SearchExpenses(w http.ResponseWriter, r *http.Request) {
+	userID, err := lib.GetUserIDFromContext(r)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	params := r.URL.Query()
+	query := model.SearchQuery{Query: params.Get("q")}
+	if query.Query == "" {
+		lib.WriteJSONError(w, http.StatusBadRequest, "q parameter is required")
+		return
+	}
+
+	if category := params.Get("category"); category != "" {
+		query.Category = &category
+	}
+	if query.Start, err = parseOptionalDate(params.Get("start")); err != nil {
+		lib.WriteJSONError(w, http.StatusBadRequest, "invalid start time (use YYYY-MM-DD)")
+		return
+	}
+	if query.End, err = parseOptionalDate(params.Get("end")); err != nil {
+		lib.WriteJSONError(w, http.StatusBadRequest, "invalid end time (use YYYY-MM-DD)")
+		return
+	}
+	if query.Limit, err = parseOptionalInt(params.Get("limit")); err != nil {
+		lib.WriteJSONError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+	if query.Offset, err = parseOptionalInt(params.Get("offset")); err != nil {
+		lib.WriteJSONError(w, http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	results, err := h.expenseService.SearchExpenses(r.Context(), userID, &query)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// parseOptionalDate parses a YYYY-MM-DD query value, returning nil for an empty value.
+func parseOptionalDate(value string) (*model.Date, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Date{Time: t}, nil
+}
+
+// parseOptionalInt parses an integer query value, returning 0 for an empty value.
+func parseOptionalInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
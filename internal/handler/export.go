@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"bytes"
+	"expense_tracker/internal/model"
+	"expense_tracker/lib"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ExportExpenses handles the HTTP request to export the expenses of the authenticated user as a plain-text
+// accounting journal. It expects a "format" query parameter ("ledger", which hledger reads as well, or
+// "beancount") and accepts the "currency" of the amounts, the "funding_account" the expenses are paid from,
+// "account" parameters of the form category=Account booking the expenses of a category to an account, and
+// "start", "end", "category" and "tag" filters.
+// Possible HTTP responses:
+// - 200 OK: Journal exported.
+// - 400 Bad Request: Missing or unknown format, invalid currency, account, date or filter.
+// - 401 Unauthorized: User authentication failed.
+// - 500 Internal Server Error: Failed to export the expenses.
+func (h *ExpenseHandler) ExportExpenses(w http.ResponseWriter, r *http.Request) {
+	userID, err := lib.GetUserIDFromContext(r)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	params := r.URL.Query()
+	opts := model.ExportOptions{
+		Format:         params.Get("format"),
+		Currency:       params.Get("currency"),
+		FundingAccount: params.Get("funding_account"),
+	}
+	if opts.Format == "" {
+		lib.WriteJSONError(w, http.StatusBadRequest, "format parameter is required")
+		return
+	}
+	for _, mapping := range params["account"] {
+		category, account, ok := strings.Cut(mapping, "=")
+		if !ok || category == "" {
+			lib.WriteJSONError(w, http.StatusBadRequest, "invalid account parameter (use category=Account)")
+			return
+		}
+		if opts.Accounts == nil {
+			opts.Accounts = map[string]string{}
+		}
+		opts.Accounts[category] = account
+	}
+
+	if category := params.Get("category"); category != "" {
+		opts.Filter.Category = &category
+	}
+	if tag := params.Get("tag"); tag != "" {
+		opts.Filter.Tag = &tag
+	}
+	if opts.Filter.Start, err = parseOptionalDate(params.Get("start")); err != nil {
+		lib.WriteJSONError(w, http.StatusBadRequest, "invalid start time (use YYYY-MM-DD)")
+		return
+	}
+	if opts.Filter.End, err = parseOptionalDate(params.Get("end")); err != nil {
+		lib.WriteJSONError(w, http.StatusBadRequest, "invalid end time (use YYYY-MM-DD)")
+		return
+	}
+
+	// The journal is buffered, so that failures still get an error response.
+	var journal bytes.Buffer
+	if err := h.expenseService.ExportJournal(r.Context(), userID, &opts, &journal); err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="expenses.%s"`, opts.Format))
+	w.Write(journal.Bytes())
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"encoding/json"
+	"expense_tracker/internal/graphqlapi"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/validate"
+	"expense_tracker/lib"
+	"fmt"
+	"net/http"
+)
+
+// GraphQLHandler serves the GraphQL API.
+type GraphQLHandler struct {
+	schema *graphqlapi.Schema
+}
+
+// NewGraphQLHandler creates a new GraphQLHandler executing queries against schema.
+func NewGraphQLHandler(schema *graphqlapi.Schema) *GraphQLHandler {
+	return &GraphQLHandler{
+		schema: schema,
+	}
+}
+
+// Query handles the HTTP request to run a GraphQL query for the authenticated user.
+// Errors of the query itself are reported in the errors of the GraphQL response.
+// Possible HTTP responses:
+// - 200 OK: Query executed; failed fields are listed in errors.
+// - 400 Bad Request: Invalid request body, or a query that can't be parsed, is invalid or too complex.
+// - 401 Unauthorized: User authentication failed.
+// - 413 Payload Too Large: Request body exceeds the size limit.
+func (h *GraphQLHandler) Query(w http.ResponseWriter, r *http.Request) {
+	userID, err := lib.GetUserIDFromContext(r)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	var req model.GraphQLRequest
+	if err := decodeJSON(w, r, &req, MaxBodyBytes); err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+	if err := validate.Struct(&req); err != nil {
+		lib.WriteError(w, r, fmt.Errorf("handler: invalid GraphQL request: %w", err))
+		return
+	}
+
+	resp, executed := h.schema.Execute(r.Context(), userID, &req)
+	status := http.StatusOK
+	if !executed {
+		status = http.StatusBadRequest
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
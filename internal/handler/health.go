@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"encoding/json"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/service"
+	"net/http"
+)
+
+// HealthHandler handles liveness and readiness probes.
+type HealthHandler struct {
+	healthService *service.HealthService
+}
+
+// NewHealthHandler creates a new HealthHandler with the given HealthService.
+func NewHealthHandler(healthService *service.HealthService) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+	}
+}
+
+// Liveness handles the liveness probe. It only reports that the process is able to serve HTTP.
+// Possible HTTP responses:
+// - 200 OK: The process is alive.
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": model.HealthOK})
+}
+
+// Readiness handles the readiness probe. It checks the database connection, the schema
+// migration version and whether the server is shutting down, and reports each check.
+// Possible HTTP responses:
+// - 200 OK: All checks passed.
+// - 503 Service Unavailable: At least one check failed.
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := h.healthService.Readiness(r.Context())
+
+	status := http.StatusOK
+	if report.Status != model.HealthOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
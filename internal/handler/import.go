@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"expense_tracker/internal/model"
+	"expense_tracker/lib"
+	"net/http"
+)
+
+// ImportExpenses handles the HTTP request to import the debits of a bank statement file, sent as
+// the request body, as expenses of the authenticated user. It expects a "format" query parameter
+// ("ofx", which also covers QFX, "camt053", "mt940", or the journal formats "ledger" and "beancount")
+// and accepts an optional "category" for created expenses whose transaction has none and a "currency"
+// restricting the import to transactions in that currency.
+// Transactions imported before and credits are skipped; the response counts and lists them.
+// Possible HTTP responses:
+// - 200 OK: Statement imported.
+// - 400 Bad Request: Missing or unknown format, invalid category or currency, debits in several
+// currencies without a currency parameter, or invalid statement file.
+// - 401 Unauthorized: User authentication failed.
+// - 413 Payload Too Large: Request body exceeds the size limit.
+// - 500 Internal Server Error: Failed to import the statement.
+func (h *ExpenseHandler) ImportExpenses(w http.ResponseWriter, r *http.Request) {
+	userID, err := lib.GetUserIDFromContext(r)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	opts, body, ok := readImport(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.expenseService.ImportStatement(r.Context(), userID, opts, bytes.NewReader(body))
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// PreviewImport handles the HTTP request to preview the import of a bank statement file. It takes
+// the same parameters and body as ImportExpenses and lists every transaction of the file with the
+// expense it would become or the reason it would be skipped, without storing anything.
+// Possible HTTP responses:
+// - 200 OK: Statement previewed.
+// - 400 Bad Request: Missing or unknown format, invalid category or currency, debits in several
+// currencies without a currency parameter, or invalid statement file.
+// - 401 Unauthorized: User authentication failed.
+// - 413 Payload Too Large: Request body exceeds the size limit.
+// - 500 Internal Server Error: Failed to preview the statement.
+func (h *ExpenseHandler) PreviewImport(w http.ResponseWriter, r *http.Request) {
+	userID, err := lib.GetUserIDFromContext(r)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	opts, body, ok := readImport(w, r)
+	if !ok {
+		return
+	}
+
+	preview, err := h.expenseService.PreviewStatement(r.Context(), userID, opts, bytes.NewReader(body))
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
+
+// readImport reads the import options from the query and the statement file from the body.
+// If they can't be read, it writes the error response and returns false.
+func readImport(w http.ResponseWriter, r *http.Request) (*model.ImportOptions, []byte, bool) {
+	params := r.URL.Query()
+	opts := &model.ImportOptions{
+		Format:   params.Get("format"),
+		Category: params.Get("category"),
+		Currency: params.Get("currency"),
+	}
+	if opts.Format == "" {
+		lib.WriteJSONError(w, http.StatusBadRequest, "format parameter is required")
+		return nil, nil, false
+	}
+
+	body, err := readBody(w, r, MaxStatementBodyBytes)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return nil, nil, false
+	}
+	return opts, body, true
+}
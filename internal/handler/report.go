@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"bytes"
+	"expense_tracker/internal/report"
+	"expense_tracker/lib"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// MonthlyReport handles the HTTP request to render the monthly statement of the authenticated user as a PDF
+// document: totals by category, a chart of daily spending, every expense of the month and the comparison
+// with the month before. It expects a "month" query parameter in the YYYY-MM format.
+// Possible HTTP responses:
+// - 200 OK: Statement rendered.
+// - 400 Bad Request: Missing or invalid month.
+// - 401 Unauthorized: User authentication failed.
+// - 500 Internal Server Error: Failed to render the statement.
+func (h *ExpenseHandler) MonthlyReport(w http.ResponseWriter, r *http.Request) {
+	userID, err := lib.GetUserIDFromContext(r)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	month, err := time.Parse("2006-01", r.URL.Query().Get("month"))
+	if err != nil || month.Year() < 1900 || month.Year() > 2100 {
+		lib.WriteJSONError(w, http.StatusBadRequest, "invalid month (use YYYY-MM)")
+		return
+	}
+
+	monthly, err := h.expenseService.MonthlyReport(r.Context(), userID, month)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+	var document bytes.Buffer
+	if err := report.WriteMonthlyPDF(&document, monthly); err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="statement-%s.pdf"`, month.Format("2006-01")))
+	w.Write(document.Bytes())
+}
@@ -23,24 +23,28 @@ func NewUserHandler(userService *service.UserService) *UserHandler {
 // UpdateUsername handles the HTTP request to update a user's username.
 // Possible HTTP responses:
 // - 200 OK: Username updated successfully.
-// - 400 Bad Request: Invalid request body or update error.
+// - 400 Bad Request: Invalid request body or empty or too long username.
 // - 401 Unauthorized: User authentication failed.
+// - 404 Not Found: User not found.
+// - 409 Conflict: Username is already taken.
+// - 413 Payload Too Large: Request body exceeds the size limit.
+// - 500 Internal Server Error: Failed to update the username.
 func (h *UserHandler) UpdateUsername(w http.ResponseWriter, r *http.Request) {
 	userID, err := lib.GetUserIDFromContext(r)
 	if err != nil {
-		lib.WriteJSONError(w, http.StatusUnauthorized, err.Error())
+		lib.WriteError(w, r, err)
 		return
 	}
 
 	var input model.UpdateUsernameInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		lib.WriteJSONError(w, http.StatusBadRequest, "invalid request body")
+	if err := decodeJSON(w, r, &input, MaxBodyBytes); err != nil {
+		lib.WriteError(w, r, err)
 		return
 	}
 
 	updatedUser, err := h.userService.UpdateUsername(r.Context(), userID, &input)
 	if err != nil {
-		lib.WriteJSONError(w, http.StatusBadRequest, err.Error())
+		lib.WriteError(w, r, err)
 		return
 	}
 
@@ -51,17 +55,18 @@ func (h *UserHandler) UpdateUsername(w http.ResponseWriter, r *http.Request) {
 // DeleteUser handles the HTTP request to delete the authenticated user.
 // Possible HTTP responses:
 // - 204 No Content: User deleted successfully.
-// - 400 Bad Request: Deletion error.
 // - 401 Unauthorized: User authentication failed.
+// - 404 Not Found: User not found.
+// - 500 Internal Server Error: Failed to delete the user.
 func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	userID, err := lib.GetUserIDFromContext(r)
 	if err != nil {
-		lib.WriteJSONError(w, http.StatusUnauthorized, err.Error())
+		lib.WriteError(w, r, err)
 		return
 	}
 
 	if err := h.userService.DeleteUser(r.Context(), userID); err != nil {
-		lib.WriteJSONError(w, http.StatusBadRequest, err.Error())
+		lib.WriteError(w, r, err)
 		return
 	}
 
@@ -72,17 +77,18 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 // Possible HTTP responses:
 // - 200 OK: Profile retrieved successfully.
 // - 401 Unauthorized: User authentication failed.
+// - 404 Not Found: User not found.
 // - 500 Internal Server Error: Failed to retrieve profile.
 func (h *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	userID, err := lib.GetUserIDFromContext(r)
 	if err != nil {
-		lib.WriteJSONError(w, http.StatusUnauthorized, err.Error())
+		lib.WriteError(w, r, err)
 		return
 	}
 
 	user, err := h.userService.GetUserProfile(r.Context(), userID)
 	if err != nil {
-		lib.WriteJSONError(w, http.StatusInternalServerError, "internal server error")
+		lib.WriteError(w, r, err)
 		return
 	}
 
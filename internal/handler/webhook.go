@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"encoding/json"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/service"
+	"expense_tracker/lib"
+	"net/http"
+	"strconv"
+)
+
+// WebhookHandler handles HTTP requests related to webhooks and their deliveries.
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+}
+
+// NewWebhookHandler creates a new WebhookHandler with the given WebhookService.
+func NewWebhookHandler(webhookService *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// CreateWebhook handles the HTTP request to create a webhook for the authenticated user.
+// The response is the only one including the secret the deliveries are signed with.
+// Possible HTTP responses:
+// - 201 Created: Webhook created successfully.
+// - 400 Bad Request: Invalid request body or validation error.
+// - 401 Unauthorized: User authentication failed.
+// - 413 Payload Too Large: Request body exceeds the size limit.
+// - 500 Internal Server Error: Failed to create the webhook.
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, err := lib.GetUserIDFromContext(r)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	var input model.WebhookInput
+	if err := decodeJSON(w, r, &input, MaxBodyBytes); err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	created, err := h.webhookService.CreateWebhook(r.Context(), userID, &input)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// ListWebhooks handles the HTTP request to list the webhooks of the authenticated user.
+// Possible HTTP responses:
+// - 200 OK: Webhooks retrieved successfully.
+// - 401 Unauthorized: User authentication failed.
+// - 500 Internal Server Error: Failed to retrieve webhooks.
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID, err := lib.GetUserIDFromContext(r)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	webhooks, err := h.webhookService.ListWebhooks(r.Context(), userID)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhooks)
+}
+
+// GetWebhook handles the HTTP request to retrieve a webhook by its ID for the authenticated user.
+// Possible HTTP responses:
+// - 200 OK: Webhook retrieved successfully.
+// - 400 Bad Request: Invalid webhook ID.
+// - 401 Unauthorized: User authentication failed.
+// - 404 Not Found: Webhook not found.
+// - 500 Internal Server Error: Failed to retrieve the webhook.
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, err := lib.GetUserIDFromContext(r)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	webhookID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		lib.WriteJSONError(w, http.StatusBadRequest, "invalid webhook ID")
+		return
+	}
+
+	webhook, err := h.webhookService.GetWebhook(r.Context(), userID, webhookID)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// UpdateWebhook handles the HTTP request to update a webhook by its ID for the authenticated user.
+// Setting active to true re-enables a webhook disabled after failed deliveries.
+// Possible HTTP responses:
+// - 200 OK: Webhook updated successfully.
+// - 400 Bad Request: Invalid webhook ID, request body, or validation error.
+// - 401 Unauthorized: User authentication failed.
+// - 404 Not Found: Webhook not found.
+// - 413 Payload Too Large: Request body exceeds the size limit.
+// - 500 Internal Server Error: Failed to update the webhook.
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, err := lib.GetUserIDFromContext(r)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	webhookID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		lib.WriteJSONError(w, http.StatusBadRequest, "invalid webhook ID")
+		return
+	}
+
+	var input model.UpdateWebhookInput
+	if err := decodeJSON(w, r, &input, MaxBodyBytes); err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	updated, err := h.webhookService.UpdateWebhook(r.Context(), userID, webhookID, &input)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeleteWebhook handles the HTTP request to delete a webhook and its deliveries.
+// Possible HTTP responses:
+// - 204 No Content: Webhook deleted successfully.
+// - 400 Bad Request: Invalid webhook ID.
+// - 401 Unauthorized: User authentication failed.
+// - 404 Not Found: Webhook not found.
+// - 500 Internal Server Error: Failed to delete the webhook.
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, err := lib.GetUserIDFromContext(r)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	webhookID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		lib.WriteJSONError(w, http.StatusBadRequest, "invalid webhook ID")
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(r.Context(), userID, webhookID); err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries handles the HTTP request to list the latest deliveries of a webhook,
+// newest first. It accepts an optional "limit" parameter (default 20, at most 100).
+// Possible HTTP responses:
+// - 200 OK: Deliveries retrieved successfully.
+// - 400 Bad Request: Invalid webhook ID or limit.
+// - 401 Unauthorized: User authentication failed.
+// - 404 Not Found: Webhook not found.
+// - 500 Internal Server Error: Failed to retrieve deliveries.
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, err := lib.GetUserIDFromContext(r)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	webhookID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		lib.WriteJSONError(w, http.StatusBadRequest, "invalid webhook ID")
+		return
+	}
+	limit, err := parseOptionalInt(r.URL.Query().Get("limit"))
+	if err != nil {
+		lib.WriteJSONError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(r.Context(), userID, webhookID, limit)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// GetDelivery handles the HTTP request to retrieve a delivery of a webhook with the log
+// of its attempts, including the response codes.
+// Possible HTTP responses:
+// - 200 OK: Delivery retrieved successfully.
+// - 400 Bad Request: Invalid webhook or delivery ID.
+// - 401 Unauthorized: User authentication failed.
+// - 404 Not Found: Webhook or delivery not found.
+// - 500 Internal Server Error: Failed to retrieve the delivery.
+func (h *WebhookHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	userID, err := lib.GetUserIDFromContext(r)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	webhookID, deliveryID, ok := deliveryPath(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.GetDelivery(r.Context(), userID, webhookID, deliveryID)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// Redeliver handles the HTTP request to send the payload of a delivery again. The
+// redelivery is a new delivery, queued to be sent right away.
+// Possible HTTP responses:
+// - 202 Accepted: Redelivery queued; the body is the new delivery.
+// - 400 Bad Request: Invalid webhook or delivery ID.
+// - 401 Unauthorized: User authentication failed.
+// - 404 Not Found: Webhook or delivery not found.
+// - 409 Conflict: The webhook is disabled.
+// - 500 Internal Server Error: Failed to queue the redelivery.
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	userID, err := lib.GetUserIDFromContext(r)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	webhookID, deliveryID, ok := deliveryPath(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(r.Context(), userID, webhookID, deliveryID)
+	if err != nil {
+		lib.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// deliveryPath parses the webhook and delivery IDs of the path, answering 400
+// if one is invalid.
+func deliveryPath(w http.ResponseWriter, r *http.Request) (webhookID int, deliveryID int64, ok bool) {
+	webhookID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		lib.WriteJSONError(w, http.StatusBadRequest, "invalid webhook ID")
+		return 0, 0, false
+	}
+	deliveryID, err = strconv.ParseInt(r.PathValue("deliveryID"), 10, 64)
+	if err != nil {
+		lib.WriteJSONError(w, http.StatusBadRequest, "invalid delivery ID")
+		return 0, 0, false
+	}
+	return webhookID, deliveryID, true
+}
@@ -0,0 +1,283 @@
+package journal
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// beancountHeader matches the first line of a directive: its date, its
+	// keyword or transaction flag, and the rest.
+	beancountHeader = regexp.MustCompile(`^(\d{4}[-/]\d{2}[-/]\d{2})\s+(\S+)(.*)$`)
+	// beancountMeta matches a line of metadata, "key: value".
+	beancountMeta = regexp.MustCompile(`^([a-z][A-Za-z0-9_-]*):\s*(.*)$`)
+	// beancountCurrency matches commodity names.
+	beancountCurrency = regexp.MustCompile(`^[A-Z][A-Z0-9'._-]*$`)
+)
+
+// beancountAccountWidth is the width accounts are padded to, so that amounts line up.
+const beancountAccountWidth = 36
+
+// writeBeancount writes the transactions preceded by open directives for
+// their accounts, dated the day of their first transaction.
+func writeBeancount(w *bufio.Writer, transactions []Transaction) {
+	opened := map[string]time.Time{}
+	var accounts []string
+	for _, t := range transactions {
+		for _, p := range t.Postings {
+			if date, ok := opened[p.Account]; !ok || t.Date.Before(date) {
+				if !ok {
+					accounts = append(accounts, p.Account)
+				}
+				opened[p.Account] = t.Date
+			}
+		}
+	}
+	slices.SortFunc(accounts, func(a, b string) int {
+		return cmp.Or(opened[a].Compare(opened[b]), strings.Compare(a, b))
+	})
+	for _, account := range accounts {
+		fmt.Fprintf(w, "%s open %s\n", opened[account].Format(time.DateOnly), account)
+	}
+
+	for _, t := range transactions {
+		w.WriteString("\n" + t.Date.Format(time.DateOnly) + " *")
+		if t.Payee != "" {
+			w.WriteString(" " + beancountString(t.Payee))
+		}
+		w.WriteString(" " + beancountString(t.Narration) + "\n")
+		writeBeancountMeta(w, "  ", t.Meta)
+
+		for _, p := range t.Postings {
+			if p.Amount == nil {
+				fmt.Fprintf(w, "  %s\n", p.Account)
+			} else {
+				fmt.Fprintf(w, "  %-*s  %s %s\n", beancountAccountWidth, p.Account,
+					formatNumber(p.Amount.Number), p.Amount.Commodity)
+			}
+			writeBeancountMeta(w, "    ", p.Meta)
+		}
+	}
+}
+
+func writeBeancountMeta(w *bufio.Writer, indent string, meta []Meta) {
+	for _, m := range meta {
+		fmt.Fprintf(w, "%s%s: %s\n", indent, m.Key, beancountString(m.Value))
+	}
+}
+
+// beancountString quotes s on a single line.
+func beancountString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(oneLine(s)) + `"`
+}
+
+func parseBeancount(lines []string) ([]Transaction, error) {
+	var (
+		transactions []Transaction
+		current      *Transaction
+		// postingIndent is the indentation of the last posting; metadata
+		// indented further belongs to it.
+		postingIndent int
+		// pushed are the tags of pushtag directives.
+		pushed []string
+	)
+	finish := func() {
+		if current != nil {
+			transactions = append(transactions, *current)
+			current = nil
+		}
+	}
+
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			continue
+		}
+
+		if indented(line) {
+			// Lines of other directives, such as metadata of accounts, are skipped.
+			if current == nil {
+				continue
+			}
+			text := strings.TrimLeft(line, " \t")
+			if strings.HasPrefix(text, ";") {
+				continue
+			}
+			indent := len(line) - len(text)
+			if m := beancountMeta.FindStringSubmatch(text); m != nil {
+				value, err := beancountValue(m[2])
+				if err != nil {
+					return nil, &SyntaxError{Line: i + 1, Msg: err.Error()}
+				}
+				meta := &current.Meta
+				if n := len(current.Postings); n > 0 && indent > postingIndent {
+					meta = &current.Postings[n-1].Meta
+				}
+				*meta = append(*meta, Meta{Key: m[1], Value: value})
+				continue
+			}
+			p, err := parseBeancountPosting(text)
+			if err != nil {
+				return nil, &SyntaxError{Line: i + 1, Msg: err.Error()}
+			}
+			current.Postings = append(current.Postings, p)
+			postingIndent = indent
+			continue
+		}
+
+		finish()
+		if keyword, tag, ok := strings.Cut(line, " "); ok && (keyword == "pushtag" || keyword == "poptag") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+			if keyword == "pushtag" {
+				pushed = append(pushed, tag)
+			} else if i := slices.Index(pushed, tag); i >= 0 {
+				pushed = slices.Delete(pushed, i, i+1)
+			}
+			continue
+		}
+		m := beancountHeader.FindStringSubmatch(line)
+		// Other lines are comments, options and the like; only transactions
+		// are read of dated directives.
+		if m == nil || m[2] != "txn" && len(m[2]) != 1 {
+			continue
+		}
+		t, err := parseBeancountHeader(m[1], m[3])
+		if err != nil {
+			return nil, &SyntaxError{Line: i + 1, Msg: err.Error()}
+		}
+		t.Tags = append(t.Tags, pushed...)
+		current = &t
+	}
+	finish()
+	return transactions, nil
+}
+
+// parseBeancountHeader parses the date and the strings, tags and links
+// following the flag of a transaction.
+func parseBeancountHeader(date, rest string) (Transaction, error) {
+	var t Transaction
+	var err error
+	if t.Date, err = parseDate(date); err != nil {
+		return Transaction{}, err
+	}
+	tokens, err := beancountTokens(rest)
+	if err != nil {
+		return Transaction{}, err
+	}
+	var strs []string
+	for _, token := range tokens {
+		switch {
+		case strings.HasPrefix(token, `"`):
+			if len(t.Tags) > 0 || len(strs) == 2 {
+				return Transaction{}, fmt.Errorf("unexpected string %s", token)
+			}
+			strs = append(strs, beancountUnquote(token))
+		case strings.HasPrefix(token, "#"):
+			t.Tags = append(t.Tags, token[1:])
+		case strings.HasPrefix(token, "^"):
+			// Links are not read.
+		default:
+			return Transaction{}, fmt.Errorf("unexpected %q", token)
+		}
+	}
+	switch len(strs) {
+	case 1:
+		t.Narration = strs[0]
+	case 2:
+		t.Payee, t.Narration = strs[0], strs[1]
+	}
+	return t, nil
+}
+
+// parseBeancountPosting parses a posting. Costs and prices are ignored.
+func parseBeancountPosting(text string) (Posting, error) {
+	tokens, err := beancountTokens(text)
+	if err != nil {
+		return Posting{}, err
+	}
+	if tokens[0] == "*" || tokens[0] == "!" {
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 {
+		return Posting{}, errors.New("posting has no account")
+	}
+	p := Posting{Account: tokens[0]}
+	if len(tokens) == 1 || strings.HasPrefix(tokens[1], "{") || strings.HasPrefix(tokens[1], "@") {
+		return p, nil
+	}
+	number, err := parseNumber(tokens[1])
+	if err != nil {
+		return Posting{}, err
+	}
+	p.Amount = &Amount{Number: number}
+	if len(tokens) > 2 && !strings.HasPrefix(tokens[2], "{") && !strings.HasPrefix(tokens[2], "@") {
+		if !beancountCurrency.MatchString(tokens[2]) {
+			return Posting{}, fmt.Errorf("invalid amount %q", strings.Join(tokens[1:3], " "))
+		}
+		p.Amount.Commodity = tokens[2]
+	}
+	return p, nil
+}
+
+// beancountValue returns the value of metadata: strings are unquoted and
+// other values, such as numbers and dates, kept as they are.
+func beancountValue(s string) (string, error) {
+	tokens, err := beancountTokens(s)
+	if err != nil || len(tokens) == 0 {
+		return "", err
+	}
+	if strings.HasPrefix(tokens[0], `"`) {
+		return beancountUnquote(tokens[0]), nil
+	}
+	return strings.Join(tokens, " "), nil
+}
+
+// beancountTokens splits a line into tokens up to a comment. Strings are kept
+// with their quotes.
+func beancountTokens(s string) ([]string, error) {
+	var tokens []string
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" || s[0] == ';' {
+			return tokens, nil
+		}
+		end := strings.IndexAny(s, " \t")
+		if s[0] == '"' {
+			end = 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, errors.New("unterminated string")
+			}
+			end++
+		}
+		if end < 0 {
+			end = len(s)
+		}
+		tokens = append(tokens, s[:end])
+		s = s[end:]
+	}
+}
+
+// beancountUnquote returns the content of a string token.
+func beancountUnquote(token string) string {
+	var b strings.Builder
+	inner := token[1 : len(token)-1]
+	for i := 0; i < len(inner); i++ {
+		if inner[i] == '\\' && i+1 < len(inner) {
+			i++
+		}
+		b.WriteByte(inner[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,165 @@
+package journal
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const beancountJournal = `option "title" "Test"
+2024-01-01 open Expenses:Food
+  description: "not a transaction"
+
+pushtag #trip
+2024-03-15 * "Caf\"e" "Lunch \\ dinner" #food ^receipt-42
+  category: "eating out"
+  id: 42
+  Expenses:Food    12.50 EUR
+    tags: "lunch, work"
+    ; a comment
+  Assets:Cash     -12.50 EUR ; paid cash
+poptag #trip
+
+2024-03-16 txn "Only narration"
+  Expenses:Food   1,234.56 USD {1.1 EUR}
+  ! Assets:Cash
+2024-03-17 balance Assets:Cash 0 EUR
+`
+
+func TestParseBeancount(t *testing.T) {
+	got, err := Parse(FormatBeancount, strings.NewReader(beancountJournal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Transaction{
+		{
+			Date:      day(2024, 3, 15),
+			Payee:     `Caf"e`,
+			Narration: `Lunch \ dinner`,
+			Tags:      []string{"food", "trip"},
+			Meta:      []Meta{{Key: "category", Value: "eating out"}, {Key: "id", Value: "42"}},
+			Postings: []Posting{
+				{
+					Account: "Expenses:Food",
+					Amount:  &Amount{Number: 12.5, Commodity: "EUR"},
+					Meta:    []Meta{{Key: "tags", Value: "lunch, work"}},
+				},
+				{Account: "Assets:Cash", Amount: &Amount{Number: -12.5, Commodity: "EUR"}},
+			},
+		},
+		{
+			Date:      day(2024, 3, 16),
+			Narration: "Only narration",
+			Postings: []Posting{
+				{Account: "Expenses:Food", Amount: &Amount{Number: 1234.56, Commodity: "USD"}},
+				{Account: "Assets:Cash"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseBeancountTags(t *testing.T) {
+	journal := `pushtag #a
+pushtag #b
+2024-03-15 * "one" #c
+  Expenses:Food  1 EUR
+poptag #a
+2024-03-16 * "two"
+  Expenses:Food  1 EUR
+poptag #b
+poptag #unknown
+2024-03-17 * "three"
+  Expenses:Food  1 EUR
+`
+	got, err := Parse(FormatBeancount, strings.NewReader(journal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"c", "a", "b"}, {"b"}, nil}
+	for i, tags := range want {
+		if !reflect.DeepEqual(got[i].Tags, tags) {
+			t.Errorf("transaction %d has tags %q, want %q", i, got[i].Tags, tags)
+		}
+	}
+}
+
+func TestBeancountStrings(t *testing.T) {
+	for _, s := range []string{"", "plain", `say "hi"`, `back\slash`, `\"`} {
+		quoted := beancountString(s)
+		tokens, err := beancountTokens(quoted + " ; comment")
+		if err != nil || len(tokens) != 1 || beancountUnquote(tokens[0]) != s {
+			t.Errorf("%q quoted as %s reads back as %q, %v", s, quoted, tokens, err)
+		}
+	}
+	if got := beancountString("two\nlines"); got != `"two lines"` {
+		t.Errorf("line break quoted as %s", got)
+	}
+}
+
+func TestParseBeancountErrors(t *testing.T) {
+	tests := []struct {
+		journal string
+		line    int
+	}{
+		{"2024-03-15 * \"unterminated\n", 1},
+		{"2024-03-15 * \"a\" \"b\" \"c\"\n", 1},
+		{"2024-03-15 * #tag \"late string\"\n", 1},
+		{"2024-03-15 * \"a\" flag\n", 1},
+		{"2024-03-15 * \"a\"\n  Expenses:Food  12 eur\n", 2},
+		{"2024-03-15 * \"a\"\n  Expenses:Food  twelve EUR\n", 2},
+		{"2024-03-15 * \"a\"\n  note: \"unterminated\n", 2},
+		{"2024-03-15 * \"a\"\n  !\n", 2},
+	}
+	for _, tt := range tests {
+		_, err := Parse(FormatBeancount, strings.NewReader(tt.journal))
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) || syntaxErr.Line != tt.line {
+			t.Errorf("%q: got %v, want an error on line %d", tt.journal, err, tt.line)
+		}
+	}
+}
+
+func TestWriteBeancount(t *testing.T) {
+	var b strings.Builder
+	err := Write(&b, FormatBeancount, []Transaction{
+		{
+			Date:      day(2024, 3, 16),
+			Payee:     "Shop",
+			Narration: `Lunch "to go"`,
+			Postings: []Posting{
+				{Account: "Expenses:Food", Amount: &Amount{Number: 12.5, Commodity: "EUR"}, Meta: []Meta{{Key: "category", Value: "food"}}},
+				{Account: "Assets:Cash"},
+			},
+		},
+		{
+			Date: day(2024, 3, 15),
+			Postings: []Posting{
+				{Account: "Expenses:Rent", Amount: &Amount{Number: 800, Commodity: "EUR"}},
+				{Account: "Assets:Cash"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `2024-03-15 open Assets:Cash
+2024-03-15 open Expenses:Rent
+2024-03-16 open Expenses:Food
+
+2024-03-16 * "Shop" "Lunch \"to go\""
+  Expenses:Food                         12.50 EUR
+    category: "food"
+  Assets:Cash
+
+2024-03-15 * ""
+  Expenses:Rent                         800.00 EUR
+  Assets:Cash
+`
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,225 @@
+// Package journal reads and writes the plain-text accounting journals of
+// ledger, which hledger reads as well, and beancount. Only what expenses need
+// is supported: dated transactions with their postings, metadata and tags.
+package journal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Format names a journal file format.
+type Format string
+
+// Supported formats.
+const (
+	// FormatLedger is the journal of ledger, which hledger reads as well.
+	FormatLedger Format = "ledger"
+	// FormatBeancount is the input file of beancount.
+	FormatBeancount Format = "beancount"
+)
+
+// ErrUnknownFormat is returned for unsupported formats.
+var ErrUnknownFormat = errors.New("journal: unknown format")
+
+// SyntaxError reports a line of a journal that can't be read.
+type SyntaxError struct {
+	Line int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// Transaction is a dated transaction of a journal.
+type Transaction struct {
+	Date time.Time
+	// Payee is the payee of beancount transactions. Ledger has a single
+	// description, which is read into Narration; it is written from Payee and
+	// Narration joined by " | ", the way hledger separates them.
+	Payee     string
+	Narration string
+	// Tags are the tags of the transaction. They are read, not written.
+	Tags []string
+	// Meta is the metadata of the transaction in file order.
+	Meta     []Meta
+	Postings []Posting
+}
+
+// Meta is a key and value of metadata.
+type Meta struct {
+	Key   string
+	Value string
+}
+
+// Posting is the change of the balance of an account by a transaction.
+type Posting struct {
+	Account string
+	// Amount is nil for the posting balancing the transaction.
+	Amount *Amount
+	Meta   []Meta
+	// Tags are the tags of the posting. They are read, not written.
+	Tags []string
+}
+
+// Amount is a quantity of a commodity.
+type Amount struct {
+	Number float64
+	// Commodity is a currency code such as "EUR" or, in ledger, a symbol such as "$".
+	Commodity string
+}
+
+// Lookup returns the value of the last metadata with the key.
+func Lookup(meta []Meta, key string) (string, bool) {
+	for i := len(meta) - 1; i >= 0; i-- {
+		if meta[i].Key == key {
+			return meta[i].Value, true
+		}
+	}
+	return "", false
+}
+
+// Write writes the transactions as a journal of the format.
+func Write(w io.Writer, format Format, transactions []Transaction) error {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case FormatLedger:
+		writeLedger(bw, transactions)
+	case FormatBeancount:
+		writeBeancount(bw, transactions)
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("journal: can't write: %w", err)
+	}
+	return nil
+}
+
+// Parse reads the transactions of a journal of the format. Directives other
+// than transactions, such as account declarations and prices, are skipped.
+// Lines that can't be read are reported with a *SyntaxError.
+func Parse(format Format, r io.Reader) ([]Transaction, error) {
+	var parse func(lines []string) ([]Transaction, error)
+	switch format {
+	case FormatLedger:
+		parse = parseLedger
+	case FormatBeancount:
+		parse = parseBeancount
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("journal: can't read file: %w", err)
+	}
+	text := strings.TrimPrefix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\ufeff")
+	return parse(strings.Split(text, "\n"))
+}
+
+// beancountAccount matches beancount account names, whose components start
+// with an upper-case letter, a digit or a non-ASCII character.
+var beancountAccount = regexp.MustCompile(`^(Assets|Liabilities|Equity|Income|Expenses)(:([A-Z0-9]|[^\x00-\x7F])([A-Za-z0-9-]|[^\x00-\x7F])*)+$`)
+
+// ValidAccount reports whether name is a valid account name of the format.
+// Ledger accepts nearly everything but empty components, tabs and double
+// spaces, which end account names.
+func ValidAccount(format Format, name string) bool {
+	if format == FormatBeancount {
+		return beancountAccount.MatchString(name) && !strings.ContainsFunc(name, unicode.IsSpace)
+	}
+	if strings.ContainsAny(name, "\t\n\r;") || strings.Contains(name, "  ") ||
+		strings.TrimSpace(name) != name || strings.ContainsAny(name[:min(1, len(name))], "([") {
+		return false
+	}
+	for _, component := range strings.Split(name, ":") {
+		if component == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// AccountName turns text such as a category into an account name component
+// valid in every format: words are capitalized and joined by dashes, so
+// "eating out" becomes "Eating-Out". It returns "" if s has no letters or digits.
+func AccountName(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, "-")
+}
+
+// formatNumber formats an amount with at least two decimals.
+func formatNumber(n float64) string {
+	s := strconv.FormatFloat(n, 'f', -1, 64)
+	dot := strings.IndexByte(s, '.')
+	switch {
+	case dot < 0:
+		s += ".00"
+	case len(s)-dot == 2:
+		s += "0"
+	}
+	return s
+}
+
+// parseNumber parses a decimal number with an optional sign. A comma is a
+// thousands separator, unless it is the only separator and not followed by
+// three digits or comes after the dots, as in "12,5" or "1.234,56".
+func parseNumber(s string) (float64, error) {
+	digits := strings.TrimLeft(s, "+-")
+	if len(s)-len(digits) > 1 || digits == "" || strings.Trim(digits, "0123456789.,") != "" {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	if comma := strings.LastIndexByte(digits, ','); comma >= 0 {
+		dot := strings.LastIndexByte(digits, '.')
+		decimalComma := comma > dot && (dot >= 0 || strings.Count(digits, ",") == 1 && len(digits)-comma-1 != 3)
+		if decimalComma {
+			digits = strings.ReplaceAll(digits, ".", "")
+			digits = strings.Replace(digits, ",", ".", 1)
+		} else {
+			digits = strings.ReplaceAll(digits, ",", "")
+		}
+	}
+	n, err := strconv.ParseFloat(digits, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	if strings.HasPrefix(s, "-") {
+		n = -n
+	}
+	return n, nil
+}
+
+// parseDate parses dates such as 2024-03-15, 2024/03/15 or 2024.3.15.
+func parseDate(s string) (time.Time, error) {
+	normalized := strings.NewReplacer("/", "-", ".", "-").Replace(s)
+	t, err := time.Parse("2006-1-2", normalized)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", s)
+	}
+	return t, nil
+}
+
+// oneLine collapses the whitespace of s, including line breaks, into single spaces.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// indented reports whether a line belongs to the entry above it.
+func indented(line string) bool {
+	return strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+}
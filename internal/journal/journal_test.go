@@ -0,0 +1,154 @@
+package journal
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"12", 12},
+		{"12.50", 12.5},
+		{"-12.50", -12.5},
+		{"+3", 3},
+		{"1,234.56", 1234.56},
+		{"1.234,56", 1234.56},
+		{"-1.234.567,8", -1234567.8},
+		{"12,5", 12.5},
+		{"12,50", 12.5},
+		{"1,234", 1234},
+		{"1,234,567", 1234567},
+	}
+	for _, tt := range tests {
+		if got, err := parseNumber(tt.in); err != nil || got != tt.want {
+			t.Errorf("parseNumber(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "-", "--1", "+-1", "1e5", "NaN", "$12", "1.2.3"} {
+		if got, err := parseNumber(in); err == nil {
+			t.Errorf("parseNumber(%q) = %v, want an error", in, got)
+		}
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	for n, want := range map[float64]string{12: "12.00", 12.5: "12.50", 12.345: "12.345", -0.1: "-0.10"} {
+		if got := formatNumber(n); got != want {
+			t.Errorf("formatNumber(%v) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	for _, in := range []string{"2024-03-05", "2024/03/05", "2024.3.5"} {
+		if got, err := parseDate(in); err != nil || !got.Equal(day(2024, 3, 5)) {
+			t.Errorf("parseDate(%q) = %v, %v", in, got, err)
+		}
+	}
+	if _, err := parseDate("2024-13-01"); err == nil {
+		t.Error("month 13 accepted")
+	}
+}
+
+func TestValidAccount(t *testing.T) {
+	tests := []struct {
+		format Format
+		name   string
+		want   bool
+	}{
+		{FormatLedger, "Expenses:Food", true},
+		{FormatLedger, "expenses:eating out", true},
+		{FormatLedger, "Expenses::Food", false},
+		{FormatLedger, "Expenses:Eating  Out", false},
+		{FormatLedger, "Expenses:Food;", false},
+		{FormatLedger, "(Budget)", false},
+		{FormatLedger, " Expenses", false},
+		{FormatBeancount, "Expenses:Food", true},
+		{FormatBeancount, "Expenses:Eating-Out:2024", true},
+		{FormatBeancount, "Expenses:Café", true},
+		{FormatBeancount, "Expenses:food", false},
+		{FormatBeancount, "Spending:Food", false},
+		{FormatBeancount, "Expenses", false},
+		{FormatBeancount, "Expenses:Eating Out", false},
+	}
+	for _, tt := range tests {
+		if got := ValidAccount(tt.format, tt.name); got != tt.want {
+			t.Errorf("ValidAccount(%s, %q) = %v", tt.format, tt.name, got)
+		}
+	}
+}
+
+func TestAccountName(t *testing.T) {
+	for in, want := range map[string]string{
+		"food":          "Food",
+		"eating out":    "Eating-Out",
+		"  kids' toys ": "Kids-Toys",
+		"café":          "Café",
+		"--":            "",
+	} {
+		if got := AccountName(in); got != want {
+			t.Errorf("AccountName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	if _, err := Parse("qif", strings.NewReader("")); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Parse error = %v", err)
+	}
+	if err := Write(&bytes.Buffer{}, "qif", nil); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Write error = %v", err)
+	}
+}
+
+// roundTrip are transactions that survive writing and reading in every format.
+var roundTrip = []Transaction{
+	{
+		Date:      day(2024, 3, 15),
+		Narration: `Lunch "downtown" \ with a colleague`,
+		Meta:      []Meta{{Key: "id", Value: "1"}, {Key: "category", Value: "eating out"}, {Key: "tags", Value: "lunch, work"}},
+		Postings: []Posting{
+			{Account: "Expenses:Eating-Out", Amount: &Amount{Number: 12.5, Commodity: "EUR"}},
+			{Account: "Assets:Cash"},
+		},
+	},
+	{
+		Date:      day(2024, 3, 16),
+		Narration: "(refund)",
+		Meta:      []Meta{{Key: "id", Value: "2"}, {Key: "category", Value: "food"}},
+		Postings: []Posting{
+			{Account: "Expenses:Food", Amount: &Amount{Number: -1234.5, Commodity: "EUR"}, Meta: []Meta{{Key: "note", Value: "returned"}}},
+			{Account: "Assets:Cash"},
+		},
+	},
+}
+
+func TestWriteParse(t *testing.T) {
+	for _, format := range []Format{FormatLedger, FormatBeancount} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, format, roundTrip); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Parse(format, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, roundTrip) {
+				t.Errorf("got %+v\nwant %+v", got, roundTrip)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package journal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// ledgerHeader matches the first line of a transaction: the date, an
+	// optional auxiliary date, status and code, and the description.
+	ledgerHeader = regexp.MustCompile(`^(\d{4}[-/.]\d{1,2}[-/.]\d{1,2})(?:=[\d/.-]+)?(?:\s*([*!]))?(?:\s*\(([^)]*)\))?\s*(.*)$`)
+	// ledgerNote splits a note off the text before it. A note starts with a
+	// semicolon at the beginning or after a tab or two spaces.
+	ledgerNote = regexp.MustCompile(`^(.*?)(?:(?:^|[ \t]{2,}|\t);(.*))?$`)
+	// ledgerAmount matches an amount with its commodity before or after the
+	// number, such as "$-12.50", "-$12.50" or "12.50 EUR".
+	ledgerAmount = regexp.MustCompile(`^(-?)\s*(?:("[^"]*"|[^-+\d\s.,"]+)\s*)?([-+]?[\d.,]+)\s*("[^"]*"|[^-+\d\s.,"]+)?$`)
+	// ledgerTags matches a comment made of tags, ":food:work:".
+	ledgerTags = regexp.MustCompile(`^:(?:[^:\s]+:)+$`)
+	// ledgerMeta matches a comment holding metadata, "key: value".
+	ledgerMeta = regexp.MustCompile(`^([^\s:]+)::?(?:\s+(.*))?$`)
+)
+
+// ledgerAccountWidth is the width accounts are padded to, so that amounts line up.
+const ledgerAccountWidth = 36
+
+func writeLedger(w *bufio.Writer, transactions []Transaction) {
+	for i, t := range transactions {
+		if i > 0 {
+			w.WriteString("\n")
+		}
+		description := oneLine(t.Payee)
+		if narration := oneLine(t.Narration); narration != "" {
+			description = strings.TrimPrefix(description+" | "+narration, " | ")
+		}
+		w.WriteString(t.Date.Format(time.DateOnly) + " *")
+		if strings.HasPrefix(description, "(") {
+			// An empty code keeps the description from being read as the code.
+			w.WriteString(" ()")
+		}
+		if description != "" {
+			w.WriteString(" " + description)
+		}
+		w.WriteString("\n")
+		writeLedgerMeta(w, t.Meta)
+
+		for _, p := range t.Postings {
+			if p.Amount == nil {
+				fmt.Fprintf(w, "    %s\n", p.Account)
+			} else {
+				fmt.Fprintf(w, "    %-*s  %s %s\n", ledgerAccountWidth, p.Account,
+					formatNumber(p.Amount.Number), ledgerCommodity(p.Amount.Commodity))
+			}
+			writeLedgerMeta(w, p.Meta)
+		}
+	}
+}
+
+func writeLedgerMeta(w *bufio.Writer, meta []Meta) {
+	for _, m := range meta {
+		fmt.Fprintf(w, "    ; %s: %s\n", m.Key, oneLine(m.Value))
+	}
+}
+
+// ledgerCommodity quotes commodities that can't be written bare.
+func ledgerCommodity(c string) string {
+	if strings.ContainsAny(c, "0123456789 \t-+.,;@={}()[]\"") {
+		return `"` + strings.ReplaceAll(c, `"`, "") + `"`
+	}
+	return c
+}
+
+func parseLedger(lines []string) ([]Transaction, error) {
+	var (
+		transactions []Transaction
+		current      *Transaction
+		inComment    bool
+	)
+	finish := func() {
+		if current != nil {
+			transactions = append(transactions, *current)
+			current = nil
+		}
+	}
+
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if inComment {
+			inComment = line != "end comment" && line != "end test"
+			continue
+		}
+		if line == "" {
+			continue
+		}
+
+		if indented(line) {
+			// Lines of directives and automated or periodic transactions are skipped.
+			if current == nil {
+				continue
+			}
+			text := strings.TrimSpace(line)
+			meta, tags := &current.Meta, &current.Tags
+			if n := len(current.Postings); n > 0 {
+				meta, tags = &current.Postings[n-1].Meta, &current.Postings[n-1].Tags
+			}
+			if strings.HasPrefix(text, ";") {
+				parseLedgerComment(text[1:], meta, tags)
+				continue
+			}
+			p, note, err := parseLedgerPosting(text)
+			if err != nil {
+				return nil, &SyntaxError{Line: i + 1, Msg: err.Error()}
+			}
+			current.Postings = append(current.Postings, p)
+			last := &current.Postings[len(current.Postings)-1]
+			parseLedgerComment(note, &last.Meta, &last.Tags)
+			continue
+		}
+
+		finish()
+		switch {
+		case line[0] >= '0' && line[0] <= '9':
+			t, note, err := parseLedgerHeader(line)
+			if err != nil {
+				return nil, &SyntaxError{Line: i + 1, Msg: err.Error()}
+			}
+			parseLedgerComment(note, &t.Meta, &t.Tags)
+			current = &t
+		case line == "comment" || line == "test":
+			inComment = true
+		}
+		// Other lines are comments and directives.
+	}
+	finish()
+	return transactions, nil
+}
+
+func parseLedgerHeader(line string) (Transaction, string, error) {
+	m := ledgerHeader.FindStringSubmatch(line)
+	if m == nil {
+		return Transaction{}, "", fmt.Errorf("invalid transaction %q", line)
+	}
+	date, err := parseDate(m[1])
+	if err != nil {
+		return Transaction{}, "", err
+	}
+	parts := ledgerNote.FindStringSubmatch(m[4])
+	return Transaction{Date: date, Narration: strings.TrimSpace(parts[1])}, parts[2], nil
+}
+
+// parseLedgerPosting parses a posting, returning its note. The account ends
+// at a tab or two spaces; virtual accounts lose their parentheses or brackets.
+func parseLedgerPosting(text string) (Posting, string, error) {
+	if text[0] == '*' || text[0] == '!' {
+		text = strings.TrimSpace(text[1:])
+	}
+	end := len(text)
+	if i := strings.IndexByte(text, '\t'); i >= 0 {
+		end = i
+	}
+	if i := strings.Index(text, "  "); i >= 0 && i < end {
+		end = i
+	}
+	account := text[:end]
+	rest, note, _ := strings.Cut(text[end:], ";")
+
+	p := Posting{Account: strings.Trim(account, "()[]")}
+	// Prices, lot annotations and balance assertions follow the amount.
+	if i := strings.IndexAny(rest, "@={"); i >= 0 {
+		rest = rest[:i]
+	}
+	rest = strings.TrimSpace(rest)
+	if rest == "" {
+		return p, note, nil
+	}
+	if strings.HasPrefix(rest, "(") {
+		return Posting{}, "", errors.New("amount expressions are not supported")
+	}
+	m := ledgerAmount.FindStringSubmatch(rest)
+	if m == nil || m[2] != "" && m[4] != "" {
+		return Posting{}, "", fmt.Errorf("invalid amount %q", rest)
+	}
+	number, err := parseNumber(m[3])
+	if err != nil {
+		return Posting{}, "", err
+	}
+	if m[1] == "-" {
+		number = -number
+	}
+	p.Amount = &Amount{Number: number, Commodity: strings.Trim(m[2]+m[4], `"`)}
+	return p, note, nil
+}
+
+// parseLedgerComment adds the tags or metadata of a comment. Other comments
+// are ignored.
+func parseLedgerComment(comment string, meta *[]Meta, tags *[]string) {
+	comment = strings.TrimSpace(comment)
+	switch {
+	case ledgerTags.MatchString(comment):
+		*tags = append(*tags, strings.Split(strings.Trim(comment, ":"), ":")...)
+	case ledgerMeta.MatchString(comment):
+		m := ledgerMeta.FindStringSubmatch(comment)
+		*meta = append(*meta, Meta{Key: m[1], Value: strings.TrimSpace(m[2])})
+	}
+}
@@ -0,0 +1,162 @@
+package journal
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const ledgerJournal = `; A comment
+account Expenses:Food
+
+2024-03-15 * (42) Shop | Lunch  ; :food:work:
+    ; category: eating out
+    Expenses:Food                 $12.50  ; :lunch:
+    ; note: extra
+    (Budget:Food)                -$12.50
+    [Assets:Savings]              12.50 EUR @ $1.10
+    * Assets:Cash
+
+2024/3/16=2024/03/17 ! Refund
+    Expenses:Food                 -1,234.56 "MY FUND"
+    Assets:Cash                   = 0 EUR
+
+comment
+2024-01-01 hidden
+    Expenses:Food  $1
+end comment
+
+~ monthly
+    Expenses:Rent  $1000
+`
+
+func TestParseLedger(t *testing.T) {
+	got, err := Parse(FormatLedger, strings.NewReader(ledgerJournal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Transaction{
+		{
+			Date:      day(2024, 3, 15),
+			Narration: "Shop | Lunch",
+			Tags:      []string{"food", "work"},
+			Meta:      []Meta{{Key: "category", Value: "eating out"}},
+			Postings: []Posting{
+				{
+					Account: "Expenses:Food",
+					Amount:  &Amount{Number: 12.5, Commodity: "$"},
+					Meta:    []Meta{{Key: "note", Value: "extra"}},
+					Tags:    []string{"lunch"},
+				},
+				{Account: "Budget:Food", Amount: &Amount{Number: -12.5, Commodity: "$"}},
+				{Account: "Assets:Savings", Amount: &Amount{Number: 12.5, Commodity: "EUR"}},
+				{Account: "Assets:Cash"},
+			},
+		},
+		{
+			Date:      day(2024, 3, 16),
+			Narration: "Refund",
+			Postings: []Posting{
+				{Account: "Expenses:Food", Amount: &Amount{Number: -1234.56, Commodity: "MY FUND"}},
+				{Account: "Assets:Cash"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseLedgerAmounts(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Amount
+	}{
+		{"$12.50", Amount{12.5, "$"}},
+		{"-$12.50", Amount{-12.5, "$"}},
+		{"$-12.50", Amount{-12.5, "$"}},
+		{"- $ 12.50", Amount{-12.5, "$"}},
+		{"12.50 EUR", Amount{12.5, "EUR"}},
+		{"-12,50 EUR", Amount{-12.5, "EUR"}},
+		{"€1.234,56", Amount{1234.56, "€"}},
+		{"1,234.56", Amount{1234.56, ""}},
+		{`"MY FUND" 3`, Amount{3, "MY FUND"}},
+	}
+	for _, tt := range tests {
+		p, _, err := parseLedgerPosting("Expenses:Food  " + tt.in)
+		if err != nil || p.Amount == nil || *p.Amount != tt.want {
+			t.Errorf("amount %q = %+v, %v; want %+v", tt.in, p.Amount, err, tt.want)
+		}
+	}
+}
+
+func TestParseLedgerComments(t *testing.T) {
+	tests := []struct {
+		comment string
+		meta    []Meta
+		tags    []string
+	}{
+		{":food:", nil, []string{"food"}},
+		{" :food:work: ", nil, []string{"food", "work"}},
+		{"category: eating out", []Meta{{Key: "category", Value: "eating out"}}, nil},
+		{"id:: 42", []Meta{{Key: "id", Value: "42"}}, nil},
+		{"reviewed:", []Meta{{Key: "reviewed"}}, nil},
+		{"just a note", nil, nil},
+		{":not tags", nil, nil},
+	}
+	for _, tt := range tests {
+		var (
+			meta []Meta
+			tags []string
+		)
+		parseLedgerComment(tt.comment, &meta, &tags)
+		if !reflect.DeepEqual(meta, tt.meta) || !reflect.DeepEqual(tags, tt.tags) {
+			t.Errorf("comment %q: meta %+v, tags %q", tt.comment, meta, tags)
+		}
+	}
+}
+
+func TestParseLedgerErrors(t *testing.T) {
+	tests := []struct {
+		journal string
+		line    int
+	}{
+		{"2024-13-01 Shop\n    Expenses:Food  $1\n", 1},
+		{"2024-03-15 Shop\n    Expenses:Food  (1 + 2)\n", 2},
+		{"2024-03-15 Shop\n    Expenses:Food  $1 EUR\n", 2},
+		{"2024-03-15 Shop\n    Assets:Cash\n    Expenses:Food  1..2 EUR\n", 3},
+	}
+	for _, tt := range tests {
+		_, err := Parse(FormatLedger, strings.NewReader(tt.journal))
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) || syntaxErr.Line != tt.line {
+			t.Errorf("%q: got %v, want an error on line %d", tt.journal, err, tt.line)
+		}
+	}
+}
+
+func TestWriteLedger(t *testing.T) {
+	var b strings.Builder
+	err := Write(&b, FormatLedger, []Transaction{{
+		Date:      day(2024, 3, 15),
+		Payee:     "Shop",
+		Narration: "Lunch\nfor two",
+		Meta:      []Meta{{Key: "category", Value: "food"}},
+		Postings: []Posting{
+			{Account: "Expenses:Food", Amount: &Amount{Number: 12.5, Commodity: "EUR 2"}},
+			{Account: "Assets:Cash"},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `2024-03-15 * Shop | Lunch for two
+    ; category: food
+    Expenses:Food                         12.50 "EUR 2"
+    Assets:Cash
+`
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
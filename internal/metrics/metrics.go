@@ -0,0 +1,203 @@
+package metrics
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "expense_tracker"
+
+// statsTimeout bounds the database query made on each scrape.
+const statsTimeout = 2 * time.Second
+
+// PoolStatter is implemented by repository.Database.
+type PoolStatter interface {
+	Stat() *pgxpool.Stat
+}
+
+// StatsSource is implemented by repository.StatsRepository.
+type StatsSource interface {
+	Stats(ctx context.Context) (*model.Stats, error)
+}
+
+// Metrics owns the Prometheus registry and the HTTP instruments of the server.
+type Metrics struct {
+	registry        *prometheus.Registry
+	requestsTotal   *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	inFlight        prometheus.Gauge
+}
+
+// New creates a registry with Go runtime, process, HTTP, connection pool and
+// business metrics. pool and stats may be nil to skip the corresponding collectors.
+func New(pool PoolStatter, stats StatsSource) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by method and route pattern.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_in_flight",
+			Help:      "Number of HTTP requests currently being served.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requestsTotal,
+		m.requestDuration,
+		m.inFlight,
+	)
+	if pool != nil {
+		m.registry.MustRegister(newPoolCollector(pool))
+	}
+	if stats != nil {
+		m.registry.MustRegister(newStatsCollector(stats))
+	}
+	return m
+}
+
+// Handler serves the registry in the Prometheus text exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Registry returns the underlying registry so other packages can register collectors.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// RequestStarted marks the beginning of an HTTP request.
+func (m *Metrics) RequestStarted() {
+	m.inFlight.Inc()
+}
+
+// ObserveRequest records a finished HTTP request. route must be the ServeMux
+// pattern rather than the raw path to keep label cardinality bounded.
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	m.inFlight.Dec()
+	m.requestsTotal.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.requestDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// poolCollector exports pgxpool statistics at scrape time.
+type poolCollector struct {
+	pool PoolStatter
+
+	acquiredConns     *prometheus.Desc
+	idleConns         *prometheus.Desc
+	totalConns        *prometheus.Desc
+	maxConns          *prometheus.Desc
+	acquireCount      *prometheus.Desc
+	acquireDuration   *prometheus.Desc
+	emptyAcquireCount *prometheus.Desc
+	canceledAcquires  *prometheus.Desc
+}
+
+func newPoolCollector(pool PoolStatter) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+	return &poolCollector{
+		pool:              pool,
+		acquiredConns:     desc("acquired_connections", "Number of connections currently in use."),
+		idleConns:         desc("idle_connections", "Number of idle connections in the pool."),
+		totalConns:        desc("total_connections", "Total number of connections in the pool."),
+		maxConns:          desc("max_connections", "Maximum size of the pool."),
+		acquireCount:      desc("acquires_total", "Number of successful connection acquires."),
+		acquireDuration:   desc("acquire_duration_seconds_total", "Total time spent waiting for a connection."),
+		emptyAcquireCount: desc("empty_acquires_total", "Number of acquires that had to wait for a connection."),
+		canceledAcquires:  desc("canceled_acquires_total", "Number of acquires canceled by their context."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquireCount
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquireCount
+	ch <- c.canceledAcquires
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(s.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, s.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireCount, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquires, prometheus.CounterValue, float64(s.CanceledAcquireCount()))
+}
+
+// statsCollector exports business gauges queried from the database at scrape time.
+type statsCollector struct {
+	source StatsSource
+
+	users                *prometheus.Desc
+	activeUsers          *prometheus.Desc
+	expensesTotal        *prometheus.Desc
+	expensesCreatedToday *prometheus.Desc
+}
+
+func newStatsCollector(source StatsSource) *statsCollector {
+	return &statsCollector{
+		source: source,
+		users: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "users"),
+			"Number of registered users.", nil, nil),
+		activeUsers: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "active_users"),
+			"Number of users who recorded an expense in the last 30 days.", nil, nil),
+		expensesTotal: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "expenses"),
+			"Number of stored expenses.", nil, nil),
+		expensesCreatedToday: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "expenses_created_today"),
+			"Number of expenses created since midnight (database time).", nil, nil),
+	}
+}
+
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.users
+	ch <- c.activeUsers
+	ch <- c.expensesTotal
+	ch <- c.expensesCreatedToday
+}
+
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), statsTimeout)
+	defer cancel()
+
+	s, err := c.source.Stats(ctx)
+	if err != nil {
+		slog.Warn("metrics: can't collect business stats", "error", err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.users, prometheus.GaugeValue, float64(s.Users))
+	ch <- prometheus.MustNewConstMetric(c.activeUsers, prometheus.GaugeValue, float64(s.ActiveUsers))
+	ch <- prometheus.MustNewConstMetric(c.expensesTotal, prometheus.GaugeValue, float64(s.ExpensesTotal))
+	ch <- prometheus.MustNewConstMetric(c.expensesCreatedToday, prometheus.GaugeValue, float64(s.ExpensesCreatedToday))
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"crypto/subtle"
 	"expense_tracker/internal/service"
 	"expense_tracker/lib"
 	"net/http"
@@ -14,6 +15,7 @@ import (
 // The middleware validates the token using the provided AuthService.
 // If the token is valid, it extracts the user ID and stores it in the request context
 // under the key defined by lib.UserIDkey, allowing subsequent handlers to identify the user.
+// The request-scoped logger is annotated with the user ID as well.
 //
 // If authentication fails, it responds with HTTP 401 Unauthorized and a JSON error message.
 //
@@ -49,8 +51,51 @@ func AuthMiddleware(authService *service.AuthService) func(http.Handler) http.Ha
 			}
 
 			ctx := context.WithValue(r.Context(), lib.UserIDkey, userID)
+			ctx = lib.WithLogger(ctx, lib.Logger(ctx).With("user_id", userID))
+			setRequestUser(ctx, userID)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// QueryToken returns an HTTP middleware that takes the bearer token from the
+// access_token query parameter when the request has no Authorization header.
+// It is meant for routes opened by browser APIs that can't set headers, such
+// as EventSource, and has to wrap AuthMiddleware. Request logs and traces
+// record only the path, so the token doesn't end up in them.
+//
+// Usage:
+//
+//	http.Handle("/events", QueryToken()(AuthMiddleware(authService)(eventsHandler)))
+func QueryToken() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token := r.URL.Query().Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+				r = r.Clone(r.Context())
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// StaticToken returns an HTTP middleware that only lets through requests
+// sending token as a bearer token. It protects routes used by machines rather
+// than users, such as the metrics scraped by Prometheus.
+//
+// Usage:
+//
+//	http.Handle("/metrics", StaticToken(token)(metricsHandler))
+func StaticToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				lib.WriteJSONError(w, http.StatusUnauthorized, "invalid token")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
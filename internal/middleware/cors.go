@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// corsExposedHeaders are the response headers browsers let scripts read.
+var corsExposedHeaders = []string{
+	RequestIDHeader, "Retry-After",
+	"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy",
+}
+
+// CORSOptions configures the CORS middleware.
+type CORSOptions struct {
+	// AllowedOrigins lists the origins allowed to call the API, such as
+	// "https://app.example.com"; "*" allows every origin.
+	AllowedOrigins []string
+	// AllowedMethods and AllowedHeaders are the methods and request headers
+	// preflight requests may ask for.
+	AllowedMethods []string
+	AllowedHeaders []string
+	// AllowCredentials lets browsers send cookies and HTTP authentication. It
+	// can't be combined with the "*" origin.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+// CORS returns an HTTP middleware that implements Cross-Origin Resource Sharing.
+//
+// Requests from an allowed origin get the Access-Control-Allow-Origin header, together with
+// Access-Control-Expose-Headers for the request ID, Retry-After and RateLimit-* headers.
+// Preflight requests (OPTIONS with Access-Control-Request-Method) are answered directly with
+// 204 No Content; the allow headers are only added when the origin, method and headers are
+// all allowed, so the browser blocks anything else. Requests from other origins are served
+// without CORS headers.
+//
+// The middleware must wrap the router, because the ServeMux rejects OPTIONS requests to
+// routes registered for other methods.
+//
+// Parameters:
+// - opts: the allowed origins, methods and headers.
+//
+// Usage:
+//
+//	server.Handler = CORS(opts)(router)
+func CORS(opts CORSOptions) func(http.Handler) http.Handler {
+	anyOrigin := slices.Contains(opts.AllowedOrigins, "*")
+	methods := strings.Join(opts.AllowedMethods, ", ")
+	headers := strings.Join(opts.AllowedHeaders, ", ")
+	exposed := strings.Join(corsExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(opts.MaxAge / time.Second))
+
+	allowedOrigin := func(origin string) bool {
+		return anyOrigin || slices.Contains(opts.AllowedOrigins, origin)
+	}
+	allowedMethod := func(method string) bool {
+		return slices.Contains(opts.AllowedMethods, method)
+	}
+	allowedHeaders := func(requested string) bool {
+		for _, h := range strings.Split(requested, ",") {
+			h = strings.TrimSpace(h)
+			if h != "" && !slices.ContainsFunc(opts.AllowedHeaders, func(a string) bool { return strings.EqualFold(a, h) }) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			if preflight {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+			}
+
+			if origin == "" || !allowedOrigin(origin) {
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			allowOrigin := origin
+			if anyOrigin && !opts.AllowCredentials {
+				allowOrigin = "*"
+			}
+
+			if preflight {
+				if allowedMethod(r.Header.Get("Access-Control-Request-Method")) &&
+					allowedHeaders(r.Header.Get("Access-Control-Request-Headers")) {
+					h.Set("Access-Control-Allow-Origin", allowOrigin)
+					h.Set("Access-Control-Allow-Methods", methods)
+					h.Set("Access-Control-Allow-Headers", headers)
+					h.Set("Access-Control-Max-Age", maxAge)
+					if opts.AllowCredentials {
+						h.Set("Access-Control-Allow-Credentials", "true")
+					}
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			h.Set("Access-Control-Allow-Origin", allowOrigin)
+			h.Set("Access-Control-Expose-Headers", exposed)
+			if opts.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
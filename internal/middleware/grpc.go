@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"context"
+	"expense_tracker/internal/service"
+	"expense_tracker/lib"
+	"log/slog"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// grpcPublicPrefixes are the gRPC services callable without a token.
+var grpcPublicPrefixes = []string{
+	"/expense.v1.AuthService/",
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.",
+}
+
+// GRPCLogger returns gRPC interceptors that assign every call a request ID and log it once
+// it completes, like RequestLogger does for HTTP requests.
+//
+// The ID is taken from the "x-request-id" metadata when the client sends a valid one and
+// generated otherwise; it is returned in the response header metadata.
+//
+// Parameters:
+// - logger: the base logger used for call-scoped loggers.
+//
+// Usage:
+//
+//	unary, stream := GRPCLogger(logger)
+//	grpc.NewServer(grpc.ChainUnaryInterceptor(unary), grpc.ChainStreamInterceptor(stream))
+func GRPCLogger(logger *slog.Logger) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	begin := func(ctx context.Context) (context.Context, *requestInfo) {
+		requestID := firstMetadata(ctx, "x-request-id")
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		grpc.SetHeader(ctx, metadata.Pairs("x-request-id", requestID))
+
+		info := &requestInfo{}
+		ctx = context.WithValue(ctx, requestInfoKey{}, info)
+		ctx = lib.WithRequestID(ctx, requestID)
+		ctx = lib.WithLogger(ctx, logger.With("request_id", requestID))
+		return ctx, info
+	}
+	end := func(ctx context.Context, info *requestInfo, method string, start time.Time, err error) {
+		code := status.Code(err)
+		attrs := []any{
+			"method", method,
+			"code", code.String(),
+			"duration", time.Since(start),
+		}
+		if p, ok := peer.FromContext(ctx); ok {
+			attrs = append(attrs, "remote_addr", p.Addr.String())
+		}
+		if info.userID != 0 {
+			attrs = append(attrs, "user_id", info.userID)
+		}
+
+		level := slog.LevelInfo
+		switch code {
+		case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+			level = slog.LevelError
+		}
+		lib.Logger(ctx).Log(ctx, level, "grpc request", attrs...)
+	}
+
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx, reqInfo := begin(ctx)
+		resp, err := handler(ctx, req)
+		end(ctx, reqInfo, info.FullMethod, start, err)
+		return resp, err
+	}
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx, reqInfo := begin(ss.Context())
+		err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		end(ctx, reqInfo, info.FullMethod, start, err)
+		return err
+	}
+	return unary, stream
+}
+
+// GRPCAuth returns gRPC interceptors that authenticate calls using a Bearer token, like
+// AuthMiddleware does for HTTP requests.
+//
+// It expects the "authorization" metadata with the format "Bearer <token>" on every method
+// except those of the auth, health and reflection services. The user ID is stored in the
+// context under lib.UserIDkey. Failures are returned as lib.Unauthorized errors.
+//
+// Parameters:
+// - authService: a pointer to AuthService used to validate tokens.
+func GRPCAuth(authService *service.AuthService) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	authenticate := func(ctx context.Context, method string) (context.Context, error) {
+		for _, prefix := range grpcPublicPrefixes {
+			if strings.HasPrefix(method, prefix) {
+				return ctx, nil
+			}
+		}
+
+		authHeader := firstMetadata(ctx, "authorization")
+		if authHeader == "" {
+			return nil, lib.Unauthorized("authorization metadata is required")
+		}
+		tokenString, ok := strings.CutPrefix(authHeader, "Bearer ")
+		if !ok {
+			return nil, lib.Unauthorized("invalid authorization format")
+		}
+		userID, err := authService.ValidateToken(tokenString)
+		if err != nil {
+			return nil, lib.Unauthorized("invalid token")
+		}
+
+		ctx = context.WithValue(ctx, lib.UserIDkey, userID)
+		ctx = lib.WithLogger(ctx, lib.Logger(ctx).With("user_id", userID))
+		setRequestUser(ctx, userID)
+		return ctx, nil
+	}
+
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+	return unary, stream
+}
+
+// GRPCLimit returns a unary gRPC interceptor that applies the rate limit policy returned
+// by policyFor for the called method, sharing the buckets of the HTTP routes. An empty
+// policy name leaves the method unlimited. Throttled calls fail with a
+// lib.TooManyRequests error; as with Limit, calls are let through if the store fails.
+//
+// The client is identified by its peer address, or by the last "x-forwarded-for"
+// metadata entry when the limiter trusts the proxy. It must run after GRPCAuth for
+// ByUser policies.
+//
+// Parameters:
+// - policyFor: maps a full method name such as "/expense.v1.ExpenseService/GetExpense"
+// to the name of a policy given to NewRateLimiter.
+func (rl *RateLimiter) GRPCLimit(policyFor func(method string) string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		name := policyFor(info.FullMethod)
+		if rl == nil || name == "" {
+			return handler(ctx, req)
+		}
+		p, ok := rl.policies[name]
+		if !ok {
+			panic("middleware: unknown rate limit policy " + name)
+		}
+
+		var remoteAddr string
+		if pr, ok := peer.FromContext(ctx); ok {
+			remoteAddr = pr.Addr.String()
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		res, key, err := rl.take(ctx, p, remoteAddr, md.Get("x-forwarded-for"))
+		if err != nil {
+			lib.Logger(ctx).Error("middleware: rate limit store unavailable", "policy", p.Name, "error", err)
+			return handler(ctx, req)
+		}
+		if !res.Allowed {
+			lib.Logger(ctx).Warn("rate limit exceeded", "policy", p.Name, "key", key)
+			return nil, lib.TooManyRequests("rate limit exceeded, retry later", res.RetryAfter)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// firstMetadata returns the first value of the incoming metadata key, or "".
+func firstMetadata(ctx context.Context, key string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// contextStream replaces the context of a server stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -1 +1,184 @@
 package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"expense_tracker/lib"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader is the header used to receive and return the request ID.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds client-supplied request IDs to keep log lines sane.
+const maxRequestIDLength = 128
+
+type requestInfoKey struct{}
+
+// requestInfo is filled in by inner middlewares so outer ones can report it.
+type requestInfo struct {
+	userID int
+	route  string
+}
+
+// RequestLogger returns an HTTP middleware that assigns every request an ID and logs it
+// once it completes.
+//
+// The ID is taken from the X-Request-ID header when the client sends a valid one and
+// generated otherwise; it is echoed back in the response header. A logger annotated with
+// the request ID is stored in the request context (see lib.Logger), so every line written
+// while serving the request can be correlated.
+//
+// The completion line contains the method, the ServeMux route pattern, the path, status,
+// latency, response size and the authenticated user ID, if any.
+//
+// Parameters:
+// - logger: the base logger used for request-scoped loggers.
+//
+// Usage:
+//
+//	server.Handler = RequestLogger(logger)(router)
+func RequestLogger(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestID := r.Header.Get(RequestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, requestID)
+
+			r, info := withRequestInfo(r)
+			reqLogger := logger.With("request_id", requestID)
+			if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+				reqLogger = reqLogger.With("trace_id", sc.TraceID().String())
+			}
+
+			ctx := lib.WithRequestID(r.Context(), requestID)
+			ctx = lib.WithLogger(ctx, reqLogger)
+			r = r.WithContext(ctx)
+
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			route := routeOf(r, info)
+
+			attrs := []any{
+				"method", r.Method,
+				"route", route,
+				"path", r.URL.Path,
+				"status", rec.status,
+				"duration", time.Since(start),
+				"bytes", rec.bytes,
+				"remote_addr", r.RemoteAddr,
+			}
+			if info.userID != 0 {
+				attrs = append(attrs, "user_id", info.userID)
+			}
+
+			level := slog.LevelInfo
+			if rec.status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			reqLogger.Log(ctx, level, "http request", attrs...)
+		})
+	}
+}
+
+// withRequestInfo returns the requestInfo shared by the middlewares of this request,
+// attaching a new one to the request context if no outer middleware did so yet.
+func withRequestInfo(r *http.Request) (*http.Request, *requestInfo) {
+	if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+		return r, info
+	}
+	info := &requestInfo{}
+	return r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info)), info
+}
+
+// recordRoute stores the ServeMux pattern matched for r so that middlewares
+// holding an earlier copy of the request can see it.
+func recordRoute(r *http.Request) {
+	if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok && r.Pattern != "" {
+		info.route = r.Pattern
+	}
+}
+
+// routeOf returns the matched route pattern of r, or "unmatched".
+func routeOf(r *http.Request, info *requestInfo) string {
+	switch {
+	case r.Pattern != "":
+		return r.Pattern
+	case info != nil && info.route != "":
+		return info.route
+	default:
+		return "unmatched"
+	}
+}
+
+// setRequestUser records the authenticated user for the request log line.
+func setRequestUser(ctx context.Context, userID int) {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.userID = userID
+	}
+}
+
+// validRequestID reports whether a client-supplied request ID can be reused.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID generates a random 128-bit request ID.
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// responseRecorder captures the status code and body size written by a handler.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// Flush implements http.Flusher for streaming handlers.
+func (rec *responseRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
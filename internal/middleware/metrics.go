@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"expense_tracker/internal/metrics"
+	"net/http"
+	"time"
+)
+
+// Metrics returns an HTTP middleware that records request counts and latencies.
+//
+// Requests are labelled with the ServeMux route pattern (for example
+// "GET /expenses/{id}") instead of the raw path, so the number of series stays
+// bounded. Requests that match no route are labelled "unmatched".
+//
+// The pattern is read from the request after ServeMux has matched it, so no
+// middleware that replaces the request may sit between this one and the
+// ServeMux. The pattern is also made available to outer middlewares such as
+// RequestLogger and Tracing.
+//
+// Parameters:
+// - m: the metrics registry that owns the HTTP instruments.
+//
+// Usage:
+//
+//	server.Handler = Tracing()(RequestLogger(logger)(Metrics(m)(router)))
+func Metrics(m *metrics.Metrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			m.RequestStarted()
+
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			// ServeMux stores the matched pattern on the request it was given.
+			recordRoute(r)
+			route := routeOf(r, nil)
+			m.ObserveRequest(r.Method, route, rec.status, time.Since(start))
+		})
+	}
+}
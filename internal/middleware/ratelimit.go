@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"expense_tracker/internal/ratelimit"
+	"expense_tracker/lib"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitPolicy throttles the routes it is applied to.
+type RateLimitPolicy struct {
+	// Name identifies the policy; each policy has its own buckets.
+	Name  string
+	Limit ratelimit.Limit
+	// ByUser keys buckets by the authenticated user instead of the client IP.
+	// Requests without a user fall back to the IP.
+	ByUser bool
+}
+
+// RateLimiter applies named rate limit policies to routes. A nil *RateLimiter
+// applies none, which disables rate limiting.
+type RateLimiter struct {
+	store      ratelimit.Store
+	policies   map[string]RateLimitPolicy
+	trustProxy bool
+}
+
+// NewRateLimiter creates a RateLimiter keeping its buckets in store. With
+// trustProxy the client IP is taken from the last X-Forwarded-For entry, which
+// is the address seen by the reverse proxy in front of the server.
+func NewRateLimiter(store ratelimit.Store, trustProxy bool, policies ...RateLimitPolicy) *RateLimiter {
+	rl := &RateLimiter{
+		store:      store,
+		policies:   map[string]RateLimitPolicy{},
+		trustProxy: trustProxy,
+	}
+	for _, p := range policies {
+		rl.policies[p.Name] = p
+	}
+	return rl
+}
+
+// Limit returns an HTTP middleware that applies the named policy using a token bucket
+// per client.
+//
+// Every response carries the RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and
+// RateLimit-Policy headers of the IETF RateLimit header fields draft. When the bucket is
+// empty the request is answered with 429 Too Many Requests and a Retry-After header.
+//
+// If the store fails, the request is let through and the error is logged, so an outage of
+// a shared store doesn't take the API down.
+//
+// For ByUser policies the middleware must run after AuthMiddleware so that the user ID is
+// in the request context.
+//
+// Parameters:
+// - policy: the name of a policy given to NewRateLimiter.
+//
+// Usage:
+//
+//	http.Handle("/auth/login", limiter.Limit("auth")(loginHandler))
+func (rl *RateLimiter) Limit(policy string) func(http.Handler) http.Handler {
+	if rl == nil {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	p, ok := rl.policies[policy]
+	if !ok {
+		panic("middleware: unknown rate limit policy " + policy)
+	}
+	policyHeader := fmt.Sprintf("%d;w=%d", p.Limit.Burst, int64(p.Limit.Period/time.Second))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res, key, err := rl.take(r.Context(), p, r.RemoteAddr, r.Header.Values("X-Forwarded-For"))
+			if err != nil {
+				lib.Logger(r.Context()).Error("middleware: rate limit store unavailable", "policy", p.Name, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", strconv.FormatInt(int64((res.Reset+time.Second-1)/time.Second), 10))
+			h.Set("RateLimit-Policy", policyHeader)
+
+			if !res.Allowed {
+				lib.Logger(r.Context()).Warn("rate limit exceeded", "policy", p.Name, "key", key)
+				lib.WriteError(w, r, lib.TooManyRequests("rate limit exceeded, retry later", res.RetryAfter))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// take spends a token from the bucket of the client in policy p and returns
+// the result with the bucket key.
+func (rl *RateLimiter) take(ctx context.Context, p RateLimitPolicy, remoteAddr string, forwardedFor []string) (ratelimit.Result, string, error) {
+	key := "ip:" + rl.clientIP(remoteAddr, forwardedFor)
+	if userID, ok := ctx.Value(lib.UserIDkey).(int); ok && p.ByUser {
+		key = "user:" + strconv.Itoa(userID)
+	}
+	res, err := rl.store.Take(ctx, "ratelimit:"+p.Name+":"+key, p.Limit)
+	return res, key, err
+}
+
+// clientIP returns the IP address of the client connected from remoteAddr.
+func (rl *RateLimiter) clientIP(remoteAddr string, forwardedFor []string) string {
+	if rl.trustProxy {
+		if len(forwardedFor) > 0 {
+			last := forwardedFor[len(forwardedFor)-1]
+			if i := strings.LastIndex(last, ","); i >= 0 {
+				last = last[i+1:]
+			}
+			if ip := strings.TrimSpace(last); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "expense_tracker/internal/middleware"
+
+// Tracing returns an HTTP middleware that starts an OpenTelemetry server span for
+// every request.
+//
+// An incoming W3C traceparent header is honoured, so the span joins the caller's
+// trace. Once the request has been routed the span is renamed to the ServeMux
+// pattern (for example "GET /expenses/{id}") and annotated with the status code;
+// 5xx responses mark the span as failed.
+//
+// Usage:
+//
+//	server.Handler = Tracing()(RequestLogger(logger)(Metrics(m)(router)))
+func Tracing() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r, info := withRequestInfo(r)
+
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := otel.Tracer(tracerName).Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("url.path", r.URL.Path),
+					attribute.String("user_agent.original", r.UserAgent()),
+				),
+			)
+			defer span.End()
+
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r.WithContext(ctx))
+
+			route := routeOf(r, info)
+			span.SetName(route)
+			span.SetAttributes(
+				attribute.String("http.route", route),
+				attribute.Int("http.response.status_code", rec.status),
+			)
+			if info.userID != 0 {
+				span.SetAttributes(attribute.Int("enduser.id", info.userID))
+			}
+			if rec.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(rec.status))
+			}
+		})
+	}
+}
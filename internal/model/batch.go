@@ -0,0 +1,87 @@
+package model
+
+// BatchMode controls how a batch of expense operations is executed.
+type BatchMode string
+
+const (
+	// BatchAtomic rolls back every operation if any of them fails.
+	BatchAtomic BatchMode = "atomic"
+	// BatchBestEffort commits every operation that succeeds and reports the rest as failed.
+	BatchBestEffort BatchMode = "best_effort"
+)
+
+// BatchOp is the kind of a single batch operation.
+type BatchOp string
+
+const (
+	BatchCreate BatchOp = "create"
+	BatchUpdate BatchOp = "update"
+	BatchDelete BatchOp = "delete"
+)
+
+// Batch item statuses reported in BatchResult.
+const (
+	BatchStatusOK         = "ok"
+	BatchStatusFailed     = "failed"
+	BatchStatusRolledBack = "rolled_back"
+)
+
+// BatchOperation is one create, update or delete inside a batch request.
+// Create uses Expense, update uses ID and Update, delete uses ID only.
+type BatchOperation struct {
+	Op      BatchOp             `json:"op" validate:"oneof=create update delete"`
+	ID      int                 `json:"id,omitempty"`
+	Expense *Expense            `json:"expense,omitempty"`
+	Update  *UpdateExpenseInput `json:"update,omitempty"`
+}
+
+// BatchRequest contains a list of operations executed in a single transaction.
+type BatchRequest struct {
+	Mode       BatchMode        `json:"mode" validate:"omitempty,oneof=atomic best_effort"`
+	Operations []BatchOperation `json:"operations" validate:"required,max=500"`
+}
+
+// BatchResult reports the outcome of the operation at Index in the request.
+type BatchResult struct {
+	Index   int      `json:"index"`
+	Op      BatchOp  `json:"op"`
+	Status  string   `json:"status"`
+	ID      int      `json:"id,omitempty"`
+	Expense *Expense `json:"expense,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// BatchResponse is the per-item summary of an executed batch.
+type BatchResponse struct {
+	Mode      BatchMode     `json:"mode"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// ExpenseFilter selects the expenses affected by a bulk operation. Empty fields are ignored.
+type ExpenseFilter struct {
+	IDs      []int   `json:"ids,omitempty" validate:"dive,gt=0"`
+	Category *string `json:"category,omitempty" validate:"max=40"`
+	Start    *Date   `json:"start,omitempty"`
+	End      *Date   `json:"end,omitempty" validate:"gtefield=Start"`
+	Tag      *string `json:"tag,omitempty" validate:"max=40"`
+}
+
+// IsEmpty reports whether the filter has no criteria and would match every expense.
+func (f ExpenseFilter) IsEmpty() bool {
+	return len(f.IDs) == 0 && f.Category == nil && f.Start == nil && f.End == nil && f.Tag == nil
+}
+
+// BulkUpdateInput recategorizes and/or retags every expense matching Filter.
+type BulkUpdateInput struct {
+	Filter      ExpenseFilter `json:"filter"`
+	SetCategory *string       `json:"set_category,omitempty" validate:"required,max=40"`
+	AddTags     []string      `json:"add_tags,omitempty" validate:"max=20,dive,required,max=40"`
+	RemoveTags  []string      `json:"remove_tags,omitempty" validate:"max=20,dive,required,max=40"`
+}
+
+// BulkUpdateResult reports how many expenses a bulk update changed.
+type BulkUpdateResult struct {
+	Updated int64 `json:"updated"`
+}
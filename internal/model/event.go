@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// EventType names the change reported by an ExpenseEvent.
+type EventType string
+
+const (
+	EventExpenseCreated EventType = "expense.created"
+	EventExpenseUpdated EventType = "expense.updated"
+	EventExpenseDeleted EventType = "expense.deleted"
+)
+
+// ExpenseEvent reports a committed change of an expense.
+//
+// ID increases with every event and is derived from the time of the
+// change, so it stays increasing across restarts. Expense is the
+// expense after the change and is omitted for deletions.
+type ExpenseEvent struct {
+	ID        int64     `json:"id"`
+	Type      EventType `json:"type"`
+	UserID    int       `json:"user_id"`
+	ExpenseID int       `json:"expense_id"`
+	Expense   *Expense  `json:"expense,omitempty"`
+	Time      time.Time `json:"time"`
+}
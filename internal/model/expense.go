@@ -9,20 +9,22 @@ import (
 
 // Expense represents a financial expense record in the system.
 type Expense struct {
-	ID          int     `json:"id,omitempty"`
-	UserID      int     `json:"user_id,omitempty"`
-	Amount      float64 `json:"amount"`
-	Category    string  `json:"category"`
-	Description string  `json:"description"`
-	Date        Date    `json:"date"`
+	ID          int      `json:"id,omitempty"`
+	UserID      int      `json:"user_id,omitempty"`
+	Amount      float64  `json:"amount" validate:"gt=0,lt=100000000"`
+	Category    string   `json:"category" validate:"required,max=40"`
+	Description string   `json:"description" validate:"max=1000"`
+	Date        Date     `json:"date" validate:"required,min=1900-01-01,max=2100-12-31"`
+	Tags        []string `json:"tags,omitempty" validate:"max=20,dive,required,max=40"`
 }
 
 // UpdateExpenseInput contains fields for updating an existing expense record. All fields are optional.
 type UpdateExpenseInput struct {
-	Amount      *float64 `json:"amount,omitempty"`
-	Category    *string  `json:"category,omitempty"`
-	Description *string  `json:"description,omitempty"`
-	Date        *Date    `json:"date,omitempty"`
+	Amount      *float64  `json:"amount,omitempty" validate:"gt=0,lt=100000000"`
+	Category    *string   `json:"category,omitempty" validate:"required,max=40"`
+	Description *string   `json:"description,omitempty" validate:"max=1000"`
+	Date        *Date     `json:"date,omitempty" validate:"required,min=1900-01-01,max=2100-12-31"`
+	Tags        *[]string `json:"tags,omitempty" validate:"max=20,dive,required,max=40"`
 }
 
 // Custom date type that extends time.Time with specific serialization behavior.
@@ -0,0 +1,16 @@
+package model
+
+// ExportOptions control how expenses are exported as a plain-text accounting journal.
+type ExportOptions struct {
+	// Format is the journal format.
+	Format string `json:"format" validate:"oneof=ledger beancount"`
+	// Currency is the ISO 4217 code of the commodity of the amounts.
+	Currency string `json:"currency" validate:"required,min=3,max=3"`
+	// FundingAccount is the account expenses are paid from.
+	FundingAccount string `json:"funding_account" validate:"required,max=200"`
+	// Accounts maps categories to the accounts their expenses are booked to.
+	// Other categories are booked to a subaccount of Expenses named after them.
+	Accounts map[string]string `json:"accounts,omitempty"`
+	// Filter selects the exported expenses.
+	Filter ExpenseFilter `json:"filter"`
+}
@@ -0,0 +1,32 @@
+package model
+
+// GraphQLRequest is the body of a POST /graphql request.
+type GraphQLRequest struct {
+	Query         string         `json:"query" validate:"required"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+	// Extensions are accepted for compatibility with clients and ignored.
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// GraphQLResponse is the result of a GraphQL request. Data is absent when the
+// request was rejected before execution.
+type GraphQLResponse struct {
+	Data   any            `json:"data,omitempty"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+// GraphQLError reports a failed query or field. Extensions carry the error
+// code of the problem responses and, for invalid input, the field errors.
+type GraphQLError struct {
+	Message    string            `json:"message"`
+	Locations  []GraphQLLocation `json:"locations,omitempty"`
+	Path       []any             `json:"path,omitempty"`
+	Extensions map[string]any    `json:"extensions,omitempty"`
+}
+
+// GraphQLLocation points to a position in the query document.
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
@@ -0,0 +1,71 @@
+package model
+
+// ImportOptions control how a bank statement is imported.
+type ImportOptions struct {
+	// Format is the statement file format.
+	Format string `json:"format" validate:"oneof=ofx camt053 mt940 ledger beancount"`
+	// Category is given to imported expenses whose transaction has none;
+	// only journals give categories.
+	Category string `json:"category" validate:"required,max=40"`
+	// Currency is the ISO 4217 code of the transactions to import; the
+	// others are skipped. It may be empty if all debits share a currency.
+	Currency string `json:"currency,omitempty" validate:"omitempty,min=3,max=3"`
+}
+
+// ImportNew is the preview status of a transaction that would be imported.
+const ImportNew = "new"
+
+// Reasons a transaction of a statement is not imported.
+const (
+	// SkipDuplicate means the transaction was imported before or appears twice in the file.
+	SkipDuplicate = "duplicate"
+	// SkipCredit means money was paid into the account, which is not an expense.
+	SkipCredit = "credit"
+	// SkipCurrency means the transaction is not in the currency being imported.
+	SkipCurrency = "currency"
+	// SkipInvalid means the transaction can't be stored as an expense, e.g. it has no ID.
+	SkipInvalid = "invalid"
+)
+
+// SkippedTransaction is a transaction of a statement that was not imported.
+type SkippedTransaction struct {
+	Account string `json:"account"`
+	ID      string `json:"id"`
+	Reason  string `json:"reason"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ImportResult reports how the transactions of a statement were imported.
+// Parsed is the sum of Imported and Skipped.
+type ImportResult struct {
+	Parsed   int                  `json:"parsed"`
+	Imported int                  `json:"imported"`
+	Skipped  int                  `json:"skipped"`
+	Expenses []Expense            `json:"expenses"`
+	Skips    []SkippedTransaction `json:"skips"`
+}
+
+// ImportPreview shows what importing a statement would do, without changing anything.
+// Parsed is the sum of New and Skipped.
+type ImportPreview struct {
+	Parsed       int                  `json:"parsed"`
+	New          int                  `json:"new"`
+	Skipped      int                  `json:"skipped"`
+	Transactions []PreviewTransaction `json:"transactions"`
+}
+
+// PreviewTransaction is a transaction of a statement as read from the file.
+// Amount is negative for debits. Status is ImportNew, with the expense an
+// import would create, or the reason the transaction would be skipped.
+type PreviewTransaction struct {
+	Account      string   `json:"account"`
+	ID           string   `json:"id"`
+	Date         Date     `json:"date"`
+	Amount       float64  `json:"amount"`
+	Currency     string   `json:"currency,omitempty"`
+	Counterparty string   `json:"counterparty,omitempty"`
+	Memo         string   `json:"memo,omitempty"`
+	Status       string   `json:"status"`
+	Error        string   `json:"error,omitempty"`
+	Expense      *Expense `json:"expense,omitempty"`
+}
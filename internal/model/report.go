@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// MonthlyReport summarizes the expenses of a month and compares them with
+// the month before.
+type MonthlyReport struct {
+	// Month is the first day of the month.
+	Month         time.Time `json:"month"`
+	Total         float64   `json:"total"`
+	Count         int       `json:"count"`
+	PreviousTotal float64   `json:"previous_total"`
+	PreviousCount int       `json:"previous_count"`
+	// Categories are the categories of both months, largest total first.
+	Categories []CategoryTotal `json:"categories"`
+	// Daily holds the total of every day of the month, starting with the first.
+	Daily []float64 `json:"daily"`
+	// Expenses are the expenses of the month ordered by date.
+	Expenses []Expense `json:"expenses"`
+	// GeneratedAt is when the report was made.
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// CategoryTotal is the spending in a category during a month and the month before.
+type CategoryTotal struct {
+	Category      string  `json:"category"`
+	Total         float64 `json:"total"`
+	Count         int     `json:"count"`
+	Share         float64 `json:"share"`
+	PreviousTotal float64 `json:"previous_total"`
+}
@@ -2,6 +2,6 @@ package model
 
 // LoginInput contains user credentials for authentication.
 type LoginInput struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username string `json:"username" validate:"required,max=40"`
+	Password string `json:"password" validate:"required,max=72"`
 }
@@ -0,0 +1,21 @@
+package model
+
+// SearchQuery describes a full-text search over a user's expenses.
+// Query supports bare words (prefix matched) and "quoted phrases".
+type SearchQuery struct {
+	Query    string
+	Category *string
+	Start    *Date
+	End      *Date
+	Limit    int
+	Offset   int
+}
+
+// SearchResult is an expense matched by a full-text search together with its
+// relevance rank and a description snippet with matches wrapped in <mark> tags.
+// The rest of the snippet is HTML-escaped.
+type SearchResult struct {
+	Expense
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
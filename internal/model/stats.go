@@ -0,0 +1,9 @@
+package model
+
+// Stats is a snapshot of application-wide business figures exposed as metrics.
+type Stats struct {
+	Users                int64
+	ActiveUsers          int64
+	ExpensesTotal        int64
+	ExpensesCreatedToday int64
+}
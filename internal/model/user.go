@@ -3,11 +3,11 @@ package model
 // User represents an application user account.
 type User struct {
 	ID       int    `json:"id,omitempty"`
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username string `json:"username" validate:"required,max=40"`
+	Password string `json:"password" validate:"required,max=72"`
 }
 
 // UpdateUsernameInput contains data for username update operation.
 type UpdateUsernameInput struct {
-	Username string `json:"username"`
+	Username string `json:"username" validate:"required,max=40"`
 }
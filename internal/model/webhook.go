@@ -0,0 +1,96 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DeliveryStatus is the state of a WebhookDelivery.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending deliveries wait for their next attempt.
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliverySucceeded deliveries were answered with a 2xx status.
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryFailed deliveries failed every attempt.
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// Webhook subscribes a URL to the expense events of a user. Events are
+// POSTed to it signed with Secret, which is only returned on creation.
+type Webhook struct {
+	ID         int         `json:"id"`
+	UserID     int         `json:"-"`
+	URL        string      `json:"url"`
+	Secret     string      `json:"secret,omitempty"`
+	EventTypes []EventType `json:"event_types"`
+	Active     bool        `json:"active"`
+	// ConsecutiveFailures counts the deliveries that failed since the last
+	// successful one; the webhook is disabled when it reaches the limit.
+	ConsecutiveFailures int `json:"consecutive_failures"`
+	// DisabledAt is set when the webhook was disabled because of failures.
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// WebhookInput contains the fields of a new webhook. Without a secret, a
+// random one is generated.
+type WebhookInput struct {
+	URL        string      `json:"url" validate:"required,max=2000,url"`
+	Secret     string      `json:"secret,omitempty" validate:"omitempty,min=16,max=200"`
+	EventTypes []EventType `json:"event_types" validate:"required,max=3,dive,oneof=expense.created expense.updated expense.deleted"`
+}
+
+// UpdateWebhookInput contains fields for updating a webhook. All fields are
+// optional; activating a webhook resets its failure count.
+type UpdateWebhookInput struct {
+	URL        *string      `json:"url,omitempty" validate:"required,max=2000,url"`
+	Secret     *string      `json:"secret,omitempty" validate:"min=16,max=200"`
+	EventTypes *[]EventType `json:"event_types,omitempty" validate:"required,max=3,dive,oneof=expense.created expense.updated expense.deleted"`
+	Active     *bool        `json:"active,omitempty"`
+}
+
+// WebhookDelivery is one event to deliver to a webhook, kept in the outbox
+// until it succeeds or runs out of attempts.
+type WebhookDelivery struct {
+	ID        int64           `json:"id"`
+	WebhookID int             `json:"webhook_id"`
+	EventType EventType       `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+	Status    DeliveryStatus  `json:"status"`
+	Attempts  int             `json:"attempts"`
+	// ResponseStatus is the HTTP status of the last attempt, 0 without a response.
+	ResponseStatus int        `json:"response_status,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+	// Log lists the attempts, oldest first. It is only filled for a single delivery.
+	Log []WebhookAttempt `json:"log,omitempty"`
+}
+
+// OutboxEvent is an encoded event to enqueue for delivery to the webhooks
+// subscribed to its type.
+type OutboxEvent struct {
+	Type    EventType
+	Payload json.RawMessage
+}
+
+// WebhookAttempt logs one attempt of a delivery.
+type WebhookAttempt struct {
+	DeliveryID     int64     `json:"-"`
+	AttemptedAt    time.Time `json:"attempted_at"`
+	ResponseStatus int       `json:"response_status,omitempty"`
+	// ResponseBody is the beginning of the response body.
+	ResponseBody string `json:"response_body,omitempty"`
+	Error        string `json:"error,omitempty"`
+	DurationMS   int64  `json:"duration_ms"`
+}
+
+// PendingDelivery is a delivery claimed for an attempt, with the target of
+// its webhook.
+type PendingDelivery struct {
+	WebhookDelivery
+	URL    string
+	Secret string
+}
@@ -0,0 +1,43 @@
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Patterns returns the ServeMux patterns of every documented operation, sorted.
+func (d *Document) Patterns() []string {
+	var patterns []string
+	for path, item := range d.Paths {
+		for method := range item {
+			patterns = append(patterns, strings.ToUpper(method)+" "+path)
+		}
+	}
+	slices.Sort(patterns)
+	return patterns
+}
+
+// CheckRoutes compares the ServeMux patterns registered by the server with the
+// documented operations and reports every route missing from either side.
+func (d *Document) CheckRoutes(registered []string) error {
+	documented := d.Patterns()
+
+	var errs []error
+	for _, pattern := range registered {
+		if !slices.Contains(documented, pattern) {
+			errs = append(errs, fmt.Errorf("route %q is not documented", pattern))
+		}
+	}
+	for _, pattern := range documented {
+		if !slices.Contains(registered, pattern) {
+			errs = append(errs, fmt.Errorf("documented operation %q is not registered", pattern))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("openapi: document and routes differ: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package openapi
+
+import (
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/validate"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	dateType = reflect.TypeOf(model.Date{})
+	timeType = reflect.TypeOf(time.Time{})
+)
+
+// schemas generates schemas from Go types and collects named struct types as
+// components, so each of them is described once and referenced elsewhere.
+type schemas struct {
+	components map[string]*Schema
+	// descriptions documents component schemas by type name.
+	descriptions map[string]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{
+		components:   map[string]*Schema{},
+		descriptions: map[string]string{},
+	}
+}
+
+// of returns the schema of the type of v.
+func (s *schemas) of(v any) *Schema {
+	return s.forType(reflect.TypeOf(v))
+}
+
+// arrayOf returns the schema of a JSON array of the type of v.
+func (s *schemas) arrayOf(v any) *Schema {
+	return &Schema{Type: "array", Items: s.of(v)}
+}
+
+func (s *schemas) forType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == dateType:
+		return &Schema{Type: "string", Format: "date", Example: "2024-03-15"}
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: s.forType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.forType(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		name := t.Name()
+		if _, ok := s.components[name]; !ok {
+			// Register a placeholder first so recursive types terminate.
+			s.components[name] = &Schema{}
+			schema := s.object(t)
+			schema.Description = s.descriptions[name]
+			s.components[name] = schema
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	default:
+		return &Schema{}
+	}
+}
+
+// object describes the JSON encoding of struct type t. Fields without
+// omitempty are always present in the output and are therefore required.
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	s.addFields(schema, t)
+	return schema
+}
+
+func (s *schemas) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		// Embedded structs without a JSON name are flattened by encoding/json.
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			s.addFields(schema, field.Type)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := s.forType(field.Type)
+		rules := validate.ParseTag(field.Tag.Get("validate"))
+		applyRules(prop, rules)
+		schema.Properties[name] = prop
+
+		// A required rule after dive applies to the elements, not to the field.
+		fieldRules := rules
+		if i := slices.Index(rules, validate.Rule{Name: "dive"}); i >= 0 {
+			fieldRules = rules[:i]
+		}
+		required := !strings.Contains(opts, "omitempty") ||
+			slices.Contains(fieldRules, validate.Rule{Name: "required"})
+		if required && field.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// applyRules translates the validate rules of a field into schema constraints.
+// Rules without a schema equivalent, such as date bounds and gtefield, are skipped.
+func applyRules(schema *Schema, rules []validate.Rule) {
+	for i, r := range rules {
+		n, _ := strconv.ParseFloat(r.Param, 64)
+		switch {
+		case r.Name == "dive" && schema.Items != nil:
+			applyRules(schema.Items, rules[i+1:])
+			return
+
+		case r.Name == "required" && schema.Type == "string" && schema.Format == "":
+			if schema.MinLength == nil {
+				schema.MinLength = ptr(1)
+			}
+
+		case r.Name == "url":
+			schema.Format = "uri"
+
+		case r.Name == "oneof":
+			for _, option := range strings.Fields(r.Param) {
+				schema.Enum = append(schema.Enum, option)
+			}
+
+		case schema.Format == "date":
+			// Date bounds are documented in descriptions where they matter.
+
+		case r.Name == "min" && schema.Type == "string":
+			schema.MinLength = ptr(int(n))
+		case r.Name == "max" && schema.Type == "string":
+			schema.MaxLength = ptr(int(n))
+		case r.Name == "min" && schema.Type == "array":
+			schema.MinItems = ptr(int(n))
+		case r.Name == "max" && schema.Type == "array":
+			schema.MaxItems = ptr(int(n))
+		case r.Name == "min" || r.Name == "gt":
+			schema.Minimum, schema.ExclusiveMinimum = ptr(n), r.Name == "gt"
+		case r.Name == "max" || r.Name == "lt":
+			schema.Maximum, schema.ExclusiveMaximum = ptr(n), r.Name == "lt"
+		}
+	}
+}
+
+// object returns an inline object schema with the given properties, all of them required.
+func object(properties map[string]*Schema) *Schema {
+	schema := &Schema{Type: "object", Properties: properties}
+	for name := range properties {
+		schema.Required = append(schema.Required, name)
+	}
+	slices.Sort(schema.Required)
+	return schema
+}
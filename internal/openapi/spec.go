@@ -0,0 +1,669 @@
+package openapi
+
+import (
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/service"
+	"expense_tracker/lib"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// securityScheme is the name of the bearer token scheme in the components.
+const securityScheme = "bearerAuth"
+
+// builder assembles a Document from operations keyed by ServeMux patterns.
+type builder struct {
+	doc     *Document
+	schemas *schemas
+}
+
+// add registers op under a ServeMux pattern such as "GET /expenses/{id}".
+// Operations with a request body also get the 413 response for oversized bodies.
+func (b *builder) add(pattern string, op *Operation) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		panic("openapi: pattern without method: " + pattern)
+	}
+	item, ok := b.doc.Paths[path]
+	if !ok {
+		item = PathItem{}
+		b.doc.Paths[path] = item
+	}
+	if op.RequestBody != nil {
+		op.Responses["413"] = problem(http.StatusRequestEntityTooLarge)
+	}
+	item[strings.ToLower(method)] = op
+}
+
+// New builds the OpenAPI document of every route registered by the server.
+// version is reported as the API version.
+func New(version string) *Document {
+	b := &builder{
+		doc: &Document{
+			OpenAPI: Version,
+			Info: Info{
+				Title: "Expense Tracker API",
+				Description: "Track personal expenses. Errors are returned as RFC 7807 problem details. " +
+					"Requests are rate limited per IP or per user and carry RateLimit-* headers.",
+				Version: version,
+			},
+			Tags: []Tag{
+				{Name: "auth", Description: "Registration and login"},
+				{Name: "user", Description: "The authenticated user's account"},
+				{Name: "expenses", Description: "Expenses of the authenticated user"},
+				{Name: "reports", Description: "Printable reports of the authenticated user's expenses"},
+				{Name: "graphql", Description: "GraphQL API over the expenses of the authenticated user"},
+				{Name: "events", Description: "Live changes of the authenticated user's expenses"},
+				{Name: "webhooks", Description: "Signed HTTP callbacks for the changes of the authenticated user's expenses"},
+				{Name: "operations", Description: "Health checks and metrics"},
+			},
+			Paths: map[string]PathItem{},
+		},
+		schemas: newSchemas(),
+	}
+	s := b.schemas
+	s.descriptions["Expense"] = "A single expense. Amounts are rounded to cents."
+	s.descriptions["Problem"] = "RFC 7807 problem details returned for every error."
+	s.descriptions["SearchResult"] = "An expense matched by a search with its relevance and a highlighted snippet."
+
+	expense := s.of(model.Expense{})
+	profile := object(map[string]*Schema{
+		"id":       {Type: "integer", Format: "int32"},
+		"username": {Type: "string"},
+	})
+
+	b.add("GET /healthz", &Operation{
+		OperationID: "liveness",
+		Summary:     "Liveness probe",
+		Description: "Reports that the process is able to serve HTTP.",
+		Tags:        []string{"operations"},
+		Responses: map[string]*Response{
+			"200": jsonResponse("The process is alive.", object(map[string]*Schema{"status": {Type: "string", Enum: []any{model.HealthOK}}})),
+		},
+	})
+	b.add("GET /readyz", &Operation{
+		OperationID: "readiness",
+		Summary:     "Readiness probe",
+		Description: "Checks the database, the schema version and whether the server is shutting down.",
+		Tags:        []string{"operations"},
+		Responses: map[string]*Response{
+			"200": jsonResponse("All checks passed.", s.of(model.HealthReport{})),
+			"503": jsonResponse("At least one check failed.", s.of(model.HealthReport{})),
+		},
+	})
+	b.add("GET /metrics", &Operation{
+		OperationID: "metrics",
+		Summary:     "Prometheus metrics",
+		Description: "Served on the API port only in development or when metrics_token is set, " +
+			"in which case scrapers send it as a bearer token. metrics_addr moves it to a separate listener.",
+		Tags: []string{"operations"},
+		Responses: map[string]*Response{
+			"200": {
+				Description: "Metrics in the Prometheus text exposition format.",
+				Content:     map[string]MediaType{"text/plain": {Schema: &Schema{Type: "string"}}},
+			},
+			"401": problem(http.StatusUnauthorized),
+			"404": {Description: "Metrics are not served on this port."},
+		},
+	})
+
+	b.add("POST /auth/register", &Operation{
+		OperationID: "register",
+		Summary:     "Register a new user",
+		Tags:        []string{"auth"},
+		RequestBody: jsonBody(s.of(model.LoginInput{})),
+		Responses: map[string]*Response{
+			"201": jsonResponse("User registered.", profile),
+			"400": problem(http.StatusBadRequest),
+			"409": problem(http.StatusConflict),
+			"429": problem(http.StatusTooManyRequests),
+			"500": problem(http.StatusInternalServerError),
+		},
+	})
+	b.add("POST /auth/login", &Operation{
+		OperationID: "login",
+		Summary:     "Log in and obtain an access token",
+		Description: "Repeated failed logins lock the account for a growing duration; " +
+			"locked accounts get 429 with a Retry-After header.",
+		Tags:        []string{"auth"},
+		RequestBody: jsonBody(s.of(model.LoginInput{})),
+		Responses: map[string]*Response{
+			"200": jsonResponse("Login successful.", object(map[string]*Schema{"token": {Type: "string", Description: "JWT to send as a bearer token."}})),
+			"400": problem(http.StatusBadRequest),
+			"401": problem(http.StatusUnauthorized),
+			"429": problem(http.StatusTooManyRequests),
+			"500": problem(http.StatusInternalServerError),
+		},
+	})
+
+	b.add("GET /user", authenticated(&Operation{
+		OperationID: "getProfile",
+		Summary:     "Get the authenticated user's profile",
+		Tags:        []string{"user"},
+		Responses: map[string]*Response{
+			"200": jsonResponse("The user's profile.", profile),
+			"404": problem(http.StatusNotFound),
+		},
+	}))
+	b.add("PUT /user/username", authenticated(&Operation{
+		OperationID: "updateUsername",
+		Summary:     "Change the username",
+		Tags:        []string{"user"},
+		RequestBody: jsonBody(s.of(model.UpdateUsernameInput{})),
+		Responses: map[string]*Response{
+			"200": jsonResponse("Username updated.", profile),
+			"400": problem(http.StatusBadRequest),
+			"404": problem(http.StatusNotFound),
+			"409": problem(http.StatusConflict),
+		},
+	}))
+	b.add("DELETE /user", authenticated(&Operation{
+		OperationID: "deleteUser",
+		Summary:     "Delete the user and all their expenses",
+		Tags:        []string{"user"},
+		Responses: map[string]*Response{
+			"204": {Description: "User deleted."},
+			"404": problem(http.StatusNotFound),
+		},
+	}))
+
+	b.add("POST /expenses", authenticated(&Operation{
+		OperationID: "createExpense",
+		Summary:     "Create an expense",
+		Tags:        []string{"expenses"},
+		RequestBody: jsonBody(expense),
+		Responses: map[string]*Response{
+			"201": jsonResponse("Expense created.", expense),
+			"400": problem(http.StatusBadRequest),
+		},
+	}))
+	b.add("GET /expenses", authenticated(&Operation{
+		OperationID: "listExpenses",
+		Summary:     "List all expenses ordered by ID",
+		Tags:        []string{"expenses"},
+		Responses: map[string]*Response{
+			"200": jsonResponse("The user's expenses.", s.arrayOf(model.Expense{})),
+		},
+	}))
+	b.add("GET /expenses/{id}", authenticated(&Operation{
+		OperationID: "getExpense",
+		Summary:     "Get an expense",
+		Tags:        []string{"expenses"},
+		Parameters:  []*Parameter{expenseID()},
+		Responses: map[string]*Response{
+			"200": jsonResponse("The expense.", expense),
+			"400": problem(http.StatusBadRequest),
+			"404": problem(http.StatusNotFound),
+		},
+	}))
+	b.add("PUT /expenses/{id}", authenticated(&Operation{
+		OperationID: "updateExpense",
+		Summary:     "Update the given fields of an expense",
+		Tags:        []string{"expenses"},
+		Parameters:  []*Parameter{expenseID()},
+		RequestBody: jsonBody(s.of(model.UpdateExpenseInput{})),
+		Responses: map[string]*Response{
+			"200": jsonResponse("The updated expense.", expense),
+			"400": problem(http.StatusBadRequest),
+			"404": problem(http.StatusNotFound),
+		},
+	}))
+	b.add("DELETE /expenses/{id}", authenticated(&Operation{
+		OperationID: "deleteExpense",
+		Summary:     "Delete an expense",
+		Tags:        []string{"expenses"},
+		Parameters:  []*Parameter{expenseID()},
+		Responses: map[string]*Response{
+			"204": {Description: "Expense deleted."},
+			"400": problem(http.StatusBadRequest),
+			"404": problem(http.StatusNotFound),
+		},
+	}))
+	b.add("GET /expenses/period", authenticated(&Operation{
+		OperationID: "listExpensesByPeriod",
+		Summary:     "List expenses within an inclusive date range ordered by date",
+		Tags:        []string{"expenses"},
+		Parameters: []*Parameter{
+			query("start", "First day of the range.", true, s.of(model.Date{})),
+			query("end", "Last day of the range, not before start.", true, s.of(model.Date{})),
+		},
+		Responses: map[string]*Response{
+			"200": jsonResponse("Matching expenses.", s.arrayOf(model.Expense{})),
+			"400": problem(http.StatusBadRequest),
+		},
+	}))
+	b.add("GET /expenses/category", authenticated(&Operation{
+		OperationID: "listExpensesByCategory",
+		Summary:     "List expenses of a category ordered by date",
+		Tags:        []string{"expenses"},
+		Parameters: []*Parameter{
+			query("category", "Category name, compared case-sensitively.", true, &Schema{Type: "string"}),
+		},
+		Responses: map[string]*Response{
+			"200": jsonResponse("Matching expenses.", s.arrayOf(model.Expense{})),
+			"400": problem(http.StatusBadRequest),
+		},
+	}))
+	b.add("GET /expenses/search", authenticated(&Operation{
+		OperationID: "searchExpenses",
+		Summary:     "Full-text search over descriptions and categories",
+		Description: `Bare words are prefix matched and "quoted phrases" match exactly; all terms must match. ` +
+			"Results are ordered by relevance and the snippet wraps matches in <mark> tags.",
+		Tags: []string{"expenses"},
+		Parameters: []*Parameter{
+			query("q", "Search query.", true, &Schema{Type: "string"}),
+			query("category", "Only search this category.", false, &Schema{Type: "string"}),
+			query("start", "Only expenses on or after this day.", false, s.of(model.Date{})),
+			query("end", "Only expenses on or before this day.", false, s.of(model.Date{})),
+			query("limit", fmt.Sprintf("Maximum number of results (default %d, at most %d).",
+				service.DefaultSearchLimit, service.MaxSearchLimit), false, &Schema{Type: "integer", Minimum: ptr(0.0)}),
+			query("offset", "Number of results to skip.", false, &Schema{Type: "integer", Minimum: ptr(0.0)}),
+		},
+		Responses: map[string]*Response{
+			"200": jsonResponse("Matching expenses.", s.arrayOf(model.SearchResult{})),
+			"400": problem(http.StatusBadRequest),
+		},
+	}))
+	b.add("POST /expenses/batch", authenticated(&Operation{
+		OperationID: "batchExpenses",
+		Summary:     "Create, update and delete expenses in one transaction",
+		Description: fmt.Sprintf("Accepts up to %d operations. In atomic mode any failure rolls back the whole batch; "+
+			"in best_effort mode successful operations are kept.", service.MaxBatchOperations),
+		Tags:        []string{"expenses"},
+		RequestBody: jsonBody(s.of(model.BatchRequest{})),
+		Responses: map[string]*Response{
+			"200": jsonResponse("All operations succeeded.", s.of(model.BatchResponse{})),
+			"207": jsonResponse("Best-effort batch where some operations failed.", s.of(model.BatchResponse{})),
+			"400": problem(http.StatusBadRequest),
+			"422": jsonResponse("Atomic batch rolled back because an operation failed.", s.of(model.BatchResponse{})),
+		},
+	}))
+	b.add("POST /expenses/bulk-update", authenticated(&Operation{
+		OperationID: "bulkUpdateExpenses",
+		Summary:     "Recategorize and/or retag every expense matching a filter",
+		Tags:        []string{"expenses"},
+		RequestBody: jsonBody(s.of(model.BulkUpdateInput{})),
+		Responses: map[string]*Response{
+			"200": jsonResponse("Number of updated expenses.", s.of(model.BulkUpdateResult{})),
+			"400": problem(http.StatusBadRequest),
+		},
+	}))
+	importParameters := []*Parameter{
+		query("format", "Statement format; ofx covers OFX 1.x, OFX 2.x and QFX, camt053 ISO 20022 camt.053 "+
+			"and mt940 SWIFT MT940. Journals of ledger, hledger and beancount are read as statements of their "+
+			"expense accounts, with the category and tags of every posting.", true,
+			&Schema{Type: "string", Enum: []any{"ofx", "camt053", "mt940", "ledger", "beancount"}}),
+		query("category", fmt.Sprintf("Category of the created expenses whose transaction has none (default %q).",
+			service.DefaultImportCategory),
+			false, &Schema{Type: "string", MaxLength: ptr(40)}),
+		query("currency", "ISO 4217 code of the transactions to import; debits in other currencies are skipped. "+
+			"Required if the debits of the statement are in more than one currency.", false,
+			&Schema{Type: "string", MinLength: ptr(3), MaxLength: ptr(3)}),
+	}
+	statementBody := &RequestBody{
+		Required: true,
+		Content: map[string]MediaType{
+			"application/octet-stream": {Schema: &Schema{Type: "string", Format: "binary"}},
+		},
+	}
+	b.add("POST /expenses/import", authenticated(&Operation{
+		OperationID: "importExpenses",
+		Summary:     "Import the debits of a bank statement file as expenses",
+		Description: fmt.Sprintf("The body is the statement file, of at most %d transactions. "+
+			"Every debit becomes an expense described by its counterparty and remittance information; "+
+			"credits and transactions imported before, recognized by the ID the bank gave them, are skipped.",
+			service.MaxImportTransactions),
+		Tags:        []string{"expenses"},
+		Parameters:  importParameters,
+		RequestBody: statementBody,
+		Responses: map[string]*Response{
+			"200": jsonResponse("Import summary with the created expenses and the skipped transactions.",
+				s.of(model.ImportResult{})),
+			"400": problem(http.StatusBadRequest),
+		},
+	}))
+	b.add("POST /expenses/import/preview", authenticated(&Operation{
+		OperationID: "previewImport",
+		Summary:     "Preview the import of a bank statement file",
+		Description: "Takes the same parameters and file as importExpenses and lists every transaction of the file " +
+			"with the expense it would become or the reason it would be skipped. Nothing is stored.",
+		Tags:        []string{"expenses"},
+		Parameters:  importParameters,
+		RequestBody: statementBody,
+		Responses: map[string]*Response{
+			"200": jsonResponse("The transactions of the statement.", s.of(model.ImportPreview{})),
+			"400": problem(http.StatusBadRequest),
+		},
+	}))
+
+	b.add("GET /expenses/export", authenticated(&Operation{
+		OperationID: "exportExpenses",
+		Summary:     "Export expenses as a plain-text accounting journal",
+		Description: "Every expense is a transaction moving its amount from the funding account to the account of " +
+			"its category, with its ID, category and tags as metadata. Categories without an account parameter are " +
+			"booked to a subaccount of Expenses named after them. Importing the journal restores categories and tags.",
+		Tags: []string{"expenses"},
+		Parameters: []*Parameter{
+			query("format", "Journal format; hledger reads ledger journals.", true,
+				&Schema{Type: "string", Enum: []any{"ledger", "beancount"}}),
+			query("currency", fmt.Sprintf("ISO 4217 code of the commodity of the amounts (default %q).",
+				service.DefaultExportCurrency), false, &Schema{Type: "string", MinLength: ptr(3), MaxLength: ptr(3)}),
+			query("funding_account", fmt.Sprintf("Account the expenses are paid from (default %q).",
+				service.DefaultFundingAccount), false, &Schema{Type: "string", MaxLength: ptr(200)}),
+			query("account", "Account of the expenses of a category, as category=Account; may be repeated.", false,
+				&Schema{Type: "array", Items: &Schema{Type: "string", Example: "food=Expenses:Food:Groceries"}}),
+			query("start", "Only expenses on or after this day.", false, s.of(model.Date{})),
+			query("end", "Only expenses on or before this day.", false, s.of(model.Date{})),
+			query("category", "Only expenses in this category.", false, &Schema{Type: "string", MaxLength: ptr(40)}),
+			query("tag", "Only expenses with this tag.", false, &Schema{Type: "string", MaxLength: ptr(40)}),
+		},
+		Responses: map[string]*Response{
+			"200": {
+				Description: "The journal, as an attachment.",
+				Content:     map[string]MediaType{"text/plain": {Schema: &Schema{Type: "string"}}},
+			},
+			"400": problem(http.StatusBadRequest),
+		},
+	}))
+
+	b.add("GET /reports/monthly.pdf", authenticated(&Operation{
+		OperationID: "monthlyReport",
+		Summary:     "Render the monthly statement as a PDF document",
+		Description: "The statement totals the expenses of the month by category, charts the daily spending, " +
+			"lists every expense and compares the totals with the month before.",
+		Tags: []string{"reports"},
+		Parameters: []*Parameter{
+			query("month", "The month, as YYYY-MM.", true,
+				&Schema{Type: "string", Example: "2024-03"}),
+		},
+		Responses: map[string]*Response{
+			"200": {
+				Description: "The statement.",
+				Content:     map[string]MediaType{"application/pdf": {Schema: &Schema{Type: "string", Format: "binary"}}},
+			},
+			"400": problem(http.StatusBadRequest),
+		},
+	}))
+
+	graphqlResponse := s.of(model.GraphQLResponse{})
+	b.add("POST /graphql", authenticated(&Operation{
+		OperationID: "graphql",
+		Summary:     "Run a GraphQL query",
+		Description: "Queries expenses, categories and aggregates in a single request. " +
+			"The schema is available through introspection, and GraphiQL is served on GET /graphql in development. " +
+			"Queries whose estimated cost or depth exceeds the configured limits are rejected before they run. " +
+			"Errors carry the code of the problem responses in their extensions.",
+		Tags:        []string{"graphql"},
+		RequestBody: jsonBody(s.of(model.GraphQLRequest{})),
+		Responses: map[string]*Response{
+			"200": jsonResponse("The query ran; fields that failed are null and listed in errors.", graphqlResponse),
+			"400": {
+				Description: "The request body is invalid, or the query can't be parsed, is invalid or is too complex.",
+				Content: map[string]MediaType{
+					"application/json":         {Schema: graphqlResponse},
+					"application/problem+json": {Schema: &Schema{Ref: "#/components/schemas/Problem"}},
+				},
+			},
+		},
+	}))
+
+	s.descriptions["ExpenseEvent"] = "A committed change of an expense, sent as the data of a server-sent event."
+	s.of(model.ExpenseEvent{})
+	b.add("GET /events", authenticated(&Operation{
+		OperationID: "streamEvents",
+		Summary:     "Stream changes of expenses as server-sent events",
+		Description: "Every event has its ID, the type expense.created, expense.updated or expense.deleted " +
+			"and an ExpenseEvent as JSON data. Clients reconnecting with the Last-Event-ID header first receive " +
+			"the events they missed; if those are no longer known, a reset event asks them to reload their expenses. " +
+			"Browsers' EventSource can't set headers, so the token may be passed in the access_token parameter.",
+		Tags: []string{"events"},
+		Parameters: []*Parameter{
+			{Name: "Last-Event-ID", In: "header", Description: "ID of the last event received.",
+				Schema: &Schema{Type: "integer", Format: "int64"}},
+			query("last_event_id", "Same as the Last-Event-ID header, which takes precedence.", false,
+				&Schema{Type: "integer", Format: "int64"}),
+			query("access_token", "Bearer token, for clients that can't set the Authorization header.", false,
+				&Schema{Type: "string"}),
+		},
+		Responses: map[string]*Response{
+			"200": {
+				Description: "The stream of events, until the client disconnects.",
+				Content: map[string]MediaType{
+					"text/event-stream": {Schema: &Schema{Type: "string"}},
+				},
+			},
+			"400": problem(http.StatusBadRequest),
+		},
+	}))
+
+	s.descriptions["Webhook"] = "A URL receiving the expense events of the user. " +
+		"The secret is only returned when the webhook is created."
+	s.descriptions["WebhookDelivery"] = "An event sent, or to be sent, to a webhook. " +
+		"The log of attempts is only included for a single delivery."
+	webhook := s.of(model.Webhook{})
+	delivery := s.of(model.WebhookDelivery{})
+	b.add("POST /webhooks", authenticated(&Operation{
+		OperationID: "createWebhook",
+		Summary:     "Create a webhook",
+		Description: "Events are POSTed to the URL as JSON with the X-Expense-Event, X-Expense-Delivery, " +
+			"X-Expense-Timestamp and X-Expense-Signature headers. The signature is sha256= followed by the " +
+			"hex-encoded HMAC-SHA256 of the timestamp, a dot and the body, keyed with the secret. " +
+			"Responses other than 2xx are retried with exponential backoff, and the webhook is disabled " +
+			"after too many failed deliveries in a row. Without a secret, a random one is generated.",
+		Tags:        []string{"webhooks"},
+		RequestBody: jsonBody(s.of(model.WebhookInput{})),
+		Responses: map[string]*Response{
+			"201": jsonResponse("Webhook created, including its secret.", webhook),
+			"400": problem(http.StatusBadRequest),
+		},
+	}))
+	b.add("GET /webhooks", authenticated(&Operation{
+		OperationID: "listWebhooks",
+		Summary:     "List all webhooks ordered by ID",
+		Tags:        []string{"webhooks"},
+		Responses: map[string]*Response{
+			"200": jsonResponse("The user's webhooks.", s.arrayOf(model.Webhook{})),
+		},
+	}))
+	b.add("GET /webhooks/{id}", authenticated(&Operation{
+		OperationID: "getWebhook",
+		Summary:     "Get a webhook",
+		Tags:        []string{"webhooks"},
+		Parameters:  []*Parameter{webhookID()},
+		Responses: map[string]*Response{
+			"200": jsonResponse("The webhook.", webhook),
+			"400": problem(http.StatusBadRequest),
+			"404": problem(http.StatusNotFound),
+		},
+	}))
+	b.add("PUT /webhooks/{id}", authenticated(&Operation{
+		OperationID: "updateWebhook",
+		Summary:     "Update the given fields of a webhook",
+		Description: "Setting active to true re-enables a webhook disabled after failed deliveries.",
+		Tags:        []string{"webhooks"},
+		Parameters:  []*Parameter{webhookID()},
+		RequestBody: jsonBody(s.of(model.UpdateWebhookInput{})),
+		Responses: map[string]*Response{
+			"200": jsonResponse("The updated webhook.", webhook),
+			"400": problem(http.StatusBadRequest),
+			"404": problem(http.StatusNotFound),
+		},
+	}))
+	b.add("DELETE /webhooks/{id}", authenticated(&Operation{
+		OperationID: "deleteWebhook",
+		Summary:     "Delete a webhook and its deliveries",
+		Tags:        []string{"webhooks"},
+		Parameters:  []*Parameter{webhookID()},
+		Responses: map[string]*Response{
+			"204": {Description: "Webhook deleted."},
+			"400": problem(http.StatusBadRequest),
+			"404": problem(http.StatusNotFound),
+		},
+	}))
+	b.add("GET /webhooks/{id}/deliveries", authenticated(&Operation{
+		OperationID: "listWebhookDeliveries",
+		Summary:     "List the latest deliveries of a webhook, newest first",
+		Tags:        []string{"webhooks"},
+		Parameters: []*Parameter{
+			webhookID(),
+			query("limit", fmt.Sprintf("Maximum number of deliveries (default %d, at most %d).",
+				service.DefaultDeliveryLimit, service.MaxDeliveryLimit), false, &Schema{Type: "integer", Minimum: ptr(0.0)}),
+		},
+		Responses: map[string]*Response{
+			"200": jsonResponse("The deliveries.", s.arrayOf(model.WebhookDelivery{})),
+			"400": problem(http.StatusBadRequest),
+			"404": problem(http.StatusNotFound),
+		},
+	}))
+	b.add("GET /webhooks/{id}/deliveries/{deliveryID}", authenticated(&Operation{
+		OperationID: "getWebhookDelivery",
+		Summary:     "Get a delivery with the log of its attempts",
+		Tags:        []string{"webhooks"},
+		Parameters:  []*Parameter{webhookID(), deliveryID()},
+		Responses: map[string]*Response{
+			"200": jsonResponse("The delivery.", delivery),
+			"400": problem(http.StatusBadRequest),
+			"404": problem(http.StatusNotFound),
+		},
+	}))
+	b.add("POST /webhooks/{id}/deliveries/{deliveryID}/redeliver", authenticated(&Operation{
+		OperationID: "redeliverWebhookDelivery",
+		Summary:     "Send the event of a delivery again",
+		Description: "The redelivery is a new delivery with its own ID, sent right away.",
+		Tags:        []string{"webhooks"},
+		Parameters:  []*Parameter{webhookID(), deliveryID()},
+		Responses: map[string]*Response{
+			"202": jsonResponse("Redelivery queued.", delivery),
+			"400": problem(http.StatusBadRequest),
+			"404": problem(http.StatusNotFound),
+			"409": problem(http.StatusConflict),
+		},
+	}))
+
+	b.finish()
+	return b.doc
+}
+
+// finish adds the shared components and the enum values of response types,
+// which have no validate tags for the reflection to read.
+func (b *builder) finish() {
+	s := b.schemas
+	s.of(lib.Problem{})
+
+	if result, ok := s.components["BatchResult"]; ok {
+		result.Properties["op"] = &Schema{Type: "string", Enum: []any{model.BatchCreate, model.BatchUpdate, model.BatchDelete}}
+		result.Properties["status"] = &Schema{Type: "string",
+			Enum: []any{model.BatchStatusOK, model.BatchStatusFailed, model.BatchStatusRolledBack}}
+	}
+	if skipped, ok := s.components["SkippedTransaction"]; ok {
+		skipped.Properties["reason"] = &Schema{Type: "string",
+			Enum: []any{model.SkipDuplicate, model.SkipCredit, model.SkipCurrency, model.SkipInvalid}}
+	}
+	if preview, ok := s.components["PreviewTransaction"]; ok {
+		preview.Properties["status"] = &Schema{Type: "string",
+			Enum: []any{model.ImportNew, model.SkipDuplicate, model.SkipCredit, model.SkipCurrency, model.SkipInvalid}}
+	}
+	eventTypes := []any{model.EventExpenseCreated, model.EventExpenseUpdated, model.EventExpenseDeleted}
+	if event, ok := s.components["ExpenseEvent"]; ok {
+		event.Properties["type"] = &Schema{Type: "string", Enum: eventTypes}
+	}
+	if webhook, ok := s.components["Webhook"]; ok {
+		webhook.Properties["event_types"] = &Schema{Type: "array", Items: &Schema{Type: "string", Enum: eventTypes}}
+	}
+	if delivery, ok := s.components["WebhookDelivery"]; ok {
+		delivery.Properties["event_type"] = &Schema{Type: "string", Enum: eventTypes}
+		delivery.Properties["status"] = &Schema{Type: "string",
+			Enum: []any{model.DeliveryPending, model.DeliverySucceeded, model.DeliveryFailed}}
+		// json.RawMessage would be described as an array of bytes.
+		delivery.Properties["payload"] = &Schema{Ref: "#/components/schemas/ExpenseEvent"}
+	}
+
+	responses := map[string]*Response{}
+	for _, status := range []int{
+		http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound,
+		http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+	} {
+		responses[responseName(status)] = &Response{
+			Description: http.StatusText(status),
+			Content: map[string]MediaType{
+				"application/problem+json": {Schema: &Schema{Ref: "#/components/schemas/Problem"}},
+			},
+		}
+	}
+
+	b.doc.Components = Components{
+		Schemas:   s.components,
+		Responses: responses,
+		SecuritySchemes: map[string]*SecurityScheme{
+			securityScheme: {
+				Type:         "http",
+				Scheme:       "bearer",
+				BearerFormat: "JWT",
+				Description:  "Token returned by POST /auth/login.",
+			},
+		},
+	}
+}
+
+// authenticated marks op as requiring a bearer token, which adds the 401, 429
+// (per-user rate limit) and 500 responses.
+func authenticated(op *Operation) *Operation {
+	op.Security = []SecurityRequirement{{securityScheme: {}}}
+	op.Responses["401"] = problem(http.StatusUnauthorized)
+	op.Responses["429"] = problem(http.StatusTooManyRequests)
+	if _, ok := op.Responses["500"]; !ok {
+		op.Responses["500"] = problem(http.StatusInternalServerError)
+	}
+	return op
+}
+
+// problem references the shared problem response for status.
+func problem(status int) *Response {
+	return &Response{Ref: "#/components/responses/" + responseName(status)}
+}
+
+// responseName is the component name of the problem response for status, e.g. "NotFound".
+func responseName(status int) string {
+	name := strings.ReplaceAll(http.StatusText(status), " ", "")
+	if name == "" {
+		return "Status" + strconv.Itoa(status)
+	}
+	return name
+}
+
+func jsonBody(schema *Schema) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]MediaType{"application/json": {Schema: schema}},
+	}
+}
+
+func jsonResponse(description string, schema *Schema) *Response {
+	return &Response{
+		Description: description,
+		Content:     map[string]MediaType{"application/json": {Schema: schema}},
+	}
+}
+
+func query(name, description string, required bool, schema *Schema) *Parameter {
+	return &Parameter{Name: name, In: "query", Description: description, Required: required, Schema: schema}
+}
+
+func expenseID() *Parameter {
+	return &Parameter{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "integer", Format: "int32"}}
+}
+
+func webhookID() *Parameter {
+	return &Parameter{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "integer", Format: "int32"}}
+}
+
+func deliveryID() *Parameter {
+	return &Parameter{Name: "deliveryID", In: "path", Required: true, Schema: &Schema{Type: "integer", Format: "int64"}}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
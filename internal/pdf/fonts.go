@@ -0,0 +1,64 @@
+package pdf
+
+import (
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Advance widths of the printable ASCII characters, from the space to the
+// tilde, in thousandths of the font size, from the Adobe font metrics.
+var (
+	helveticaASCII = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBoldASCII = [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+// helveticaWidths and helveticaBoldWidths are the widths of the Windows-1252
+// characters. Characters outside ASCII, which the tables above don't cover,
+// are measured as the letter they are based on, such as "e" for "é", or as
+// digits otherwise.
+var helveticaWidths, helveticaBoldWidths = winAnsiWidths(&helveticaASCII), winAnsiWidths(&helveticaBoldASCII)
+
+func winAnsiWidths(ascii *[95]int) [256]int {
+	var widths [256]int
+	for b := range widths {
+		switch r := charmap.Windows1252.DecodeByte(byte(b)); {
+		case b >= ' ' && b <= '~':
+			widths[b] = ascii[b-' ']
+		case b > '~':
+			widths[b] = ascii['0'-' ']
+			if base, _ := utf8.DecodeRuneInString(norm.NFD.String(string(r))); base >= ' ' && base <= '~' {
+				widths[b] = ascii[base-' ']
+			}
+		}
+	}
+	return widths
+}
+
+// encode converts s to Windows-1252, the encoding of the fonts.
+func encode(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		c, ok := charmap.Windows1252.EncodeRune(r)
+		if !ok || c < ' ' {
+			c = '?'
+		}
+		b = append(b, c)
+	}
+	return b
+}
@@ -0,0 +1,200 @@
+// Package pdf writes simple PDF documents: pages of text in the standard
+// Helvetica fonts, lines and filled rectangles. The standard fonts need not
+// be embedded, which keeps documents small, but they only cover the
+// Windows-1252 character set; other characters are written as "?".
+//
+// Coordinates are in points (1/72 inch) from the top left corner of the page.
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Size of A4 pages in points.
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Font is one of the standard fonts.
+type Font int
+
+// Supported fonts.
+const (
+	Helvetica Font = iota
+	HelveticaBold
+)
+
+func (f Font) name() string {
+	if f == HelveticaBold {
+		return "Helvetica-Bold"
+	}
+	return "Helvetica"
+}
+
+// Color is an RGB color with components from 0 to 1.
+type Color struct {
+	R, G, B float64
+}
+
+// Black is the default color of text.
+var Black = Color{}
+
+// Gray returns the gray of the given lightness, from 0 (black) to 1 (white).
+func Gray(lightness float64) Color {
+	return Color{lightness, lightness, lightness}
+}
+
+// Document is a PDF document made of pages.
+type Document struct {
+	// Title is shown by viewers instead of the file name.
+	Title string
+	pages []*Page
+}
+
+// AddPage adds an A4 page to the document.
+func (d *Document) AddPage() *Page {
+	p := &Page{}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Pages returns the pages of the document.
+func (d *Document) Pages() []*Page {
+	return d.pages
+}
+
+// Page is a page of a document; its content is drawn in the order of the calls.
+type Page struct {
+	content bytes.Buffer
+}
+
+// Text draws s with the left end of its baseline at x, y.
+func (p *Page) Text(x, y float64, font Font, size float64, color Color, s string) {
+	fmt.Fprintf(&p.content, "BT /F%d %s Tf %s rg %s %s Td (%s) Tj ET\n",
+		font+1, num(size), color.operands(), num(x), num(A4Height-y), escape(encode(s)))
+}
+
+// Rect fills the rectangle whose top left corner is at x, y.
+func (p *Page) Rect(x, y, width, height float64, fill Color) {
+	fmt.Fprintf(&p.content, "%s rg %s %s %s %s re f\n",
+		fill.operands(), num(x), num(A4Height-y-height), num(width), num(height))
+}
+
+// Line draws a line from x1, y1 to x2, y2.
+func (p *Page) Line(x1, y1, x2, y2, width float64, stroke Color) {
+	fmt.Fprintf(&p.content, "%s w %s RG %s %s m %s %s l S\n",
+		num(width), stroke.operands(), num(x1), num(A4Height-y1), num(x2), num(A4Height-y2))
+}
+
+func (c Color) operands() string {
+	return num(c.R) + " " + num(c.G) + " " + num(c.B)
+}
+
+// TextWidth returns the width of s in points.
+func TextWidth(font Font, size float64, s string) float64 {
+	widths := &helveticaWidths
+	if font == HelveticaBold {
+		widths = &helveticaBoldWidths
+	}
+	var units int
+	for _, b := range encode(s) {
+		units += widths[b]
+	}
+	return float64(units) * size / 1000
+}
+
+// WriteTo writes the document in the PDF format.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	pw := &writer{w: bufio.NewWriter(w)}
+	pw.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 to 5 are the catalog, the page tree, the fonts and the
+	// document information, followed by every page and its content.
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+	pw.object("<< /Type /Catalog /Pages 2 0 R >>")
+	pw.object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	for _, font := range []Font{Helvetica, HelveticaBold} {
+		pw.object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font.name()))
+	}
+	pw.object(fmt.Sprintf("<< /Title (%s) /Producer (expense_tracker) >>", escape(encode(d.Title))))
+	for i, p := range d.pages {
+		pw.object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			num(A4Width), num(A4Height), 7+2*i))
+		pw.stream(p.content.Bytes())
+	}
+
+	xref := pw.n
+	pw.printf("xref\n0 %d\n0000000000 65535 f \n", len(pw.offsets)+1)
+	for _, offset := range pw.offsets {
+		pw.printf("%010d 00000 n \n", offset)
+	}
+	pw.printf("trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(pw.offsets)+1, xref)
+
+	if pw.err == nil {
+		pw.err = pw.w.Flush()
+	}
+	if pw.err != nil {
+		return pw.n, fmt.Errorf("pdf: can't write document: %w", pw.err)
+	}
+	return pw.n, nil
+}
+
+// writer writes numbered objects, recording their offsets for the
+// cross-reference table. It keeps the first error and ignores later writes.
+type writer struct {
+	w       *bufio.Writer
+	n       int64
+	offsets []int64
+	err     error
+}
+
+func (w *writer) printf(format string, args ...any) {
+	if w.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(w.w, format, args...)
+	w.n += int64(n)
+	w.err = err
+}
+
+func (w *writer) object(dict string) {
+	w.offsets = append(w.offsets, w.n)
+	w.printf("%d 0 obj\n%s\nendobj\n", len(w.offsets), dict)
+}
+
+// stream writes data as a compressed stream object.
+func (w *writer) stream(data []byte) {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(data)
+	zw.Close()
+
+	w.offsets = append(w.offsets, w.n)
+	w.printf("%d 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream\nendobj\n",
+		len(w.offsets), compressed.Len(), compressed.Bytes())
+}
+
+// num formats a number with at most two decimals.
+func num(f float64) string {
+	s := strings.TrimRight(strings.TrimRight(strconv.FormatFloat(f, 'f', 2, 64), "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// escape escapes the delimiters of a literal string.
+func escape(b []byte) string {
+	return strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`, "\r", `\r`).Replace(string(b))
+}
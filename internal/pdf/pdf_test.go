@@ -0,0 +1,170 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var (
+	startXref = regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`)
+	xrefEntry = regexp.MustCompile(`(\d{10}) 00000 n \n`)
+	streams   = regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`)
+)
+
+// checkDocument checks the structure of a written document and returns the
+// decompressed content of its pages.
+func checkDocument(t *testing.T, data []byte, pages int) []string {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) {
+		t.Fatalf("document starts with %q", data[:min(len(data), 16)])
+	}
+	m := startXref.FindSubmatch(data)
+	if m == nil {
+		t.Fatal("no startxref at the end")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d points to %q", xref, data[xref:min(len(data), xref+16)])
+	}
+	// Objects are numbered from 1 in the order of the table.
+	entries := xrefEntry.FindAllSubmatch(data[xref:], -1)
+	if want := 5 + 2*pages; len(entries) != want {
+		t.Fatalf("%d objects, want %d", len(entries), want)
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if obj := strconv.Itoa(i+1) + " 0 obj\n"; !bytes.HasPrefix(data[offset:], []byte(obj)) {
+			t.Errorf("object %d at %d is %q", i+1, offset, data[offset:min(len(data), offset+16)])
+		}
+	}
+	if count := "/Count " + strconv.Itoa(pages) + " >>"; !bytes.Contains(data, []byte(count)) {
+		t.Errorf("page tree doesn't hold %d pages", pages)
+	}
+	if n := bytes.Count(data, []byte("/Type /Page /Parent")); n != pages {
+		t.Errorf("%d page objects, want %d", n, pages)
+	}
+
+	var contents []string
+	for _, stream := range streams.FindAllSubmatch(data, -1) {
+		zr, err := zlib.NewReader(bytes.NewReader(stream[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents = append(contents, string(content))
+	}
+	if len(contents) != pages {
+		t.Fatalf("%d content streams, want %d", len(contents), pages)
+	}
+	return contents
+}
+
+func TestWriteTo(t *testing.T) {
+	doc := &Document{Title: "Report (March) \\ 日本"}
+	first := doc.AddPage()
+	first.Text(50, 50, HelveticaBold, 12, Black, "Café (€5)")
+	first.Text(50, 70, Helvetica, 10, Gray(0.5), "Привет 世界\r")
+	first.Rect(50, 100, 100, 20, Color{R: 1})
+	doc.AddPage()
+	doc.AddPage().Line(0, 0, A4Width, A4Height, 0.5, Black)
+
+	var buf bytes.Buffer
+	n, err := doc.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo reported %d bytes, wrote %d", n, buf.Len())
+	}
+	contents := checkDocument(t, buf.Bytes(), 3)
+
+	if !bytes.Contains(buf.Bytes(), []byte(`/Title (Report \(March\) \\ ??)`)) {
+		t.Error("title not escaped")
+	}
+	want := []string{
+		"BT /F2 12 Tf 0 0 0 rg 50 791.89 Td (Caf\xe9 \\(\x805\\)) Tj ET\n",
+		"BT /F1 10 Tf 0.5 0.5 0.5 rg 50 771.89 Td (?????? ???) Tj ET\n",
+		"1 0 0 rg 50 721.89 100 20 re f\n",
+	}
+	if got := contents[0]; got != strings.Join(want, "") {
+		t.Errorf("first page:\n%q\nwant\n%q", got, strings.Join(want, ""))
+	}
+	if contents[1] != "" {
+		t.Errorf("empty page: %q", contents[1])
+	}
+	if want := "0.5 w 0 0 0 RG 0 841.89 m 595.28 0 l S\n"; contents[2] != want {
+		t.Errorf("last page: %q, want %q", contents[2], want)
+	}
+}
+
+func TestWriteToEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := (&Document{}).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	checkDocument(t, buf.Bytes(), 0)
+}
+
+// failingWriter accepts n bytes and fails afterwards.
+type failingWriter struct {
+	n int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		written := w.n
+		w.n = 0
+		return written, errors.New("disk full")
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestWriteToError(t *testing.T) {
+	doc := &Document{}
+	for range 20 {
+		doc.AddPage().Text(50, 50, Helvetica, 10, Black, strings.Repeat("text ", 1000))
+	}
+	_, err := doc.WriteTo(&failingWriter{n: 1000})
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("got %v, want the write error", err)
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	tests := []struct {
+		font Font
+		s    string
+		want float64
+	}{
+		{Helvetica, "", 0},
+		{Helvetica, "Hi", (722 + 222) * 0.01},
+		{HelveticaBold, "Hi", (722 + 278) * 0.01},
+		// Accented letters are as wide as their base letter.
+		{Helvetica, "é", 556 * 0.01},
+		// Characters written as "?" are as wide as it.
+		{Helvetica, "日", 556 * 0.01},
+	}
+	for _, tt := range tests {
+		if got := TextWidth(tt.font, 10, tt.s); got-tt.want > 1e-9 || tt.want-got > 1e-9 {
+			t.Errorf("TextWidth(%v, %q) = %v, want %v", tt.font, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestNum(t *testing.T) {
+	for f, want := range map[float64]string{0: "0", 1: "1", 1.5: "1.5", 1.234: "1.23", -0.001: "0", 841.89: "841.89", -2.5: "-2.5"} {
+		if got := num(f); got != want {
+			t.Errorf("num(%v) = %q, want %q", f, got, want)
+		}
+	}
+}
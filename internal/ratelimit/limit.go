@@ -0,0 +1,97 @@
+// Package ratelimit implements token-bucket rate limits and login lockouts on
+// top of a Store, which keeps the state in memory for a single instance or in a
+// Redis-compatible server shared by every instance.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Rate requests per Period on average, with bursts of up to Burst
+// requests. A bucket holds Burst tokens and is refilled with one token every
+// Period/Rate; each request takes one token.
+type Limit struct {
+	Rate   int
+	Period time.Duration
+	Burst  int
+}
+
+// ParseLimit parses a limit written as "RATE/PERIOD" or "RATE/PERIOD:BURST",
+// e.g. "120/m", "10/30s" or "60/1m:20". The unit-only periods s, m and h mean
+// one second, minute and hour. The burst defaults to the rate.
+func ParseLimit(s string) (Limit, error) {
+	spec, burst, hasBurst := strings.Cut(strings.TrimSpace(s), ":")
+	rate, period, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("ratelimit: invalid limit %q, want RATE/PERIOD[:BURST]", s)
+	}
+
+	var l Limit
+	var err error
+	if l.Rate, err = strconv.Atoi(rate); err != nil || l.Rate <= 0 {
+		return Limit{}, fmt.Errorf("ratelimit: invalid rate in limit %q", s)
+	}
+
+	switch period {
+	case "s", "m", "h":
+		period = "1" + period
+	}
+	if l.Period, err = time.ParseDuration(period); err != nil || l.Period <= 0 {
+		return Limit{}, fmt.Errorf("ratelimit: invalid period in limit %q", s)
+	}
+
+	l.Burst = l.Rate
+	if hasBurst {
+		if l.Burst, err = strconv.Atoi(burst); err != nil || l.Burst <= 0 {
+			return Limit{}, fmt.Errorf("ratelimit: invalid burst in limit %q", s)
+		}
+	}
+	return l, nil
+}
+
+// String formats l in the syntax accepted by ParseLimit.
+func (l Limit) String() string {
+	s := strconv.Itoa(l.Rate) + "/" + l.Period.String()
+	if l.Burst != l.Rate {
+		s += ":" + strconv.Itoa(l.Burst)
+	}
+	return s
+}
+
+// interval is the time it takes to refill one token.
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Rate)
+}
+
+// Result is the state of a bucket after a request tried to take a token.
+type Result struct {
+	// Allowed reports whether the request got a token.
+	Allowed bool
+	// Limit is the size of the bucket.
+	Limit int
+	// Remaining is the number of whole tokens left.
+	Remaining int
+	// RetryAfter is how long to wait for the next token; zero if Allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// newResult derives a Result from the tokens left in a bucket of limit l.
+func newResult(l Limit, tokens float64, allowed bool) Result {
+	interval := float64(l.interval())
+	res := Result{
+		Allowed:   allowed,
+		Limit:     l.Burst,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(l.Burst) - tokens) * interval),
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1 - tokens) * interval)
+	}
+	return res
+}
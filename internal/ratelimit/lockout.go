@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Lockout locks an account after repeated failures. After MaxFailures failed
+// attempts the account is locked for Duration; every further failure doubles
+// the lock, up to MaxDuration. Failures are counted for MaxDuration from the
+// first one and forgotten on the first success.
+type Lockout struct {
+	store       Store
+	maxFailures int
+	duration    time.Duration
+	maxDuration time.Duration
+}
+
+// NewLockout creates a Lockout keeping its state in store.
+func NewLockout(store Store, maxFailures int, duration, maxDuration time.Duration) *Lockout {
+	return &Lockout{
+		store:       store,
+		maxFailures: maxFailures,
+		duration:    duration,
+		maxDuration: max(maxDuration, duration),
+	}
+}
+
+// LockedFor returns how long the account is still locked, or 0 if it is not.
+func (l *Lockout) LockedFor(ctx context.Context, account string) (time.Duration, error) {
+	ttl, err := l.store.TTL(ctx, lockKey(account))
+	if err != nil {
+		return 0, fmt.Errorf("ratelimit: can't check lockout: %w", err)
+	}
+	return ttl, nil
+}
+
+// Fail records a failed attempt and returns how long the account is locked as
+// a result, or 0 if it is not.
+func (l *Lockout) Fail(ctx context.Context, account string) (time.Duration, error) {
+	failures, err := l.store.Incr(ctx, failuresKey(account), l.maxDuration)
+	if err != nil {
+		return 0, fmt.Errorf("ratelimit: can't record failure: %w", err)
+	}
+	if failures < int64(l.maxFailures) {
+		return 0, nil
+	}
+
+	d := l.duration
+	for i := int64(l.maxFailures); i < failures && d < l.maxDuration; i++ {
+		d *= 2
+	}
+	d = min(d, l.maxDuration)
+
+	if err := l.store.Set(ctx, lockKey(account), d); err != nil {
+		return 0, fmt.Errorf("ratelimit: can't lock account: %w", err)
+	}
+	return d, nil
+}
+
+// Reset forgets the failures of the account after a successful attempt.
+func (l *Lockout) Reset(ctx context.Context, account string) error {
+	if err := l.store.Delete(ctx, failuresKey(account), lockKey(account)); err != nil {
+		return fmt.Errorf("ratelimit: can't reset lockout: %w", err)
+	}
+	return nil
+}
+
+func failuresKey(account string) string {
+	return "lockout:failures:" + account
+}
+
+func lockKey(account string) string {
+	return "lockout:lock:" + account
+}
@@ -0,0 +1,139 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript refills and takes from the bucket stored as a hash at KEYS[1]
+// atomically. ARGV holds the burst, the refill interval and the current time,
+// both in milliseconds. Tokens are returned as a string because Lua numbers
+// are truncated to integers in replies.
+var takeScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1])
+local updated = tonumber(state[2])
+if tokens == nil then
+	tokens = burst
+	updated = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - updated) / interval)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) * interval) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisStore is a Store shared by every instance through a Redis-compatible
+// server such as Redis, Valkey or KeyDB. Keys are prefixed to share a database
+// with other applications.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+	now    func() time.Time
+}
+
+// NewRedisStore connects to the server at url, e.g. "redis://localhost:6379/0",
+// and checks that it responds.
+func NewRedisStore(ctx context.Context, url, prefix string) (*RedisStore, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("ratelimit: invalid redis url: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("ratelimit: can't connect to redis: %w", err)
+	}
+	return &RedisStore{client: client, prefix: prefix, now: time.Now}, nil
+}
+
+// Take implements Store.
+func (s *RedisStore) Take(ctx context.Context, key string, l Limit) (Result, error) {
+	interval := float64(l.interval()) / float64(time.Millisecond)
+	now := s.now().UnixMilli()
+
+	reply, err := takeScript.Run(ctx, s.client, []string{s.prefix + key}, l.Burst, interval, now).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: can't take token: %w", err)
+	}
+	if len(reply) != 2 {
+		return Result{}, fmt.Errorf("ratelimit: unexpected reply %v", reply)
+	}
+
+	allowed, _ := reply[0].(int64)
+	text, _ := reply[1].(string)
+	tokens, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: unexpected token count %q: %w", text, err)
+	}
+	return newResult(l, tokens, allowed == 1), nil
+}
+
+// Incr implements Store.
+func (s *RedisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	key = s.prefix + key
+
+	var incr *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		// NX keeps the expiry of an existing counter. It needs Redis 7.
+		pipe.ExpireNX(ctx, key, ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("ratelimit: can't increment counter: %w", err)
+	}
+	return incr.Val(), nil
+}
+
+// Set implements Store.
+func (s *RedisStore) Set(ctx context.Context, key string, ttl time.Duration) error {
+	if err := s.client.Set(ctx, s.prefix+key, 1, ttl).Err(); err != nil {
+		return fmt.Errorf("ratelimit: can't set marker: %w", err)
+	}
+	return nil
+}
+
+// TTL implements Store.
+func (s *RedisStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.client.PTTL(ctx, s.prefix+key).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, fmt.Errorf("ratelimit: can't read ttl: %w", err)
+	}
+	// Missing keys report -2 and keys without expiry -1.
+	return max(ttl, 0), nil
+}
+
+// Delete implements Store.
+func (s *RedisStore) Delete(ctx context.Context, keys ...string) error {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = s.prefix + key
+	}
+	if err := s.client.Del(ctx, prefixed...).Err(); err != nil {
+		return fmt.Errorf("ratelimit: can't delete keys: %w", err)
+	}
+	return nil
+}
+
+// Close implements Store.
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,158 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Store keeps rate limit buckets and lockout counters. Implementations must be
+// safe for concurrent use.
+type Store interface {
+	// Take removes one token from the bucket at key, creating a full bucket of
+	// limit l if there is none, and reports the resulting state.
+	Take(ctx context.Context, key string, l Limit) (Result, error)
+	// Incr increments the counter at key and returns its new value. A new
+	// counter expires after ttl.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Set creates or replaces a marker at key that expires after ttl.
+	Set(ctx context.Context, key string, ttl time.Duration) error
+	// TTL returns how long the counter or marker at key lives, or 0 if there is none.
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	// Delete removes keys.
+	Delete(ctx context.Context, keys ...string) error
+	// Close releases the resources of the store.
+	Close() error
+}
+
+// sweepEvery is the number of MemoryStore operations between sweeps of expired entries.
+const sweepEvery = 1024
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// full is when the bucket is full again and can be forgotten.
+	full time.Time
+}
+
+type counter struct {
+	value   int64
+	expires time.Time
+}
+
+// MemoryStore is a Store for a single instance. Expired entries are removed
+// periodically as the store is used.
+type MemoryStore struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	buckets  map[string]*bucket
+	counters map[string]*counter
+	ops      int
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		now:      time.Now,
+		buckets:  map[string]*bucket{},
+		counters: map[string]*counter{},
+	}
+}
+
+// Take implements Store.
+func (s *MemoryStore) Take(_ context.Context, key string, l Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.maybeSweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), updated: now}
+		s.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.updated)
+	b.tokens = math.Min(float64(l.Burst), b.tokens+float64(elapsed)/float64(l.interval()))
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	res := newResult(l, b.tokens, allowed)
+	b.full = now.Add(res.Reset)
+	return res, nil
+}
+
+// Incr implements Store.
+func (s *MemoryStore) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.maybeSweep(now)
+
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.expires) {
+		c = &counter{expires: now.Add(ttl)}
+		s.counters[key] = c
+	}
+	c.value++
+	return c.value, nil
+}
+
+// Set implements Store.
+func (s *MemoryStore) Set(_ context.Context, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counters[key] = &counter{value: 1, expires: s.now().Add(ttl)}
+	return nil
+}
+
+// TTL implements Store.
+func (s *MemoryStore) TTL(_ context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.counters[key]
+	if !ok {
+		return 0, nil
+	}
+	return max(c.expires.Sub(s.now()), 0), nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.buckets, key)
+		delete(s.counters, key)
+	}
+	return nil
+}
+
+// Close implements Store.
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// maybeSweep removes full buckets and expired counters every sweepEvery operations.
+func (s *MemoryStore) maybeSweep(now time.Time) {
+	s.ops++
+	if s.ops < sweepEvery {
+		return
+	}
+	s.ops = 0
+
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, c := range s.counters {
+		if !now.Before(c.expires) {
+			delete(s.counters, key)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// testStore is a store whose clock only moves when advance is called.
+type testStore struct {
+	name    string
+	store   Store
+	advance func(d time.Duration)
+}
+
+// testStores returns a MemoryStore and a RedisStore backed by miniredis.
+func testStores(t *testing.T) []testStore {
+	t.Helper()
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	memory := NewMemoryStore()
+	memory.now = clock
+
+	server := miniredis.RunT(t)
+	redis, err := NewRedisStore(context.Background(), "redis://"+server.Addr(), "test:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { redis.Close() })
+	redis.now = clock
+
+	return []testStore{
+		{"memory", memory, func(d time.Duration) { now = now.Add(d) }},
+		{"redis", redis, func(d time.Duration) {
+			now = now.Add(d)
+			server.FastForward(d)
+		}},
+	}
+}
+
+func TestTake(t *testing.T) {
+	ctx := context.Background()
+	// One token every 500ms, up to 3.
+	limit := Limit{Rate: 2, Period: time.Second, Burst: 3}
+
+	for _, s := range testStores(t) {
+		t.Run(s.name, func(t *testing.T) {
+			take := func(key string) Result {
+				t.Helper()
+				res, err := s.store.Take(ctx, key, limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return res
+			}
+
+			for i := 2; i >= 0; i-- {
+				res := take("ip:1")
+				if !res.Allowed || res.Remaining != i || res.Limit != 3 {
+					t.Fatalf("burst: got %+v, want allowed with %d remaining", res, i)
+				}
+			}
+			res := take("ip:1")
+			if res.Allowed || res.RetryAfter != 500*time.Millisecond || res.Reset != 1500*time.Millisecond {
+				t.Fatalf("empty bucket: got %+v", res)
+			}
+			if res := take("ip:2"); !res.Allowed || res.Remaining != 2 {
+				t.Errorf("other key: got %+v", res)
+			}
+
+			s.advance(250 * time.Millisecond)
+			if res := take("ip:1"); res.Allowed || res.RetryAfter != 250*time.Millisecond {
+				t.Errorf("half a token: got %+v", res)
+			}
+			s.advance(250 * time.Millisecond)
+			if res := take("ip:1"); !res.Allowed || res.Remaining != 0 {
+				t.Errorf("refilled token: got %+v", res)
+			}
+
+			// The bucket never holds more than the burst.
+			s.advance(time.Minute)
+			if res := take("ip:1"); !res.Allowed || res.Remaining != 2 || res.Reset != 500*time.Millisecond {
+				t.Errorf("full bucket: got %+v", res)
+			}
+		})
+	}
+}
+
+func TestLockout(t *testing.T) {
+	ctx := context.Background()
+
+	for _, s := range testStores(t) {
+		t.Run(s.name, func(t *testing.T) {
+			lockout := NewLockout(s.store, 3, time.Minute, 4*time.Minute)
+			fail := func(want time.Duration) {
+				t.Helper()
+				got, err := lockout.Fail(ctx, "alice")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != want {
+					t.Fatalf("Fail locked for %v, want %v", got, want)
+				}
+			}
+			lockedFor := func(want time.Duration) {
+				t.Helper()
+				got, err := lockout.LockedFor(ctx, "alice")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != want {
+					t.Fatalf("LockedFor = %v, want %v", got, want)
+				}
+			}
+
+			fail(0)
+			fail(0)
+			lockedFor(0)
+			fail(time.Minute)
+			s.advance(20 * time.Second)
+			lockedFor(40 * time.Second)
+			s.advance(40 * time.Second)
+			lockedFor(0)
+
+			// Further failures double the lock up to the maximum.
+			fail(2 * time.Minute)
+			fail(4 * time.Minute)
+			fail(4 * time.Minute)
+
+			if err := lockout.Reset(ctx, "alice"); err != nil {
+				t.Fatal(err)
+			}
+			lockedFor(0)
+			fail(0)
+			fail(0)
+
+			// Failures are forgotten once the longest lock has passed.
+			s.advance(4*time.Minute + time.Second)
+			fail(0)
+			fail(0)
+			fail(time.Minute)
+		})
+	}
+}
@@ -0,0 +1,363 @@
+// Package report renders reports of expenses as PDF documents.
+package report
+
+import (
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/pdf"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Layout of the pages, in points.
+const (
+	margin     = 50.0
+	pageWidth  = pdf.A4Width - 2*margin
+	pageBottom = pdf.A4Height - 60
+	rowHeight  = 16.0
+	chartSize  = 150.0
+)
+
+var (
+	accent    = pdf.Color{R: 0.2, G: 0.4, B: 0.7}
+	muted     = pdf.Gray(0.4)
+	rule      = pdf.Gray(0.75)
+	shade     = pdf.Gray(0.94)
+	increased = pdf.Color{R: 0.7, G: 0.15, B: 0.15}
+	decreased = pdf.Color{R: 0.1, G: 0.5, B: 0.2}
+)
+
+// column of a table. Amounts are right-aligned at the right end of their column.
+type column struct {
+	title string
+	width float64
+	right bool
+}
+
+// WriteMonthlyPDF renders a monthly statement: totals with the change from
+// the month before, spending by category, a chart of daily spending and
+// every expense of the month.
+func WriteMonthlyPDF(w io.Writer, r *model.MonthlyReport) error {
+	doc := &pdf.Document{Title: "Expense statement " + r.Month.Format("January 2006")}
+	m := &monthly{report: r, doc: doc}
+	m.newPage()
+
+	m.header()
+	m.summary()
+	m.categories()
+	m.dailyChart()
+	m.expenses()
+	m.footers()
+
+	if _, err := doc.WriteTo(w); err != nil {
+		return fmt.Errorf("report: can't write monthly statement: %w", err)
+	}
+	return nil
+}
+
+// monthly lays out a monthly statement from the top of the first page down.
+type monthly struct {
+	report *model.MonthlyReport
+	doc    *pdf.Document
+	page   *pdf.Page
+	// y is the top of the free space on the page.
+	y float64
+}
+
+func (m *monthly) newPage() {
+	m.page = m.doc.AddPage()
+	m.y = margin
+}
+
+// reserve starts a new page unless height fits on the current one, and
+// reports whether it did.
+func (m *monthly) reserve(height float64) bool {
+	if m.y+height <= pageBottom {
+		return false
+	}
+	m.newPage()
+	return true
+}
+
+func (m *monthly) header() {
+	month := m.report.Month.Format("January 2006")
+	m.page.Text(margin, m.y+20, pdf.HelveticaBold, 20, pdf.Black, "Expense statement")
+	m.text(margin+pageWidth, m.y+20, pdf.HelveticaBold, 14, accent, month, true)
+	m.y += 30
+	m.page.Line(margin, m.y, margin+pageWidth, m.y, 1, accent)
+	m.y += 14
+	m.page.Text(margin, m.y, pdf.Helvetica, 8, muted,
+		"Generated "+m.report.GeneratedAt.Format("2006-01-02 15:04 MST"))
+	m.y += 20
+}
+
+func (m *monthly) summary() {
+	r := m.report
+	days := float64(len(r.Daily))
+	change, percent, color := change(r.Total, r.PreviousTotal)
+	changeLabel := "Change"
+	if percent != "" {
+		changeLabel += " (" + percent + ")"
+	}
+	boxes := []struct {
+		label, value string
+		color        pdf.Color
+	}{
+		{"Total spent", formatAmount(r.Total), pdf.Black},
+		{"Previous month", formatAmount(r.PreviousTotal), pdf.Black},
+		{changeLabel, change, color},
+		{"Daily average", formatAmount(r.Total / days), pdf.Black},
+	}
+
+	const gap, height = 10.0, 46.0
+	width := (pageWidth - gap*float64(len(boxes)-1)) / float64(len(boxes))
+	for i, box := range boxes {
+		x := margin + float64(i)*(width+gap)
+		m.page.Rect(x, m.y, width, height, shade)
+		m.page.Text(x+8, m.y+16, pdf.Helvetica, 8, muted, box.label)
+		m.page.Text(x+8, m.y+35, pdf.HelveticaBold, 13, box.color, truncate(pdf.HelveticaBold, 13, box.value, width-16))
+	}
+	m.y += height + 10
+	m.page.Text(margin, m.y, pdf.Helvetica, 8, muted,
+		fmt.Sprintf("%d expenses this month, %d the month before.", r.Count, r.PreviousCount))
+	m.y += 26
+}
+
+func (m *monthly) categories() {
+	columns := []column{
+		{"Category", 175, false},
+		{"Expenses", 60, true},
+		{"Total", 75, true},
+		{"Share", 50, true},
+		{"Previous month", 75, true},
+		{"Change", pageWidth - 435, true},
+	}
+	m.heading("Spending by category")
+	if len(m.report.Categories) == 0 {
+		m.page.Text(margin, m.y, pdf.Helvetica, 10, muted, "No expenses in this month or the month before.")
+		m.y += 28
+		return
+	}
+	m.tableHeader(columns)
+	for i, c := range m.report.Categories {
+		if m.reserve(rowHeight) {
+			m.tableHeader(columns)
+		}
+		change, color := changeText(c.Total, c.PreviousTotal)
+		m.row(columns, i%2 == 1, pdf.Helvetica, []string{
+			c.Category,
+			strconv.Itoa(c.Count),
+			formatAmount(c.Total),
+			fmt.Sprintf("%.1f%%", c.Share*100),
+			formatAmount(c.PreviousTotal),
+			change,
+		}, map[int]pdf.Color{5: color})
+	}
+	m.reserve(rowHeight)
+	m.page.Line(margin, m.y, margin+pageWidth, m.y, 0.5, rule)
+	total, color := changeText(m.report.Total, m.report.PreviousTotal)
+	m.row(columns, false, pdf.HelveticaBold, []string{
+		"Total", strconv.Itoa(m.report.Count), formatAmount(m.report.Total), "100.0%",
+		formatAmount(m.report.PreviousTotal), total,
+	}, map[int]pdf.Color{5: color})
+	m.y += 24
+}
+
+// dailyChart draws a bar per day, on a scale with four grid lines.
+func (m *monthly) dailyChart() {
+	m.reserve(chartSize + 70)
+	m.heading("Daily spending")
+
+	daily := m.report.Daily
+	var peak float64
+	for _, total := range daily {
+		peak = math.Max(peak, total)
+	}
+	step := niceStep(peak / 4)
+	scale := chartSize / (4 * step)
+
+	const labelWidth = 50.0
+	left, width := margin+labelWidth, pageWidth-labelWidth
+	bottom := m.y + chartSize
+	for i := 0; i <= 4; i++ {
+		y := bottom - float64(i)*step*scale
+		m.page.Line(left, y, left+width, y, 0.5, rule)
+		m.text(left-6, y+3, pdf.Helvetica, 7, muted, formatAmount(float64(i)*step), true)
+	}
+
+	slot := width / float64(len(daily))
+	for i, total := range daily {
+		x := left + float64(i)*slot
+		if height := total * scale; height > 0 {
+			m.page.Rect(x+slot*0.15, bottom-height, slot*0.7, height, accent)
+		}
+		if day := i + 1; day == 1 || day%5 == 0 || day == len(daily) {
+			label := strconv.Itoa(day)
+			m.page.Text(x+(slot-pdf.TextWidth(pdf.Helvetica, 7, label))/2, bottom+11, pdf.Helvetica, 7, muted, label)
+		}
+	}
+	m.y = bottom + 40
+}
+
+func (m *monthly) expenses() {
+	columns := []column{
+		{"Date", 65, false},
+		{"Category", 100, false},
+		{"Description", pageWidth - 240, false},
+		{"Amount", 75, true},
+	}
+	m.reserve(3 * rowHeight)
+	m.heading("Expenses")
+	if len(m.report.Expenses) == 0 {
+		m.page.Text(margin, m.y, pdf.Helvetica, 10, muted, "No expenses in this month.")
+		return
+	}
+	m.tableHeader(columns)
+	for i, e := range m.report.Expenses {
+		if m.reserve(rowHeight) {
+			m.tableHeader(columns)
+		}
+		description := strings.Join(strings.Fields(e.Description), " ")
+		if len(e.Tags) > 0 {
+			description = strings.TrimSpace(description + " [" + strings.Join(e.Tags, ", ") + "]")
+		}
+		m.row(columns, i%2 == 1, pdf.Helvetica, []string{
+			e.Date.Format("2006-01-02"), e.Category, description, formatAmount(e.Amount),
+		}, nil)
+	}
+}
+
+// footers numbers the pages once all of them are laid out.
+func (m *monthly) footers() {
+	pages := m.doc.Pages()
+	title := "Expense statement " + m.report.Month.Format("January 2006")
+	for i, page := range pages {
+		y := pdf.A4Height - 30
+		page.Line(margin, y-12, margin+pageWidth, y-12, 0.5, rule)
+		page.Text(margin, y, pdf.Helvetica, 8, muted, title)
+		label := fmt.Sprintf("Page %d of %d", i+1, len(pages))
+		page.Text(margin+pageWidth-pdf.TextWidth(pdf.Helvetica, 8, label), y, pdf.Helvetica, 8, muted, label)
+	}
+}
+
+func (m *monthly) heading(title string) {
+	m.reserve(40)
+	m.page.Text(margin, m.y+12, pdf.HelveticaBold, 12, accent, title)
+	m.y += 24
+}
+
+func (m *monthly) tableHeader(columns []column) {
+	titles := make([]string, len(columns))
+	for i, c := range columns {
+		titles[i] = c.title
+	}
+	m.row(columns, false, pdf.HelveticaBold, titles, nil)
+	m.page.Line(margin, m.y, margin+pageWidth, m.y, 0.5, rule)
+}
+
+// row draws a table row, optionally shaded, with the text of some cells in
+// another color than black. Text that doesn't fit its column is truncated.
+func (m *monthly) row(columns []column, shaded bool, font pdf.Font, cells []string, colors map[int]pdf.Color) {
+	const size, padding = 9.0, 4.0
+	if shaded {
+		m.page.Rect(margin, m.y, pageWidth, rowHeight, shade)
+	}
+	x := margin
+	for i, c := range columns {
+		color, ok := colors[i]
+		if !ok {
+			color = pdf.Black
+		}
+		text := truncate(font, size, cells[i], c.width-2*padding)
+		if c.right {
+			m.text(x+c.width-padding, m.y+11.5, font, size, color, text, true)
+		} else {
+			m.page.Text(x+padding, m.y+11.5, font, size, color, text)
+		}
+		x += c.width
+	}
+	m.y += rowHeight
+}
+
+// text draws text starting at x, or ending at x if rightAligned.
+func (m *monthly) text(x, y float64, font pdf.Font, size float64, color pdf.Color, s string, rightAligned bool) {
+	if rightAligned {
+		x -= pdf.TextWidth(font, size, s)
+	}
+	m.page.Text(x, y, font, size, color, s)
+}
+
+// truncate shortens s with an ellipsis to fit width.
+func truncate(font pdf.Font, size float64, s string, width float64) string {
+	if pdf.TextWidth(font, size, s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdf.TextWidth(font, size, string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "…"
+}
+
+// changeText describes the change from previous to current, e.g. "+12.50
+// (+8.3%)", in red for increases and green for decreases.
+func changeText(current, previous float64) (string, pdf.Color) {
+	diff, percent, color := change(current, previous)
+	if percent != "" {
+		diff += " (" + percent + ")"
+	}
+	return diff, color
+}
+
+// change returns the difference from previous to current, e.g. "+12.50",
+// the percentage, e.g. "+8.3%", unless previous is zero or there is no
+// change, and the color of changeText.
+func change(current, previous float64) (diff, percent string, color pdf.Color) {
+	d := current - previous
+	switch {
+	case math.Abs(d) < 0.005:
+		return "±0.00", "", muted
+	case previous == 0:
+		return "+" + formatAmount(d), "", increased
+	}
+	color = increased
+	sign := "+"
+	if d < 0 {
+		color, sign = decreased, "-"
+	}
+	return sign + formatAmount(math.Abs(d)), fmt.Sprintf("%s%.1f%%", sign, math.Abs(d/previous*100)), color
+}
+
+// formatAmount formats an amount with two decimals and thousands separators.
+func formatAmount(amount float64) string {
+	s := strconv.FormatFloat(math.Abs(amount), 'f', 2, 64)
+	integer, decimals := s[:len(s)-3], s[len(s)-3:]
+	var b strings.Builder
+	if amount <= -0.005 {
+		b.WriteByte('-')
+	}
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return b.String() + decimals
+}
+
+// niceStep returns the smallest step of 1, 2 or 5 times a power of ten that
+// is at least least, or 1 if least isn't positive.
+func niceStep(least float64) float64 {
+	if least <= 0 {
+		return 1
+	}
+	power := math.Pow(10, math.Floor(math.Log10(least)))
+	for _, factor := range []float64{1, 2, 5, 10} {
+		if step := factor * power; step >= least {
+			return step
+		}
+	}
+	return 10 * power
+}
@@ -0,0 +1,161 @@
+package report
+
+import (
+	"bytes"
+	"compress/zlib"
+	"expense_tracker/internal/model"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	startXref = regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`)
+	pageCount = regexp.MustCompile(`/Type /Pages /Kids \[[^\]]*\] /Count (\d+)`)
+	streams   = regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`)
+)
+
+// render writes the statement of r and returns the decompressed content of
+// its pages after checking the structure of the document.
+func render(t *testing.T, r *model.MonthlyReport) []string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := WriteMonthlyPDF(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		t.Fatalf("document starts with %q", data[:min(len(data), 16)])
+	}
+	m := startXref.FindSubmatch(data)
+	if m == nil {
+		t.Fatal("no startxref at the end")
+	}
+	if xref, _ := strconv.Atoi(string(m[1])); !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d doesn't point to the cross-reference table", xref)
+	}
+
+	var pages []string
+	for _, stream := range streams.FindAllSubmatch(data, -1) {
+		zr, err := zlib.NewReader(bytes.NewReader(stream[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, string(content))
+	}
+	count := pageCount.FindSubmatch(data)
+	if count == nil || string(count[1]) != strconv.Itoa(len(pages)) {
+		t.Fatalf("page tree %q, %d pages", count, len(pages))
+	}
+	for i, page := range pages {
+		if footer := fmt.Sprintf("(Page %d of %d)", i+1, len(pages)); !strings.Contains(page, footer) {
+			t.Errorf("page %d has no footer %q", i+1, footer)
+		}
+	}
+	return pages
+}
+
+func TestWriteMonthlyPDF(t *testing.T) {
+	month := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	r := &model.MonthlyReport{
+		Month:         month,
+		PreviousTotal: 100,
+		PreviousCount: 2,
+		Daily:         make([]float64, 31),
+		Expenses:      []model.Expense{},
+		GeneratedAt:   month.AddDate(0, 1, 0),
+	}
+	categories := []string{"food", "еда", "食べ物", strings.Repeat("very long category ", 5)}
+	for i := range 120 {
+		e := model.Expense{
+			ID:          i + 1,
+			Amount:      float64(i%7) + 0.5,
+			Category:    categories[i%len(categories)],
+			Description: fmt.Sprintf("Обед %d (café) \\ 午餐", i),
+			Date:        model.Date{Time: month.AddDate(0, 0, i/4)},
+			Tags:        []string{"работа"},
+		}
+		r.Expenses = append(r.Expenses, e)
+		r.Total += e.Amount
+		r.Count++
+		r.Daily[i/4] += e.Amount
+	}
+	for _, c := range categories {
+		r.Categories = append(r.Categories, model.CategoryTotal{Category: c, Total: r.Total / 4, Count: 30, Share: 0.25})
+	}
+
+	pages := render(t, r)
+	if len(pages) < 3 {
+		t.Fatalf("%d pages for %d expenses", len(pages), len(r.Expenses))
+	}
+	all := strings.Join(pages, "")
+	// Characters outside Windows-1252 are written as "?".
+	if !strings.Contains(all, `(???? 119 \(caf`+"\xe9"+`\) \\ ?? [??????])`) {
+		t.Error("last expense missing")
+	}
+	if !strings.Contains(all, "(March 2024)") {
+		t.Error("month missing")
+	}
+}
+
+func TestWriteMonthlyPDFEmptyMonth(t *testing.T) {
+	month := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	pages := render(t, &model.MonthlyReport{
+		Month:       month,
+		Daily:       make([]float64, 29),
+		Expenses:    []model.Expense{},
+		GeneratedAt: month,
+	})
+	if len(pages) != 1 {
+		t.Fatalf("%d pages", len(pages))
+	}
+	for _, text := range []string{"(No expenses in this month or the month before.)", "(No expenses in this month.)", "(\xb10.00)"} {
+		if !strings.Contains(pages[0], text) {
+			t.Errorf("empty month lacks %q", text)
+		}
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	for amount, want := range map[float64]string{
+		0: "0.00", 0.004: "0.00", -0.004: "0.00", 1.5: "1.50", 999.999: "1,000.00",
+		1234567.891: "1,234,567.89", -1234.5: "-1,234.50",
+	} {
+		if got := formatAmount(amount); got != want {
+			t.Errorf("formatAmount(%v) = %q, want %q", amount, got, want)
+		}
+	}
+}
+
+func TestChangeText(t *testing.T) {
+	tests := []struct {
+		current, previous float64
+		want              string
+	}{
+		{100, 100, "±0.00"},
+		{100, 0, "+100.00"},
+		{110, 100, "+10.00 (+10.0%)"},
+		{50, 200, "-150.00 (-75.0%)"},
+	}
+	for _, tt := range tests {
+		if got, _ := changeText(tt.current, tt.previous); got != tt.want {
+			t.Errorf("changeText(%v, %v) = %q, want %q", tt.current, tt.previous, got, tt.want)
+		}
+	}
+}
+
+func TestNiceStep(t *testing.T) {
+	for least, want := range map[float64]float64{0: 1, -3: 1, 0.3: 0.5, 1: 1, 1.2: 2, 3: 5, 7: 10, 1500: 2000} {
+		if got := niceStep(least); got != want {
+			t.Errorf("niceStep(%v) = %v, want %v", least, got, want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Package memory implements the repository interfaces on top of plain Go maps.
+// It is thread-safe, keeps the same semantics as the PostgreSQL backend
+// (constraints, rounding, ordering, cascades) and is meant for tests and
+// local development. Nothing is persisted.
+package memory
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"sync"
+	"time"
+)
+
+// DB is an in-memory database shared by the repositories of this package.
+type DB struct {
+	mu    sync.Mutex
+	state *state
+}
+
+// state holds all tables. Transactions work on a copy of it that replaces
+// the original on commit.
+type state struct {
+	nextUserID    int
+	nextExpenseID int
+	users         map[int]model.User
+	expenses      map[int]expenseRecord
+	imported      map[importKey]time.Time
+
+	// Webhooks are never changed inside a transaction, so copies of the
+	// state share them; deliveries are enqueued with the changes of expenses.
+	nextWebhookID  int
+	nextDeliveryID int64
+	webhooks       map[int]model.Webhook
+	deliveries     map[int64]model.WebhookDelivery
+}
+
+// expenseRecord is a stored expense with the columns not exposed in model.Expense.
+type expenseRecord struct {
+	expense   model.Expense
+	createdAt time.Time
+}
+
+// importKey is the primary key of the imported_transactions table.
+type importKey struct {
+	userID     int
+	account    string
+	externalID string
+}
+
+// NewDB creates an empty in-memory database.
+func NewDB() *DB {
+	return &DB{
+		state: &state{
+			nextUserID:    1,
+			nextExpenseID: 1,
+			users:         map[int]model.User{},
+			expenses:      map[int]expenseRecord{},
+			imported:      map[importKey]time.Time{},
+
+			nextWebhookID:  1,
+			nextDeliveryID: 1,
+			webhooks:       map[int]model.Webhook{},
+			deliveries:     map[int64]model.WebhookDelivery{},
+		},
+	}
+}
+
+// Ping always succeeds; it exists so DB can be used as a health check target.
+func (db *DB) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// SchemaVersion reports version 0: the in-memory database has no migrations.
+func (db *DB) SchemaVersion(ctx context.Context) (uint, bool, error) {
+	return 0, false, nil
+}
+
+// do runs fn with exclusive access to the database state.
+func (db *DB) do(fn func(s *state) error) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return fn(db.state)
+}
+
+// clone returns a copy of s that can be modified independently.
+func (s *state) clone() *state {
+	c := &state{
+		nextUserID:    s.nextUserID,
+		nextExpenseID: s.nextExpenseID,
+		users:         make(map[int]model.User, len(s.users)),
+		expenses:      make(map[int]expenseRecord, len(s.expenses)),
+		imported:      make(map[importKey]time.Time, len(s.imported)),
+
+		nextWebhookID:  s.nextWebhookID,
+		nextDeliveryID: s.nextDeliveryID,
+		webhooks:       s.webhooks,
+		deliveries:     make(map[int64]model.WebhookDelivery, len(s.deliveries)),
+	}
+	for id, u := range s.users {
+		c.users[id] = u
+	}
+	for id, e := range s.expenses {
+		c.expenses[id] = e
+	}
+	for key, at := range s.imported {
+		c.imported[key] = at
+	}
+	for id, d := range s.deliveries {
+		c.deliveries[id] = d
+	}
+	return c
+}
+
+// truncateDate drops the time of day like a DATE column does.
+func truncateDate(d model.Date) model.Date {
+	y, m, day := d.Date()
+	return model.Date{Time: time.Date(y, m, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// copyTags returns a copy of tags that is never nil, matching TEXT[] NOT NULL DEFAULT '{}'.
+func copyTags(tags []string) []string {
+	return append([]string{}, tags...)
+}
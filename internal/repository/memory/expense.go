@@ -0,0 +1,297 @@
+package memory
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"expense_tracker/lib"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// ExpenseRepository is the in-memory implementation of repository.ExpenseRepository.
+type ExpenseRepository struct {
+	db *DB
+	// tx is the transaction state this repository is bound to, or nil.
+	tx *state
+}
+
+var _ repository.ExpenseRepository = (*ExpenseRepository)(nil)
+
+// NewExpenseRepository creates a new instance of ExpenseRepository.
+func NewExpenseRepository(db *DB) *ExpenseRepository {
+	return &ExpenseRepository{
+		db: db,
+	}
+}
+
+// WithTx runs fn on a copy of the database that replaces the original only if fn
+// returns nil. Calling WithTx on a repository that is already bound to a
+// transaction behaves like a savepoint.
+//
+// The database stays locked until fn returns, so fn must only use the
+// repository it is given.
+func (r *ExpenseRepository) WithTx(ctx context.Context, fn func(tx repository.ExpenseRepository) error) error {
+	if r.tx != nil {
+		savepoint := r.tx.clone()
+		if err := fn(&ExpenseRepository{db: r.db, tx: savepoint}); err != nil {
+			return err
+		}
+		*r.tx = *savepoint
+		return nil
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	tx := r.db.state.clone()
+	if err := fn(&ExpenseRepository{db: r.db, tx: tx}); err != nil {
+		lib.Logger(ctx).Debug("repository/expense: transaction rolled back", "error", err)
+		return err
+	}
+	r.db.state = tx
+	return nil
+}
+
+// do runs fn on the transaction state or, outside a transaction, on the locked database state.
+func (r *ExpenseRepository) do(fn func(s *state) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+	return r.db.do(fn)
+}
+
+// CreateExpense inserts a new expense record into the database.
+func (r *ExpenseRepository) CreateExpense(ctx context.Context, expense model.Expense) (int, error) {
+	var id int
+	err := r.do(func(s *state) error {
+		if _, ok := s.users[expense.UserID]; !ok {
+			return fmt.Errorf("repository/expense: can't create expense: user %d does not exist", expense.UserID)
+		}
+		if err := checkExpense(&expense); err != nil {
+			return fmt.Errorf("repository/expense: can't create expense: %w", err)
+		}
+
+		id = s.nextExpenseID
+		s.nextExpenseID++
+		expense.ID = id
+		s.expenses[id] = expenseRecord{expense: expense, createdAt: time.Now()}
+		return nil
+	})
+	return id, err
+}
+
+// GetExpenseByID retrieves an expense by its ID and associated user ID.
+func (r *ExpenseRepository) GetExpenseByID(ctx context.Context, id int, userID int) (*model.Expense, error) {
+	var expense *model.Expense
+	err := r.do(func(s *state) error {
+		rec, ok := s.expenses[id]
+		if !ok || rec.expense.UserID != userID {
+			return fmt.Errorf("repository/expense: no such expense: %w", lib.NotFound("expense not found"))
+		}
+		expense = copyExpense(rec.expense)
+		return nil
+	})
+	return expense, err
+}
+
+// IsExists checks if an expense with given ID exists for specified user.
+func (r *ExpenseRepository) IsExists(ctx context.Context, id int, userID int) (bool, error) {
+	var exists bool
+	err := r.do(func(s *state) error {
+		rec, ok := s.expenses[id]
+		exists = ok && rec.expense.UserID == userID
+		return nil
+	})
+	return exists, err
+}
+
+// UpdateExpense modifies an existing expense record.
+func (r *ExpenseRepository) UpdateExpense(ctx context.Context, id int, userID int, input *model.UpdateExpenseInput) (*model.Expense, error) {
+	var updated *model.Expense
+	err := r.do(func(s *state) error {
+		rec, ok := s.expenses[id]
+		if !ok || rec.expense.UserID != userID {
+			return fmt.Errorf("repository/expense: no such expense to update: %w", lib.NotFound("expense not found"))
+		}
+
+		e := rec.expense
+		if input.Amount != nil {
+			e.Amount = *input.Amount
+		}
+		if input.Category != nil {
+			e.Category = *input.Category
+		}
+		if input.Description != nil {
+			e.Description = *input.Description
+		}
+		if input.Date != nil {
+			e.Date = *input.Date
+		}
+		if input.Tags != nil {
+			e.Tags = *input.Tags
+		}
+		if err := checkExpense(&e); err != nil {
+			return fmt.Errorf("repository/expense: can't update expense: %w", err)
+		}
+
+		rec.expense = e
+		s.expenses[id] = rec
+		updated = copyExpense(e)
+		return nil
+	})
+	return updated, err
+}
+
+// DeleteExpense removes an expense record by ID.
+func (r *ExpenseRepository) DeleteExpense(ctx context.Context, id int, userID int) error {
+	return r.do(func(s *state) error {
+		rec, ok := s.expenses[id]
+		if !ok || rec.expense.UserID != userID {
+			return fmt.Errorf("repository/expense: expanse not found (id: %d, user_id: %d): %w", id, userID, lib.NotFound("expense not found"))
+		}
+		delete(s.expenses, id)
+		return nil
+	})
+}
+
+// GetExpensesList retrieves all expenses for a specific user.
+func (r *ExpenseRepository) GetExpensesList(ctx context.Context, userID int) ([]model.Expense, error) {
+	return r.selectExpenses(byID, func(e *model.Expense) bool {
+		return e.UserID == userID
+	})
+}
+
+// GetExpensesByPeriod retrieves expenses for a user within a specific date range.
+func (r *ExpenseRepository) GetExpensesByPeriod(ctx context.Context, userID int, start, end time.Time) ([]model.Expense, error) {
+	return r.selectExpenses(byDate, func(e *model.Expense) bool {
+		return e.UserID == userID && !e.Date.Before(start) && !e.Date.After(end)
+	})
+}
+
+// GetExpensesByCategory retrieves expenses for a user in a specific category.
+func (r *ExpenseRepository) GetExpensesByCategory(ctx context.Context, userID int, category string) ([]model.Expense, error) {
+	return r.selectExpenses(byDate, func(e *model.Expense) bool {
+		return e.UserID == userID && e.Category == category
+	})
+}
+
+// FindExpenses retrieves the expenses of a user matching the filter.
+func (r *ExpenseRepository) FindExpenses(ctx context.Context, userID int, f *model.ExpenseFilter) ([]model.Expense, error) {
+	return r.selectExpenses(byDate, func(e *model.Expense) bool {
+		return e.UserID == userID && matchesFilter(e, f)
+	})
+}
+
+// BulkUpdate applies a category change and/or tag edits to every expense of the user
+// matching the filter and returns the number of affected rows.
+func (r *ExpenseRepository) BulkUpdate(ctx context.Context, userID int, input *model.BulkUpdateInput) (int64, error) {
+	var updated int64
+	err := r.do(func(s *state) error {
+		// Like a single UPDATE statement, either every matching row changes or none does.
+		changes := map[int]expenseRecord{}
+		for id, rec := range s.expenses {
+			e := rec.expense
+			if e.UserID != userID || !matchesFilter(&e, &input.Filter) {
+				continue
+			}
+
+			if input.SetCategory != nil {
+				e.Category = *input.SetCategory
+			}
+			e.Tags = repository.MergeTags(e.Tags, input.AddTags, input.RemoveTags)
+			if err := checkExpense(&e); err != nil {
+				return fmt.Errorf("repository/expense: can't bulk update expenses: %w", err)
+			}
+
+			rec.expense = e
+			changes[id] = rec
+		}
+
+		for id, rec := range changes {
+			s.expenses[id] = rec
+		}
+		updated = int64(len(changes))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
+
+// selectExpenses returns copies of the expenses matching keep, sorted by less.
+// Like the PostgreSQL backend it returns nil when nothing matches.
+func (r *ExpenseRepository) selectExpenses(less func(a, b *model.Expense) bool, keep func(e *model.Expense) bool) ([]model.Expense, error) {
+	var expenses []model.Expense
+	err := r.do(func(s *state) error {
+		for _, rec := range s.expenses {
+			if keep(&rec.expense) {
+				expenses = append(expenses, *copyExpense(rec.expense))
+			}
+		}
+		return nil
+	})
+	sort.Slice(expenses, func(i, j int) bool {
+		return less(&expenses[i], &expenses[j])
+	})
+	return expenses, err
+}
+
+func byID(a, b *model.Expense) bool {
+	return a.ID < b.ID
+}
+
+func byDate(a, b *model.Expense) bool {
+	if !a.Date.Equal(b.Date.Time) {
+		return a.Date.Before(b.Date.Time)
+	}
+	return a.ID < b.ID
+}
+
+// matchesFilter reports whether e satisfies every criterion of f.
+func matchesFilter(e *model.Expense, f *model.ExpenseFilter) bool {
+	if len(f.IDs) > 0 && !slices.Contains(f.IDs, e.ID) {
+		return false
+	}
+	if f.Category != nil && e.Category != *f.Category {
+		return false
+	}
+	if f.Start != nil && e.Date.Before(f.Start.Time) {
+		return false
+	}
+	if f.End != nil && e.Date.After(f.End.Time) {
+		return false
+	}
+	if f.Tag != nil && !slices.Contains(e.Tags, *f.Tag) {
+		return false
+	}
+	return true
+}
+
+// checkExpense enforces the column constraints of the expenses table and
+// normalizes values the way PostgreSQL stores them.
+func checkExpense(e *model.Expense) error {
+	e.Amount = repository.RoundCents(e.Amount)
+	if e.Amount <= 0 {
+		return fmt.Errorf("amount violates check constraint (amount > 0)")
+	}
+	if e.Amount >= repository.MaxAmount {
+		return fmt.Errorf("amount overflows DECIMAL(10,2)")
+	}
+	if utf8.RuneCountInString(e.Category) > repository.MaxCategoryLength {
+		return fmt.Errorf("category exceeds %d characters", repository.MaxCategoryLength)
+	}
+	e.Date = truncateDate(e.Date)
+	e.Tags = copyTags(e.Tags)
+	return nil
+}
+
+// copyExpense returns a copy of e that shares no memory with the stored record.
+func copyExpense(e model.Expense) *model.Expense {
+	e.Tags = copyTags(e.Tags)
+	return &e
+}
@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// MarkImported records the IDs of transactions imported from a statement of
+// the account and returns the ones not recorded before.
+func (r *ExpenseRepository) MarkImported(ctx context.Context, userID int, account string, ids []string) ([]string, error) {
+	var marked []string
+	err := r.do(func(s *state) error {
+		if _, ok := s.users[userID]; !ok {
+			return fmt.Errorf("repository/expense: can't mark transactions imported: user %d does not exist", userID)
+		}
+		if utf8.RuneCountInString(account) > 100 {
+			return fmt.Errorf("repository/expense: can't mark transactions imported: account exceeds 100 characters")
+		}
+		for _, id := range ids {
+			if utf8.RuneCountInString(id) > 255 {
+				return fmt.Errorf("repository/expense: can't mark transactions imported: ID exceeds 255 characters")
+			}
+		}
+
+		// Every ID is checked before any is recorded, so that a failure
+		// records none, like the single INSERT of the other backends.
+		now := time.Now()
+		for _, id := range ids {
+			key := importKey{userID: userID, account: account, externalID: id}
+			if _, ok := s.imported[key]; ok {
+				continue
+			}
+			s.imported[key] = now
+			marked = append(marked, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return marked, nil
+}
+
+// ImportedIDs returns the IDs recorded by MarkImported for the account among ids.
+func (r *ExpenseRepository) ImportedIDs(ctx context.Context, userID int, account string, ids []string) ([]string, error) {
+	var imported []string
+	err := r.do(func(s *state) error {
+		for _, id := range ids {
+			if _, ok := s.imported[importKey{userID: userID, account: account, externalID: id}]; ok {
+				imported = append(imported, id)
+			}
+		}
+		return nil
+	})
+	return imported, err
+}
@@ -0,0 +1,18 @@
+package memory_test
+
+import (
+	"expense_tracker/internal/repository/memory"
+	"expense_tracker/internal/repository/repotest"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		db := memory.NewDB()
+		return repotest.Repositories{
+			Users:    memory.NewUserRepository(db),
+			Expenses: memory.NewExpenseRepository(db),
+			Webhooks: memory.NewWebhookRepository(db),
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package memory
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"slices"
+	"time"
+)
+
+// EnqueueDeliveries adds a delivery of every event for every active webhook
+// of the user subscribed to its type.
+func (r *ExpenseRepository) EnqueueDeliveries(ctx context.Context, userID int, events []model.OutboxEvent, now time.Time) (int, error) {
+	var n int
+	err := r.do(func(s *state) error {
+		ids := make([]int, 0, len(s.webhooks))
+		for id, w := range s.webhooks {
+			if w.UserID == userID && w.Active {
+				ids = append(ids, id)
+			}
+		}
+		slices.Sort(ids)
+
+		for _, e := range events {
+			for _, id := range ids {
+				if !slices.Contains(s.webhooks[id].EventTypes, e.Type) {
+					continue
+				}
+				s.addDelivery(model.WebhookDelivery{
+					WebhookID: id,
+					EventType: e.Type,
+					Payload:   slices.Clone(e.Payload),
+				}, now)
+				n++
+			}
+		}
+		return nil
+	})
+	return n, err
+}
@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+)
+
+// SearchExpenses matches the query terms against the words of the category and
+// description, following the same prefix and phrase rules as the PostgreSQL backend.
+func (r *ExpenseRepository) SearchExpenses(ctx context.Context, userID int, query *model.SearchQuery) ([]model.SearchResult, error) {
+	terms := repository.ParseSearchQuery(query.Query)
+	results := []model.SearchResult{}
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	err := r.do(func(s *state) error {
+		for _, rec := range s.expenses {
+			e := rec.expense
+			if e.UserID != userID ||
+				(query.Category != nil && e.Category != *query.Category) ||
+				(query.Start != nil && e.Date.Before(query.Start.Time)) ||
+				(query.End != nil && e.Date.After(query.End.Time)) {
+				continue
+			}
+
+			rank, ok := repository.MatchSearch(&e, terms)
+			if !ok {
+				continue
+			}
+			results = append(results, model.SearchResult{
+				Expense: *copyExpense(e),
+				Rank:    rank,
+				Snippet: repository.Highlight(e.Description, terms),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return repository.PageSearchResults(results, query), nil
+}
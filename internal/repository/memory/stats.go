@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"time"
+)
+
+// StatsRepository is the in-memory implementation of repository.StatsRepository.
+type StatsRepository struct {
+	db *DB
+}
+
+var _ repository.StatsRepository = (*StatsRepository)(nil)
+
+// NewStatsRepository creates a new instance of StatsRepository.
+func NewStatsRepository(db *DB) *StatsRepository {
+	return &StatsRepository{
+		db: db,
+	}
+}
+
+// Stats returns the current user and expense counts.
+func (r *StatsRepository) Stats(ctx context.Context) (*model.Stats, error) {
+	stats := &model.Stats{}
+	now := time.Now()
+	activeSince := now.AddDate(0, 0, -repository.ActiveUserDays)
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+
+	err := r.db.do(func(s *state) error {
+		active := map[int]bool{}
+		for _, rec := range s.expenses {
+			if !rec.createdAt.Before(activeSince) {
+				active[rec.expense.UserID] = true
+			}
+			if !rec.createdAt.Before(today) {
+				stats.ExpensesCreatedToday++
+			}
+		}
+		stats.Users = int64(len(s.users))
+		stats.ActiveUsers = int64(len(active))
+		stats.ExpensesTotal = int64(len(s.expenses))
+		return nil
+	})
+	return stats, err
+}
@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"expense_tracker/lib"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// passwordLength is the width of the CHAR(60) password column.
+const passwordLength = 60
+
+// UserRepository is the in-memory implementation of repository.UserRepository.
+type UserRepository struct {
+	db *DB
+}
+
+var _ repository.UserRepository = (*UserRepository)(nil)
+
+// NewUserRepository creates a new instance of UserRepository.
+func NewUserRepository(db *DB) *UserRepository {
+	return &UserRepository{
+		db: db,
+	}
+}
+
+// CreateUser inserts a new user into the database.
+func (r *UserRepository) CreateUser(ctx context.Context, user *model.User) error {
+	return r.db.do(func(s *state) error {
+		if err := checkUsername(s, user.Username, 0); err != nil {
+			return fmt.Errorf("repository/user: can't create user: %w", err)
+		}
+		if len(user.Password) > passwordLength {
+			return fmt.Errorf("repository/user: can't create user: password exceeds %d characters", passwordLength)
+		}
+
+		user.ID = s.nextUserID
+		s.nextUserID++
+		s.users[user.ID] = model.User{
+			ID:       user.ID,
+			Username: user.Username,
+			Password: user.Password + strings.Repeat(" ", passwordLength-len(user.Password)),
+		}
+		return nil
+	})
+}
+
+// GetUserByName retrieves a user by their username.
+func (r *UserRepository) GetUserByName(ctx context.Context, username string) (*model.User, error) {
+	var user *model.User
+	err := r.db.do(func(s *state) error {
+		for _, u := range s.users {
+			if u.Username == username {
+				user = &u
+				return nil
+			}
+		}
+		return fmt.Errorf("repository/user: can't get user by name: %w", lib.NotFound("user not found"))
+	})
+	return user, err
+}
+
+// GetUserById retrieves a user by their ID.
+func (r *UserRepository) GetUserById(ctx context.Context, id int) (*model.User, error) {
+	var user *model.User
+	err := r.db.do(func(s *state) error {
+		u, ok := s.users[id]
+		if !ok {
+			return fmt.Errorf("repository/user: can't get user by id: %w", lib.NotFound("user not found"))
+		}
+		user = &u
+		return nil
+	})
+	return user, err
+}
+
+// DeleteUser removes a user and, like ON DELETE CASCADE, their expenses.
+func (r *UserRepository) DeleteUser(ctx context.Context, id int) error {
+	return r.db.do(func(s *state) error {
+		if _, ok := s.users[id]; !ok {
+			return fmt.Errorf("repository/user: user with id %d not found: %w", id, lib.NotFound("user not found"))
+		}
+		delete(s.users, id)
+		for expenseID, e := range s.expenses {
+			if e.expense.UserID == id {
+				delete(s.expenses, expenseID)
+			}
+		}
+		for key := range s.imported {
+			if key.userID == id {
+				delete(s.imported, key)
+			}
+		}
+		for webhookID, w := range s.webhooks {
+			if w.UserID == id {
+				s.deleteWebhook(webhookID)
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateUsername changes a user's username.
+func (r *UserRepository) UpdateUsername(ctx context.Context, id int, input *model.UpdateUsernameInput) (*model.User, error) {
+	if input.Username == "" {
+		return nil, fmt.Errorf("repository/user: %w", lib.Validation("username cannot be empty",
+			lib.FieldError{Field: "username", Code: "required", Message: "username cannot be empty"}))
+	}
+
+	var updated *model.User
+	err := r.db.do(func(s *state) error {
+		u, ok := s.users[id]
+		if !ok {
+			return fmt.Errorf("repository/user: no such user to update: %w", lib.NotFound("user not found"))
+		}
+		if err := checkUsername(s, input.Username, id); err != nil {
+			return fmt.Errorf("repository/user: can't update user: %w", err)
+		}
+
+		u.Username = input.Username
+		s.users[id] = u
+		updated = &model.User{ID: u.ID, Username: u.Username}
+		return nil
+	})
+	return updated, err
+}
+
+// IsExistsUser checks if a user with given ID exists.
+func (r *UserRepository) IsExistsUser(ctx context.Context, id int) (bool, error) {
+	var exists bool
+	err := r.db.do(func(s *state) error {
+		_, exists = s.users[id]
+		return nil
+	})
+	return exists, err
+}
+
+// checkUsername enforces the VARCHAR(40) UNIQUE constraint, ignoring the user with exceptID.
+func checkUsername(s *state, username string, exceptID int) error {
+	if utf8.RuneCountInString(username) > repository.MaxUsernameLength {
+		return fmt.Errorf("username exceeds %d characters", repository.MaxUsernameLength)
+	}
+	for _, u := range s.users {
+		if u.ID != exceptID && u.Username == username {
+			return lib.Conflict("username is already taken")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,336 @@
+package memory
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"expense_tracker/lib"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// WebhookRepository is the in-memory implementation of repository.WebhookRepository.
+type WebhookRepository struct {
+	db *DB
+}
+
+var _ repository.WebhookRepository = (*WebhookRepository)(nil)
+
+// NewWebhookRepository creates a new instance of WebhookRepository.
+func NewWebhookRepository(db *DB) *WebhookRepository {
+	return &WebhookRepository{
+		db: db,
+	}
+}
+
+// CreateWebhook inserts a new active webhook.
+func (r *WebhookRepository) CreateWebhook(ctx context.Context, webhook model.Webhook) (*model.Webhook, error) {
+	var created model.Webhook
+	err := r.db.do(func(s *state) error {
+		if _, ok := s.users[webhook.UserID]; !ok {
+			return fmt.Errorf("repository/webhook: can't create webhook: user %d does not exist", webhook.UserID)
+		}
+
+		webhook.ID = s.nextWebhookID
+		s.nextWebhookID++
+		webhook.EventTypes = slices.Clone(webhook.EventTypes)
+		webhook.Active = true
+		webhook.ConsecutiveFailures = 0
+		webhook.DisabledAt = nil
+		webhook.CreatedAt = time.Now().UTC()
+		s.webhooks[webhook.ID] = webhook
+
+		created = copyWebhook(webhook)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetWebhook retrieves a webhook by its ID and associated user ID.
+func (r *WebhookRepository) GetWebhook(ctx context.Context, id, userID int) (*model.Webhook, error) {
+	var webhook model.Webhook
+	err := r.db.do(func(s *state) error {
+		w, ok := s.webhooks[id]
+		if !ok || w.UserID != userID {
+			return fmt.Errorf("repository/webhook: no such webhook: %w", lib.NotFound("webhook not found"))
+		}
+		webhook = copyWebhook(w)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListWebhooks retrieves all webhooks of a user ordered by ID.
+func (r *WebhookRepository) ListWebhooks(ctx context.Context, userID int) ([]model.Webhook, error) {
+	webhooks := []model.Webhook{}
+	err := r.db.do(func(s *state) error {
+		for _, w := range s.webhooks {
+			if w.UserID == userID {
+				webhooks = append(webhooks, copyWebhook(w))
+			}
+		}
+		return nil
+	})
+	slices.SortFunc(webhooks, func(a, b model.Webhook) int { return a.ID - b.ID })
+	return webhooks, err
+}
+
+// UpdateWebhook modifies the non-nil fields of a webhook.
+func (r *WebhookRepository) UpdateWebhook(ctx context.Context, id, userID int, input *model.UpdateWebhookInput) (*model.Webhook, error) {
+	var updated model.Webhook
+	err := r.db.do(func(s *state) error {
+		w, ok := s.webhooks[id]
+		if !ok || w.UserID != userID {
+			return fmt.Errorf("repository/webhook: no such webhook to update: %w", lib.NotFound("webhook not found"))
+		}
+
+		if input.URL != nil {
+			w.URL = *input.URL
+		}
+		if input.Secret != nil {
+			w.Secret = *input.Secret
+		}
+		if input.EventTypes != nil {
+			w.EventTypes = slices.Clone(*input.EventTypes)
+		}
+		if input.Active != nil {
+			w.Active = *input.Active
+			if w.Active {
+				w.ConsecutiveFailures = 0
+				w.DisabledAt = nil
+			}
+		}
+		s.webhooks[id] = w
+
+		updated = copyWebhook(w)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteWebhook removes a webhook and its deliveries.
+func (r *WebhookRepository) DeleteWebhook(ctx context.Context, id, userID int) error {
+	return r.db.do(func(s *state) error {
+		w, ok := s.webhooks[id]
+		if !ok || w.UserID != userID {
+			return fmt.Errorf("repository/webhook: webhook not found (id: %d, user_id: %d): %w", id, userID, lib.NotFound("webhook not found"))
+		}
+		s.deleteWebhook(id)
+		return nil
+	})
+}
+
+// ClaimDeliveries postpones due deliveries of active webhooks by the lease and returns them.
+func (r *WebhookRepository) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.PendingDelivery, error) {
+	var claimed []model.PendingDelivery
+	err := r.db.do(func(s *state) error {
+		var due []model.WebhookDelivery
+		for _, d := range s.deliveries {
+			if d.Status == model.DeliveryPending && !d.NextAttemptAt.After(now) && s.webhooks[d.WebhookID].Active {
+				due = append(due, d)
+			}
+		}
+		slices.SortFunc(due, func(a, b model.WebhookDelivery) int {
+			if c := a.NextAttemptAt.Compare(*b.NextAttemptAt); c != 0 {
+				return c
+			}
+			return int(a.ID - b.ID)
+		})
+
+		leaseEnd := now.Add(lease)
+		for _, d := range due[:min(limit, len(due))] {
+			d.NextAttemptAt = &leaseEnd
+			s.deliveries[d.ID] = d
+
+			w := s.webhooks[d.WebhookID]
+			claimed = append(claimed, model.PendingDelivery{
+				WebhookDelivery: copyDelivery(d, false),
+				URL:             w.URL,
+				Secret:          w.Secret,
+			})
+		}
+		return nil
+	})
+	return claimed, err
+}
+
+// RecordAttempt logs an attempt and updates the delivery and its webhook.
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, attempt model.WebhookAttempt, status model.DeliveryStatus, nextAttemptAt time.Time, disableAfter int) error {
+	return r.db.do(func(s *state) error {
+		d, ok := s.deliveries[attempt.DeliveryID]
+		if !ok {
+			return fmt.Errorf("repository/webhook: can't record attempt: %w", lib.NotFound("delivery not found"))
+		}
+
+		d.Log = append(slices.Clone(d.Log), attempt)
+		d.Status = status
+		d.Attempts++
+		d.ResponseStatus = attempt.ResponseStatus
+		d.NextAttemptAt, d.CompletedAt = nil, nil
+		if status == model.DeliveryPending {
+			d.NextAttemptAt = &nextAttemptAt
+		} else {
+			completed := attempt.AttemptedAt
+			d.CompletedAt = &completed
+		}
+		s.deliveries[d.ID] = d
+
+		w, ok := s.webhooks[d.WebhookID]
+		if !ok {
+			return nil
+		}
+		switch status {
+		case model.DeliverySucceeded:
+			w.ConsecutiveFailures = 0
+		case model.DeliveryFailed:
+			w.ConsecutiveFailures++
+			if w.Active && w.ConsecutiveFailures >= disableAfter {
+				disabled := attempt.AttemptedAt
+				w.Active = false
+				w.DisabledAt = &disabled
+			}
+		}
+		s.webhooks[w.ID] = w
+		return nil
+	})
+}
+
+// ListDeliveries retrieves the latest deliveries of a webhook, newest first.
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID, userID, limit int) ([]model.WebhookDelivery, error) {
+	deliveries := []model.WebhookDelivery{}
+	err := r.db.do(func(s *state) error {
+		if w, ok := s.webhooks[webhookID]; !ok || w.UserID != userID {
+			return nil
+		}
+		for _, d := range s.deliveries {
+			if d.WebhookID == webhookID {
+				deliveries = append(deliveries, copyDelivery(d, false))
+			}
+		}
+		return nil
+	})
+	slices.SortFunc(deliveries, func(a, b model.WebhookDelivery) int { return int(b.ID - a.ID) })
+	return deliveries[:min(limit, len(deliveries))], err
+}
+
+// GetDelivery retrieves a delivery with its attempts.
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id int64, webhookID, userID int) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := r.db.do(func(s *state) error {
+		d, ok := s.ownedDelivery(id, webhookID, userID)
+		if !ok {
+			return fmt.Errorf("repository/webhook: no such delivery: %w", lib.NotFound("delivery not found"))
+		}
+		delivery = copyDelivery(d, true)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// Redeliver copies a delivery into a new pending one.
+func (r *WebhookRepository) Redeliver(ctx context.Context, id int64, webhookID, userID int, now time.Time) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := r.db.do(func(s *state) error {
+		d, ok := s.ownedDelivery(id, webhookID, userID)
+		if !ok {
+			return fmt.Errorf("repository/webhook: no such delivery to redeliver: %w", lib.NotFound("delivery not found"))
+		}
+		delivery = copyDelivery(s.addDelivery(model.WebhookDelivery{
+			WebhookID: d.WebhookID,
+			EventType: d.EventType,
+			Payload:   d.Payload,
+		}, now), false)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// DeleteDeliveriesBefore removes the completed deliveries created before t.
+func (r *WebhookRepository) DeleteDeliveriesBefore(ctx context.Context, t time.Time) (int64, error) {
+	var n int64
+	err := r.db.do(func(s *state) error {
+		for id, d := range s.deliveries {
+			if d.Status != model.DeliveryPending && d.CreatedAt.Before(t) {
+				delete(s.deliveries, id)
+				n++
+			}
+		}
+		return nil
+	})
+	return n, err
+}
+
+// addDelivery stores d as a new pending delivery due at now.
+func (s *state) addDelivery(d model.WebhookDelivery, now time.Time) model.WebhookDelivery {
+	d.ID = s.nextDeliveryID
+	s.nextDeliveryID++
+	d.Status = model.DeliveryPending
+	d.NextAttemptAt = &now
+	d.CreatedAt = now
+	s.deliveries[d.ID] = d
+	return d
+}
+
+// ownedDelivery returns a delivery of a webhook of the user.
+func (s *state) ownedDelivery(id int64, webhookID, userID int) (model.WebhookDelivery, bool) {
+	d, ok := s.deliveries[id]
+	if !ok || d.WebhookID != webhookID || s.webhooks[webhookID].UserID != userID {
+		return model.WebhookDelivery{}, false
+	}
+	return d, true
+}
+
+// deleteWebhook removes a webhook and its deliveries.
+func (s *state) deleteWebhook(id int) {
+	delete(s.webhooks, id)
+	for deliveryID, d := range s.deliveries {
+		if d.WebhookID == id {
+			delete(s.deliveries, deliveryID)
+		}
+	}
+}
+
+// copyWebhook returns a copy of w that shares no memory with the stored one.
+func copyWebhook(w model.Webhook) model.Webhook {
+	w.EventTypes = slices.Clone(w.EventTypes)
+	if w.DisabledAt != nil {
+		disabled := *w.DisabledAt
+		w.DisabledAt = &disabled
+	}
+	return w
+}
+
+// copyDelivery returns a copy of d, with its log if withLog is set.
+func copyDelivery(d model.WebhookDelivery, withLog bool) model.WebhookDelivery {
+	log := d.Log
+	d.Payload = slices.Clone(d.Payload)
+	if d.NextAttemptAt != nil {
+		next := *d.NextAttemptAt
+		d.NextAttemptAt = &next
+	}
+	if d.CompletedAt != nil {
+		completed := *d.CompletedAt
+		d.CompletedAt = &completed
+	}
+	d.Log = nil
+	if withLog {
+		d.Log = slices.Clone(log)
+	}
+	return d
+}
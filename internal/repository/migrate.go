@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// MigrationStatus describes the state of the database schema.
+type MigrationStatus struct {
+	Version uint
+	Dirty   bool
+	Latest  uint
+	Pending int
+}
+
+// Migrator applies schema migrations to a database.
+type Migrator struct {
+	m      *migrate.Migrate
+	source source.Driver
+}
+
+// NewMigrator creates a Migrator that applies the migrations in directory dir
+// of fsys to the database at dbURL. The golang-migrate driver for the URL
+// scheme must be registered by the caller, usually the backend package.
+func NewMigrator(fsys fs.FS, dir, dbURL string) (*Migrator, error) {
+	src, err := iofs.New(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("repository: can't open migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, dbURL)
+	if err != nil {
+		return nil, fmt.Errorf("repository: migration init failed: %w", err)
+	}
+	return &Migrator{m: m, source: src}, nil
+}
+
+// NewMigratorWithInstance is like NewMigrator but uses an already opened
+// golang-migrate database driver registered under databaseName.
+func NewMigratorWithInstance(fsys fs.FS, dir, databaseName string, driver database.Driver) (*Migrator, error) {
+	src, err := iofs.New(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("repository: can't open migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, databaseName, driver)
+	if err != nil {
+		return nil, fmt.Errorf("repository: migration init failed: %w", err)
+	}
+	return &Migrator{m: m, source: src}, nil
+}
+
+// Close releases the source and database connections.
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up() error {
+	if err := m.m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("repository: migration up failed: %w", err)
+	}
+	return nil
+}
+
+// Down rolls back the given number of migrations.
+func (m *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("repository: down steps must be positive")
+	}
+	if err := m.m.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("repository: migration down failed: %w", err)
+	}
+	return nil
+}
+
+// To migrates up or down to the given version.
+func (m *Migrator) To(version uint) error {
+	if err := m.m.Migrate(version); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("repository: migration to %d failed: %w", version, err)
+	}
+	return nil
+}
+
+// Force sets the schema version without running migrations and clears the dirty flag.
+// It is used to recover after a failed migration has been fixed by hand.
+func (m *Migrator) Force(version int) error {
+	if err := m.m.Force(version); err != nil {
+		return fmt.Errorf("repository: force version %d failed: %w", version, err)
+	}
+	return nil
+}
+
+// Status reports the current and latest schema versions.
+func (m *Migrator) Status() (*MigrationStatus, error) {
+	status := &MigrationStatus{}
+
+	version, dirty, err := m.m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return nil, fmt.Errorf("repository: can't read schema version: %w", err)
+	}
+	status.Version, status.Dirty = version, dirty
+
+	versions, err := sourceVersions(m.source)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if v > status.Version {
+			status.Pending++
+		}
+	}
+	if len(versions) > 0 {
+		status.Latest = versions[len(versions)-1]
+	}
+	return status, nil
+}
+
+// LatestMigrationVersion returns the version of the newest migration in
+// directory dir of fsys, which is the schema version the running binary expects.
+func LatestMigrationVersion(fsys fs.FS, dir string) (uint, error) {
+	src, err := iofs.New(fsys, dir)
+	if err != nil {
+		return 0, fmt.Errorf("repository: can't open migrations: %w", err)
+	}
+	defer src.Close()
+
+	versions, err := sourceVersions(src)
+	if err != nil {
+		return 0, err
+	}
+	if len(versions) == 0 {
+		return 0, fmt.Errorf("repository: no migrations found")
+	}
+	return versions[len(versions)-1], nil
+}
+
+// sourceVersions lists the versions available in src in ascending order.
+func sourceVersions(src source.Driver) ([]uint, error) {
+	version, err := src.First()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository: can't read migrations: %w", err)
+	}
+
+	versions := []uint{version}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return versions, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("repository: can't read migrations: %w", err)
+		}
+		versions = append(versions, next)
+		version = next
+	}
+}
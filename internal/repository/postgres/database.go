@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"expense_tracker/internal/config"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// The Database struct provides a connection pool to PostgreSQL.
+type Database struct {
+	Pool *pgxpool.Pool
+}
+
+// querier is the set of query methods shared by *pgxpool.Pool and pgx.Tx,
+// so repositories can run the same statements inside or outside a transaction.
+type querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// uniqueViolation is the PostgreSQL SQLSTATE for unique constraint violations.
+const uniqueViolation = "23505"
+
+// isUniqueViolation reports whether err was caused by a unique constraint violation.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
+// NewDB initializes a new PostgreSQL connection pool.
+func NewDB(ctx context.Context, cfg *config.Config) (*Database, error) {
+
+	poolConfig, err := pgxpool.ParseConfig(cfg.DBURL)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to parse DB URL: %w", err)
+	}
+
+	poolConfig.ConnConfig.Tracer = queryTracer{}
+	if cfg.DBMaxConns > 0 {
+		poolConfig.MaxConns = int32(cfg.DBMaxConns)
+	}
+	if cfg.DBMinConns > 0 {
+		poolConfig.MinConns = int32(cfg.DBMinConns)
+	}
+
+	poolConfig.AfterConnect = func(ctx context.Context, c *pgx.Conn) error {
+		c.TypeMap().RegisterType(&pgtype.Type{
+			Name:  "date",
+			Codec: pgtype.DateCodec{},
+		})
+		return nil
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("repository: unable to create connection pool: %w", err)
+	}
+
+	return &Database{Pool: pool}, nil
+}
+
+// Close closes all connections in the pool.
+func (db *Database) Close() {
+	db.Pool.Close()
+}
+
+// Stat returns a snapshot of the connection pool statistics.
+func (db *Database) Stat() *pgxpool.Stat {
+	return db.Pool.Stat()
+}
+
+// Ping verifies that a connection to the database can be acquired and used.
+func (db *Database) Ping(ctx context.Context) error {
+	if err := db.Pool.Ping(ctx); err != nil {
+		return fmt.Errorf("repository: database ping failed: %w", err)
+	}
+	return nil
+}
+
+// SchemaVersion returns the migration version recorded by golang-migrate and
+// whether the last migration left the schema dirty.
+func (db *Database) SchemaVersion(ctx context.Context) (uint, bool, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+	q := `SELECT version, dirty FROM schema_migrations LIMIT 1`
+	err := db.Pool.QueryRow(ctx, q).Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("repository: can't read schema version: %w", err)
+	}
+	return uint(version), dirty, nil
+}
@@ -0,0 +1,255 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"expense_tracker/lib"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ExpenseRepository is the PostgreSQL implementation of repository.ExpenseRepository.
+type ExpenseRepository struct {
+	db   *Database
+	conn querier
+}
+
+var _ repository.ExpenseRepository = (*ExpenseRepository)(nil)
+
+// NewExpenseRepository creates a new instance of ExpenseRepository.
+func NewExpenseRepository(db *Database) *ExpenseRepository {
+	return &ExpenseRepository{
+		db:   db,
+		conn: db.Pool,
+	}
+}
+
+// WithTx runs fn inside a database transaction. The repository passed to fn
+// executes every query in that transaction; it is committed if fn returns nil
+// and rolled back otherwise. Calling WithTx on a repository that is already
+// bound to a transaction creates a savepoint.
+func (r *ExpenseRepository) WithTx(ctx context.Context, fn func(tx repository.ExpenseRepository) error) error {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("repository/expense: can't begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(&ExpenseRepository{db: r.db, conn: tx}); err != nil {
+		lib.Logger(ctx).Debug("repository/expense: transaction rolled back", "error", err)
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("repository/expense: can't commit transaction: %w", err)
+	}
+	return nil
+}
+
+// CreateExpense inserts a new expense record into the database.
+func (r *ExpenseRepository) CreateExpense(ctx context.Context, expense model.Expense) (int, error) {
+	var id int
+	q := `INSERT INTO expenses (user_id, amount, category, description, date, tags)
+		VALUES ($1, $2, $3, $4, $5, COALESCE($6, '{}')) RETURNING id`
+
+	err := r.conn.QueryRow(ctx, q, expense.UserID, expense.Amount, expense.Category, expense.Description, expense.Date, expense.Tags).Scan(&id)
+
+	if err != nil {
+		return 0, fmt.Errorf("repository/expense: can't create expense: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetExpenseByID retrieves an expense by its ID and associated user ID.
+func (r *ExpenseRepository) GetExpenseByID(ctx context.Context, id int, userID int) (*model.Expense, error) {
+	expense := &model.Expense{}
+	q := `SELECT id, user_id, amount, category, description, date, tags FROM expenses WHERE id = $1 and user_id = $2`
+	err := r.conn.QueryRow(ctx, q, id, userID).Scan(&expense.ID, &expense.UserID, &expense.Amount, &expense.Category, &expense.Description, &expense.Date, &expense.Tags)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("repository/expense: no such expense: %w", lib.NotFound("expense not found"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't found expense: %w", err)
+	}
+	return expense, nil
+}
+
+// IsExists checks if an expense with given ID exists for specified user.
+func (r *ExpenseRepository) IsExists(ctx context.Context, id int, userID int) (bool, error) {
+	var count int
+	q := `SELECT COUNT(*) FROM expenses WHERE id = $1 AND user_id = $2`
+	err := r.conn.QueryRow(ctx, q, id, userID).Scan(&count)
+
+	if err != nil {
+		return false, fmt.Errorf("repository/expense: can't check existanse of the expense: %w", err)
+	}
+	return count > 0, nil
+}
+
+// UpdateExpense modifies an existing expense record.
+func (r *ExpenseRepository) UpdateExpense(ctx context.Context, id int, userID int, input *model.UpdateExpenseInput) (*model.Expense, error) {
+	updated := &model.Expense{}
+
+	q := `UPDATE expenses SET amount = COALESCE($1, amount), category = COALESCE($2, category), 
+	description = COALESCE($3, description), date = COALESCE($4, date), tags = COALESCE($5, tags)
+	WHERE id = $6 and user_id = $7 RETURNING id, user_id, amount, category, description, date, tags`
+
+	err := r.conn.QueryRow(ctx, q, input.Amount, input.Category,
+		input.Description, input.Date, input.Tags, id, userID).Scan(&updated.ID, &updated.UserID,
+		&updated.Amount, &updated.Category, &updated.Description, &updated.Date, &updated.Tags)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("repository/expense: no such expense to update: %w", lib.NotFound("expense not found"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't update expense: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteExpense removes an expense record by ID.
+func (r *ExpenseRepository) DeleteExpense(ctx context.Context, id int, userID int) error {
+	q := `DELETE FROM expenses WHERE id = $1 AND user_id = $2`
+	result, err := r.conn.Exec(ctx, q, id, userID)
+	if err != nil {
+		return fmt.Errorf("repository/expense: can`t delete expanse: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("repository/expense: expanse not found (id: %d, user_id: %d): %w", id, userID, lib.NotFound("expense not found"))
+	}
+	return nil
+}
+
+// GetExpensesList retrieves all expenses for a specific user.
+func (r *ExpenseRepository) GetExpensesList(ctx context.Context, userID int) ([]model.Expense, error) {
+	q := `SELECT id, user_id, amount, category, description, date, tags FROM expenses
+	WHERE user_id = $1 ORDER BY id`
+
+	rows, err := r.conn.Query(ctx, q, userID)
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't get list of expenses: %w", err)
+	}
+	defer rows.Close()
+
+	return scanExpenses(rows)
+}
+
+// GetExpensesByPeriod retrieves expenses for a user within a specific date range.
+func (r *ExpenseRepository) GetExpensesByPeriod(ctx context.Context, userID int, start, end time.Time) ([]model.Expense, error) {
+	q := `SELECT id, user_id, amount, category, description, date, tags FROM expenses
+	WHERE user_id = $1 and date BETWEEN $2 AND $3 ORDER BY date, id`
+
+	rows, err := r.conn.Query(ctx, q, userID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't get expenses by period: %w", err)
+	}
+	defer rows.Close()
+
+	return scanExpenses(rows)
+}
+
+// GetExpensesByCategory retrieves expenses for a user in a specific category.
+func (r *ExpenseRepository) GetExpensesByCategory(ctx context.Context, userID int, category string) ([]model.Expense, error) {
+	q := `SELECT id, user_id, amount, category, description, date, tags FROM expenses
+	WHERE user_id = $1 and category = $2 ORDER BY date, id`
+
+	rows, err := r.conn.Query(ctx, q, userID, category)
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't get expenses by category: %w", err)
+	}
+	defer rows.Close()
+
+	return scanExpenses(rows)
+}
+
+// FindExpenses retrieves the expenses of a user matching the filter.
+func (r *ExpenseRepository) FindExpenses(ctx context.Context, userID int, f *model.ExpenseFilter) ([]model.Expense, error) {
+	q := `SELECT id, user_id, amount, category, description, date, tags FROM expenses
+	WHERE user_id = $1
+		AND (cardinality($2::int[]) = 0 OR id = ANY($2::int[]))
+		AND ($3::text IS NULL OR category = $3)
+		AND ($4::date IS NULL OR date >= $4)
+		AND ($5::date IS NULL OR date <= $5)
+		AND ($6::text IS NULL OR $6 = ANY(tags))
+	ORDER BY date, id`
+
+	rows, err := r.conn.Query(ctx, q, userID, nonNilInts(f.IDs), f.Category, f.Start, f.End, f.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't find expenses: %w", err)
+	}
+	defer rows.Close()
+
+	return scanExpenses(rows)
+}
+
+// BulkUpdate applies a category change and/or tag edits to every expense of the user
+// matching the filter and returns the number of affected rows.
+func (r *ExpenseRepository) BulkUpdate(ctx context.Context, userID int, input *model.BulkUpdateInput) (int64, error) {
+	f := input.Filter
+	q := `UPDATE expenses SET category = COALESCE($1, category),
+	tags = ARRAY(SELECT DISTINCT t FROM unnest(array_cat(tags, $2::text[])) AS t
+		WHERE NOT t = ANY($3::text[]) ORDER BY t)
+	WHERE user_id = $4
+		AND (cardinality($5::int[]) = 0 OR id = ANY($5::int[]))
+		AND ($6::text IS NULL OR category = $6)
+		AND ($7::date IS NULL OR date >= $7)
+		AND ($8::date IS NULL OR date <= $8)
+		AND ($9::text IS NULL OR $9 = ANY(tags))`
+
+	result, err := r.conn.Exec(ctx, q, input.SetCategory, nonNil(input.AddTags), nonNil(input.RemoveTags),
+		userID, nonNilInts(f.IDs), f.Category, f.Start, f.End, f.Tag)
+	if err != nil {
+		return 0, fmt.Errorf("repository/expense: can't bulk update expenses: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// nonNil turns a nil slice into an empty one so it is encoded as '{}' rather than NULL.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// nonNilInts is the []int counterpart of nonNil.
+func nonNilInts(s []int) []int {
+	if s == nil {
+		return []int{}
+	}
+	return s
+}
+
+// scanExpenses is a helper function to scan multiple expense rows from a query result.
+func scanExpenses(rows pgx.Rows) ([]model.Expense, error) {
+	var expenses []model.Expense
+
+	for rows.Next() {
+		var e model.Expense
+		err := rows.Scan(
+			&e.ID,
+			&e.UserID,
+			&e.Amount,
+			&e.Category,
+			&e.Description,
+			&e.Date,
+			&e.Tags,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("repository/expense: can't scan expense row: %w", err)
+		}
+		expenses = append(expenses, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository/expense: rows ineration error: %w", err)
+	}
+
+	return expenses, nil
+}
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// MarkImported records the IDs of transactions imported from a statement of
+// the account and returns the ones not recorded before. Concurrent
+// transactions inserting the same key wait for each other on the primary key.
+func (r *ExpenseRepository) MarkImported(ctx context.Context, userID int, account string, ids []string) ([]string, error) {
+	q := `INSERT INTO imported_transactions (user_id, account, external_id)
+		SELECT $1, $2, id FROM unnest($3::text[]) AS id
+		ON CONFLICT DO NOTHING
+		RETURNING external_id`
+
+	rows, err := r.conn.Query(ctx, q, userID, account, nonNil(ids))
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't mark transactions imported: %w", err)
+	}
+	marked, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't mark transactions imported: %w", err)
+	}
+	return marked, nil
+}
+
+// ImportedIDs returns the IDs recorded by MarkImported for the account among ids.
+func (r *ExpenseRepository) ImportedIDs(ctx context.Context, userID int, account string, ids []string) ([]string, error) {
+	q := `SELECT external_id FROM imported_transactions
+		WHERE user_id = $1 AND account = $2 AND external_id = ANY($3::text[])`
+
+	rows, err := r.conn.Query(ctx, q, userID, account, nonNil(ids))
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't get imported transactions: %w", err)
+	}
+	imported, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't get imported transactions: %w", err)
+	}
+	return imported, nil
+}
@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"expense_tracker/internal/repository"
+	"expense_tracker/migrations"
+
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+)
+
+// NewMigrator creates a Migrator for the database at dbURL using the
+// PostgreSQL migrations embedded in the binary.
+func NewMigrator(dbURL string) (*repository.Migrator, error) {
+	return repository.NewMigrator(migrations.FS, ".", dbURL)
+}
+
+// LatestMigrationVersion returns the version of the newest embedded PostgreSQL migration.
+func LatestMigrationVersion() (uint, error) {
+	return repository.LatestMigrationVersion(migrations.FS, ".")
+}
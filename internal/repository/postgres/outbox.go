@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"fmt"
+	"time"
+)
+
+// EnqueueDeliveries adds a delivery of every event for every active webhook
+// of the user subscribed to its type, in a single statement however many
+// events there are.
+func (r *ExpenseRepository) EnqueueDeliveries(ctx context.Context, userID int, events []model.OutboxEvent, now time.Time) (int, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+	types := make([]string, len(events))
+	payloads := make([]string, len(events))
+	for i, e := range events {
+		types[i], payloads[i] = string(e.Type), string(e.Payload)
+	}
+
+	q := `INSERT INTO webhook_deliveries (webhook_id, event_type, payload, next_attempt_at, created_at)
+	SELECT w.id, e.type, e.payload, $4::timestamptz, $4::timestamptz
+	FROM unnest($2::text[], $3::text[]) WITH ORDINALITY AS e(type, payload, n)
+	JOIN webhooks w ON w.user_id = $1 AND w.active AND e.type = ANY(w.event_types)
+	ORDER BY e.n, w.id`
+
+	result, err := r.conn.Exec(ctx, q, userID, types, payloads, now)
+	if err != nil {
+		return 0, fmt.Errorf("repository/expense: can't enqueue webhook deliveries: %w", err)
+	}
+	return int(result.RowsAffected()), nil
+}
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"fmt"
+	"strings"
+)
+
+// SearchExpenses runs a full-text search over the description and category of the user's
+// expenses, optionally narrowed by category and date range, ordered by relevance.
+func (r *ExpenseRepository) SearchExpenses(ctx context.Context, userID int, query *model.SearchQuery) ([]model.SearchResult, error) {
+	tsquery := buildTSQuery(query.Query)
+	if tsquery == "" {
+		return []model.SearchResult{}, nil
+	}
+
+	// The description is escaped like html.EscapeString before the matches
+	// are marked, so that the snippet is safe to render as HTML.
+	q := `SELECT id, user_id, amount, category, description, date, tags,
+		ts_rank(search_vector, query) AS rank,
+		ts_headline('simple', replace(replace(replace(replace(replace(coalesce(description, ''),
+				'&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&#34;'), '''', '&#39;'), query,
+			'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5') AS snippet
+	FROM expenses, to_tsquery('simple', $2) AS query
+	WHERE user_id = $1 AND search_vector @@ query
+		AND ($3::text IS NULL OR category = $3)
+		AND ($4::date IS NULL OR date >= $4)
+		AND ($5::date IS NULL OR date <= $5)
+	ORDER BY rank DESC, date DESC, id DESC
+	LIMIT $6 OFFSET $7`
+
+	rows, err := r.conn.Query(ctx, q, userID, tsquery, query.Category, query.Start, query.End, query.Limit, query.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't search expenses: %w", err)
+	}
+	defer rows.Close()
+
+	results := []model.SearchResult{}
+	for rows.Next() {
+		var res model.SearchResult
+		err := rows.Scan(
+			&res.ID,
+			&res.UserID,
+			&res.Amount,
+			&res.Category,
+			&res.Description,
+			&res.Date,
+			&res.Tags,
+			&res.Rank,
+			&res.Snippet,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("repository/expense: can't scan search row: %w", err)
+		}
+		results = append(results, res)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository/expense: rows ineration error: %w", err)
+	}
+
+	return results, nil
+}
+
+// buildTSQuery converts a user search string into a to_tsquery expression.
+// Prefix terms become 'ikea':*, phrase terms become followed-by chains
+// ('blue' <-> 'lamp') and all terms are AND-ed together. Terms contain only
+// letters and digits, so the result is always a syntactically valid tsquery.
+func buildTSQuery(input string) string {
+	var parts []string
+
+	for _, term := range repository.ParseSearchQuery(input) {
+		if !term.Phrase {
+			parts = append(parts, "'"+term.Words[0]+"':*")
+			continue
+		}
+
+		words := make([]string, len(term.Words))
+		for i, w := range term.Words {
+			words[i] = "'" + w + "'"
+		}
+		parts = append(parts, "("+strings.Join(words, " <-> ")+")")
+	}
+
+	return strings.Join(parts, " & ")
+}
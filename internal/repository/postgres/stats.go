@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"fmt"
+)
+
+// StatsRepository is the PostgreSQL implementation of repository.StatsRepository.
+type StatsRepository struct {
+	db *Database
+}
+
+var _ repository.StatsRepository = (*StatsRepository)(nil)
+
+// NewStatsRepository creates a new instance of StatsRepository.
+func NewStatsRepository(db *Database) *StatsRepository {
+	return &StatsRepository{
+		db: db,
+	}
+}
+
+// Stats returns the current user and expense counts.
+func (r *StatsRepository) Stats(ctx context.Context) (*model.Stats, error) {
+	stats := &model.Stats{}
+	q := `SELECT
+		(SELECT COUNT(*) FROM users),
+		(SELECT COUNT(DISTINCT user_id) FROM expenses WHERE created_at >= now() - make_interval(days => $1)),
+		(SELECT COUNT(*) FROM expenses),
+		(SELECT COUNT(*) FROM expenses WHERE created_at >= date_trunc('day', now()))`
+
+	err := r.db.Pool.QueryRow(ctx, q, repository.ActiveUserDays).Scan(&stats.Users, &stats.ActiveUsers,
+		&stats.ExpensesTotal, &stats.ExpensesCreatedToday)
+	if err != nil {
+		return nil, fmt.Errorf("repository/stats: can't get stats: %w", err)
+	}
+	return stats, nil
+}
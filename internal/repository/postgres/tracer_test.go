@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestQueryTracer(t *testing.T) {
+	tests := []struct {
+		name      string
+		sql       string
+		tag       string
+		err       error
+		span      string
+		operation string
+		status    codes.Code
+		rows      int64
+	}{
+		{
+			name:      "update",
+			sql:       "update expenses SET amount = $1 WHERE user_id = $2",
+			tag:       "UPDATE 3",
+			span:      "db UPDATE",
+			operation: "UPDATE",
+			rows:      3,
+		},
+		{
+			name:      "no rows isn't a failure",
+			sql:       "SELECT id FROM users WHERE username = $1",
+			err:       pgx.ErrNoRows,
+			span:      "db SELECT",
+			operation: "SELECT",
+		},
+		{
+			name:      "failed query",
+			sql:       "\n\tINSERT INTO expenses (amount) VALUES ($1)",
+			err:       errors.New("connection reset"),
+			span:      "db INSERT",
+			operation: "INSERT",
+			status:    codes.Error,
+		},
+		{
+			name:      "empty statement",
+			sql:       "  ",
+			span:      "db QUERY",
+			operation: "QUERY",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := tracetest.NewInMemoryExporter()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+			previous := otel.GetTracerProvider()
+			otel.SetTracerProvider(provider)
+			t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+			var tracer queryTracer
+			ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{
+				SQL:  tt.sql,
+				Args: []any{"secret"},
+			})
+			tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{
+				CommandTag: pgconn.NewCommandTag(tt.tag),
+				Err:        tt.err,
+			})
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("got %d spans, want 1", len(spans))
+			}
+			span := spans[0]
+			if span.Name != tt.span {
+				t.Errorf("span name %q, want %q", span.Name, tt.span)
+			}
+			if span.SpanKind != trace.SpanKindClient {
+				t.Errorf("span kind %v, want client", span.SpanKind)
+			}
+			if span.Status.Code != tt.status {
+				t.Errorf("status %v, want %v", span.Status.Code, tt.status)
+			}
+
+			attrs := map[attribute.Key]attribute.Value{}
+			for _, kv := range span.Attributes {
+				attrs[kv.Key] = kv.Value
+			}
+			want := map[attribute.Key]string{
+				"db.system":         "postgresql",
+				"db.operation.name": tt.operation,
+				"db.query.text":     tt.sql,
+			}
+			for key, value := range want {
+				if got := attrs[key].AsString(); got != value {
+					t.Errorf("%s = %q, want %q", key, got, value)
+				}
+			}
+			for _, kv := range span.Attributes {
+				if kv.Value.Emit() == "secret" {
+					t.Errorf("argument recorded in %s", kv.Key)
+				}
+			}
+			rows, ok := attrs["db.response.rows_affected"]
+			if tt.status == codes.Error {
+				if ok {
+					t.Error("rows affected recorded for a failed query")
+				}
+			} else if rows.AsInt64() != tt.rows {
+				t.Errorf("rows affected %d, want %d", rows.AsInt64(), tt.rows)
+			}
+		})
+	}
+}
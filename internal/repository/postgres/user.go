@@ -1,18 +1,23 @@
-package repository
+package postgres
 
 import (
 	"context"
+	"errors"
 	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"expense_tracker/lib"
 	"fmt"
 
-	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/v5"
 )
 
-// UserRepository provides data access methods for user operations.
+// UserRepository is the PostgreSQL implementation of repository.UserRepository.
 type UserRepository struct {
 	db *Database
 }
 
+var _ repository.UserRepository = (*UserRepository)(nil)
+
 // NewUserRepository creates a new instance of UserRepository.
 func NewUserRepository(db *Database) *UserRepository {
 	return &UserRepository{
@@ -25,6 +30,10 @@ func (r *UserRepository) CreateUser(ctx context.Context, user *model.User) error
 	q := `INSERT INTO users (username, password) VALUES ($1, $2) RETURNING id`
 	err := r.db.Pool.QueryRow(ctx, q, user.Username, user.Password).Scan(&user.ID)
 
+	if isUniqueViolation(err) {
+		lib.Logger(ctx).Debug("repository/user: username already taken", "username", user.Username)
+		return fmt.Errorf("repository/user: can't create user: %w", lib.Conflict("username is already taken"))
+	}
 	if err != nil {
 		return fmt.Errorf("repository/user: can't create user: %w", err)
 	}
@@ -37,6 +46,9 @@ func (r *UserRepository) GetUserByName(ctx context.Context, username string) (*m
 	user := model.User{}
 	err := r.db.Pool.QueryRow(ctx, q, username).Scan(&user.ID, &user.Username, &user.Password)
 
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("repository/user: can't get user by name: %w", lib.NotFound("user not found"))
+	}
 	if err != nil {
 		return nil, fmt.Errorf("repository/user: can't get user by name: %w", err)
 	}
@@ -49,6 +61,9 @@ func (r *UserRepository) GetUserById(ctx context.Context, id int) (*model.User,
 	user := model.User{}
 	err := r.db.Pool.QueryRow(ctx, q, id).Scan(&user.ID, &user.Username, &user.Password)
 
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("repository/user: can't get user by id: %w", lib.NotFound("user not found"))
+	}
 	if err != nil {
 		return nil, fmt.Errorf("repository/user: can't get user by id: %w", err)
 	}
@@ -65,7 +80,7 @@ func (r *UserRepository) DeleteUser(ctx context.Context, id int) error {
 	}
 
 	if result.RowsAffected() == 0 {
-		return fmt.Errorf("repository/user: user with id %d not found", id)
+		return fmt.Errorf("repository/user: user with id %d not found: %w", id, lib.NotFound("user not found"))
 	}
 	return nil
 }
@@ -73,7 +88,8 @@ func (r *UserRepository) DeleteUser(ctx context.Context, id int) error {
 // UpdateUsername changes a user's username.
 func (r *UserRepository) UpdateUsername(ctx context.Context, id int, input *model.UpdateUsernameInput) (*model.User, error) {
 	if input.Username == "" {
-		return nil, fmt.Errorf("repository/user: username cannot be empty")
+		return nil, fmt.Errorf("repository/user: %w", lib.Validation("username cannot be empty",
+			lib.FieldError{Field: "username", Code: "required", Message: "username cannot be empty"}))
 	}
 
 	updated := model.User{}
@@ -81,8 +97,11 @@ func (r *UserRepository) UpdateUsername(ctx context.Context, id int, input *mode
 
 	err := r.db.Pool.QueryRow(ctx, q, input.Username,
 		id).Scan(&updated.ID, &updated.Username)
-	if err == pgx.ErrNoRows {
-		return nil, fmt.Errorf("repository/user: no such user to update: %w", err)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("repository/user: no such user to update: %w", lib.NotFound("user not found"))
+	}
+	if isUniqueViolation(err) {
+		return nil, fmt.Errorf("repository/user: can't update user: %w", lib.Conflict("username is already taken"))
 	}
 	if err != nil {
 		return nil, fmt.Errorf("repository/user: can't update user: %w", err)
@@ -0,0 +1,339 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"expense_tracker/lib"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// webhookColumns and deliveryColumns are the column lists read by scanWebhook and scanDelivery.
+const (
+	webhookColumns  = `id, user_id, url, secret, event_types, active, consecutive_failures, disabled_at, created_at`
+	deliveryColumns = `id, webhook_id, event_type, payload, status, attempts, response_status, next_attempt_at, created_at, completed_at`
+)
+
+// WebhookRepository is the PostgreSQL implementation of repository.WebhookRepository.
+type WebhookRepository struct {
+	db *Database
+}
+
+var _ repository.WebhookRepository = (*WebhookRepository)(nil)
+
+// NewWebhookRepository creates a new instance of WebhookRepository.
+func NewWebhookRepository(db *Database) *WebhookRepository {
+	return &WebhookRepository{
+		db: db,
+	}
+}
+
+// CreateWebhook inserts a new active webhook.
+func (r *WebhookRepository) CreateWebhook(ctx context.Context, webhook model.Webhook) (*model.Webhook, error) {
+	q := `INSERT INTO webhooks (user_id, url, secret, event_types) VALUES ($1, $2, $3, $4)
+	RETURNING ` + webhookColumns
+
+	created, err := scanWebhook(r.db.Pool.QueryRow(ctx, q, webhook.UserID, webhook.URL, webhook.Secret,
+		eventTypeStrings(webhook.EventTypes)))
+	if err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't create webhook: %w", err)
+	}
+	return created, nil
+}
+
+// GetWebhook retrieves a webhook by its ID and associated user ID.
+func (r *WebhookRepository) GetWebhook(ctx context.Context, id, userID int) (*model.Webhook, error) {
+	q := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1 AND user_id = $2`
+	webhook, err := scanWebhook(r.db.Pool.QueryRow(ctx, q, id, userID))
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("repository/webhook: no such webhook: %w", lib.NotFound("webhook not found"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't get webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// ListWebhooks retrieves all webhooks of a user.
+func (r *WebhookRepository) ListWebhooks(ctx context.Context, userID int) ([]model.Webhook, error) {
+	q := `SELECT ` + webhookColumns + ` FROM webhooks WHERE user_id = $1 ORDER BY id`
+	rows, err := r.db.Pool.Query(ctx, q, userID)
+	if err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []model.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repository/webhook: can't scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't list webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// UpdateWebhook modifies an existing webhook.
+func (r *WebhookRepository) UpdateWebhook(ctx context.Context, id, userID int, input *model.UpdateWebhookInput) (*model.Webhook, error) {
+	var eventTypes []string
+	if input.EventTypes != nil {
+		eventTypes = eventTypeStrings(*input.EventTypes)
+	}
+
+	q := `UPDATE webhooks SET url = COALESCE($1, url), secret = COALESCE($2, secret),
+		event_types = COALESCE($3, event_types), active = COALESCE($4, active),
+		consecutive_failures = CASE WHEN $4::boolean THEN 0 ELSE consecutive_failures END,
+		disabled_at = CASE WHEN $4::boolean THEN NULL ELSE disabled_at END
+	WHERE id = $5 AND user_id = $6 RETURNING ` + webhookColumns
+
+	updated, err := scanWebhook(r.db.Pool.QueryRow(ctx, q, input.URL, input.Secret, eventTypes, input.Active, id, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("repository/webhook: no such webhook to update: %w", lib.NotFound("webhook not found"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't update webhook: %w", err)
+	}
+	return updated, nil
+}
+
+// DeleteWebhook removes a webhook; its deliveries are removed by the foreign key cascade.
+func (r *WebhookRepository) DeleteWebhook(ctx context.Context, id, userID int) error {
+	q := `DELETE FROM webhooks WHERE id = $1 AND user_id = $2`
+	result, err := r.db.Pool.Exec(ctx, q, id, userID)
+	if err != nil {
+		return fmt.Errorf("repository/webhook: can't delete webhook: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("repository/webhook: webhook not found (id: %d, user_id: %d): %w", id, userID, lib.NotFound("webhook not found"))
+	}
+	return nil
+}
+
+// ClaimDeliveries postpones due deliveries by the lease and returns them.
+// SKIP LOCKED lets dispatchers of other instances claim the next ones
+// instead of waiting.
+func (r *WebhookRepository) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.PendingDelivery, error) {
+	q := `WITH due AS (
+		SELECT d.id FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= $1 AND w.active
+		ORDER BY d.next_attempt_at, d.id
+		LIMIT $3
+		FOR UPDATE OF d SKIP LOCKED
+	)
+	UPDATE webhook_deliveries d SET next_attempt_at = $2
+	FROM due, webhooks w
+	WHERE d.id = due.id AND w.id = d.webhook_id
+	RETURNING d.id, d.webhook_id, d.event_type, d.payload, d.status, d.attempts, d.response_status,
+		d.next_attempt_at, d.created_at, d.completed_at, w.url, w.secret`
+
+	rows, err := r.db.Pool.Query(ctx, q, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't claim deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var claimed []model.PendingDelivery
+	for rows.Next() {
+		var p model.PendingDelivery
+		delivery, err := scanDelivery(rows, &p.URL, &p.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("repository/webhook: can't scan delivery: %w", err)
+		}
+		p.WebhookDelivery = *delivery
+		claimed = append(claimed, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't claim deliveries: %w", err)
+	}
+	return claimed, nil
+}
+
+// RecordAttempt logs an attempt and updates the delivery and its webhook in one transaction.
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, attempt model.WebhookAttempt, status model.DeliveryStatus, nextAttemptAt time.Time, disableAfter int) error {
+	err := pgx.BeginFunc(ctx, r.db.Pool, func(tx pgx.Tx) error {
+		q := `INSERT INTO webhook_attempts (delivery_id, attempted_at, response_status, response_body, error, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+		if _, err := tx.Exec(ctx, q, attempt.DeliveryID, attempt.AttemptedAt, attempt.ResponseStatus,
+			attempt.ResponseBody, attempt.Error, attempt.DurationMS); err != nil {
+			return err
+		}
+
+		var webhookID int
+		q = `UPDATE webhook_deliveries SET status = $2, attempts = attempts + 1, response_status = $3,
+			next_attempt_at = CASE WHEN $2 = 'pending' THEN $4::timestamptz END,
+			completed_at = CASE WHEN $2 <> 'pending' THEN $5::timestamptz END
+		WHERE id = $1 RETURNING webhook_id`
+		err := tx.QueryRow(ctx, q, attempt.DeliveryID, string(status), attempt.ResponseStatus,
+			nextAttemptAt, attempt.AttemptedAt).Scan(&webhookID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return lib.NotFound("delivery not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		switch status {
+		case model.DeliverySucceeded:
+			q = `UPDATE webhooks SET consecutive_failures = 0 WHERE id = $1`
+			_, err = tx.Exec(ctx, q, webhookID)
+		case model.DeliveryFailed:
+			q = `UPDATE webhooks SET consecutive_failures = consecutive_failures + 1,
+				active = active AND consecutive_failures + 1 < $2,
+				disabled_at = CASE WHEN active AND consecutive_failures + 1 >= $2 THEN $3::timestamptz ELSE disabled_at END
+			WHERE id = $1`
+			_, err = tx.Exec(ctx, q, webhookID, disableAfter, attempt.AttemptedAt)
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("repository/webhook: can't record attempt: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries retrieves the latest deliveries of a webhook.
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID, userID, limit int) ([]model.WebhookDelivery, error) {
+	q := `SELECT d.id, d.webhook_id, d.event_type, d.payload, d.status, d.attempts, d.response_status,
+		d.next_attempt_at, d.created_at, d.completed_at
+	FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+	WHERE d.webhook_id = $1 AND w.user_id = $2
+	ORDER BY d.id DESC LIMIT $3`
+
+	rows, err := r.db.Pool.Query(ctx, q, webhookID, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't list deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []model.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repository/webhook: can't scan delivery: %w", err)
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't list deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// GetDelivery retrieves a delivery with its attempts.
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id int64, webhookID, userID int) (*model.WebhookDelivery, error) {
+	q := `SELECT d.id, d.webhook_id, d.event_type, d.payload, d.status, d.attempts, d.response_status,
+		d.next_attempt_at, d.created_at, d.completed_at
+	FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+	WHERE d.id = $1 AND d.webhook_id = $2 AND w.user_id = $3`
+
+	delivery, err := scanDelivery(r.db.Pool.QueryRow(ctx, q, id, webhookID, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("repository/webhook: no such delivery: %w", lib.NotFound("delivery not found"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't get delivery: %w", err)
+	}
+
+	q = `SELECT delivery_id, attempted_at, response_status, response_body, error, duration_ms
+	FROM webhook_attempts WHERE delivery_id = $1 ORDER BY id`
+	rows, err := r.db.Pool.Query(ctx, q, id)
+	if err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't get attempts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a model.WebhookAttempt
+		if err := rows.Scan(&a.DeliveryID, &a.AttemptedAt, &a.ResponseStatus, &a.ResponseBody, &a.Error, &a.DurationMS); err != nil {
+			return nil, fmt.Errorf("repository/webhook: can't scan attempt: %w", err)
+		}
+		delivery.Log = append(delivery.Log, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't get attempts: %w", err)
+	}
+	return delivery, nil
+}
+
+// Redeliver copies a delivery into a new pending one.
+func (r *WebhookRepository) Redeliver(ctx context.Context, id int64, webhookID, userID int, now time.Time) (*model.WebhookDelivery, error) {
+	q := `INSERT INTO webhook_deliveries (webhook_id, event_type, payload, next_attempt_at, created_at)
+	SELECT d.webhook_id, d.event_type, d.payload, $4::timestamptz, $4::timestamptz
+	FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+	WHERE d.id = $1 AND d.webhook_id = $2 AND w.user_id = $3
+	RETURNING ` + deliveryColumns
+
+	delivery, err := scanDelivery(r.db.Pool.QueryRow(ctx, q, id, webhookID, userID, now))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("repository/webhook: no such delivery to redeliver: %w", lib.NotFound("delivery not found"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't redeliver: %w", err)
+	}
+	return delivery, nil
+}
+
+// DeleteDeliveriesBefore removes old completed deliveries; their attempts are removed by the cascade.
+func (r *WebhookRepository) DeleteDeliveriesBefore(ctx context.Context, t time.Time) (int64, error) {
+	q := `DELETE FROM webhook_deliveries WHERE status <> 'pending' AND created_at < $1`
+	result, err := r.db.Pool.Exec(ctx, q, t)
+	if err != nil {
+		return 0, fmt.Errorf("repository/webhook: can't delete deliveries: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// scanWebhook reads the webhookColumns of a row.
+func scanWebhook(row pgx.Row) (*model.Webhook, error) {
+	var (
+		w          model.Webhook
+		eventTypes []string
+	)
+	err := row.Scan(&w.ID, &w.UserID, &w.URL, &w.Secret, &eventTypes, &w.Active,
+		&w.ConsecutiveFailures, &w.DisabledAt, &w.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	w.EventTypes = make([]model.EventType, len(eventTypes))
+	for i, t := range eventTypes {
+		w.EventTypes[i] = model.EventType(t)
+	}
+	return &w, nil
+}
+
+// scanDelivery reads the deliveryColumns of a row followed by extra columns.
+func scanDelivery(row pgx.Row, extra ...any) (*model.WebhookDelivery, error) {
+	var (
+		d                 model.WebhookDelivery
+		eventType, status string
+		payload           string
+	)
+	dest := append([]any{&d.ID, &d.WebhookID, &eventType, &payload, &status, &d.Attempts, &d.ResponseStatus,
+		&d.NextAttemptAt, &d.CreatedAt, &d.CompletedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	d.EventType = model.EventType(eventType)
+	d.Status = model.DeliveryStatus(status)
+	d.Payload = json.RawMessage(payload)
+	return &d, nil
+}
+
+// eventTypeStrings converts event types for a TEXT[] column.
+func eventTypeStrings(types []model.EventType) []string {
+	s := make([]string, len(types))
+	for i, t := range types {
+		s[i] = string(t)
+	}
+	return s
+}
@@ -0,0 +1,130 @@
+// Package repository defines the storage interfaces consumed by the services.
+// Implementations live in subpackages: postgres for production, sqlite for
+// single-binary deployments and memory for tests and local development. Every
+// implementation must pass the conformance suite in repotest.
+package repository
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"time"
+)
+
+// ExpenseRepository provides data access methods for expense operations.
+//
+// Lookups are always scoped to a user: an expense owned by another user
+// behaves exactly like a missing one and yields a lib.ErrNotFound error.
+type ExpenseRepository interface {
+	// CreateExpense inserts a new expense record and returns its ID.
+	CreateExpense(ctx context.Context, expense model.Expense) (int, error)
+	// GetExpenseByID retrieves an expense by its ID and associated user ID.
+	GetExpenseByID(ctx context.Context, id int, userID int) (*model.Expense, error)
+	// IsExists checks if an expense with given ID exists for specified user.
+	IsExists(ctx context.Context, id int, userID int) (bool, error)
+	// UpdateExpense modifies the non-nil fields of an existing expense record.
+	UpdateExpense(ctx context.Context, id int, userID int, input *model.UpdateExpenseInput) (*model.Expense, error)
+	// DeleteExpense removes an expense record by ID.
+	DeleteExpense(ctx context.Context, id int, userID int) error
+	// GetExpensesList retrieves all expenses for a specific user ordered by ID.
+	GetExpensesList(ctx context.Context, userID int) ([]model.Expense, error)
+	// GetExpensesByPeriod retrieves expenses within an inclusive date range ordered by date.
+	GetExpensesByPeriod(ctx context.Context, userID int, start, end time.Time) ([]model.Expense, error)
+	// GetExpensesByCategory retrieves expenses in a category (case-sensitive) ordered by date.
+	GetExpensesByCategory(ctx context.Context, userID int, category string) ([]model.Expense, error)
+	// FindExpenses retrieves the expenses matching every non-empty field of the
+	// filter ordered by date. An empty filter matches all expenses of the user.
+	FindExpenses(ctx context.Context, userID int, filter *model.ExpenseFilter) ([]model.Expense, error)
+	// SearchExpenses runs a full-text search, see ParseSearchQuery for the syntax.
+	SearchExpenses(ctx context.Context, userID int, query *model.SearchQuery) ([]model.SearchResult, error)
+	// BulkUpdate recategorizes and/or retags every expense matching the filter.
+	BulkUpdate(ctx context.Context, userID int, input *model.BulkUpdateInput) (int64, error)
+	// MarkImported records the IDs of transactions imported from a statement of
+	// the account and returns the ones not recorded before, in no particular
+	// order. Within a transaction it blocks while a concurrent one records the
+	// same IDs, so every ID is returned to a single caller.
+	MarkImported(ctx context.Context, userID int, account string, ids []string) ([]string, error)
+	// ImportedIDs returns the IDs recorded by MarkImported for the account
+	// among ids, in no particular order.
+	ImportedIDs(ctx context.Context, userID int, account string, ids []string) ([]string, error)
+	// EnqueueDeliveries adds a pending delivery of every event, due at now,
+	// for every active webhook of the user subscribed to its type, and
+	// returns how many were added. Deliveries are numbered in the order of
+	// the events. Called within the transaction of the changes the events
+	// report, they are committed or rolled back with the changes.
+	EnqueueDeliveries(ctx context.Context, userID int, events []model.OutboxEvent, now time.Time) (int, error)
+	// WithTx runs fn in a transaction; nested calls create savepoints.
+	WithTx(ctx context.Context, fn func(tx ExpenseRepository) error) error
+}
+
+// UserRepository provides data access methods for user operations.
+// Usernames are unique and compared case-sensitively; deleting a user
+// deletes their expenses and webhooks as well.
+type UserRepository interface {
+	// CreateUser inserts a new user and sets user.ID.
+	CreateUser(ctx context.Context, user *model.User) error
+	// GetUserByName retrieves a user by their username.
+	GetUserByName(ctx context.Context, username string) (*model.User, error)
+	// GetUserById retrieves a user by their ID.
+	GetUserById(ctx context.Context, id int) (*model.User, error)
+	// DeleteUser removes a user with their expenses and webhooks.
+	DeleteUser(ctx context.Context, id int) error
+	// UpdateUsername changes a user's username.
+	UpdateUsername(ctx context.Context, id int, input *model.UpdateUsernameInput) (*model.User, error)
+	// IsExistsUser checks if a user with given ID exists.
+	IsExistsUser(ctx context.Context, id int) (bool, error)
+}
+
+// WebhookRepository stores the webhooks of users and the outbox of their
+// deliveries. Deliveries are enqueued by ExpenseRepository.EnqueueDeliveries
+// in the transaction of the changes they report.
+//
+// Webhooks are scoped to a user like expenses; deleting a webhook or its
+// user deletes its deliveries. Dispatchers of several instances may claim
+// deliveries concurrently, and a delivery is only claimed by one of them
+// until its lease expires.
+type WebhookRepository interface {
+	// CreateWebhook inserts an active webhook and returns it with its ID and creation time.
+	CreateWebhook(ctx context.Context, webhook model.Webhook) (*model.Webhook, error)
+	// GetWebhook retrieves a webhook by its ID and associated user ID.
+	GetWebhook(ctx context.Context, id, userID int) (*model.Webhook, error)
+	// ListWebhooks retrieves all webhooks of a user ordered by ID.
+	ListWebhooks(ctx context.Context, userID int) ([]model.Webhook, error)
+	// UpdateWebhook modifies the non-nil fields of a webhook. Activating it
+	// resets its failure count and DisabledAt.
+	UpdateWebhook(ctx context.Context, id, userID int, input *model.UpdateWebhookInput) (*model.Webhook, error)
+	// DeleteWebhook removes a webhook and its deliveries.
+	DeleteWebhook(ctx context.Context, id, userID int) error
+
+	// ClaimDeliveries returns up to limit pending deliveries of active webhooks
+	// that are due at now, oldest first, and postpones them to now+lease so
+	// that no other dispatcher claims them while they are attempted.
+	ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.PendingDelivery, error)
+	// RecordAttempt logs an attempt of a delivery and sets its status; a
+	// pending delivery is due again at nextAttemptAt. A successful delivery
+	// resets the failure count of its webhook, a failed one increments it and
+	// disables the webhook once the count reaches disableAfter.
+	RecordAttempt(ctx context.Context, attempt model.WebhookAttempt, status model.DeliveryStatus, nextAttemptAt time.Time, disableAfter int) error
+	// ListDeliveries retrieves the latest deliveries of a webhook, newest first.
+	ListDeliveries(ctx context.Context, webhookID, userID, limit int) ([]model.WebhookDelivery, error)
+	// GetDelivery retrieves a delivery of a webhook with the log of its attempts.
+	GetDelivery(ctx context.Context, id int64, webhookID, userID int) (*model.WebhookDelivery, error)
+	// Redeliver adds a pending copy of a delivery, due at now, and returns it.
+	Redeliver(ctx context.Context, id int64, webhookID, userID int, now time.Time) (*model.WebhookDelivery, error)
+	// DeleteDeliveriesBefore removes the completed deliveries created before t
+	// and returns how many were removed.
+	DeleteDeliveriesBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+// StatsRepository provides aggregate queries used for business metrics.
+type StatsRepository interface {
+	Stats(ctx context.Context) (*model.Stats, error)
+}
+
+// ActiveUserDays is how many days back a user must have recorded an expense to count as active.
+const ActiveUserDays = 30
+
+// Column limits shared by all backends.
+const (
+	MaxUsernameLength = 40
+	MaxCategoryLength = 40
+)
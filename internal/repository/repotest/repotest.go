@@ -0,0 +1,907 @@
+// Package repotest is a conformance suite for repository implementations.
+// Every backend must pass it so that services behave the same regardless of
+// the storage in use.
+//
+// A backend runs the suite from its own test file:
+//
+//	func TestConformance(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T) repotest.Repositories {
+//			db := memory.NewDB()
+//			return repotest.Repositories{
+//				Users:    memory.NewUserRepository(db),
+//				Expenses: memory.NewExpenseRepository(db),
+//				Webhooks: memory.NewWebhookRepository(db),
+//			}
+//		})
+//	}
+package repotest
+
+import (
+	"context"
+	"errors"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"expense_tracker/lib"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Repositories are the implementations under test. All must share one store.
+type Repositories struct {
+	Users    repository.UserRepository
+	Expenses repository.ExpenseRepository
+	Webhooks repository.WebhookRepository
+}
+
+// Factory returns repositories backed by a fresh, empty store.
+type Factory func(t *testing.T) Repositories
+
+// Run executes the whole conformance suite.
+func Run(t *testing.T, newRepos Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, r Repositories)
+	}{
+		{"CreateAndGetUser", testCreateAndGetUser},
+		{"UsernameUniqueAndCaseSensitive", testUsernameUnique},
+		{"UpdateUsername", testUpdateUsername},
+		{"DeleteUserCascades", testDeleteUserCascades},
+		{"ExpenseCRUD", testExpenseCRUD},
+		{"ExpenseOwnership", testExpenseOwnership},
+		{"AmountRoundingAndConstraints", testAmountRounding},
+		{"DatesDropTimeOfDay", testDates},
+		{"ListOrderingAndFilters", testListOrdering},
+		{"FindExpenses", testFindExpenses},
+		{"BulkUpdate", testBulkUpdate},
+		{"Transactions", testTransactions},
+		{"ImportedTransactions", testImportedTransactions},
+		{"Search", testSearch},
+		{"WebhookCRUD", testWebhookCRUD},
+		{"WebhookDeliveries", testWebhookDeliveries},
+		{"WebhookRetention", testWebhookRetention},
+		{"WebhookOutbox", testWebhookOutbox},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepos(t))
+		})
+	}
+}
+
+func testCreateAndGetUser(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := mustCreateUser(t, r, "alice")
+
+	byName, err := r.Users.GetUserByName(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetUserByName: %v", err)
+	}
+	byID, err := r.Users.GetUserById(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetUserById: %v", err)
+	}
+	if byName.ID != user.ID || byID.Username != "alice" {
+		t.Fatalf("got %+v and %+v, want user %d named alice", byName, byID, user.ID)
+	}
+	if strings.TrimRight(byID.Password, " ") != user.Password {
+		t.Fatalf("password not stored")
+	}
+
+	if _, err := r.Users.GetUserByName(ctx, "nobody"); !errors.Is(err, lib.ErrNotFound) {
+		t.Fatalf("GetUserByName(missing) error = %v, want ErrNotFound", err)
+	}
+	if _, err := r.Users.GetUserById(ctx, user.ID+1000); !errors.Is(err, lib.ErrNotFound) {
+		t.Fatalf("GetUserById(missing) error = %v, want ErrNotFound", err)
+	}
+	if exists, err := r.Users.IsExistsUser(ctx, user.ID); err != nil || !exists {
+		t.Fatalf("IsExistsUser = %v, %v; want true", exists, err)
+	}
+}
+
+func testUsernameUnique(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	mustCreateUser(t, r, "bob")
+
+	err := r.Users.CreateUser(ctx, &model.User{Username: "bob", Password: testPassword})
+	if !errors.Is(err, lib.ErrConflict) {
+		t.Fatalf("duplicate CreateUser error = %v, want ErrConflict", err)
+	}
+
+	mustCreateUser(t, r, "Bob")
+
+	if err := r.Users.CreateUser(ctx, &model.User{Username: strings.Repeat("x", repository.MaxUsernameLength+1), Password: testPassword}); err == nil {
+		t.Fatalf("CreateUser accepted a username longer than %d characters", repository.MaxUsernameLength)
+	}
+}
+
+func testUpdateUsername(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	carol := mustCreateUser(t, r, "carol")
+	mustCreateUser(t, r, "dave")
+
+	updated, err := r.Users.UpdateUsername(ctx, carol.ID, &model.UpdateUsernameInput{Username: "caroline"})
+	if err != nil {
+		t.Fatalf("UpdateUsername: %v", err)
+	}
+	if updated.ID != carol.ID || updated.Username != "caroline" {
+		t.Fatalf("UpdateUsername = %+v", updated)
+	}
+
+	_, err = r.Users.UpdateUsername(ctx, carol.ID, &model.UpdateUsernameInput{Username: "dave"})
+	if !errors.Is(err, lib.ErrConflict) {
+		t.Fatalf("UpdateUsername(taken) error = %v, want ErrConflict", err)
+	}
+	_, err = r.Users.UpdateUsername(ctx, carol.ID+1000, &model.UpdateUsernameInput{Username: "eve"})
+	if !errors.Is(err, lib.ErrNotFound) {
+		t.Fatalf("UpdateUsername(missing) error = %v, want ErrNotFound", err)
+	}
+	_, err = r.Users.UpdateUsername(ctx, carol.ID, &model.UpdateUsernameInput{Username: ""})
+	if !errors.Is(err, lib.ErrValidation) {
+		t.Fatalf("UpdateUsername(empty) error = %v, want ErrValidation", err)
+	}
+}
+
+func testDeleteUserCascades(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := mustCreateUser(t, r, "frank")
+	other := mustCreateUser(t, r, "grace")
+	id := mustCreateExpense(t, r, model.Expense{UserID: user.ID, Amount: 10, Category: "food", Date: date("2024-01-01")})
+	otherID := mustCreateExpense(t, r, model.Expense{UserID: other.ID, Amount: 10, Category: "food", Date: date("2024-01-01")})
+	webhook := mustCreateWebhook(t, r, user.ID, model.EventExpenseCreated)
+
+	if err := r.Users.DeleteUser(ctx, user.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if err := r.Users.DeleteUser(ctx, user.ID); !errors.Is(err, lib.ErrNotFound) {
+		t.Fatalf("second DeleteUser error = %v, want ErrNotFound", err)
+	}
+	if _, err := r.Expenses.GetExpenseByID(ctx, id, user.ID); !errors.Is(err, lib.ErrNotFound) {
+		t.Fatalf("expense of deleted user still exists: %v", err)
+	}
+	if _, err := r.Expenses.GetExpenseByID(ctx, otherID, other.ID); err != nil {
+		t.Fatalf("expense of another user was deleted: %v", err)
+	}
+	if _, err := r.Webhooks.GetWebhook(ctx, webhook.ID, user.ID); !errors.Is(err, lib.ErrNotFound) {
+		t.Fatalf("webhook of deleted user still exists: %v", err)
+	}
+	if _, err := r.Expenses.CreateExpense(ctx, model.Expense{UserID: user.ID, Amount: 1, Category: "food", Date: date("2024-01-01")}); err == nil {
+		t.Fatalf("CreateExpense accepted a deleted user")
+	}
+}
+
+func testExpenseCRUD(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := mustCreateUser(t, r, "henry")
+
+	want := model.Expense{
+		UserID:      user.ID,
+		Amount:      12.5,
+		Category:    "food",
+		Description: "lunch",
+		Date:        date("2024-02-10"),
+		Tags:        []string{"work"},
+	}
+	id := mustCreateExpense(t, r, want)
+	want.ID = id
+
+	got, err := r.Expenses.GetExpenseByID(ctx, id, user.ID)
+	if err != nil {
+		t.Fatalf("GetExpenseByID: %v", err)
+	}
+	assertExpense(t, got, &want)
+
+	amount, description := 20.0, "dinner"
+	updated, err := r.Expenses.UpdateExpense(ctx, id, user.ID, &model.UpdateExpenseInput{Amount: &amount, Description: &description})
+	if err != nil {
+		t.Fatalf("UpdateExpense: %v", err)
+	}
+	want.Amount, want.Description = amount, description
+	assertExpense(t, updated, &want)
+
+	if err := r.Expenses.DeleteExpense(ctx, id, user.ID); err != nil {
+		t.Fatalf("DeleteExpense: %v", err)
+	}
+	if err := r.Expenses.DeleteExpense(ctx, id, user.ID); !errors.Is(err, lib.ErrNotFound) {
+		t.Fatalf("second DeleteExpense error = %v, want ErrNotFound", err)
+	}
+	if _, err := r.Expenses.UpdateExpense(ctx, id, user.ID, &model.UpdateExpenseInput{Amount: &amount}); !errors.Is(err, lib.ErrNotFound) {
+		t.Fatalf("UpdateExpense(deleted) error = %v, want ErrNotFound", err)
+	}
+	if exists, err := r.Expenses.IsExists(ctx, id, user.ID); err != nil || exists {
+		t.Fatalf("IsExists(deleted) = %v, %v; want false", exists, err)
+	}
+}
+
+func testExpenseOwnership(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	owner := mustCreateUser(t, r, "ivan")
+	intruder := mustCreateUser(t, r, "judy")
+	id := mustCreateExpense(t, r, model.Expense{UserID: owner.ID, Amount: 5, Category: "food", Date: date("2024-01-01")})
+
+	if _, err := r.Expenses.GetExpenseByID(ctx, id, intruder.ID); !errors.Is(err, lib.ErrNotFound) {
+		t.Fatalf("GetExpenseByID(other user) error = %v, want ErrNotFound", err)
+	}
+	if exists, _ := r.Expenses.IsExists(ctx, id, intruder.ID); exists {
+		t.Fatalf("IsExists(other user) = true")
+	}
+	if err := r.Expenses.DeleteExpense(ctx, id, intruder.ID); !errors.Is(err, lib.ErrNotFound) {
+		t.Fatalf("DeleteExpense(other user) error = %v, want ErrNotFound", err)
+	}
+	if list, _ := r.Expenses.GetExpensesList(ctx, intruder.ID); len(list) != 0 {
+		t.Fatalf("GetExpensesList(other user) = %v, want empty", list)
+	}
+}
+
+func testAmountRounding(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := mustCreateUser(t, r, "kate")
+
+	for _, tc := range []struct{ in, want float64 }{
+		{12.345, 12.35},
+		{0.005, 0.01},
+		{99.994, 99.99},
+		{7, 7},
+	} {
+		id := mustCreateExpense(t, r, model.Expense{UserID: user.ID, Amount: tc.in, Category: "misc", Date: date("2024-01-01")})
+		got, err := r.Expenses.GetExpenseByID(ctx, id, user.ID)
+		if err != nil {
+			t.Fatalf("GetExpenseByID: %v", err)
+		}
+		if got.Amount != tc.want {
+			t.Errorf("amount %v stored as %v, want %v", tc.in, got.Amount, tc.want)
+		}
+	}
+
+	for _, amount := range []float64{0, -1, 0.004} {
+		if _, err := r.Expenses.CreateExpense(ctx, model.Expense{UserID: user.ID, Amount: amount, Category: "misc", Date: date("2024-01-01")}); err == nil {
+			t.Errorf("CreateExpense accepted amount %v", amount)
+		}
+	}
+	long := strings.Repeat("c", repository.MaxCategoryLength+1)
+	if _, err := r.Expenses.CreateExpense(ctx, model.Expense{UserID: user.ID, Amount: 1, Category: long, Date: date("2024-01-01")}); err == nil {
+		t.Errorf("CreateExpense accepted a category longer than %d characters", repository.MaxCategoryLength)
+	}
+}
+
+func testDates(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := mustCreateUser(t, r, "leo")
+
+	withTime := model.Date{Time: time.Date(2024, 3, 5, 23, 30, 0, 0, time.UTC)}
+	id := mustCreateExpense(t, r, model.Expense{UserID: user.ID, Amount: 1, Category: "misc", Date: withTime})
+
+	got, err := r.Expenses.GetExpenseByID(ctx, id, user.ID)
+	if err != nil {
+		t.Fatalf("GetExpenseByID: %v", err)
+	}
+	if got.Date.Format("2006-01-02 15:04:05") != "2024-03-05 00:00:00" {
+		t.Fatalf("date stored as %v, want 2024-03-05 00:00:00", got.Date.Time)
+	}
+}
+
+func testListOrdering(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := mustCreateUser(t, r, "mia")
+
+	a := mustCreateExpense(t, r, model.Expense{UserID: user.ID, Amount: 1, Category: "food", Date: date("2024-01-20")})
+	b := mustCreateExpense(t, r, model.Expense{UserID: user.ID, Amount: 1, Category: "Food", Date: date("2024-01-10")})
+	c := mustCreateExpense(t, r, model.Expense{UserID: user.ID, Amount: 1, Category: "food", Date: date("2024-01-10")})
+	d := mustCreateExpense(t, r, model.Expense{UserID: user.ID, Amount: 1, Category: "food", Date: date("2024-01-31")})
+
+	list, err := r.Expenses.GetExpensesList(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetExpensesList: %v", err)
+	}
+	assertIDs(t, "GetExpensesList", list, a, b, c, d)
+
+	period, err := r.Expenses.GetExpensesByPeriod(ctx, user.ID, date("2024-01-10").Time, date("2024-01-20").Time)
+	if err != nil {
+		t.Fatalf("GetExpensesByPeriod: %v", err)
+	}
+	assertIDs(t, "GetExpensesByPeriod", period, b, c, a)
+
+	byCategory, err := r.Expenses.GetExpensesByCategory(ctx, user.ID, "food")
+	if err != nil {
+		t.Fatalf("GetExpensesByCategory: %v", err)
+	}
+	assertIDs(t, "GetExpensesByCategory (case-sensitive)", byCategory, c, a, d)
+}
+
+func testFindExpenses(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := mustCreateUser(t, r, "nico")
+	other := mustCreateUser(t, r, "olga")
+
+	a := mustCreateExpense(t, r, model.Expense{UserID: user.ID, Amount: 1, Category: "food", Date: date("2024-01-20"), Tags: []string{"work"}})
+	b := mustCreateExpense(t, r, model.Expense{UserID: user.ID, Amount: 1, Category: "rent", Date: date("2024-01-01")})
+	c := mustCreateExpense(t, r, model.Expense{UserID: user.ID, Amount: 1, Category: "food", Date: date("2024-02-10"), Tags: []string{"home", "work"}})
+	d := mustCreateExpense(t, r, model.Expense{UserID: other.ID, Amount: 1, Category: "food", Date: date("2024-01-15")})
+
+	category, tag := "food", "work"
+	start, end := date("2024-01-01"), date("2024-01-31")
+	tests := []struct {
+		name   string
+		filter model.ExpenseFilter
+		want   []int
+	}{
+		{"empty filter", model.ExpenseFilter{}, []int{b, a, c}},
+		{"ids", model.ExpenseFilter{IDs: []int{c, a, d}}, []int{a, c}},
+		{"category", model.ExpenseFilter{Category: &category}, []int{a, c}},
+		{"period", model.ExpenseFilter{Start: &start, End: &end}, []int{b, a}},
+		{"tag and period", model.ExpenseFilter{Tag: &tag, Start: &start, End: &end}, []int{a}},
+	}
+	for _, tt := range tests {
+		got, err := r.Expenses.FindExpenses(ctx, user.ID, &tt.filter)
+		if err != nil {
+			t.Fatalf("FindExpenses (%s): %v", tt.name, err)
+		}
+		assertIDs(t, "FindExpenses ("+tt.name+")", got, tt.want...)
+	}
+}
+
+func testBulkUpdate(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := mustCreateUser(t, r, "nina")
+	other := mustCreateUser(t, r, "oscar")
+
+	a := mustCreateExpense(t, r, model.Expense{UserID: user.ID, Amount: 1, Category: "misc", Date: date("2024-01-05"), Tags: []string{"b", "old"}})
+	b := mustCreateExpense(t, r, model.Expense{UserID: user.ID, Amount: 1, Category: "misc", Date: date("2024-02-05")})
+	c := mustCreateExpense(t, r, model.Expense{UserID: other.ID, Amount: 1, Category: "misc", Date: date("2024-01-05")})
+
+	category := "misc"
+	end := date("2024-01-31")
+	newCategory := "groceries"
+	n, err := r.Expenses.BulkUpdate(ctx, user.ID, &model.BulkUpdateInput{
+		Filter:      model.ExpenseFilter{Category: &category, End: &end},
+		SetCategory: &newCategory,
+		AddTags:     []string{"a", "b"},
+		RemoveTags:  []string{"old"},
+	})
+	if err != nil {
+		t.Fatalf("BulkUpdate: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("BulkUpdate updated %d rows, want 1", n)
+	}
+
+	got, _ := r.Expenses.GetExpenseByID(ctx, a, user.ID)
+	if got.Category != "groceries" || !slices.Equal(got.Tags, []string{"a", "b"}) {
+		t.Fatalf("updated expense = %+v, want category groceries and tags [a b]", got)
+	}
+	for _, id := range []struct{ id, user int }{{b, user.ID}, {c, other.ID}} {
+		untouched, _ := r.Expenses.GetExpenseByID(ctx, id.id, id.user)
+		if untouched.Category != "misc" {
+			t.Fatalf("expense %d outside the filter was updated", id.id)
+		}
+	}
+}
+
+func testTransactions(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := mustCreateUser(t, r, "paul")
+	rollback := errors.New("rollback")
+
+	var rolledBack int
+	err := r.Expenses.WithTx(ctx, func(tx repository.ExpenseRepository) error {
+		id, err := tx.CreateExpense(ctx, model.Expense{UserID: user.ID, Amount: 1, Category: "misc", Date: date("2024-01-01")})
+		if err != nil {
+			return err
+		}
+		rolledBack = id
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("WithTx error = %v, want the error returned by fn", err)
+	}
+	if exists, _ := r.Expenses.IsExists(ctx, rolledBack, user.ID); exists {
+		t.Fatalf("expense created in a rolled back transaction exists")
+	}
+
+	var kept, discarded int
+	err = r.Expenses.WithTx(ctx, func(tx repository.ExpenseRepository) error {
+		var err error
+		if kept, err = tx.CreateExpense(ctx, model.Expense{UserID: user.ID, Amount: 1, Category: "misc", Date: date("2024-01-01")}); err != nil {
+			return err
+		}
+		spErr := tx.WithTx(ctx, func(sp repository.ExpenseRepository) error {
+			if discarded, err = sp.CreateExpense(ctx, model.Expense{UserID: user.ID, Amount: 2, Category: "misc", Date: date("2024-01-01")}); err != nil {
+				return err
+			}
+			return rollback
+		})
+		if !errors.Is(spErr, rollback) {
+			return spErr
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+	if exists, _ := r.Expenses.IsExists(ctx, kept, user.ID); !exists {
+		t.Fatalf("expense committed by the outer transaction is missing")
+	}
+	if exists, _ := r.Expenses.IsExists(ctx, discarded, user.ID); exists {
+		t.Fatalf("expense rolled back to a savepoint exists")
+	}
+}
+
+func testImportedTransactions(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := mustCreateUser(t, r, "pia")
+	other := mustCreateUser(t, r, "piet")
+
+	mark := func(userID int, account string, ids ...string) []string {
+		t.Helper()
+		marked, err := r.Expenses.MarkImported(ctx, userID, account, ids)
+		if err != nil {
+			t.Fatalf("MarkImported(%d, %q, %v): %v", userID, account, ids, err)
+		}
+		slices.Sort(marked)
+		return marked
+	}
+
+	if got := mark(user.ID, "1234", "a", "b"); !slices.Equal(got, []string{"a", "b"}) {
+		t.Fatalf("first MarkImported = %v, want [a b]", got)
+	}
+	if got := mark(user.ID, "1234", "b", "c"); !slices.Equal(got, []string{"c"}) {
+		t.Fatalf("MarkImported of a recorded ID = %v, want [c]", got)
+	}
+	// IDs are unique per user and account only.
+	if got := mark(user.ID, "5678", "a"); !slices.Equal(got, []string{"a"}) {
+		t.Fatalf("MarkImported for another account = %v, want [a]", got)
+	}
+	if got := mark(other.ID, "1234", "a"); !slices.Equal(got, []string{"a"}) {
+		t.Fatalf("MarkImported for another user = %v, want [a]", got)
+	}
+	if got := mark(user.ID, "1234"); len(got) != 0 {
+		t.Fatalf("MarkImported without IDs = %v, want none", got)
+	}
+	imported, err := r.Expenses.ImportedIDs(ctx, user.ID, "1234", []string{"c", "x", "a"})
+	if slices.Sort(imported); err != nil || !slices.Equal(imported, []string{"a", "c"}) {
+		t.Fatalf("ImportedIDs = %v, %v, want [a c]", imported, err)
+	}
+	if imported, err := r.Expenses.ImportedIDs(ctx, user.ID, "1234", nil); err != nil || len(imported) != 0 {
+		t.Fatalf("ImportedIDs without IDs = %v, %v, want none", imported, err)
+	}
+	if _, err := r.Expenses.MarkImported(ctx, user.ID, "1234", []string{strings.Repeat("x", 256)}); err == nil {
+		t.Fatalf("MarkImported accepted an ID longer than 255 characters")
+	}
+
+	rollback := errors.New("rollback")
+	err = r.Expenses.WithTx(ctx, func(tx repository.ExpenseRepository) error {
+		if _, err := tx.MarkImported(ctx, user.ID, "1234", []string{"d"}); err != nil {
+			return err
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("WithTx error = %v, want the error returned by fn", err)
+	}
+	if got := mark(user.ID, "1234", "d"); !slices.Equal(got, []string{"d"}) {
+		t.Fatalf("MarkImported after a rollback = %v, want [d]", got)
+	}
+
+	if err := r.Users.DeleteUser(ctx, user.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if got := mark(other.ID, "1234", "a", "e"); !slices.Equal(got, []string{"e"}) {
+		t.Fatalf("MarkImported after deleting another user = %v, want [e]", got)
+	}
+}
+
+func testSearch(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := mustCreateUser(t, r, "quinn")
+	other := mustCreateUser(t, r, "rose")
+
+	lamp := mustCreateExpense(t, r, model.Expense{UserID: user.ID, Amount: 30, Category: "home", Description: "IKEA blue lamp", Date: date("2024-04-01")})
+	shelf := mustCreateExpense(t, r, model.Expense{UserID: user.ID, Amount: 80, Category: "home", Description: "ikea shelf, lamp bulbs", Date: date("2024-05-01")})
+	mustCreateExpense(t, r, model.Expense{UserID: user.ID, Amount: 5, Category: "food", Description: "coffee", Date: date("2024-04-02")})
+	mustCreateExpense(t, r, model.Expense{UserID: other.ID, Amount: 30, Category: "home", Description: "IKEA blue lamp", Date: date("2024-04-01")})
+
+	search := func(q string, mod func(*model.SearchQuery)) []model.SearchResult {
+		t.Helper()
+		query := &model.SearchQuery{Query: q, Limit: 10}
+		if mod != nil {
+			mod(query)
+		}
+		results, err := r.Expenses.SearchExpenses(ctx, user.ID, query)
+		if err != nil {
+			t.Fatalf("SearchExpenses(%q): %v", q, err)
+		}
+		return results
+	}
+	ids := func(results []model.SearchResult) []int {
+		out := []int{}
+		for _, res := range results {
+			out = append(out, res.ID)
+		}
+		slices.Sort(out)
+		return out
+	}
+
+	if got := ids(search("ike", nil)); !slices.Equal(got, []int{lamp, shelf}) {
+		t.Errorf("prefix search = %v, want %v", got, []int{lamp, shelf})
+	}
+	if got := ids(search(`"blue lamp"`, nil)); !slices.Equal(got, []int{lamp}) {
+		t.Errorf("phrase search = %v, want %v", got, []int{lamp})
+	}
+	if got := ids(search(`"lamp blue"`, nil)); len(got) != 0 {
+		t.Errorf("phrase search matched words out of order: %v", got)
+	}
+	if got := ids(search("lamp", func(q *model.SearchQuery) { start := date("2024-04-15"); q.Start = &start })); !slices.Equal(got, []int{shelf}) {
+		t.Errorf("search with date filter = %v, want %v", got, []int{shelf})
+	}
+	if got := ids(search("home", nil)); !slices.Equal(got, []int{lamp, shelf}) {
+		t.Errorf("category search = %v, want %v", got, []int{lamp, shelf})
+	}
+	if got := search("lamp", func(q *model.SearchQuery) { q.Limit = 1 }); len(got) != 1 {
+		t.Errorf("search with limit 1 returned %d results", len(got))
+	}
+	if got := search("!!!", nil); len(got) != 0 {
+		t.Errorf("search without words returned %d results", len(got))
+	}
+	if res := search("blue", nil); len(res) != 1 || !strings.Contains(res[0].Snippet, "<mark>blue</mark>") {
+		t.Errorf("snippet = %+v, want the match wrapped in <mark>", res)
+	}
+
+	// Descriptions are escaped around the marks.
+	mustCreateExpense(t, r, model.Expense{UserID: user.ID, Amount: 1, Category: "misc", Description: `<script>alert("x")</script> & crimson`, Date: date("2024-06-01")})
+	if res := search("crimson", nil); len(res) != 1 || strings.Contains(res[0].Snippet, "<script") ||
+		!strings.Contains(res[0].Snippet, "&lt;script&gt;") || !strings.Contains(res[0].Snippet, "&amp; <mark>crimson</mark>") {
+		t.Errorf("snippet = %+v, want the description escaped", res)
+	}
+}
+
+// testPassword is a bcrypt-sized placeholder hash.
+var testPassword = strings.Repeat("h", 60)
+
+// testSecret is the secret of the webhooks created by the suite.
+const testSecret = "0123456789abcdef"
+
+func testWebhookCRUD(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := mustCreateUser(t, r, "quinn")
+	other := mustCreateUser(t, r, "rita")
+
+	created := mustCreateWebhook(t, r, user.ID, model.EventExpenseCreated, model.EventExpenseDeleted)
+	if created.ID == 0 || created.UserID != user.ID || !created.Active || created.CreatedAt.IsZero() ||
+		created.Secret != testSecret || !slices.Equal(created.EventTypes, []model.EventType{model.EventExpenseCreated, model.EventExpenseDeleted}) {
+		t.Fatalf("CreateWebhook = %+v", created)
+	}
+
+	got, err := r.Webhooks.GetWebhook(ctx, created.ID, user.ID)
+	if err != nil {
+		t.Fatalf("GetWebhook: %v", err)
+	}
+	if got.URL != created.URL || got.Secret != testSecret || !slices.Equal(got.EventTypes, created.EventTypes) {
+		t.Fatalf("GetWebhook = %+v, want %+v", got, created)
+	}
+	if _, err := r.Webhooks.GetWebhook(ctx, created.ID, other.ID); !errors.Is(err, lib.ErrNotFound) {
+		t.Fatalf("GetWebhook(other user) error = %v, want ErrNotFound", err)
+	}
+	if list, err := r.Webhooks.ListWebhooks(ctx, other.ID); err != nil || len(list) != 0 {
+		t.Fatalf("ListWebhooks(other user) = %v, %v; want none", list, err)
+	}
+
+	second := mustCreateWebhook(t, r, user.ID, model.EventExpenseUpdated)
+	list, err := r.Webhooks.ListWebhooks(ctx, user.ID)
+	if err != nil || len(list) != 2 || list[0].ID != created.ID || list[1].ID != second.ID {
+		t.Fatalf("ListWebhooks = %v, %v; want webhooks %d and %d", list, err, created.ID, second.ID)
+	}
+
+	url := "https://example.com/other"
+	types := []model.EventType{model.EventExpenseUpdated}
+	inactive := false
+	updated, err := r.Webhooks.UpdateWebhook(ctx, created.ID, user.ID, &model.UpdateWebhookInput{URL: &url, EventTypes: &types, Active: &inactive})
+	if err != nil {
+		t.Fatalf("UpdateWebhook: %v", err)
+	}
+	if updated.URL != url || updated.Active || updated.Secret != testSecret || !slices.Equal(updated.EventTypes, types) {
+		t.Fatalf("UpdateWebhook = %+v", updated)
+	}
+	if _, err := r.Webhooks.UpdateWebhook(ctx, created.ID, other.ID, &model.UpdateWebhookInput{URL: &url}); !errors.Is(err, lib.ErrNotFound) {
+		t.Fatalf("UpdateWebhook(other user) error = %v, want ErrNotFound", err)
+	}
+
+	if err := r.Webhooks.DeleteWebhook(ctx, created.ID, other.ID); !errors.Is(err, lib.ErrNotFound) {
+		t.Fatalf("DeleteWebhook(other user) error = %v, want ErrNotFound", err)
+	}
+	if err := r.Webhooks.DeleteWebhook(ctx, created.ID, user.ID); err != nil {
+		t.Fatalf("DeleteWebhook: %v", err)
+	}
+	if _, err := r.Webhooks.GetWebhook(ctx, created.ID, user.ID); !errors.Is(err, lib.ErrNotFound) {
+		t.Fatalf("GetWebhook after delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func testWebhookDeliveries(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := mustCreateUser(t, r, "sam")
+	other := mustCreateUser(t, r, "tina")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	webhook := mustCreateWebhook(t, r, user.ID, model.EventExpenseCreated, model.EventExpenseUpdated)
+	mustCreateWebhook(t, r, user.ID, model.EventExpenseDeleted)
+	mustCreateWebhook(t, r, other.ID, model.EventExpenseCreated)
+	inactive := mustCreateWebhook(t, r, user.ID, model.EventExpenseCreated)
+	disable := false
+	if _, err := r.Webhooks.UpdateWebhook(ctx, inactive.ID, user.ID, &model.UpdateWebhookInput{Active: &disable}); err != nil {
+		t.Fatalf("UpdateWebhook: %v", err)
+	}
+
+	payload := []byte(`{"type":"expense.created"}`)
+	if n, err := r.Expenses.EnqueueDeliveries(ctx, user.ID, []model.OutboxEvent{{Type: model.EventExpenseCreated, Payload: payload}}, now); err != nil || n != 1 {
+		t.Fatalf("EnqueueDeliveries = %d, %v; want 1 delivery to the active subscribed webhook", n, err)
+	}
+
+	claimed := mustClaim(t, r, now, 1)
+	first := claimed[0]
+	if first.WebhookID != webhook.ID || first.URL != webhook.URL || first.Secret != testSecret ||
+		first.EventType != model.EventExpenseCreated || string(first.Payload) != string(payload) || first.Status != model.DeliveryPending {
+		t.Fatalf("ClaimDeliveries = %+v", first)
+	}
+	mustClaim(t, r, now, 0)
+	mustClaim(t, r, now.Add(2*time.Minute), 1)
+
+	// A retry keeps the delivery pending without counting as a failure.
+	mustRecordAttempt(t, r, first.ID, now, 500, model.DeliveryPending, now.Add(5*time.Minute))
+	mustClaim(t, r, now.Add(4*time.Minute), 0)
+	mustClaim(t, r, now.Add(5*time.Minute), 1)
+	mustRecordAttempt(t, r, first.ID, now, 0, model.DeliveryFailed, time.Time{})
+
+	delivery, err := r.Webhooks.GetDelivery(ctx, first.ID, webhook.ID, user.ID)
+	if err != nil {
+		t.Fatalf("GetDelivery: %v", err)
+	}
+	if delivery.Status != model.DeliveryFailed || delivery.Attempts != 2 || delivery.CompletedAt == nil ||
+		delivery.NextAttemptAt != nil || len(delivery.Log) != 2 || delivery.Log[0].ResponseStatus != 500 || delivery.Log[1].Error == "" {
+		t.Fatalf("GetDelivery = %+v", delivery)
+	}
+	if _, err := r.Webhooks.GetDelivery(ctx, first.ID, webhook.ID, other.ID); !errors.Is(err, lib.ErrNotFound) {
+		t.Fatalf("GetDelivery(other user) error = %v, want ErrNotFound", err)
+	}
+	if _, err := r.Webhooks.GetDelivery(ctx, first.ID, inactive.ID, user.ID); !errors.Is(err, lib.ErrNotFound) {
+		t.Fatalf("GetDelivery(other webhook) error = %v, want ErrNotFound", err)
+	}
+
+	// The second failed delivery in a row disables the webhook.
+	assertFailures(t, r, webhook, 1, true)
+	if _, err := r.Expenses.EnqueueDeliveries(ctx, user.ID, []model.OutboxEvent{{Type: model.EventExpenseUpdated, Payload: payload}}, now); err != nil {
+		t.Fatalf("EnqueueDeliveries: %v", err)
+	}
+	second := mustClaim(t, r, now, 1)[0]
+	mustRecordAttempt(t, r, second.ID, now, 0, model.DeliveryFailed, time.Time{})
+	assertFailures(t, r, webhook, 2, false)
+	if n, err := r.Expenses.EnqueueDeliveries(ctx, user.ID, []model.OutboxEvent{{Type: model.EventExpenseCreated, Payload: payload}}, now); err != nil || n != 0 {
+		t.Fatalf("EnqueueDeliveries to a disabled webhook = %d, %v; want 0", n, err)
+	}
+
+	redelivery, err := r.Webhooks.Redeliver(ctx, first.ID, webhook.ID, user.ID, now)
+	if err != nil {
+		t.Fatalf("Redeliver: %v", err)
+	}
+	if redelivery.ID == first.ID || redelivery.Status != model.DeliveryPending || redelivery.Attempts != 0 ||
+		string(redelivery.Payload) != string(payload) {
+		t.Fatalf("Redeliver = %+v", redelivery)
+	}
+	if _, err := r.Webhooks.Redeliver(ctx, first.ID, webhook.ID, other.ID, now); !errors.Is(err, lib.ErrNotFound) {
+		t.Fatalf("Redeliver(other user) error = %v, want ErrNotFound", err)
+	}
+	mustClaim(t, r, now, 0)
+
+	enable := true
+	enabled, err := r.Webhooks.UpdateWebhook(ctx, webhook.ID, user.ID, &model.UpdateWebhookInput{Active: &enable})
+	if err != nil {
+		t.Fatalf("UpdateWebhook: %v", err)
+	}
+	if !enabled.Active || enabled.ConsecutiveFailures != 0 || enabled.DisabledAt != nil {
+		t.Fatalf("enabled webhook = %+v, want active without failures", enabled)
+	}
+	if claimed := mustClaim(t, r, now, 1); claimed[0].ID != redelivery.ID {
+		t.Fatalf("claimed delivery %d, want the redelivery %d", claimed[0].ID, redelivery.ID)
+	}
+	mustRecordAttempt(t, r, redelivery.ID, now, 204, model.DeliverySucceeded, time.Time{})
+
+	list, err := r.Webhooks.ListDeliveries(ctx, webhook.ID, user.ID, 2)
+	if err != nil {
+		t.Fatalf("ListDeliveries: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != redelivery.ID || list[1].ID != second.ID ||
+		list[0].Status != model.DeliverySucceeded || list[0].ResponseStatus != 204 {
+		t.Fatalf("ListDeliveries = %+v, want deliveries %d and %d", list, redelivery.ID, second.ID)
+	}
+	if list, err := r.Webhooks.ListDeliveries(ctx, webhook.ID, other.ID, 10); err != nil || len(list) != 0 {
+		t.Fatalf("ListDeliveries(other user) = %v, %v; want none", list, err)
+	}
+
+	if err := r.Webhooks.DeleteWebhook(ctx, webhook.ID, user.ID); err != nil {
+		t.Fatalf("DeleteWebhook: %v", err)
+	}
+	if _, err := r.Webhooks.GetDelivery(ctx, first.ID, webhook.ID, user.ID); !errors.Is(err, lib.ErrNotFound) {
+		t.Fatalf("delivery of deleted webhook still exists: %v", err)
+	}
+}
+
+func testWebhookRetention(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := mustCreateUser(t, r, "uma")
+	webhook := mustCreateWebhook(t, r, user.ID, model.EventExpenseCreated)
+	old := time.Now().UTC().Truncate(time.Second).Add(-48 * time.Hour)
+	now := old.Add(48 * time.Hour)
+
+	for _, at := range []time.Time{old, old, now} {
+		if _, err := r.Expenses.EnqueueDeliveries(ctx, user.ID, []model.OutboxEvent{{Type: model.EventExpenseCreated, Payload: []byte(`{}`)}}, at); err != nil {
+			t.Fatalf("EnqueueDeliveries: %v", err)
+		}
+	}
+	claimed := mustClaim(t, r, now, 3)
+	mustRecordAttempt(t, r, claimed[0].ID, now, 200, model.DeliverySucceeded, time.Time{})
+	mustRecordAttempt(t, r, claimed[2].ID, now, 200, model.DeliverySucceeded, time.Time{})
+
+	n, err := r.Webhooks.DeleteDeliveriesBefore(ctx, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("DeleteDeliveriesBefore: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("DeleteDeliveriesBefore deleted %d deliveries, want the old completed one", n)
+	}
+	list, _ := r.Webhooks.ListDeliveries(ctx, webhook.ID, user.ID, 10)
+	if len(list) != 2 || list[0].ID != claimed[2].ID || list[1].ID != claimed[1].ID {
+		t.Fatalf("ListDeliveries after pruning = %+v, want the pending and the recent delivery", list)
+	}
+}
+
+func testWebhookOutbox(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	user := mustCreateUser(t, r, "vera")
+	now := time.Now().UTC().Truncate(time.Second)
+	created := mustCreateWebhook(t, r, user.ID, model.EventExpenseCreated)
+	all := mustCreateWebhook(t, r, user.ID, model.EventExpenseCreated, model.EventExpenseDeleted)
+
+	events := []model.OutboxEvent{
+		{Type: model.EventExpenseCreated, Payload: []byte(`{"id":1}`)},
+		{Type: model.EventExpenseUpdated, Payload: []byte(`{"id":2}`)},
+		{Type: model.EventExpenseDeleted, Payload: []byte(`{"id":3}`)},
+	}
+
+	// Deliveries enqueued in a rolled back transaction are dropped with it.
+	errRollback := errors.New("rollback")
+	err := r.Expenses.WithTx(ctx, func(tx repository.ExpenseRepository) error {
+		if n, err := tx.EnqueueDeliveries(ctx, user.ID, events, now); err != nil || n != 3 {
+			t.Fatalf("EnqueueDeliveries in a transaction = %d, %v; want 3", n, err)
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("WithTx error = %v, want the rollback", err)
+	}
+	mustClaim(t, r, now, 0)
+
+	if n, err := r.Expenses.EnqueueDeliveries(ctx, user.ID, nil, now); err != nil || n != 0 {
+		t.Fatalf("EnqueueDeliveries(no events) = %d, %v; want 0", n, err)
+	}
+	err = r.Expenses.WithTx(ctx, func(tx repository.ExpenseRepository) error {
+		_, err := tx.EnqueueDeliveries(ctx, user.ID, events, now)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+
+	// Deliveries are numbered by event, then by webhook.
+	claimed := mustClaim(t, r, now, 3)
+	want := []struct {
+		webhookID int
+		payload   string
+	}{{created.ID, `{"id":1}`}, {all.ID, `{"id":1}`}, {all.ID, `{"id":3}`}}
+	for i, d := range claimed {
+		if d.WebhookID != want[i].webhookID || string(d.Payload) != want[i].payload || (i > 0 && d.ID <= claimed[i-1].ID) {
+			t.Fatalf("delivery %d = %+v, want webhook %d with %s", i, d, want[i].webhookID, want[i].payload)
+		}
+	}
+}
+
+func mustCreateUser(t *testing.T, r Repositories, username string) *model.User {
+	t.Helper()
+	user := &model.User{Username: username, Password: testPassword}
+	if err := r.Users.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("CreateUser(%q): %v", username, err)
+	}
+	if user.ID == 0 {
+		t.Fatalf("CreateUser(%q) did not set the ID", username)
+	}
+	return user
+}
+
+func mustCreateExpense(t *testing.T, r Repositories, e model.Expense) int {
+	t.Helper()
+	id, err := r.Expenses.CreateExpense(context.Background(), e)
+	if err != nil {
+		t.Fatalf("CreateExpense(%+v): %v", e, err)
+	}
+	return id
+}
+
+func mustCreateWebhook(t *testing.T, r Repositories, userID int, types ...model.EventType) *model.Webhook {
+	t.Helper()
+	webhook, err := r.Webhooks.CreateWebhook(context.Background(), model.Webhook{
+		UserID:     userID,
+		URL:        "https://example.com/hook",
+		Secret:     testSecret,
+		EventTypes: types,
+	})
+	if err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	return webhook
+}
+
+// mustClaim claims the deliveries due at now with a one minute lease and
+// checks how many there were.
+func mustClaim(t *testing.T, r Repositories, now time.Time, want int) []model.PendingDelivery {
+	t.Helper()
+	claimed, err := r.Webhooks.ClaimDeliveries(context.Background(), now, time.Minute, 10)
+	if err != nil {
+		t.Fatalf("ClaimDeliveries: %v", err)
+	}
+	if len(claimed) != want {
+		t.Fatalf("ClaimDeliveries at %s claimed %d deliveries, want %d", now, len(claimed), want)
+	}
+	return claimed
+}
+
+// mustRecordAttempt records an attempt answered with status, or failing
+// without a response if status is 0. Two failed deliveries disable a webhook.
+func mustRecordAttempt(t *testing.T, r Repositories, id int64, at time.Time, status int, result model.DeliveryStatus, next time.Time) {
+	t.Helper()
+	attempt := model.WebhookAttempt{DeliveryID: id, AttemptedAt: at, ResponseStatus: status, DurationMS: 5}
+	if status == 0 {
+		attempt.Error = "connection refused"
+	}
+	if err := r.Webhooks.RecordAttempt(context.Background(), attempt, result, next, 2); err != nil {
+		t.Fatalf("RecordAttempt: %v", err)
+	}
+}
+
+func assertFailures(t *testing.T, r Repositories, webhook *model.Webhook, failures int, active bool) {
+	t.Helper()
+	got, err := r.Webhooks.GetWebhook(context.Background(), webhook.ID, webhook.UserID)
+	if err != nil {
+		t.Fatalf("GetWebhook: %v", err)
+	}
+	if got.ConsecutiveFailures != failures || got.Active != active || (got.DisabledAt == nil) != active {
+		t.Fatalf("webhook = %+v, want %d failures and active %v", got, failures, active)
+	}
+}
+
+func assertExpense(t *testing.T, got, want *model.Expense) {
+	t.Helper()
+	if got.ID != want.ID || got.UserID != want.UserID || got.Amount != want.Amount ||
+		got.Category != want.Category || got.Description != want.Description ||
+		!got.Date.Equal(want.Date.Time) || !slices.Equal(got.Tags, want.Tags) {
+		t.Fatalf("expense = %+v, want %+v", got, want)
+	}
+}
+
+func assertIDs(t *testing.T, name string, expenses []model.Expense, want ...int) {
+	t.Helper()
+	got := make([]int, len(expenses))
+	for i, e := range expenses {
+		got[i] = e.ID
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("%s returned %v, want %v", name, got, want)
+	}
+}
+
+func date(s string) model.Date {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return model.Date{Time: t}
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"expense_tracker/internal/model"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SearchTerm is one element of a parsed search query: either a single word
+// that is matched as a prefix, or a phrase whose words must appear in order.
+type SearchTerm struct {
+	Words  []string
+	Phrase bool
+}
+
+// ParseSearchQuery splits a user search string into terms.
+// Bare words become prefix terms and "quoted phrases" become phrase terms;
+// all terms must match. Words are lower-cased and everything except letters
+// and digits is dropped.
+func ParseSearchQuery(input string) []SearchTerm {
+	var terms []SearchTerm
+
+	for i, chunk := range strings.Split(input, `"`) {
+		words := SearchWords(chunk)
+		if len(words) == 0 {
+			continue
+		}
+
+		// Odd chunks were enclosed in quotes.
+		if i%2 == 1 {
+			terms = append(terms, SearchTerm{Words: words, Phrase: true})
+			continue
+		}
+
+		for _, w := range words {
+			terms = append(terms, SearchTerm{Words: []string{w}})
+		}
+	}
+
+	return terms
+}
+
+// SearchWords splits s into lower-cased words made of letters and digits.
+func SearchWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), isSeparator)
+}
+
+// Weights mirror the default ts_rank weights of the category (A) and description (B) columns.
+const (
+	categoryWeight    = 1.0
+	descriptionWeight = 0.4
+)
+
+// snippetWords is the number of words kept around the first match of long descriptions.
+const snippetWords = 20
+
+// MatchSearch ranks e against terms for backends without a full-text index.
+// It follows the same prefix and phrase rules as the PostgreSQL backend and
+// reports false if any term matches neither the category nor the description.
+func MatchSearch(e *model.Expense, terms []SearchTerm) (float64, bool) {
+	if len(terms) == 0 {
+		return 0, false
+	}
+
+	category := SearchWords(e.Category)
+	description := SearchWords(e.Description)
+
+	var rank float64
+	for _, term := range terms {
+		switch {
+		case matchTerm(category, term):
+			rank += categoryWeight
+		case matchTerm(description, term):
+			rank += descriptionWeight
+		default:
+			return 0, false
+		}
+	}
+	return rank / float64(len(terms)), true
+}
+
+// PageSearchResults orders results like the PostgreSQL backend (rank, then
+// newest first) and applies the limit and offset of query.
+func PageSearchResults(results []model.SearchResult, query *model.SearchQuery) []model.SearchResult {
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if !a.Date.Equal(b.Date.Time) {
+			return a.Date.After(b.Date.Time)
+		}
+		return a.ID > b.ID
+	})
+
+	if query.Offset >= len(results) {
+		return []model.SearchResult{}
+	}
+	results = results[query.Offset:]
+	if query.Limit > 0 && len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+	return results
+}
+
+// matchTerm reports whether words contain term: a prefix match for single
+// words, consecutive exact matches for phrases.
+func matchTerm(words []string, term SearchTerm) bool {
+	if !term.Phrase {
+		for _, w := range words {
+			if strings.HasPrefix(w, term.Words[0]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for i := 0; i+len(term.Words) <= len(words); i++ {
+		match := true
+		for j, tw := range term.Words {
+			if words[i+j] != tw {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// Highlight wraps the words of text matching any term in <mark> tags and, for long
+// texts, keeps only a window of words starting a little before the first match.
+// The text is HTML-escaped so that the snippet can be rendered as HTML.
+func Highlight(text string, terms []SearchTerm) string {
+	fields := strings.Fields(text)
+	first := -1
+	for i, field := range fields {
+		core := strings.TrimFunc(field, isSeparator)
+		if core == "" || !highlighted(strings.ToLower(core), terms) {
+			fields[i] = html.EscapeString(field)
+			continue
+		}
+		before, after, _ := strings.Cut(field, core)
+		fields[i] = html.EscapeString(before) + "<mark>" + html.EscapeString(core) + "</mark>" + html.EscapeString(after)
+		if first < 0 {
+			first = i
+		}
+	}
+
+	if len(fields) <= snippetWords {
+		return strings.Join(fields, " ")
+	}
+	start := max(first-snippetWords/4, 0)
+	end := min(start+snippetWords, len(fields))
+	return strings.Join(fields[start:end], " ")
+}
+
+// isSeparator reports whether r is dropped from search words.
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// highlighted reports whether a single word is matched by any term.
+func highlighted(word string, terms []SearchTerm) bool {
+	for _, term := range terms {
+		for _, tw := range term.Words {
+			if (!term.Phrase && strings.HasPrefix(word, tw)) || (term.Phrase && word == tw) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+// Package sqlite implements the repository interfaces on top of SQLite using a
+// pure-Go driver, so the server runs as a single binary without a database
+// server. Values are normalized to match the PostgreSQL backend: amounts are
+// stored in cents and rounded like DECIMAL(10,2), dates drop the time of day,
+// text comparisons are case-sensitive and deleting a user cascades to their
+// expenses.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"expense_tracker/internal/config"
+	"expense_tracker/internal/repository"
+	"expense_tracker/migrations"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	sqlitedriver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// dateLayout is the text format of the date column.
+const dateLayout = "2006-01-02"
+
+// timestampLayout is the text format of the created_at column, always in UTC.
+const timestampLayout = "2006-01-02T15:04:05.000Z"
+
+// The Database struct holds the connection to a SQLite database file.
+type Database struct {
+	DB *sql.DB
+}
+
+// querier is the set of query methods shared by *sql.DB and *sql.Tx,
+// so repositories can run the same statements inside or outside a transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// isUniqueViolation reports whether err was caused by a unique constraint violation.
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlitedriver.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+// NewDB opens the SQLite database at cfg.SQLitePath, creating the file if needed.
+//
+// SQLite allows a single writer, so the database uses one connection and
+// concurrent requests queue for it. Like with the in-memory backend, code
+// running inside WithTx must only use the repository it is given.
+func NewDB(ctx context.Context, cfg *config.Config) (*Database, error) {
+	db, err := sql.Open("sqlite", dsn(cfg.SQLitePath))
+	if err != nil {
+		return nil, fmt.Errorf("repository: can't open sqlite database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("repository: can't open sqlite database: %w", err)
+	}
+	return &Database{DB: db}, nil
+}
+
+// dsn adds the connection pragmas to path: foreign keys are off by default in
+// SQLite and are needed for ON DELETE CASCADE.
+func dsn(path string) string {
+	return path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+}
+
+// Close closes the database connection.
+func (db *Database) Close() {
+	db.DB.Close()
+}
+
+// Ping verifies that the database can be queried.
+func (db *Database) Ping(ctx context.Context) error {
+	if err := db.DB.PingContext(ctx); err != nil {
+		return fmt.Errorf("repository: database ping failed: %w", err)
+	}
+	return nil
+}
+
+// SchemaVersion returns the migration version recorded by golang-migrate and
+// whether the last migration left the schema dirty.
+func (db *Database) SchemaVersion(ctx context.Context) (uint, bool, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+	q := `SELECT version, dirty FROM schema_migrations LIMIT 1`
+	err := db.DB.QueryRowContext(ctx, q).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("repository: can't read schema version: %w", err)
+	}
+	return uint(version), dirty, nil
+}
+
+// Migrate applies all pending migrations over the open connection, which also
+// works for ":memory:" databases that a separate connection could not see.
+func (db *Database) Migrate() error {
+	driver, err := sqlite.WithInstance(db.DB, &sqlite.Config{})
+	if err != nil {
+		return fmt.Errorf("repository: migration init failed: %w", err)
+	}
+
+	// The migrator is not closed: that would close db.DB as well.
+	m, err := repository.NewMigratorWithInstance(migrations.SQLiteFS, "sqlite", "sqlite", driver)
+	if err != nil {
+		return err
+	}
+	return m.Up()
+}
+
+// toCents converts an amount to the integer stored in the amount_cents column.
+// Amounts too large for DECIMAL(10,2) map to a value the CHECK constraint rejects.
+func toCents(amount float64) int64 {
+	if amount >= repository.MaxAmount {
+		return math.MaxInt64
+	}
+	return int64(math.Round(repository.RoundCents(amount) * 100))
+}
+
+// fromCents is the inverse of toCents.
+func fromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
+
+// dateBounds converts an inclusive time range into the range of dates it
+// contains, matching a comparison of a DATE column with timestamps.
+func dateBounds(start, end time.Time) (string, string) {
+	first := start.UTC().Truncate(24 * time.Hour)
+	if first.Before(start) {
+		first = first.AddDate(0, 0, 1)
+	}
+	return first.Format(dateLayout), end.UTC().Format(dateLayout)
+}
+
+// encodeTags returns the JSON array stored in the tags column; nil becomes '[]'.
+func encodeTags(tags []string) (string, error) {
+	if tags == nil {
+		tags = []string{}
+	}
+	b, err := json.Marshal(tags)
+	if err != nil {
+		return "", fmt.Errorf("can't encode tags: %w", err)
+	}
+	return string(b), nil
+}
+
+// decodeTags parses the tags column into a slice that is never nil.
+func decodeTags(s string) ([]string, error) {
+	tags := []string{}
+	if err := json.Unmarshal([]byte(s), &tags); err != nil {
+		return nil, fmt.Errorf("can't decode tags: %w", err)
+	}
+	return tags, nil
+}
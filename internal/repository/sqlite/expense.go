@@ -0,0 +1,373 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"expense_tracker/lib"
+	"fmt"
+	"time"
+)
+
+// expenseColumns is the column list read by scanExpense.
+const expenseColumns = `id, user_id, amount_cents, category, description, date, tags`
+
+// ExpenseRepository is the SQLite implementation of repository.ExpenseRepository.
+type ExpenseRepository struct {
+	db   *Database
+	conn querier
+	// tx is the transaction this repository is bound to, or nil.
+	tx *sql.Tx
+	// depth counts the savepoints opened inside tx.
+	depth int
+}
+
+var _ repository.ExpenseRepository = (*ExpenseRepository)(nil)
+
+// NewExpenseRepository creates a new instance of ExpenseRepository.
+func NewExpenseRepository(db *Database) *ExpenseRepository {
+	return &ExpenseRepository{
+		db:   db,
+		conn: db.DB,
+	}
+}
+
+// WithTx runs fn inside a database transaction. The repository passed to fn
+// executes every query in that transaction; it is committed if fn returns nil
+// and rolled back otherwise. Calling WithTx on a repository that is already
+// bound to a transaction creates a savepoint.
+func (r *ExpenseRepository) WithTx(ctx context.Context, fn func(tx repository.ExpenseRepository) error) error {
+	if r.tx != nil {
+		return r.withSavepoint(ctx, fn)
+	}
+
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("repository/expense: can't begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(&ExpenseRepository{db: r.db, conn: tx, tx: tx}); err != nil {
+		lib.Logger(ctx).Debug("repository/expense: transaction rolled back", "error", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repository/expense: can't commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *ExpenseRepository) withSavepoint(ctx context.Context, fn func(tx repository.ExpenseRepository) error) error {
+	name := fmt.Sprintf("sp_%d", r.depth+1)
+	if _, err := r.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("repository/expense: can't create savepoint: %w", err)
+	}
+
+	if err := fn(&ExpenseRepository{db: r.db, conn: r.tx, tx: r.tx, depth: r.depth + 1}); err != nil {
+		// ROLLBACK TO keeps the savepoint open, so it is released afterwards.
+		_, rbErr := r.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+		_, relErr := r.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+		if rbErr != nil || relErr != nil {
+			return errors.Join(err, rbErr, relErr)
+		}
+		return err
+	}
+
+	if _, err := r.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("repository/expense: can't release savepoint: %w", err)
+	}
+	return nil
+}
+
+// CreateExpense inserts a new expense record into the database.
+func (r *ExpenseRepository) CreateExpense(ctx context.Context, expense model.Expense) (int, error) {
+	tags, err := encodeTags(expense.Tags)
+	if err != nil {
+		return 0, fmt.Errorf("repository/expense: can't create expense: %w", err)
+	}
+
+	var id int
+	q := `INSERT INTO expenses (user_id, amount_cents, category, description, date, tags)
+		VALUES (?, ?, ?, ?, ?, ?) RETURNING id`
+
+	err = r.conn.QueryRowContext(ctx, q, expense.UserID, toCents(expense.Amount), expense.Category,
+		expense.Description, expense.Date, tags).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("repository/expense: can't create expense: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetExpenseByID retrieves an expense by its ID and associated user ID.
+func (r *ExpenseRepository) GetExpenseByID(ctx context.Context, id int, userID int) (*model.Expense, error) {
+	q := `SELECT ` + expenseColumns + ` FROM expenses WHERE id = ? AND user_id = ?`
+	expense, err := scanExpense(r.conn.QueryRowContext(ctx, q, id, userID))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("repository/expense: no such expense: %w", lib.NotFound("expense not found"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't found expense: %w", err)
+	}
+	return expense, nil
+}
+
+// IsExists checks if an expense with given ID exists for specified user.
+func (r *ExpenseRepository) IsExists(ctx context.Context, id int, userID int) (bool, error) {
+	var count int
+	q := `SELECT COUNT(*) FROM expenses WHERE id = ? AND user_id = ?`
+	err := r.conn.QueryRowContext(ctx, q, id, userID).Scan(&count)
+
+	if err != nil {
+		return false, fmt.Errorf("repository/expense: can't check existanse of the expense: %w", err)
+	}
+	return count > 0, nil
+}
+
+// UpdateExpense modifies an existing expense record.
+func (r *ExpenseRepository) UpdateExpense(ctx context.Context, id int, userID int, input *model.UpdateExpenseInput) (*model.Expense, error) {
+	var amount, tags any
+	if input.Amount != nil {
+		amount = toCents(*input.Amount)
+	}
+	if input.Tags != nil {
+		encoded, err := encodeTags(*input.Tags)
+		if err != nil {
+			return nil, fmt.Errorf("repository/expense: can't update expense: %w", err)
+		}
+		tags = encoded
+	}
+
+	q := `UPDATE expenses SET amount_cents = COALESCE(?, amount_cents), category = COALESCE(?, category),
+	description = COALESCE(?, description), date = COALESCE(?, date), tags = COALESCE(?, tags)
+	WHERE id = ? AND user_id = ? RETURNING ` + expenseColumns
+
+	updated, err := scanExpense(r.conn.QueryRowContext(ctx, q, amount, input.Category,
+		input.Description, input.Date, tags, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("repository/expense: no such expense to update: %w", lib.NotFound("expense not found"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't update expense: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteExpense removes an expense record by ID.
+func (r *ExpenseRepository) DeleteExpense(ctx context.Context, id int, userID int) error {
+	q := `DELETE FROM expenses WHERE id = ? AND user_id = ?`
+	result, err := r.conn.ExecContext(ctx, q, id, userID)
+	if err != nil {
+		return fmt.Errorf("repository/expense: can`t delete expanse: %w", err)
+	}
+
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return fmt.Errorf("repository/expense: expanse not found (id: %d, user_id: %d): %w", id, userID, lib.NotFound("expense not found"))
+	}
+	return nil
+}
+
+// GetExpensesList retrieves all expenses for a specific user.
+func (r *ExpenseRepository) GetExpensesList(ctx context.Context, userID int) ([]model.Expense, error) {
+	q := `SELECT ` + expenseColumns + ` FROM expenses WHERE user_id = ? ORDER BY id`
+
+	rows, err := r.conn.QueryContext(ctx, q, userID)
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't get list of expenses: %w", err)
+	}
+	defer rows.Close()
+
+	return scanExpenses(rows)
+}
+
+// GetExpensesByPeriod retrieves expenses for a user within a specific date range.
+func (r *ExpenseRepository) GetExpensesByPeriod(ctx context.Context, userID int, start, end time.Time) ([]model.Expense, error) {
+	from, to := dateBounds(start, end)
+	q := `SELECT ` + expenseColumns + ` FROM expenses
+	WHERE user_id = ? AND date BETWEEN ? AND ? ORDER BY date, id`
+
+	rows, err := r.conn.QueryContext(ctx, q, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't get expenses by period: %w", err)
+	}
+	defer rows.Close()
+
+	return scanExpenses(rows)
+}
+
+// GetExpensesByCategory retrieves expenses for a user in a specific category.
+func (r *ExpenseRepository) GetExpensesByCategory(ctx context.Context, userID int, category string) ([]model.Expense, error) {
+	q := `SELECT ` + expenseColumns + ` FROM expenses
+	WHERE user_id = ? AND category = ? ORDER BY date, id`
+
+	rows, err := r.conn.QueryContext(ctx, q, userID, category)
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't get expenses by category: %w", err)
+	}
+	defer rows.Close()
+
+	return scanExpenses(rows)
+}
+
+// FindExpenses retrieves the expenses of a user matching the filter.
+func (r *ExpenseRepository) FindExpenses(ctx context.Context, userID int, f *model.ExpenseFilter) ([]model.Expense, error) {
+	ids, err := idList(f.IDs)
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't find expenses: %w", err)
+	}
+
+	q := `SELECT ` + expenseColumns + ` FROM expenses
+	WHERE user_id = ?1` + filterClause + `
+	ORDER BY date, id`
+
+	rows, err := r.conn.QueryContext(ctx, q, userID, ids, f.Category, f.Start, f.End, f.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't find expenses: %w", err)
+	}
+	defer rows.Close()
+
+	return scanExpenses(rows)
+}
+
+// filterClause restricts a query to the rows matching an ExpenseFilter bound
+// to the parameters ?2 to ?6, see idList for the encoding of the IDs.
+const filterClause = `
+		AND (?2 IS NULL OR id IN (SELECT value FROM json_each(?2)))
+		AND (?3 IS NULL OR category = ?3)
+		AND (?4 IS NULL OR date >= ?4)
+		AND (?5 IS NULL OR date <= ?5)
+		AND (?6 IS NULL OR EXISTS (SELECT 1 FROM json_each(expenses.tags) WHERE value = ?6))`
+
+// idList encodes IDs as a JSON array for json_each, or NULL when there are none.
+func idList(ids []int) (any, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(ids)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// BulkUpdate applies a category change and/or tag edits to every expense of the user
+// matching the filter and returns the number of affected rows.
+//
+// SQLite can't merge JSON arrays in an UPDATE, so the matching rows are read and
+// rewritten one by one inside a savepoint: either every row changes or none does.
+func (r *ExpenseRepository) BulkUpdate(ctx context.Context, userID int, input *model.BulkUpdateInput) (int64, error) {
+	var updated int64
+	err := r.WithTx(ctx, func(tx repository.ExpenseRepository) error {
+		n, err := tx.(*ExpenseRepository).bulkUpdate(ctx, userID, input)
+		updated = n
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
+
+func (r *ExpenseRepository) bulkUpdate(ctx context.Context, userID int, input *model.BulkUpdateInput) (int64, error) {
+	f := input.Filter
+	ids, err := idList(f.IDs)
+	if err != nil {
+		return 0, fmt.Errorf("repository/expense: can't bulk update expenses: %w", err)
+	}
+
+	q := `SELECT id, tags FROM expenses
+	WHERE user_id = ?1` + filterClause
+
+	rows, err := r.conn.QueryContext(ctx, q, userID, ids, f.Category, f.Start, f.End, f.Tag)
+	if err != nil {
+		return 0, fmt.Errorf("repository/expense: can't bulk update expenses: %w", err)
+	}
+
+	// The rows are collected first: the single connection can't run the
+	// updates while the result set is still open.
+	changes := map[int]string{}
+	for rows.Next() {
+		var (
+			id      int
+			encoded string
+		)
+		if err := rows.Scan(&id, &encoded); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("repository/expense: can't scan expense row: %w", err)
+		}
+		tags, err := decodeTags(encoded)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("repository/expense: can't bulk update expenses: %w", err)
+		}
+		if changes[id], err = encodeTags(repository.MergeTags(tags, input.AddTags, input.RemoveTags)); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("repository/expense: can't bulk update expenses: %w", err)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("repository/expense: rows ineration error: %w", err)
+	}
+
+	update := `UPDATE expenses SET category = COALESCE(?, category), tags = ? WHERE id = ?`
+	for id, tags := range changes {
+		if _, err := r.conn.ExecContext(ctx, update, input.SetCategory, tags, id); err != nil {
+			return 0, fmt.Errorf("repository/expense: can't bulk update expenses: %w", err)
+		}
+	}
+	return int64(len(changes)), nil
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanExpense reads the expenseColumns of one row and converts them to their model types.
+func scanExpense(row scanner) (*model.Expense, error) {
+	var (
+		e     model.Expense
+		cents int64
+		date  string
+		tags  string
+	)
+	if err := row.Scan(&e.ID, &e.UserID, &cents, &e.Category, &e.Description, &date, &tags); err != nil {
+		return nil, err
+	}
+
+	e.Amount = fromCents(cents)
+	d, err := time.Parse(dateLayout, date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: %w", date, err)
+	}
+	e.Date = model.Date{Time: d}
+	if e.Tags, err = decodeTags(tags); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// scanExpenses is a helper function to scan multiple expense rows from a query result.
+func scanExpenses(rows *sql.Rows) ([]model.Expense, error) {
+	var expenses []model.Expense
+
+	for rows.Next() {
+		e, err := scanExpense(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repository/expense: can't scan expense row: %w", err)
+		}
+		expenses = append(expenses, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository/expense: rows ineration error: %w", err)
+	}
+
+	return expenses, nil
+}
@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// MarkImported records the IDs of transactions imported from a statement of
+// the account and returns the ones not recorded before.
+func (r *ExpenseRepository) MarkImported(ctx context.Context, userID int, account string, ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(ids)
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't mark transactions imported: %w", err)
+	}
+
+	// WHERE true tells the parser that ON CONFLICT is not a join constraint.
+	q := `INSERT INTO imported_transactions (user_id, account, external_id)
+		SELECT ?1, ?2, value FROM json_each(?3) WHERE true
+		ON CONFLICT DO NOTHING
+		RETURNING external_id`
+
+	marked, err := r.queryIDs(ctx, q, userID, account, string(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't mark transactions imported: %w", err)
+	}
+	return marked, nil
+}
+
+// ImportedIDs returns the IDs recorded by MarkImported for the account among ids.
+func (r *ExpenseRepository) ImportedIDs(ctx context.Context, userID int, account string, ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(ids)
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't get imported transactions: %w", err)
+	}
+
+	q := `SELECT external_id FROM imported_transactions
+		WHERE user_id = ?1 AND account = ?2 AND external_id IN (SELECT value FROM json_each(?3))`
+
+	imported, err := r.queryIDs(ctx, q, userID, account, string(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't get imported transactions: %w", err)
+	}
+	return imported, nil
+}
+
+// queryIDs runs a query returning a column of transaction IDs.
+func (r *ExpenseRepository) queryIDs(ctx context.Context, q string, args ...any) ([]string, error) {
+	rows, err := r.conn.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
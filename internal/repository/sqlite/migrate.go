@@ -0,0 +1,17 @@
+package sqlite
+
+import (
+	"expense_tracker/internal/repository"
+	"expense_tracker/migrations"
+)
+
+// NewMigrator creates a Migrator for the database file at path using the
+// SQLite migrations embedded in the binary.
+func NewMigrator(path string) (*repository.Migrator, error) {
+	return repository.NewMigrator(migrations.SQLiteFS, "sqlite", "sqlite://"+dsn(path))
+}
+
+// LatestMigrationVersion returns the version of the newest embedded SQLite migration.
+func LatestMigrationVersion() (uint, error) {
+	return repository.LatestMigrationVersion(migrations.SQLiteFS, "sqlite")
+}
@@ -0,0 +1,48 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"expense_tracker/internal/model"
+	"fmt"
+	"time"
+)
+
+// outboxEvent is the JSON encoding of a model.OutboxEvent passed to json_each.
+type outboxEvent struct {
+	Type    model.EventType `json:"type"`
+	Payload string          `json:"payload"`
+}
+
+// EnqueueDeliveries adds a delivery of every event for every active webhook
+// of the user subscribed to its type, in a single statement however many
+// events there are.
+func (r *ExpenseRepository) EnqueueDeliveries(ctx context.Context, userID int, events []model.OutboxEvent, now time.Time) (int, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+	encoded := make([]outboxEvent, len(events))
+	for i, e := range events {
+		encoded[i] = outboxEvent{Type: e.Type, Payload: string(e.Payload)}
+	}
+	list, err := json.Marshal(encoded)
+	if err != nil {
+		return 0, fmt.Errorf("repository/expense: can't enqueue webhook deliveries: %w", err)
+	}
+
+	q := `INSERT INTO webhook_deliveries (webhook_id, event_type, payload, next_attempt_at, created_at)
+	SELECT w.id, json_extract(e.value, '$.type'), json_extract(e.value, '$.payload'), ?3, ?3
+	FROM json_each(?2) e JOIN webhooks w ON w.user_id = ?1 AND w.active
+		AND EXISTS (SELECT 1 FROM json_each(w.event_types) t WHERE t.value = json_extract(e.value, '$.type'))
+	ORDER BY e.key, w.id`
+
+	result, err := r.conn.ExecContext(ctx, q, userID, string(list), formatTimestamp(now))
+	if err != nil {
+		return 0, fmt.Errorf("repository/expense: can't enqueue webhook deliveries: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("repository/expense: can't enqueue webhook deliveries: %w", err)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"fmt"
+)
+
+// SearchExpenses narrows the user's expenses by category and date range in SQL
+// and matches the query terms in Go, following the same prefix and phrase
+// rules and ranking as the PostgreSQL backend.
+func (r *ExpenseRepository) SearchExpenses(ctx context.Context, userID int, query *model.SearchQuery) ([]model.SearchResult, error) {
+	terms := repository.ParseSearchQuery(query.Query)
+	results := []model.SearchResult{}
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	q := `SELECT ` + expenseColumns + ` FROM expenses
+	WHERE user_id = ?1
+		AND (?2 IS NULL OR category = ?2)
+		AND (?3 IS NULL OR date >= ?3)
+		AND (?4 IS NULL OR date <= ?4)`
+
+	rows, err := r.conn.QueryContext(ctx, q, userID, query.Category, query.Start, query.End)
+	if err != nil {
+		return nil, fmt.Errorf("repository/expense: can't search expenses: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanExpense(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repository/expense: can't scan search row: %w", err)
+		}
+
+		rank, ok := repository.MatchSearch(e, terms)
+		if !ok {
+			continue
+		}
+		results = append(results, model.SearchResult{
+			Expense: *e,
+			Rank:    rank,
+			Snippet: repository.Highlight(e.Description, terms),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository/expense: rows ineration error: %w", err)
+	}
+
+	return repository.PageSearchResults(results, query), nil
+}
@@ -0,0 +1,29 @@
+package sqlite_test
+
+import (
+	"context"
+	"expense_tracker/internal/config"
+	"expense_tracker/internal/repository/repotest"
+	"expense_tracker/internal/repository/sqlite"
+	"path/filepath"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		cfg := &config.Config{SQLitePath: filepath.Join(t.TempDir(), "expenses.db")}
+		db, err := sqlite.NewDB(context.Background(), cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(db.Close)
+		if err := db.Migrate(); err != nil {
+			t.Fatal(err)
+		}
+		return repotest.Repositories{
+			Users:    sqlite.NewUserRepository(db),
+			Expenses: sqlite.NewExpenseRepository(db),
+			Webhooks: sqlite.NewWebhookRepository(db),
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package sqlite
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"fmt"
+	"time"
+)
+
+// StatsRepository is the SQLite implementation of repository.StatsRepository.
+type StatsRepository struct {
+	db *Database
+}
+
+var _ repository.StatsRepository = (*StatsRepository)(nil)
+
+// NewStatsRepository creates a new instance of StatsRepository.
+func NewStatsRepository(db *Database) *StatsRepository {
+	return &StatsRepository{
+		db: db,
+	}
+}
+
+// Stats returns the current user and expense counts.
+func (r *StatsRepository) Stats(ctx context.Context) (*model.Stats, error) {
+	now := time.Now()
+	activeSince := now.AddDate(0, 0, -repository.ActiveUserDays)
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+
+	stats := &model.Stats{}
+	q := `SELECT
+		(SELECT COUNT(*) FROM users),
+		(SELECT COUNT(DISTINCT user_id) FROM expenses WHERE created_at >= ?1),
+		(SELECT COUNT(*) FROM expenses),
+		(SELECT COUNT(*) FROM expenses WHERE created_at >= ?2)`
+
+	err := r.db.DB.QueryRowContext(ctx, q, activeSince.UTC().Format(timestampLayout),
+		today.UTC().Format(timestampLayout)).Scan(&stats.Users, &stats.ActiveUsers,
+		&stats.ExpensesTotal, &stats.ExpensesCreatedToday)
+	if err != nil {
+		return nil, fmt.Errorf("repository/stats: can't get stats: %w", err)
+	}
+	return stats, nil
+}
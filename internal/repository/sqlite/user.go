@@ -0,0 +1,130 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"expense_tracker/lib"
+	"fmt"
+	"strings"
+)
+
+// passwordLength is the width of the CHAR(60) password column of the PostgreSQL schema.
+const passwordLength = 60
+
+// UserRepository is the SQLite implementation of repository.UserRepository.
+type UserRepository struct {
+	db *Database
+}
+
+var _ repository.UserRepository = (*UserRepository)(nil)
+
+// NewUserRepository creates a new instance of UserRepository.
+func NewUserRepository(db *Database) *UserRepository {
+	return &UserRepository{
+		db: db,
+	}
+}
+
+// CreateUser inserts a new user into the database.
+func (r *UserRepository) CreateUser(ctx context.Context, user *model.User) error {
+	// Pad the password like a CHAR(60) column does.
+	password := user.Password
+	if len(password) < passwordLength {
+		password += strings.Repeat(" ", passwordLength-len(password))
+	}
+
+	q := `INSERT INTO users (username, password) VALUES (?, ?) RETURNING id`
+	err := r.db.DB.QueryRowContext(ctx, q, user.Username, password).Scan(&user.ID)
+
+	if isUniqueViolation(err) {
+		lib.Logger(ctx).Debug("repository/user: username already taken", "username", user.Username)
+		return fmt.Errorf("repository/user: can't create user: %w", lib.Conflict("username is already taken"))
+	}
+	if err != nil {
+		return fmt.Errorf("repository/user: can't create user: %w", err)
+	}
+	return nil
+}
+
+// GetUserByName retrieves a user by their username.
+func (r *UserRepository) GetUserByName(ctx context.Context, username string) (*model.User, error) {
+	q := `SELECT id, username, password FROM users WHERE username = ?`
+	user := model.User{}
+	err := r.db.DB.QueryRowContext(ctx, q, username).Scan(&user.ID, &user.Username, &user.Password)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("repository/user: can't get user by name: %w", lib.NotFound("user not found"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository/user: can't get user by name: %w", err)
+	}
+	return &user, nil
+}
+
+// GetUserById retrieves a user by their ID.
+func (r *UserRepository) GetUserById(ctx context.Context, id int) (*model.User, error) {
+	q := `SELECT id, username, password FROM users WHERE id = ?`
+	user := model.User{}
+	err := r.db.DB.QueryRowContext(ctx, q, id).Scan(&user.ID, &user.Username, &user.Password)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("repository/user: can't get user by id: %w", lib.NotFound("user not found"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository/user: can't get user by id: %w", err)
+	}
+	return &user, nil
+}
+
+// DeleteUser removes a user and, through ON DELETE CASCADE, their expenses.
+func (r *UserRepository) DeleteUser(ctx context.Context, id int) error {
+	q := `DELETE FROM users WHERE id = ?`
+	result, err := r.db.DB.ExecContext(ctx, q, id)
+	if err != nil {
+		return fmt.Errorf("repository/user: can't delete user: %w", err)
+	}
+
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return fmt.Errorf("repository/user: user with id %d not found: %w", id, lib.NotFound("user not found"))
+	}
+	return nil
+}
+
+// UpdateUsername changes a user's username.
+func (r *UserRepository) UpdateUsername(ctx context.Context, id int, input *model.UpdateUsernameInput) (*model.User, error) {
+	if input.Username == "" {
+		return nil, fmt.Errorf("repository/user: %w", lib.Validation("username cannot be empty",
+			lib.FieldError{Field: "username", Code: "required", Message: "username cannot be empty"}))
+	}
+
+	updated := model.User{}
+	q := `UPDATE users SET username = ? WHERE id = ? RETURNING id, username`
+
+	err := r.db.DB.QueryRowContext(ctx, q, input.Username, id).Scan(&updated.ID, &updated.Username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("repository/user: no such user to update: %w", lib.NotFound("user not found"))
+	}
+	if isUniqueViolation(err) {
+		return nil, fmt.Errorf("repository/user: can't update user: %w", lib.Conflict("username is already taken"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository/user: can't update user: %w", err)
+	}
+
+	return &updated, nil
+}
+
+// IsExistsUser checks if a user with given ID exists.
+func (r *UserRepository) IsExistsUser(ctx context.Context, id int) (bool, error) {
+	var count int
+	q := `SELECT COUNT(*) FROM users WHERE id = ?`
+	err := r.db.DB.QueryRowContext(ctx, q, id).Scan(&count)
+
+	if err != nil {
+		return false, fmt.Errorf("repository/user: can't check existanse of the user: %w", err)
+	}
+	return count > 0, nil
+}
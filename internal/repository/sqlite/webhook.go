@@ -0,0 +1,423 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"expense_tracker/lib"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// webhookColumns and deliveryColumns are the column lists read by scanWebhook and scanDelivery.
+const (
+	webhookColumns  = `id, user_id, url, secret, event_types, active, consecutive_failures, disabled_at, created_at`
+	deliveryColumns = `id, webhook_id, event_type, payload, status, attempts, response_status, next_attempt_at, created_at, completed_at`
+)
+
+// WebhookRepository is the SQLite implementation of repository.WebhookRepository.
+type WebhookRepository struct {
+	db *Database
+}
+
+var _ repository.WebhookRepository = (*WebhookRepository)(nil)
+
+// NewWebhookRepository creates a new instance of WebhookRepository.
+func NewWebhookRepository(db *Database) *WebhookRepository {
+	return &WebhookRepository{
+		db: db,
+	}
+}
+
+// CreateWebhook inserts a new active webhook.
+func (r *WebhookRepository) CreateWebhook(ctx context.Context, webhook model.Webhook) (*model.Webhook, error) {
+	eventTypes, err := encodeEventTypes(webhook.EventTypes)
+	if err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't create webhook: %w", err)
+	}
+
+	q := `INSERT INTO webhooks (user_id, url, secret, event_types) VALUES (?, ?, ?, ?)
+	RETURNING ` + webhookColumns
+
+	created, err := scanWebhook(r.db.DB.QueryRowContext(ctx, q, webhook.UserID, webhook.URL, webhook.Secret, eventTypes))
+	if err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't create webhook: %w", err)
+	}
+	return created, nil
+}
+
+// GetWebhook retrieves a webhook by its ID and associated user ID.
+func (r *WebhookRepository) GetWebhook(ctx context.Context, id, userID int) (*model.Webhook, error) {
+	q := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = ? AND user_id = ?`
+	webhook, err := scanWebhook(r.db.DB.QueryRowContext(ctx, q, id, userID))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("repository/webhook: no such webhook: %w", lib.NotFound("webhook not found"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't get webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// ListWebhooks retrieves all webhooks of a user.
+func (r *WebhookRepository) ListWebhooks(ctx context.Context, userID int) ([]model.Webhook, error) {
+	q := `SELECT ` + webhookColumns + ` FROM webhooks WHERE user_id = ? ORDER BY id`
+	rows, err := r.db.DB.QueryContext(ctx, q, userID)
+	if err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []model.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repository/webhook: can't scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't list webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// UpdateWebhook modifies an existing webhook.
+func (r *WebhookRepository) UpdateWebhook(ctx context.Context, id, userID int, input *model.UpdateWebhookInput) (*model.Webhook, error) {
+	var eventTypes any
+	if input.EventTypes != nil {
+		encoded, err := encodeEventTypes(*input.EventTypes)
+		if err != nil {
+			return nil, fmt.Errorf("repository/webhook: can't update webhook: %w", err)
+		}
+		eventTypes = encoded
+	}
+
+	q := `UPDATE webhooks SET url = COALESCE(?1, url), secret = COALESCE(?2, secret),
+		event_types = COALESCE(?3, event_types), active = COALESCE(?4, active),
+		consecutive_failures = CASE WHEN ?4 THEN 0 ELSE consecutive_failures END,
+		disabled_at = CASE WHEN ?4 THEN NULL ELSE disabled_at END
+	WHERE id = ?5 AND user_id = ?6 RETURNING ` + webhookColumns
+
+	updated, err := scanWebhook(r.db.DB.QueryRowContext(ctx, q, input.URL, input.Secret, eventTypes, input.Active, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("repository/webhook: no such webhook to update: %w", lib.NotFound("webhook not found"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't update webhook: %w", err)
+	}
+	return updated, nil
+}
+
+// DeleteWebhook removes a webhook; its deliveries are removed by the foreign key cascade.
+func (r *WebhookRepository) DeleteWebhook(ctx context.Context, id, userID int) error {
+	q := `DELETE FROM webhooks WHERE id = ? AND user_id = ?`
+	result, err := r.db.DB.ExecContext(ctx, q, id, userID)
+	if err != nil {
+		return fmt.Errorf("repository/webhook: can't delete webhook: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return fmt.Errorf("repository/webhook: webhook not found (id: %d, user_id: %d): %w", id, userID, lib.NotFound("webhook not found"))
+	}
+	return nil
+}
+
+// ClaimDeliveries postpones due deliveries by the lease and returns them.
+func (r *WebhookRepository) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.PendingDelivery, error) {
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := `SELECT d.id, d.webhook_id, d.event_type, d.payload, d.status, d.attempts, d.response_status,
+		d.next_attempt_at, d.created_at, d.completed_at, w.url, w.secret
+	FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+	WHERE d.status = 'pending' AND d.next_attempt_at <= ? AND w.active
+	ORDER BY d.next_attempt_at, d.id
+	LIMIT ?`
+
+	rows, err := tx.QueryContext(ctx, q, formatTimestamp(now), limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't claim deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		claimed []model.PendingDelivery
+		ids     []any
+	)
+	for rows.Next() {
+		var p model.PendingDelivery
+		delivery, err := scanDelivery(rows, &p.URL, &p.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("repository/webhook: can't scan delivery: %w", err)
+		}
+		p.WebhookDelivery = *delivery
+		claimed = append(claimed, p)
+		ids = append(ids, p.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't claim deliveries: %w", err)
+	}
+	rows.Close()
+	if len(claimed) == 0 {
+		return nil, nil
+	}
+
+	leaseEnd := now.Add(lease)
+	q = `UPDATE webhook_deliveries SET next_attempt_at = ?
+	WHERE id IN (?` + strings.Repeat(", ?", len(ids)-1) + `)`
+	if _, err := tx.ExecContext(ctx, q, append([]any{formatTimestamp(leaseEnd)}, ids...)...); err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't claim deliveries: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't commit transaction: %w", err)
+	}
+
+	for i := range claimed {
+		next := leaseEnd.UTC().Truncate(time.Millisecond)
+		claimed[i].NextAttemptAt = &next
+	}
+	return claimed, nil
+}
+
+// RecordAttempt logs an attempt and updates the delivery and its webhook in one transaction.
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, attempt model.WebhookAttempt, status model.DeliveryStatus, nextAttemptAt time.Time, disableAfter int) error {
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("repository/webhook: can't begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	attemptedAt := formatTimestamp(attempt.AttemptedAt)
+	q := `INSERT INTO webhook_attempts (delivery_id, attempted_at, response_status, response_body, error, duration_ms)
+	VALUES (?, ?, ?, ?, ?, ?)`
+	if _, err := tx.ExecContext(ctx, q, attempt.DeliveryID, attemptedAt, attempt.ResponseStatus,
+		attempt.ResponseBody, attempt.Error, attempt.DurationMS); err != nil {
+		return fmt.Errorf("repository/webhook: can't record attempt: %w", err)
+	}
+
+	var webhookID int
+	q = `UPDATE webhook_deliveries SET status = ?2, attempts = attempts + 1, response_status = ?3,
+		next_attempt_at = CASE WHEN ?2 = 'pending' THEN ?4 END,
+		completed_at = CASE WHEN ?2 <> 'pending' THEN ?5 END
+	WHERE id = ?1 RETURNING webhook_id`
+	err = tx.QueryRowContext(ctx, q, attempt.DeliveryID, string(status), attempt.ResponseStatus,
+		formatTimestamp(nextAttemptAt), attemptedAt).Scan(&webhookID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("repository/webhook: can't record attempt: %w", lib.NotFound("delivery not found"))
+	}
+	if err != nil {
+		return fmt.Errorf("repository/webhook: can't record attempt: %w", err)
+	}
+
+	switch status {
+	case model.DeliverySucceeded:
+		q = `UPDATE webhooks SET consecutive_failures = 0 WHERE id = ?`
+		_, err = tx.ExecContext(ctx, q, webhookID)
+	case model.DeliveryFailed:
+		q = `UPDATE webhooks SET consecutive_failures = consecutive_failures + 1,
+			active = active AND consecutive_failures + 1 < ?2,
+			disabled_at = CASE WHEN active AND consecutive_failures + 1 >= ?2 THEN ?3 ELSE disabled_at END
+		WHERE id = ?1`
+		_, err = tx.ExecContext(ctx, q, webhookID, disableAfter, attemptedAt)
+	}
+	if err != nil {
+		return fmt.Errorf("repository/webhook: can't record attempt: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repository/webhook: can't commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries retrieves the latest deliveries of a webhook.
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID, userID, limit int) ([]model.WebhookDelivery, error) {
+	q := `SELECT d.id, d.webhook_id, d.event_type, d.payload, d.status, d.attempts, d.response_status,
+		d.next_attempt_at, d.created_at, d.completed_at
+	FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+	WHERE d.webhook_id = ? AND w.user_id = ?
+	ORDER BY d.id DESC LIMIT ?`
+
+	rows, err := r.db.DB.QueryContext(ctx, q, webhookID, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't list deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []model.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repository/webhook: can't scan delivery: %w", err)
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't list deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// GetDelivery retrieves a delivery with its attempts.
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id int64, webhookID, userID int) (*model.WebhookDelivery, error) {
+	q := `SELECT d.id, d.webhook_id, d.event_type, d.payload, d.status, d.attempts, d.response_status,
+		d.next_attempt_at, d.created_at, d.completed_at
+	FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+	WHERE d.id = ? AND d.webhook_id = ? AND w.user_id = ?`
+
+	delivery, err := scanDelivery(r.db.DB.QueryRowContext(ctx, q, id, webhookID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("repository/webhook: no such delivery: %w", lib.NotFound("delivery not found"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't get delivery: %w", err)
+	}
+
+	q = `SELECT delivery_id, attempted_at, response_status, response_body, error, duration_ms
+	FROM webhook_attempts WHERE delivery_id = ? ORDER BY id`
+	rows, err := r.db.DB.QueryContext(ctx, q, id)
+	if err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't get attempts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			a           model.WebhookAttempt
+			attemptedAt string
+		)
+		if err := rows.Scan(&a.DeliveryID, &attemptedAt, &a.ResponseStatus, &a.ResponseBody, &a.Error, &a.DurationMS); err != nil {
+			return nil, fmt.Errorf("repository/webhook: can't scan attempt: %w", err)
+		}
+		if a.AttemptedAt, err = time.Parse(timestampLayout, attemptedAt); err != nil {
+			return nil, fmt.Errorf("repository/webhook: can't scan attempt: %w", err)
+		}
+		delivery.Log = append(delivery.Log, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't get attempts: %w", err)
+	}
+	return delivery, nil
+}
+
+// Redeliver copies a delivery into a new pending one.
+func (r *WebhookRepository) Redeliver(ctx context.Context, id int64, webhookID, userID int, now time.Time) (*model.WebhookDelivery, error) {
+	q := `INSERT INTO webhook_deliveries (webhook_id, event_type, payload, next_attempt_at, created_at)
+	SELECT d.webhook_id, d.event_type, d.payload, ?4, ?4
+	FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+	WHERE d.id = ?1 AND d.webhook_id = ?2 AND w.user_id = ?3
+	RETURNING ` + deliveryColumns
+
+	delivery, err := scanDelivery(r.db.DB.QueryRowContext(ctx, q, id, webhookID, userID, formatTimestamp(now)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("repository/webhook: no such delivery to redeliver: %w", lib.NotFound("delivery not found"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository/webhook: can't redeliver: %w", err)
+	}
+	return delivery, nil
+}
+
+// DeleteDeliveriesBefore removes old completed deliveries; their attempts are removed by the cascade.
+func (r *WebhookRepository) DeleteDeliveriesBefore(ctx context.Context, t time.Time) (int64, error) {
+	q := `DELETE FROM webhook_deliveries WHERE status <> 'pending' AND created_at < ?`
+	result, err := r.db.DB.ExecContext(ctx, q, formatTimestamp(t))
+	if err != nil {
+		return 0, fmt.Errorf("repository/webhook: can't delete deliveries: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("repository/webhook: can't delete deliveries: %w", err)
+	}
+	return n, nil
+}
+
+// scanWebhook reads the webhookColumns of a row.
+func scanWebhook(row scanner) (*model.Webhook, error) {
+	var (
+		w                     model.Webhook
+		eventTypes, createdAt string
+		disabledAt            sql.NullString
+	)
+	err := row.Scan(&w.ID, &w.UserID, &w.URL, &w.Secret, &eventTypes, &w.Active,
+		&w.ConsecutiveFailures, &disabledAt, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(eventTypes), &w.EventTypes); err != nil {
+		return nil, fmt.Errorf("can't decode event types: %w", err)
+	}
+	if w.CreatedAt, err = time.Parse(timestampLayout, createdAt); err != nil {
+		return nil, err
+	}
+	if w.DisabledAt, err = parseNullTimestamp(disabledAt); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// scanDelivery reads the deliveryColumns of a row followed by extra columns.
+func scanDelivery(row scanner, extra ...any) (*model.WebhookDelivery, error) {
+	var (
+		d                          model.WebhookDelivery
+		eventType, status, payload string
+		createdAt                  string
+		nextAttemptAt, completedAt sql.NullString
+	)
+	dest := append([]any{&d.ID, &d.WebhookID, &eventType, &payload, &status, &d.Attempts, &d.ResponseStatus,
+		&nextAttemptAt, &createdAt, &completedAt}, extra...)
+	err := row.Scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+	d.EventType = model.EventType(eventType)
+	d.Status = model.DeliveryStatus(status)
+	d.Payload = json.RawMessage(payload)
+	if d.CreatedAt, err = time.Parse(timestampLayout, createdAt); err != nil {
+		return nil, err
+	}
+	if d.NextAttemptAt, err = parseNullTimestamp(nextAttemptAt); err != nil {
+		return nil, err
+	}
+	if d.CompletedAt, err = parseNullTimestamp(completedAt); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// encodeEventTypes returns the JSON array stored in the event_types column.
+func encodeEventTypes(types []model.EventType) (string, error) {
+	if types == nil {
+		types = []model.EventType{}
+	}
+	b, err := json.Marshal(types)
+	if err != nil {
+		return "", fmt.Errorf("can't encode event types: %w", err)
+	}
+	return string(b), nil
+}
+
+// formatTimestamp converts t to the text stored in timestamp columns.
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(timestampLayout)
+}
+
+// parseNullTimestamp parses a nullable timestamp column.
+func parseNullTimestamp(s sql.NullString) (*time.Time, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	t, err := time.Parse(timestampLayout, s.String)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
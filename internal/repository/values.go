@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"math/big"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// MaxAmount is the first amount that no longer fits into DECIMAL(10,2).
+const MaxAmount = 1e8
+
+// RoundCents rounds x to two decimal places the way a DECIMAL(10,2) column does:
+// the shortest decimal representation of x is rounded half away from zero.
+// Backends without a decimal type use it to store the same values as PostgreSQL.
+func RoundCents(x float64) float64 {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(x, 'f', -1, 64))
+	if !ok {
+		return x
+	}
+	r.Mul(r, big.NewRat(100, 1))
+
+	n := new(big.Int).Quo(r.Num(), r.Denom())
+	rem := new(big.Rat).Sub(r, new(big.Rat).SetInt(n))
+	if rem.Abs(rem).Cmp(big.NewRat(1, 2)) >= 0 {
+		if r.Sign() > 0 {
+			n.Add(n, big.NewInt(1))
+		} else {
+			n.Sub(n, big.NewInt(1))
+		}
+	}
+
+	f, _ := new(big.Rat).SetFrac(n, big.NewInt(100)).Float64()
+	return f
+}
+
+// MergeTags returns the sorted, de-duplicated union of tags and add without remove.
+// It is never nil, matching TEXT[] NOT NULL DEFAULT '{}'.
+func MergeTags(tags, add, remove []string) []string {
+	merged := []string{}
+	for _, t := range append(append([]string{}, tags...), add...) {
+		if !slices.Contains(remove, t) && !slices.Contains(merged, t) {
+			merged = append(merged, t)
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
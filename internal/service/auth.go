@@ -2,8 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
 	"expense_tracker/internal/model"
+	"expense_tracker/internal/ratelimit"
 	"expense_tracker/internal/repository"
+	"expense_tracker/internal/validate"
+	"expense_tracker/lib"
 	"fmt"
 	"time"
 
@@ -11,53 +15,85 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// errInvalidCredentials is returned by Login for both unknown usernames and wrong
+// passwords, so clients can't tell which one was wrong.
+var errInvalidCredentials = lib.Unauthorized("invalid credentials")
+
 // AuthService provides methods for authentication and authorization operations.
 type AuthService struct {
-	userRepository *repository.UserRepository
+	userRepository repository.UserRepository
 	jwtSecret      string
 	tokenExpiry    time.Duration
+	lockout        *ratelimit.Lockout
 }
 
-// NewAuthService create an instance of AuthService.
-func NewAuthService(userRepository *repository.UserRepository, jwtSecret string, tokenExpiry time.Duration) *AuthService {
+// NewAuthService create an instance of AuthService. Repeated failed logins lock
+// the account through lockout; a nil lockout disables the protection.
+func NewAuthService(userRepository repository.UserRepository, jwtSecret string, tokenExpiry time.Duration, lockout *ratelimit.Lockout) *AuthService {
 	return &AuthService{
 		userRepository: userRepository,
 		jwtSecret:      jwtSecret,
 		tokenExpiry:    tokenExpiry,
+		lockout:        lockout,
 	}
 }
 
 // Register creates a new user account with hashed password.
-func (s *AuthService) Register(ctx context.Context, user *model.User) error {
-	exists, err := s.userRepository.IsExistsUser(ctx, user.ID)
-	if err != nil {
-		return fmt.Errorf("service/auth: can`t check existence of user: %w", err)
-	}
-	if exists {
-		return fmt.Errorf("service/auth: user is already exists")
+func (s *AuthService) Register(ctx context.Context, user *model.User) (err error) {
+	ctx, span := startSpan(ctx, "AuthService.Register")
+	defer func() { endSpan(span, err) }()
+
+	if err := validate.Struct(user); err != nil {
+		return fmt.Errorf("service/auth: invalid registration: %w", err)
 	}
+
+	_, hashSpan := startSpan(ctx, "bcrypt.GenerateFromPassword")
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	hashSpan.End()
 	if err != nil {
 		return fmt.Errorf("service/auth: can't hash password: %w", err)
 	}
 	user.Password = string(hashedPassword)
 
 	if err := s.userRepository.CreateUser(ctx, user); err != nil {
-		return fmt.Errorf("service/auth: can't registrate user")
+		return fmt.Errorf("service/auth: can't registrate user: %w", err)
 	}
+	lib.Logger(ctx).Info("user registered", "new_user_id", user.ID)
 	return nil
 }
 
 // Login authenticates a user and generates a JWT token.
-func (s *AuthService) Login(ctx context.Context, input *model.LoginInput) (string, error) {
+func (s *AuthService) Login(ctx context.Context, input *model.LoginInput) (_ string, err error) {
+	ctx, span := startSpan(ctx, "AuthService.Login")
+	defer func() { endSpan(span, err) }()
+
+	// The password isn't checked while the account is locked, so a locked
+	// account can't be used to probe passwords.
+	if lockedFor := s.lockedFor(ctx, input.Username); lockedFor > 0 {
+		lib.Logger(ctx).Warn("login refused: account locked", "username", input.Username, "locked_for", lockedFor)
+		return "", fmt.Errorf("service/auth: account locked: %w",
+			lib.TooManyRequests("too many failed login attempts, try again later", lockedFor))
+	}
+
 	user, err := s.userRepository.GetUserByName(ctx, input.Username)
+	if errors.Is(err, lib.ErrNotFound) {
+		lib.Logger(ctx).Warn("login failed: unknown username", "username", input.Username)
+		s.loginFailed(ctx, input.Username)
+		return "", fmt.Errorf("service/auth: wrong username: %w", errInvalidCredentials)
+	}
 	if err != nil {
-		return "", fmt.Errorf("service/auth: wrong username: %w", err)
+		return "", fmt.Errorf("service/auth: can't get user: %w", err)
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
-		return "", fmt.Errorf("service/auth: wrong password: %w", err)
+	_, compareSpan := startSpan(ctx, "bcrypt.CompareHashAndPassword")
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password))
+	compareSpan.End()
+	if err != nil {
+		lib.Logger(ctx).Warn("login failed: wrong password", "username", input.Username)
+		s.loginFailed(ctx, input.Username)
+		return "", fmt.Errorf("service/auth: wrong password: %w", errInvalidCredentials)
 	}
+	s.loginSucceeded(ctx, input.Username)
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"user_id": user.ID,
@@ -71,6 +107,45 @@ func (s *AuthService) Login(ctx context.Context, input *model.LoginInput) (strin
 	return signedToken, nil
 }
 
+// lockedFor returns how long username is locked out. Lockout store errors are
+// logged and let the attempt through, so an outage of a shared store doesn't
+// prevent every login.
+func (s *AuthService) lockedFor(ctx context.Context, username string) time.Duration {
+	if s.lockout == nil {
+		return 0
+	}
+	lockedFor, err := s.lockout.LockedFor(ctx, username)
+	if err != nil {
+		lib.Logger(ctx).Error("service/auth: login lockout unavailable", "error", err)
+	}
+	return lockedFor
+}
+
+// loginFailed records a failed login of username and logs when it locks the account.
+func (s *AuthService) loginFailed(ctx context.Context, username string) {
+	if s.lockout == nil {
+		return
+	}
+	lockedFor, err := s.lockout.Fail(ctx, username)
+	if err != nil {
+		lib.Logger(ctx).Error("service/auth: login lockout unavailable", "error", err)
+		return
+	}
+	if lockedFor > 0 {
+		lib.Logger(ctx).Warn("account locked after failed logins", "username", username, "locked_for", lockedFor)
+	}
+}
+
+// loginSucceeded clears the failed logins of username.
+func (s *AuthService) loginSucceeded(ctx context.Context, username string) {
+	if s.lockout == nil {
+		return
+	}
+	if err := s.lockout.Reset(ctx, username); err != nil {
+		lib.Logger(ctx).Error("service/auth: login lockout unavailable", "error", err)
+	}
+}
+
 // ValidateToken verifies a JWT token and extracts user ID.
 func (s *AuthService) ValidateToken(tokenString string) (int, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
@@ -81,12 +156,13 @@ func (s *AuthService) ValidateToken(tokenString string) (int, error) {
 	})
 
 	if err != nil {
-		return 0, fmt.Errorf("service/auth: can't validate token %w", err)
+		return 0, fmt.Errorf("service/auth: can't validate token %v: %w", err, lib.Unauthorized("invalid token"))
 	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID := int(claims["user_id"].(float64))
-		return userID, nil
+		if userID, ok := claims["user_id"].(float64); ok {
+			return int(userID), nil
+		}
 	}
-	return 0, fmt.Errorf("service/auth: invalid token")
+	return 0, fmt.Errorf("service/auth: %w", lib.Unauthorized("invalid token"))
 }
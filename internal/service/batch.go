@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"expense_tracker/internal/validate"
+	"expense_tracker/lib"
+	"fmt"
+)
+
+// MaxBatchOperations limits the number of operations accepted in one batch request.
+// It matches the max rule on model.BatchRequest.Operations.
+const MaxBatchOperations = 500
+
+// errBatchAborted signals that an atomic batch must be rolled back.
+var errBatchAborted = errors.New("service/expense: batch aborted")
+
+// Batch executes a list of create, update and delete operations in a single transaction.
+//
+// In atomic mode the first failing operation rolls back the whole batch and every
+// other operation is reported as rolled back. In best-effort mode each operation runs
+// in its own savepoint, so failures are reported per item and successful operations
+// are committed.
+func (s *ExpenseService) Batch(ctx context.Context, userID int, req *model.BatchRequest) (_ *model.BatchResponse, err error) {
+	ctx, span := startSpan(ctx, "ExpenseService.Batch")
+	defer func() { endSpan(span, err) }()
+
+	// Operations are validated one by one below, so that a best-effort batch
+	// reports invalid items individually instead of rejecting the whole request.
+	if err := validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("service/expense: invalid batch: %w", err)
+	}
+	if req.Mode == "" {
+		req.Mode = model.BatchAtomic
+	}
+
+	resp := &model.BatchResponse{
+		Mode:    req.Mode,
+		Results: make([]model.BatchResult, len(req.Operations)),
+	}
+	for i, op := range req.Operations {
+		resp.Results[i] = model.BatchResult{Index: i, Op: op.Op, ID: op.ID}
+	}
+
+	var events []model.ExpenseEvent
+	err = s.expenseRepository.WithTx(ctx, func(tx repository.ExpenseRepository) error {
+		for i, op := range req.Operations {
+			res := &resp.Results[i]
+
+			var err error
+			if req.Mode == model.BatchBestEffort {
+				err = tx.WithTx(ctx, func(sp repository.ExpenseRepository) error {
+					return applyBatchOperation(ctx, sp, userID, op, res)
+				})
+			} else {
+				err = applyBatchOperation(ctx, tx, userID, op, res)
+			}
+
+			if err != nil {
+				res.Status = model.BatchStatusFailed
+				res.Error = lib.PublicMessage(err)
+				res.Expense = nil
+				if req.Mode == model.BatchAtomic {
+					return errBatchAborted
+				}
+				continue
+			}
+			res.Status = model.BatchStatusOK
+		}
+
+		for _, res := range resp.Results {
+			if res.Status != model.BatchStatusOK {
+				continue
+			}
+			switch res.Op {
+			case model.BatchCreate:
+				events = append(events, s.newEvent(model.EventExpenseCreated, userID, res.ID, res.Expense))
+			case model.BatchUpdate:
+				events = append(events, s.newEvent(model.EventExpenseUpdated, userID, res.ID, res.Expense))
+			case model.BatchDelete:
+				events = append(events, s.newEvent(model.EventExpenseDeleted, userID, res.ID, nil))
+			}
+		}
+		return enqueue(ctx, tx, userID, events)
+	})
+	if err != nil && !errors.Is(err, errBatchAborted) {
+		return nil, fmt.Errorf("service/expense: can't execute batch: %w", err)
+	}
+
+	for i := range resp.Results {
+		res := &resp.Results[i]
+		switch {
+		case errors.Is(err, errBatchAborted) && res.Status != model.BatchStatusFailed:
+			res.Status = model.BatchStatusRolledBack
+			res.Expense = nil
+			if res.Op == model.BatchCreate {
+				res.ID = 0
+			}
+			resp.Failed++
+		case res.Status == model.BatchStatusOK:
+			resp.Succeeded++
+		default:
+			resp.Failed++
+		}
+	}
+
+	s.publish(ctx, events...)
+
+	lib.Logger(ctx).Info("expense batch executed",
+		"mode", resp.Mode, "succeeded", resp.Succeeded, "failed", resp.Failed)
+	return resp, nil
+}
+
+// applyBatchOperation validates and executes a single batch operation, filling res on success.
+func applyBatchOperation(ctx context.Context, repo repository.ExpenseRepository, userID int, op model.BatchOperation, res *model.BatchResult) error {
+	switch op.Op {
+	case model.BatchCreate:
+		if op.Expense == nil {
+			return lib.Validation("create requires an expense")
+		}
+		if err := validate.Struct(op.Expense); err != nil {
+			return err
+		}
+		created, err := createExpense(ctx, repo, userID, *op.Expense)
+		if err != nil {
+			return err
+		}
+		res.ID = created.ID
+		res.Expense = created
+
+	case model.BatchUpdate:
+		if op.ID <= 0 || op.Update == nil {
+			return lib.Validation("update requires an id and an update")
+		}
+		if err := validate.Struct(op.Update); err != nil {
+			return err
+		}
+		updated, err := updateExpense(ctx, repo, op.ID, userID, op.Update)
+		if err != nil {
+			return err
+		}
+		res.Expense = updated
+
+	case model.BatchDelete:
+		if op.ID <= 0 {
+			return lib.Validation("delete requires an id")
+		}
+		if err := repo.DeleteExpense(ctx, op.ID, userID); err != nil {
+			return fmt.Errorf("service/expense: can't delete expense: %w", err)
+		}
+
+	default:
+		return lib.Validation(fmt.Sprintf("unknown batch operation %q", op.Op))
+	}
+	return nil
+}
+
+// BulkUpdate recategorizes and/or retags every expense of the user matching the filter.
+func (s *ExpenseService) BulkUpdate(ctx context.Context, userID int, input *model.BulkUpdateInput) (_ *model.BulkUpdateResult, err error) {
+	ctx, span := startSpan(ctx, "ExpenseService.BulkUpdate")
+	defer func() { endSpan(span, err) }()
+
+	if err := validate.Struct(input); err != nil {
+		return nil, fmt.Errorf("service/expense: invalid bulk update: %w", err)
+	}
+	if input.Filter.IsEmpty() {
+		return nil, fmt.Errorf("service/expense: %w", lib.Validation("bulk update requires at least one filter",
+			lib.FieldError{Field: "filter", Code: "required", Message: "bulk update requires at least one filter"}))
+	}
+	if input.SetCategory == nil && len(input.AddTags) == 0 && len(input.RemoveTags) == 0 {
+		return nil, fmt.Errorf("service/expense: %w", lib.Validation("bulk update has nothing to change"))
+	}
+
+	// Events carry the updated expenses, so the matching ones are looked up
+	// first and the update is restricted to them.
+	var (
+		updated int64
+		events  []model.ExpenseEvent
+	)
+	err = s.expenseRepository.WithTx(ctx, func(tx repository.ExpenseRepository) error {
+		matched, err := tx.FindExpenses(ctx, userID, &input.Filter)
+		if err != nil || len(matched) == 0 {
+			return err
+		}
+
+		scoped := *input
+		scoped.Filter = model.ExpenseFilter{IDs: make([]int, len(matched))}
+		for i, e := range matched {
+			scoped.Filter.IDs[i] = e.ID
+		}
+		if updated, err = tx.BulkUpdate(ctx, userID, &scoped); err != nil {
+			return err
+		}
+		changed, err := tx.FindExpenses(ctx, userID, &scoped.Filter)
+		if err != nil {
+			return err
+		}
+		events = make([]model.ExpenseEvent, len(changed))
+		for i := range changed {
+			events[i] = s.newEvent(model.EventExpenseUpdated, userID, changed[i].ID, &changed[i])
+		}
+		return enqueue(ctx, tx, userID, events)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("service/expense: can't bulk update expenses: %w", err)
+	}
+
+	s.publish(ctx, events...)
+	lib.Logger(ctx).Info("expenses bulk updated", "updated", updated)
+	return &model.BulkUpdateResult{Updated: updated}, nil
+}
@@ -2,48 +2,161 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"expense_tracker/internal/model"
 	"expense_tracker/internal/repository"
+	"expense_tracker/internal/validate"
+	"expense_tracker/lib"
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
+// EventPublisher receives the changes of expenses once they are committed.
+// It is implemented by events.Hub and events.PostgresBridge. Webhook
+// deliveries don't go through it: they are enqueued in the transaction of
+// the change.
+type EventPublisher interface {
+	Publish(ctx context.Context, event model.ExpenseEvent)
+}
+
+// Publishers is an EventPublisher publishing every event to each of its elements.
+type Publishers []EventPublisher
+
+// Publish publishes event to every publisher in order.
+func (p Publishers) Publish(ctx context.Context, event model.ExpenseEvent) {
+	for _, publisher := range p {
+		publisher.Publish(ctx, event)
+	}
+}
+
 // ExpenseService provides methods for expense management.
 type ExpenseService struct {
-	expenseRepository *repository.ExpenseRepository
+	expenseRepository repository.ExpenseRepository
+	events            EventPublisher
+	// lastEventID is the ID of the latest published event.
+	lastEventID atomic.Int64
 }
 
-// NewExpenseService create an instanse of ExpenseService.
-func NewExpenseService(expenseRepository *repository.ExpenseRepository) *ExpenseService {
+// NewExpenseService create an instanse of ExpenseService. Committed changes
+// are published to events; a nil publisher disables them. Webhook deliveries
+// are enqueued either way.
+func NewExpenseService(expenseRepository repository.ExpenseRepository, events EventPublisher) *ExpenseService {
 	return &ExpenseService{
 		expenseRepository: expenseRepository,
+		events:            events,
+	}
+}
+
+// newEvent returns the event reporting a change of an expense; expense is
+// nil for deletions.
+func (s *ExpenseService) newEvent(eventType model.EventType, userID, expenseID int, expense *model.Expense) model.ExpenseEvent {
+	return model.ExpenseEvent{
+		ID:        s.nextEventID(),
+		Type:      eventType,
+		UserID:    userID,
+		ExpenseID: expenseID,
+		Expense:   expense,
+		Time:      time.Now().UTC(),
+	}
+}
+
+// enqueue adds the webhook deliveries of the events of a user with a single
+// call of the repository. It must be given the transaction of the changes
+// the events report, so that their deliveries are committed with them.
+func enqueue(ctx context.Context, tx repository.ExpenseRepository, userID int, events []model.ExpenseEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	outbox := make([]model.OutboxEvent, len(events))
+	for i, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("service/expense: can't encode event: %w", err)
+		}
+		outbox[i] = model.OutboxEvent{Type: event.Type, Payload: payload}
+	}
+
+	n, err := tx.EnqueueDeliveries(ctx, userID, outbox, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("service/expense: can't enqueue webhook deliveries: %w", err)
+	}
+	if n > 0 {
+		lib.Logger(ctx).Debug("webhook deliveries enqueued", "events", len(events), "deliveries", n)
+	}
+	return nil
+}
+
+// publish reports committed changes to the event publisher.
+func (s *ExpenseService) publish(ctx context.Context, events ...model.ExpenseEvent) {
+	if s.events == nil {
+		return
+	}
+	for _, event := range events {
+		s.events.Publish(ctx, event)
+	}
+}
+
+// nextEventID returns the ID of a new event: the time in microseconds, bumped
+// when several events are published within the same microsecond or the clock
+// goes back.
+func (s *ExpenseService) nextEventID() int64 {
+	for {
+		last := s.lastEventID.Load()
+		id := max(last+1, time.Now().UnixMicro())
+		if s.lastEventID.CompareAndSwap(last, id) {
+			return id
+		}
 	}
 }
 
 // CreateExpense create an expense.
-func (s *ExpenseService) CreateExpense(ctx context.Context, userID int, expense model.Expense) (*model.Expense, error) {
-	if expense.Amount <= 0 {
-		return nil, fmt.Errorf("service/expense: amount must be positive")
+func (s *ExpenseService) CreateExpense(ctx context.Context, userID int, expense model.Expense) (_ *model.Expense, err error) {
+	ctx, span := startSpan(ctx, "ExpenseService.CreateExpense")
+	defer func() { endSpan(span, err) }()
+
+	if err := validate.Struct(&expense); err != nil {
+		return nil, fmt.Errorf("service/expense: invalid expense: %w", err)
 	}
 
-	if len(expense.Category) == 0 {
-		return nil, fmt.Errorf("service/expense: category is required")
+	var (
+		created *model.Expense
+		event   model.ExpenseEvent
+	)
+	err = s.expenseRepository.WithTx(ctx, func(tx repository.ExpenseRepository) error {
+		if created, err = createExpense(ctx, tx, userID, expense); err != nil {
+			return err
+		}
+		event = s.newEvent(model.EventExpenseCreated, userID, created.ID, created)
+		return enqueue(ctx, tx, userID, []model.ExpenseEvent{event})
+	})
+	if err != nil {
+		return nil, err
 	}
+	s.publish(ctx, event)
+	return created, nil
+}
 
+// createExpense stores an already validated expense using the given repository.
+func createExpense(ctx context.Context, repo repository.ExpenseRepository, userID int, expense model.Expense) (*model.Expense, error) {
 	expense.UserID = userID
 
-	id, err := s.expenseRepository.CreateExpense(ctx, expense)
+	id, err := repo.CreateExpense(ctx, expense)
 	if err != nil {
 		return nil, fmt.Errorf("service/expense: can't create expense: %w", err)
 	}
 
 	expense.ID = id
+	lib.Logger(ctx).Debug("expense created", "expense_id", id)
 	return &expense, nil
 }
 
 // GetExpenses retrieves an expense by ID.
-func (s *ExpenseService) GetExpense(ctx context.Context, userID, expenseID int) (*model.Expense, error) {
+func (s *ExpenseService) GetExpense(ctx context.Context, userID, expenseID int) (_ *model.Expense, err error) {
+	ctx, span := startSpan(ctx, "ExpenseService.GetExpense")
+	defer func() { endSpan(span, err) }()
+
 	expense, err := s.expenseRepository.GetExpenseByID(ctx, expenseID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("service/expense: can't get expense: %w", err)
@@ -53,16 +166,43 @@ func (s *ExpenseService) GetExpense(ctx context.Context, userID, expenseID int)
 }
 
 // UpdateExpense updates atributes of expenses by ID.
-func (s *ExpenseService) UpdateExpense(ctx context.Context, expenseID, userID int, input *model.UpdateExpenseInput) (*model.Expense, error) {
-	exists, err := s.expenseRepository.IsExists(ctx, expenseID, userID)
+func (s *ExpenseService) UpdateExpense(ctx context.Context, expenseID, userID int, input *model.UpdateExpenseInput) (_ *model.Expense, err error) {
+	ctx, span := startSpan(ctx, "ExpenseService.UpdateExpense")
+	defer func() { endSpan(span, err) }()
+
+	if err := validate.Struct(input); err != nil {
+		return nil, fmt.Errorf("service/expense: invalid update: %w", err)
+	}
+
+	var (
+		updated *model.Expense
+		event   model.ExpenseEvent
+	)
+	err = s.expenseRepository.WithTx(ctx, func(tx repository.ExpenseRepository) error {
+		if updated, err = updateExpense(ctx, tx, expenseID, userID, input); err != nil {
+			return err
+		}
+		event = s.newEvent(model.EventExpenseUpdated, userID, expenseID, updated)
+		return enqueue(ctx, tx, userID, []model.ExpenseEvent{event})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.publish(ctx, event)
+	return updated, nil
+}
+
+// updateExpense updates an existing expense using the given repository.
+func updateExpense(ctx context.Context, repo repository.ExpenseRepository, expenseID, userID int, input *model.UpdateExpenseInput) (*model.Expense, error) {
+	exists, err := repo.IsExists(ctx, expenseID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("service/expense: can't found this expense: %w", err)
 	}
 	if !exists {
-		return nil, fmt.Errorf("service/expense: expense not found")
+		return nil, fmt.Errorf("service/expense: %w", lib.NotFound("expense not found"))
 	}
 
-	updated, err := s.expenseRepository.UpdateExpense(ctx, expenseID, userID, input)
+	updated, err := repo.UpdateExpense(ctx, expenseID, userID, input)
 	if err != nil {
 		return nil, fmt.Errorf("service/expense: can't update expense: %w", err)
 	}
@@ -70,15 +210,30 @@ func (s *ExpenseService) UpdateExpense(ctx context.Context, expenseID, userID in
 }
 
 // DeleteExpense delete expense by ID.
-func (s *ExpenseService) DeleteExpense(ctx context.Context, expenseID, userID int) error {
-	if err := s.expenseRepository.DeleteExpense(ctx, expenseID, userID); err != nil {
-		return fmt.Errorf("service/expense: can't delete expense: %w", err)
+func (s *ExpenseService) DeleteExpense(ctx context.Context, expenseID, userID int) (err error) {
+	ctx, span := startSpan(ctx, "ExpenseService.DeleteExpense")
+	defer func() { endSpan(span, err) }()
+
+	var event model.ExpenseEvent
+	err = s.expenseRepository.WithTx(ctx, func(tx repository.ExpenseRepository) error {
+		if err := tx.DeleteExpense(ctx, expenseID, userID); err != nil {
+			return fmt.Errorf("service/expense: can't delete expense: %w", err)
+		}
+		event = s.newEvent(model.EventExpenseDeleted, userID, expenseID, nil)
+		return enqueue(ctx, tx, userID, []model.ExpenseEvent{event})
+	})
+	if err != nil {
+		return err
 	}
+	lib.Logger(ctx).Debug("expense deleted", "expense_id", expenseID)
+	s.publish(ctx, event)
 	return nil
 }
 
 // GetExpensesList retrieves all user's expenses by user ID.
-func (s *ExpenseService) GetExpensesList(ctx context.Context, userID int) ([]model.Expense, error) {
+func (s *ExpenseService) GetExpensesList(ctx context.Context, userID int) (_ []model.Expense, err error) {
+	ctx, span := startSpan(ctx, "ExpenseService.GetExpensesList")
+	defer func() { endSpan(span, err) }()
 
 	expenses, err := s.expenseRepository.GetExpensesList(ctx, userID)
 	if err != nil {
@@ -89,7 +244,9 @@ func (s *ExpenseService) GetExpensesList(ctx context.Context, userID int) ([]mod
 }
 
 // GetExpensesByPeriod retrieves expenses for a user within a specific date range.
-func (s *ExpenseService) GetExpensesByPeriod(ctx context.Context, userID int, start, end time.Time) ([]model.Expense, error) {
+func (s *ExpenseService) GetExpensesByPeriod(ctx context.Context, userID int, start, end time.Time) (_ []model.Expense, err error) {
+	ctx, span := startSpan(ctx, "ExpenseService.GetExpensesByPeriod")
+	defer func() { endSpan(span, err) }()
 
 	expenses, err := s.expenseRepository.GetExpensesByPeriod(ctx, userID, start, end)
 	if err != nil {
@@ -100,9 +257,13 @@ func (s *ExpenseService) GetExpensesByPeriod(ctx context.Context, userID int, st
 }
 
 // GetExpensesByCategory retrieves expenses in a specific category.
-func (s *ExpenseService) GetExpensesByCategory(ctx context.Context, userID int, category string) ([]model.Expense, error) {
+func (s *ExpenseService) GetExpensesByCategory(ctx context.Context, userID int, category string) (_ []model.Expense, err error) {
+	ctx, span := startSpan(ctx, "ExpenseService.GetExpensesByCategory")
+	defer func() { endSpan(span, err) }()
+
 	if strings.TrimSpace(category) == "" {
-		return nil, fmt.Errorf("service/expense: category can't be empty")
+		return nil, fmt.Errorf("service/expense: %w", lib.Validation("category can't be empty",
+			lib.FieldError{Field: "category", Code: "required", Message: "category can't be empty"}))
 	}
 
 	expenses, err := s.expenseRepository.GetExpensesByCategory(ctx, userID, category)
@@ -112,3 +273,55 @@ func (s *ExpenseService) GetExpensesByCategory(ctx context.Context, userID int,
 	return expenses, nil
 
 }
+
+// FindExpenses retrieves the expenses matching a filter ordered by date.
+func (s *ExpenseService) FindExpenses(ctx context.Context, userID int, filter *model.ExpenseFilter) (_ []model.Expense, err error) {
+	ctx, span := startSpan(ctx, "ExpenseService.FindExpenses")
+	defer func() { endSpan(span, err) }()
+
+	if err := validate.Struct(filter); err != nil {
+		return nil, fmt.Errorf("service/expense: invalid filter: %w", err)
+	}
+
+	expenses, err := s.expenseRepository.FindExpenses(ctx, userID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("service/expense: can't find expenses: %w", err)
+	}
+	return expenses, nil
+}
+
+// Default and maximum page sizes for SearchExpenses.
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// SearchExpenses performs a full-text search over a user's expenses.
+func (s *ExpenseService) SearchExpenses(ctx context.Context, userID int, query *model.SearchQuery) (_ []model.SearchResult, err error) {
+	ctx, span := startSpan(ctx, "ExpenseService.SearchExpenses")
+	defer func() { endSpan(span, err) }()
+
+	if strings.TrimSpace(query.Query) == "" {
+		return nil, fmt.Errorf("service/expense: %w", lib.Validation("search query can't be empty",
+			lib.FieldError{Field: "q", Code: "required", Message: "search query can't be empty"}))
+	}
+	if query.Start != nil && query.End != nil && query.End.Before(query.Start.Time) {
+		return nil, fmt.Errorf("service/expense: %w", lib.Validation("end date must be after start date",
+			lib.FieldError{Field: "end", Code: "date_range", Message: "end date must be after start date"}))
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultSearchLimit
+	}
+	if query.Limit > MaxSearchLimit {
+		query.Limit = MaxSearchLimit
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+
+	results, err := s.expenseRepository.SearchExpenses(ctx, userID, query)
+	if err != nil {
+		return nil, fmt.Errorf("service/expense: can't search expenses: %w", err)
+	}
+	return results, nil
+}
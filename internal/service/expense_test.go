@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"expense_tracker/internal/repository/memory"
+	"testing"
+	"time"
+)
+
+// outboxExpenses counts the calls of EnqueueDeliveries, inside transactions
+// too, and fails them if fail is set.
+type outboxExpenses struct {
+	repository.ExpenseRepository
+	calls *int
+	fail  bool
+}
+
+func (r outboxExpenses) WithTx(ctx context.Context, fn func(tx repository.ExpenseRepository) error) error {
+	return r.ExpenseRepository.WithTx(ctx, func(tx repository.ExpenseRepository) error {
+		return fn(outboxExpenses{tx, r.calls, r.fail})
+	})
+}
+
+func (r outboxExpenses) EnqueueDeliveries(ctx context.Context, userID int, events []model.OutboxEvent, now time.Time) (int, error) {
+	*r.calls++
+	if r.fail {
+		return 0, errors.New("connection refused")
+	}
+	return r.ExpenseRepository.EnqueueDeliveries(ctx, userID, events, now)
+}
+
+// newOutboxService returns a service on an in-memory database holding user 1
+// with a webhook subscribed to every event.
+func newOutboxService(t *testing.T, fail bool) (*ExpenseService, *memory.DB, *int) {
+	t.Helper()
+	ctx := context.Background()
+	db := memory.NewDB()
+	if err := memory.NewUserRepository(db).CreateUser(ctx, &model.User{Username: "alice", Password: "hash"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := memory.NewWebhookRepository(db).CreateWebhook(ctx, model.Webhook{
+		UserID:     1,
+		URL:        "https://example.com/hook",
+		Secret:     "secret",
+		EventTypes: []model.EventType{model.EventExpenseCreated, model.EventExpenseUpdated, model.EventExpenseDeleted},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := new(int)
+	return NewExpenseService(outboxExpenses{memory.NewExpenseRepository(db), calls, fail}, nil), db, calls
+}
+
+// pendingDeliveries returns the deliveries waiting to be sent.
+func pendingDeliveries(t *testing.T, db *memory.DB) []model.PendingDelivery {
+	t.Helper()
+	claimed, err := memory.NewWebhookRepository(db).ClaimDeliveries(context.Background(), time.Now().UTC().Add(time.Hour), time.Minute, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return claimed
+}
+
+func TestBatchEnqueuesDeliveriesOnce(t *testing.T) {
+	s, db, calls := newOutboxService(t, false)
+	expense := &model.Expense{Amount: 12.5, Category: "food", Date: model.Date{Time: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)}}
+
+	resp, err := s.Batch(context.Background(), 1, &model.BatchRequest{
+		Mode: model.BatchBestEffort,
+		Operations: []model.BatchOperation{
+			{Op: model.BatchCreate, Expense: expense},
+			{Op: model.BatchDelete, ID: 99},
+			{Op: model.BatchCreate, Expense: expense},
+		},
+	})
+	if err != nil || resp.Succeeded != 2 {
+		t.Fatalf("Batch = %+v, %v", resp, err)
+	}
+	if *calls != 1 {
+		t.Errorf("EnqueueDeliveries called %d times, want once", *calls)
+	}
+	if deliveries := pendingDeliveries(t, db); len(deliveries) != 2 {
+		t.Errorf("%d deliveries, want one per successful operation", len(deliveries))
+	}
+}
+
+func TestFailedEnqueueRollsBack(t *testing.T) {
+	ctx := context.Background()
+	s, db, _ := newOutboxService(t, true)
+
+	_, err := s.CreateExpense(ctx, 1, model.Expense{Amount: 12.5, Category: "food", Date: model.Date{Time: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)}})
+	if err == nil {
+		t.Fatal("expense created without its webhook deliveries")
+	}
+	expenses, err := memory.NewExpenseRepository(db).GetExpensesList(ctx, 1)
+	if err != nil || len(expenses) != 0 {
+		t.Errorf("expenses after a failed enqueue: %+v, %v", expenses, err)
+	}
+	if deliveries := pendingDeliveries(t, db); len(deliveries) != 0 {
+		t.Errorf("%d deliveries after a failed enqueue", len(deliveries))
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"expense_tracker/internal/journal"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/validate"
+	"expense_tracker/lib"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Defaults of the export options.
+const (
+	DefaultExportCurrency = "USD"
+	DefaultFundingAccount = "Assets:Cash"
+)
+
+// ExportJournal writes the expenses matching the filter of opts to w as a
+// ledger or beancount journal, ordered by date. Every expense is a
+// transaction moving its amount from the funding account to the account of
+// its category, with its ID, category and tags as metadata, so that
+// importing the journal restores the category and tags.
+func (s *ExpenseService) ExportJournal(ctx context.Context, userID int, opts *model.ExportOptions, w io.Writer) (err error) {
+	ctx, span := startSpan(ctx, "ExpenseService.ExportJournal")
+	defer func() { endSpan(span, err) }()
+
+	opts.Currency = strings.ToUpper(cmp.Or(opts.Currency, DefaultExportCurrency))
+	opts.FundingAccount = cmp.Or(opts.FundingAccount, DefaultFundingAccount)
+	if err := validate.Struct(opts); err != nil {
+		return fmt.Errorf("service/expense: invalid export options: %w", err)
+	}
+
+	format := journal.Format(opts.Format)
+	var fields []lib.FieldError
+	if !isCurrencyCode(opts.Currency) {
+		fields = append(fields, lib.FieldError{Field: "currency", Code: "invalid", Message: "currency must be an ISO 4217 code"})
+	}
+	if !journal.ValidAccount(format, opts.FundingAccount) {
+		fields = append(fields, lib.FieldError{Field: "funding_account", Code: "invalid",
+			Message: fmt.Sprintf("%q is not a valid %s account", opts.FundingAccount, format)})
+	}
+	for _, category := range slices.Sorted(maps.Keys(opts.Accounts)) {
+		if account := opts.Accounts[category]; !journal.ValidAccount(format, account) {
+			fields = append(fields, lib.FieldError{Field: "accounts." + category, Code: "invalid",
+				Message: fmt.Sprintf("%q is not a valid %s account", account, format)})
+		}
+	}
+	if len(fields) > 0 {
+		return fmt.Errorf("service/expense: %w", lib.Validation("invalid export options", fields...))
+	}
+
+	expenses, err := s.expenseRepository.FindExpenses(ctx, userID, &opts.Filter)
+	if err != nil {
+		return fmt.Errorf("service/expense: can't find expenses to export: %w", err)
+	}
+
+	transactions := make([]journal.Transaction, 0, len(expenses))
+	for _, e := range expenses {
+		account, ok := opts.Accounts[e.Category]
+		if !ok {
+			account = "Expenses:" + cmp.Or(journal.AccountName(e.Category), "Other")
+		}
+		meta := []journal.Meta{{Key: "id", Value: strconv.Itoa(e.ID)}, {Key: "category", Value: e.Category}}
+		if len(e.Tags) > 0 {
+			meta = append(meta, journal.Meta{Key: "tags", Value: strings.Join(e.Tags, ", ")})
+		}
+		transactions = append(transactions, journal.Transaction{
+			Date:      e.Date.Time,
+			Narration: e.Description,
+			Meta:      meta,
+			Postings: []journal.Posting{
+				{Account: account, Amount: &journal.Amount{Number: e.Amount, Commodity: opts.Currency}},
+				{Account: opts.FundingAccount},
+			},
+		})
+	}
+
+	if err := journal.Write(w, format, transactions); err != nil {
+		return fmt.Errorf("service/expense: can't write journal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"expense_tracker/internal/model"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExportImportJournal(t *testing.T) {
+	ctx := context.Background()
+	date := model.Date{Time: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)}
+	expenses := []model.Expense{
+		{Amount: 12.5, Category: "eating out", Description: `Lunch "downtown"`, Date: date, Tags: []string{"lunch", "work"}},
+		{Amount: 1234.56, Category: "rent", Description: "March", Date: date},
+		// The category has no account name, so it's booked to Expenses:Other.
+		{Amount: 3, Category: "?!", Date: date, Tags: []string{"odd"}},
+	}
+
+	for _, format := range []string{"ledger", "beancount"} {
+		t.Run(format, func(t *testing.T) {
+			exporter := newExpenseService(t)
+			for _, e := range expenses {
+				if _, err := exporter.CreateExpense(ctx, 1, e); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var journal bytes.Buffer
+			if err := exporter.ExportJournal(ctx, 1, &model.ExportOptions{Format: format, Currency: "eur"}, &journal); err != nil {
+				t.Fatal(err)
+			}
+
+			importer := newExpenseService(t)
+			result, err := importer.ImportStatement(ctx, 1, &model.ImportOptions{Format: format}, &journal)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Imported != len(expenses) || result.Skipped != 0 {
+				t.Fatalf("imported %d, skipped %+v", result.Imported, result.Skips)
+			}
+			for i, got := range result.Expenses {
+				got.ID, got.UserID = 0, 0
+				if !reflect.DeepEqual(got, expenses[i]) {
+					t.Errorf("expense %d: got %+v, want %+v\n%s", i, got, expenses[i], journal.String())
+				}
+			}
+		})
+	}
+}
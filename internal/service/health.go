@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// checkTimeout bounds each dependency check of a readiness probe.
+const checkTimeout = 2 * time.Second
+
+// DatabaseChecker is implemented by repository.Database.
+type DatabaseChecker interface {
+	Ping(ctx context.Context) error
+	SchemaVersion(ctx context.Context) (uint, bool, error)
+}
+
+// HealthService reports whether the application is alive and ready to serve traffic.
+type HealthService struct {
+	db              DatabaseChecker
+	expectedVersion uint
+	shuttingDown    atomic.Bool
+}
+
+// NewHealthService create an instance of HealthService. expectedVersion is the
+// newest migration shipped with the binary; readiness fails until the database
+// schema reaches it.
+func NewHealthService(db DatabaseChecker, expectedVersion uint) *HealthService {
+	return &HealthService{
+		db:              db,
+		expectedVersion: expectedVersion,
+	}
+}
+
+// SetShuttingDown makes every following readiness check fail, so load balancers
+// stop routing new requests while in-flight ones are drained.
+func (s *HealthService) SetShuttingDown() {
+	s.shuttingDown.Store(true)
+}
+
+// Readiness runs all dependency checks.
+func (s *HealthService) Readiness(ctx context.Context) *model.HealthReport {
+	report := &model.HealthReport{
+		Status: model.HealthOK,
+		Checks: map[string]model.HealthCheck{
+			"database":   runCheck(ctx, s.db.Ping),
+			"migrations": runCheck(ctx, s.checkMigrations),
+			"shutdown":   runCheck(ctx, s.checkShutdown),
+		},
+	}
+
+	for _, check := range report.Checks {
+		if check.Status != model.HealthOK {
+			report.Status = model.HealthUnavailable
+		}
+	}
+	return report
+}
+
+func (s *HealthService) checkMigrations(ctx context.Context) error {
+	version, dirty, err := s.db.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("schema version %d is dirty", version)
+	}
+	if version != s.expectedVersion {
+		return fmt.Errorf("schema version %d, expected %d", version, s.expectedVersion)
+	}
+	return nil
+}
+
+func (s *HealthService) checkShutdown(context.Context) error {
+	if s.shuttingDown.Load() {
+		return fmt.Errorf("server is shutting down")
+	}
+	return nil
+}
+
+// runCheck executes check with a timeout and measures its duration.
+func runCheck(ctx context.Context, check func(context.Context) error) model.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := model.HealthCheck{
+		Status:     model.HealthOK,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = model.HealthUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
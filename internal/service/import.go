@@ -0,0 +1,300 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"expense_tracker/internal/statement"
+	"expense_tracker/internal/validate"
+	"expense_tracker/lib"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxImportTransactions limits the number of transactions of an imported statement file.
+const MaxImportTransactions = 5000
+
+// DefaultImportCategory is the category of imported expenses unless another is given.
+const DefaultImportCategory = "uncategorized"
+
+// Column sizes of the imported_transactions table.
+const (
+	maxImportAccount = 100
+	maxImportID      = 255
+)
+
+// importEntry is a transaction of a statement with the expense to create
+// for it, or the reason it is skipped.
+type importEntry struct {
+	account string
+	tx      statement.Transaction
+	expense model.Expense
+	skip    *model.SkippedTransaction
+}
+
+// ImportStatement creates an expense for every debit of a bank statement
+// file, in a single transaction. Credits are skipped, since money paid into
+// the account is not an expense, and so are transactions imported before,
+// which are recognized by their account and the ID the bank gave them.
+func (s *ExpenseService) ImportStatement(ctx context.Context, userID int, opts *model.ImportOptions, file io.Reader) (_ *model.ImportResult, err error) {
+	ctx, span := startSpan(ctx, "ExpenseService.ImportStatement")
+	defer func() { endSpan(span, err) }()
+
+	entries, err := readImport(opts, file)
+	if err != nil {
+		return nil, err
+	}
+	accounts, pending := pendingImports(entries)
+
+	var (
+		created []model.Expense
+		events  []model.ExpenseEvent
+	)
+	err = s.expenseRepository.WithTx(ctx, func(tx repository.ExpenseRepository) error {
+		// Pending entries are new unless MarkImported reports them imported before.
+		fresh := map[[2]string]bool{}
+		for _, account := range accounts {
+			marked, err := tx.MarkImported(ctx, userID, account, pending[account])
+			if err != nil {
+				return err
+			}
+			for _, id := range marked {
+				fresh[[2]string{account, id}] = true
+			}
+		}
+
+		for i := range entries {
+			e := &entries[i]
+			if e.skip != nil {
+				continue
+			}
+			if !fresh[[2]string{e.account, e.tx.ID}] {
+				e.skip = &model.SkippedTransaction{Account: e.account, ID: e.tx.ID, Reason: model.SkipDuplicate}
+				continue
+			}
+			expense, err := createExpense(ctx, tx, userID, e.expense)
+			if err != nil {
+				return err
+			}
+			created = append(created, *expense)
+		}
+
+		events = make([]model.ExpenseEvent, len(created))
+		for i := range created {
+			events[i] = s.newEvent(model.EventExpenseCreated, userID, created[i].ID, &created[i])
+		}
+		return enqueue(ctx, tx, userID, events)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("service/expense: can't import statement: %w", err)
+	}
+
+	s.publish(ctx, events...)
+	result := &model.ImportResult{Parsed: len(entries), Expenses: []model.Expense{}, Skips: []model.SkippedTransaction{}}
+	result.Expenses = append(result.Expenses, created...)
+	for _, e := range entries {
+		if e.skip != nil {
+			result.Skips = append(result.Skips, *e.skip)
+		}
+	}
+	result.Imported = len(created)
+	result.Skipped = len(result.Skips)
+
+	lib.Logger(ctx).Info("statement imported", "format", opts.Format,
+		"parsed", result.Parsed, "imported", result.Imported, "skipped", result.Skipped)
+	return result, nil
+}
+
+// PreviewStatement reports what ImportStatement would do with a statement
+// file: every transaction as parsed, with the expense it would become or the
+// reason it would be skipped. Nothing is stored.
+func (s *ExpenseService) PreviewStatement(ctx context.Context, userID int, opts *model.ImportOptions, file io.Reader) (_ *model.ImportPreview, err error) {
+	ctx, span := startSpan(ctx, "ExpenseService.PreviewStatement")
+	defer func() { endSpan(span, err) }()
+
+	entries, err := readImport(opts, file)
+	if err != nil {
+		return nil, err
+	}
+	accounts, pending := pendingImports(entries)
+
+	imported := map[[2]string]bool{}
+	for _, account := range accounts {
+		ids, err := s.expenseRepository.ImportedIDs(ctx, userID, account, pending[account])
+		if err != nil {
+			return nil, fmt.Errorf("service/expense: can't preview statement: %w", err)
+		}
+		for _, id := range ids {
+			imported[[2]string{account, id}] = true
+		}
+	}
+
+	preview := &model.ImportPreview{Parsed: len(entries), Transactions: make([]model.PreviewTransaction, 0, len(entries))}
+	for _, e := range entries {
+		t := model.PreviewTransaction{
+			Account:      e.account,
+			ID:           e.tx.ID,
+			Date:         model.Date{Time: e.tx.Date},
+			Amount:       e.tx.Amount,
+			Currency:     e.tx.Currency,
+			Counterparty: e.tx.Counterparty,
+			Memo:         e.tx.Memo,
+			Status:       model.ImportNew,
+		}
+		switch {
+		case e.skip != nil:
+			t.Status, t.Error = e.skip.Reason, e.skip.Error
+		case imported[[2]string{e.account, e.tx.ID}]:
+			t.Status = model.SkipDuplicate
+		default:
+			expense := e.expense
+			t.Expense = &expense
+		}
+		if t.Status == model.ImportNew {
+			preview.New++
+		} else {
+			preview.Skipped++
+		}
+		preview.Transactions = append(preview.Transactions, t)
+	}
+	return preview, nil
+}
+
+// readImport validates the options, parses the statement file and lists its
+// entries.
+func readImport(opts *model.ImportOptions, file io.Reader) ([]importEntry, error) {
+	if opts.Category == "" {
+		opts.Category = DefaultImportCategory
+	}
+	opts.Currency = strings.ToUpper(opts.Currency)
+	if err := validate.Struct(opts); err != nil {
+		return nil, fmt.Errorf("service/expense: invalid import options: %w", err)
+	}
+	if !isCurrencyCode(opts.Currency) {
+		return nil, fmt.Errorf("service/expense: %w", lib.Validation("invalid import options",
+			lib.FieldError{Field: "currency", Code: "invalid", Message: "currency must be an ISO 4217 code"}))
+	}
+
+	statements, err := statement.Parse(statement.Format(opts.Format), file)
+	var parseErr *statement.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, fmt.Errorf("service/expense: %w", lib.Validation(
+			fmt.Sprintf("invalid %s statement: %v", parseErr.Format, parseErr.Err)))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("service/expense: can't parse statement: %w", err)
+	}
+
+	parsed := 0
+	for _, st := range statements {
+		parsed += len(st.Transactions)
+	}
+	if parsed > MaxImportTransactions {
+		return nil, fmt.Errorf("service/expense: %w", lib.Validation(
+			fmt.Sprintf("statement has %d transactions, at most %d can be imported at once", parsed, MaxImportTransactions)))
+	}
+
+	entries := importEntries(statements, opts)
+	if opts.Currency == "" {
+		// Expenses have no currency, so amounts in different ones can't be mixed.
+		var currencies []string
+		for _, e := range entries {
+			if e.skip == nil && e.tx.Currency != "" && !slices.Contains(currencies, e.tx.Currency) {
+				currencies = append(currencies, e.tx.Currency)
+			}
+		}
+		if len(currencies) > 1 {
+			return nil, fmt.Errorf("service/expense: %w", lib.Validation("invalid import options",
+				lib.FieldError{Field: "currency", Code: "required", Message: fmt.Sprintf(
+					"statement has debits in %s; choose the currency to import", strings.Join(currencies, ", "))}))
+		}
+	}
+	return entries, nil
+}
+
+// pendingImports groups the IDs of the entries that are not skipped by
+// account, listing the accounts in file order.
+func pendingImports(entries []importEntry) (accounts []string, pending map[string][]string) {
+	pending = map[string][]string{}
+	for _, e := range entries {
+		if e.skip == nil {
+			if _, ok := pending[e.account]; !ok {
+				accounts = append(accounts, e.account)
+			}
+			pending[e.account] = append(pending[e.account], e.tx.ID)
+		}
+	}
+	return accounts, pending
+}
+
+// importEntries lists the transactions of statements in file order with
+// the validated expense of every debit. Credits, transactions in other
+// currencies than opts.Currency, invalid transactions and IDs repeated within
+// the file are marked skipped.
+func importEntries(statements []statement.Statement, opts *model.ImportOptions) []importEntry {
+	var entries []importEntry
+	seen := map[[2]string]bool{}
+
+	for _, st := range statements {
+		for _, t := range st.Transactions {
+			e := importEntry{account: st.Account, tx: t}
+			skip := func(reason, msg string) {
+				e.skip = &model.SkippedTransaction{Account: st.Account, ID: t.ID, Reason: reason, Error: msg}
+			}
+
+			key := [2]string{st.Account, t.ID}
+			switch {
+			case t.Amount > 0:
+				skip(model.SkipCredit, "")
+			case opts.Currency != "" && t.Currency != "" && t.Currency != opts.Currency:
+				skip(model.SkipCurrency, fmt.Sprintf("transaction is in %s, not %s", t.Currency, opts.Currency))
+			case t.ID == "":
+				skip(model.SkipInvalid, "transaction has no ID")
+			case utf8.RuneCountInString(st.Account) > maxImportAccount:
+				skip(model.SkipInvalid, fmt.Sprintf("account exceeds %d characters", maxImportAccount))
+			case utf8.RuneCountInString(t.ID) > maxImportID:
+				skip(model.SkipInvalid, fmt.Sprintf("transaction ID exceeds %d characters", maxImportID))
+			case seen[key]:
+				skip(model.SkipDuplicate, "")
+			default:
+				e.expense = model.Expense{
+					Amount:      -t.Amount,
+					Category:    cmp.Or(t.Category, opts.Category),
+					Description: importDescription(t),
+					Date:        model.Date{Time: t.Date},
+					Tags:        t.Tags,
+				}
+				if err := validate.Struct(&e.expense); err != nil {
+					skip(model.SkipInvalid, lib.PublicMessage(err))
+				} else {
+					seen[key] = true
+				}
+			}
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// importDescription describes an imported expense by the counterparty and memo of
+// its transaction, cut to the maximum length of descriptions.
+func importDescription(t statement.Transaction) string {
+	description := t.Counterparty
+	if t.Memo != "" && t.Memo != t.Counterparty {
+		description = strings.TrimPrefix(description+" - "+t.Memo, " - ")
+	}
+	if utf8.RuneCountInString(description) > 1000 {
+		description = string([]rune(description)[:1000])
+	}
+	return description
+}
+
+// isCurrencyCode reports whether code, whose length is validated, is made of upper-case letters.
+func isCurrencyCode(code string) bool {
+	return strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == ""
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"expense_tracker/internal/model"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MonthlyReport summarizes the expenses of the month starting on the given
+// day, by category and by day, and compares them with the month before.
+func (s *ExpenseService) MonthlyReport(ctx context.Context, userID int, month time.Time) (_ *model.MonthlyReport, err error) {
+	ctx, span := startSpan(ctx, "ExpenseService.MonthlyReport")
+	defer func() { endSpan(span, err) }()
+
+	month = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	previous := month.AddDate(0, -1, 0)
+	end := month.AddDate(0, 1, -1)
+
+	expenses, err := s.expenseRepository.GetExpensesByPeriod(ctx, userID, previous, end)
+	if err != nil {
+		return nil, fmt.Errorf("service/expense: can't get expenses of the report: %w", err)
+	}
+
+	report := &model.MonthlyReport{
+		Month:       month,
+		Daily:       make([]float64, end.Day()),
+		Expenses:    []model.Expense{},
+		GeneratedAt: time.Now().UTC(),
+	}
+	categories := map[string]*model.CategoryTotal{}
+	for _, e := range expenses {
+		c, ok := categories[e.Category]
+		if !ok {
+			c = &model.CategoryTotal{Category: e.Category}
+			categories[e.Category] = c
+		}
+		if e.Date.Before(month) {
+			report.PreviousTotal += e.Amount
+			report.PreviousCount++
+			c.PreviousTotal += e.Amount
+			continue
+		}
+		report.Total += e.Amount
+		report.Count++
+		report.Daily[e.Date.Day()-1] += e.Amount
+		report.Expenses = append(report.Expenses, e)
+		c.Total += e.Amount
+		c.Count++
+	}
+
+	report.Categories = make([]model.CategoryTotal, 0, len(categories))
+	for _, c := range categories {
+		if report.Total > 0 {
+			c.Share = math.Round(c.Total/report.Total*10000) / 10000
+		}
+		c.Total, c.PreviousTotal = roundCents(c.Total), roundCents(c.PreviousTotal)
+		report.Categories = append(report.Categories, *c)
+	}
+	slices.SortFunc(report.Categories, func(a, b model.CategoryTotal) int {
+		return cmp.Or(cmp.Compare(b.Total, a.Total), cmp.Compare(b.PreviousTotal, a.PreviousTotal),
+			strings.Compare(a.Category, b.Category))
+	})
+	for i := range report.Daily {
+		report.Daily[i] = roundCents(report.Daily[i])
+	}
+	report.Total, report.PreviousTotal = roundCents(report.Total), roundCents(report.PreviousTotal)
+	return report, nil
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
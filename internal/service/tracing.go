@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"expense_tracker/lib"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "expense_tracker/internal/service"
+
+// startSpan starts a span for a service method, e.g. "ExpenseService.CreateExpense".
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name)
+}
+
+// endSpan finishes span, recording err if it is not nil. Client errors such as
+// validation failures are recorded as events; only internal errors mark the span as failed.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		if lib.AsError(err).Kind == lib.KindInternal {
+			span.SetStatus(codes.Error, "internal error")
+		}
+	}
+	span.End()
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"expense_tracker/internal/repository/memory"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider exporting to memory for the duration of the test.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		provider.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+// newExpenseService returns a service on an in-memory database holding user 1.
+func newExpenseService(t *testing.T) *ExpenseService {
+	t.Helper()
+	db := memory.NewDB()
+	if err := memory.NewUserRepository(db).CreateUser(context.Background(), &model.User{Username: "alice", Password: "hash"}); err != nil {
+		t.Fatal(err)
+	}
+	return NewExpenseService(memory.NewExpenseRepository(db), nil)
+}
+
+// brokenExpenses fails every lookup like a database that went away.
+type brokenExpenses struct {
+	repository.ExpenseRepository
+}
+
+func (brokenExpenses) GetExpenseByID(ctx context.Context, id, userID int) (*model.Expense, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestServiceSpans(t *testing.T) {
+	ctx := context.Background()
+	valid := model.Expense{Amount: 12.5, Category: "food", Date: model.Date{Time: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)}}
+	expenses := newExpenseService(t)
+	broken := NewExpenseService(brokenExpenses{}, nil)
+
+	tests := []struct {
+		name   string
+		call   func() error
+		span   string
+		status codes.Code
+		events int
+	}{
+		{
+			name: "success",
+			call: func() error {
+				_, err := expenses.CreateExpense(ctx, 1, valid)
+				return err
+			},
+			span:   "ExpenseService.CreateExpense",
+			status: codes.Unset,
+		},
+		{
+			name: "client error is recorded without failing the span",
+			call: func() error {
+				_, err := expenses.CreateExpense(ctx, 1, model.Expense{Amount: -1})
+				return err
+			},
+			span:   "ExpenseService.CreateExpense",
+			status: codes.Unset,
+			events: 1,
+		},
+		{
+			name: "not found",
+			call: func() error {
+				_, err := expenses.GetExpense(ctx, 1, 404)
+				return err
+			},
+			span:   "ExpenseService.GetExpense",
+			status: codes.Unset,
+			events: 1,
+		},
+		{
+			name: "internal error fails the span",
+			call: func() error {
+				_, err := broken.GetExpense(ctx, 1, 1)
+				return err
+			},
+			span:   "ExpenseService.GetExpense",
+			status: codes.Error,
+			events: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := recordSpans(t)
+			err := tt.call()
+			if (err != nil) != (tt.events > 0) {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("got %d spans, want 1", len(spans))
+			}
+			span := spans[0]
+			if span.Name != tt.span {
+				t.Errorf("span name %q, want %q", span.Name, tt.span)
+			}
+			if span.InstrumentationScope.Name != tracerName {
+				t.Errorf("tracer %q, want %q", span.InstrumentationScope.Name, tracerName)
+			}
+			if span.Status.Code != tt.status {
+				t.Errorf("status %v, want %v", span.Status.Code, tt.status)
+			}
+			if len(span.Events) != tt.events {
+				t.Fatalf("got %d events, want %d", len(span.Events), tt.events)
+			}
+			if tt.events > 0 && span.Events[0].Name != "exception" {
+				t.Errorf("event %q, want the recorded error", span.Events[0].Name)
+			}
+		})
+	}
+}
+
+func TestServiceSpanIsChildOfCaller(t *testing.T) {
+	exporter := recordSpans(t)
+	expenses := newExpenseService(t)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "GET /expenses")
+	if _, err := expenses.GetExpensesList(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	child := spans[0]
+	if child.Name != "ExpenseService.GetExpensesList" {
+		t.Fatalf("first span %q", child.Name)
+	}
+	if child.Parent.SpanID() != parent.SpanContext().SpanID() || child.SpanContext.TraceID() != parent.SpanContext().TraceID() {
+		t.Error("service span isn't a child of the request span")
+	}
+}
@@ -4,33 +4,38 @@ import (
 	"context"
 	"expense_tracker/internal/model"
 	"expense_tracker/internal/repository"
+	"expense_tracker/internal/validate"
+	"expense_tracker/lib"
 	"fmt"
 )
 
 // UserService provides methods for user management.
 type UserService struct {
-	userRepository *repository.UserRepository
+	userRepository repository.UserRepository
 }
 
 // NewUserService create an instance of UserService.
-func NewUserService(userRepository *repository.UserRepository) *UserService {
+func NewUserService(userRepository repository.UserRepository) *UserService {
 	return &UserService{
 		userRepository: userRepository,
 	}
 }
 
 // UpdateUsername updates a user's name.
-func (s *UserService) UpdateUsername(ctx context.Context, userID int, input *model.UpdateUsernameInput) (*model.User, error) {
-	if input.Username == "" {
-		return nil, fmt.Errorf("service/user: username can't be empty")
+func (s *UserService) UpdateUsername(ctx context.Context, userID int, input *model.UpdateUsernameInput) (_ *model.User, err error) {
+	ctx, span := startSpan(ctx, "UserService.UpdateUsername")
+	defer func() { endSpan(span, err) }()
+
+	if err := validate.Struct(input); err != nil {
+		return nil, fmt.Errorf("service/user: invalid username: %w", err)
 	}
 
 	exists, err := s.userRepository.IsExistsUser(ctx, userID)
-	if !exists {
-		return nil, fmt.Errorf("service/auth: user not found")
-	}
 	if err != nil {
-		return nil, fmt.Errorf("service/auth: can't found this user: %w", err)
+		return nil, fmt.Errorf("service/user: can't found this user: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("service/user: %w", lib.NotFound("user not found"))
 	}
 
 	updated, err := s.userRepository.UpdateUsername(ctx, userID, input)
@@ -41,15 +46,22 @@ func (s *UserService) UpdateUsername(ctx context.Context, userID int, input *mod
 }
 
 // DeleteUser delete a user by ID.
-func (s *UserService) DeleteUser(ctx context.Context, userID int) error {
+func (s *UserService) DeleteUser(ctx context.Context, userID int) (err error) {
+	ctx, span := startSpan(ctx, "UserService.DeleteUser")
+	defer func() { endSpan(span, err) }()
+
 	if err := s.userRepository.DeleteUser(ctx, userID); err != nil {
 		return fmt.Errorf("service/user: can't delete user: %w", err)
 	}
+	lib.Logger(ctx).Info("user deleted")
 	return nil
 }
 
 // GetUserProfile retrieves a user's profile by ID.
-func (s *UserService) GetUserProfile(ctx context.Context, userID int) (*model.User, error) {
+func (s *UserService) GetUserProfile(ctx context.Context, userID int) (_ *model.User, err error) {
+	ctx, span := startSpan(ctx, "UserService.GetUserProfile")
+	defer func() { endSpan(span, err) }()
+
 	user, err := s.userRepository.GetUserById(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("service/user: can't get user profile: %w", err)
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"expense_tracker/internal/model"
+	"expense_tracker/internal/repository"
+	"expense_tracker/internal/validate"
+	"expense_tracker/lib"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Default and maximum page sizes for ListDeliveries.
+const (
+	DefaultDeliveryLimit = 20
+	MaxDeliveryLimit     = 100
+)
+
+// WebhookService manages the webhooks of users and their deliveries.
+// ExpenseService enqueues the deliveries with the changes they report, and
+// dispatch.Dispatcher sends them.
+type WebhookService struct {
+	webhookRepository repository.WebhookRepository
+}
+
+// NewWebhookService creates an instance of WebhookService.
+func NewWebhookService(webhookRepository repository.WebhookRepository) *WebhookService {
+	return &WebhookService{
+		webhookRepository: webhookRepository,
+	}
+}
+
+// CreateWebhook creates an active webhook. A random secret is generated when
+// the input has none; this is the only response including the secret.
+func (s *WebhookService) CreateWebhook(ctx context.Context, userID int, input *model.WebhookInput) (_ *model.Webhook, err error) {
+	ctx, span := startSpan(ctx, "WebhookService.CreateWebhook")
+	defer func() { endSpan(span, err) }()
+
+	if err := validate.Struct(input); err != nil {
+		return nil, fmt.Errorf("service/webhook: invalid webhook: %w", err)
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		b := make([]byte, 32)
+		rand.Read(b)
+		secret = hex.EncodeToString(b)
+	}
+
+	created, err := s.webhookRepository.CreateWebhook(ctx, model.Webhook{
+		UserID:     userID,
+		URL:        input.URL,
+		Secret:     secret,
+		EventTypes: uniqueEventTypes(input.EventTypes),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("service/webhook: can't create webhook: %w", err)
+	}
+	lib.Logger(ctx).Info("webhook created", "webhook_id", created.ID)
+	return created, nil
+}
+
+// GetWebhook retrieves a webhook by ID.
+func (s *WebhookService) GetWebhook(ctx context.Context, userID, webhookID int) (_ *model.Webhook, err error) {
+	ctx, span := startSpan(ctx, "WebhookService.GetWebhook")
+	defer func() { endSpan(span, err) }()
+
+	webhook, err := s.webhookRepository.GetWebhook(ctx, webhookID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("service/webhook: can't get webhook: %w", err)
+	}
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// ListWebhooks retrieves all webhooks of a user.
+func (s *WebhookService) ListWebhooks(ctx context.Context, userID int) (_ []model.Webhook, err error) {
+	ctx, span := startSpan(ctx, "WebhookService.ListWebhooks")
+	defer func() { endSpan(span, err) }()
+
+	webhooks, err := s.webhookRepository.ListWebhooks(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("service/webhook: can't list webhooks: %w", err)
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks, nil
+}
+
+// UpdateWebhook updates the attributes of a webhook. Activating a webhook
+// disabled because of failures resets its failure count.
+func (s *WebhookService) UpdateWebhook(ctx context.Context, userID, webhookID int, input *model.UpdateWebhookInput) (_ *model.Webhook, err error) {
+	ctx, span := startSpan(ctx, "WebhookService.UpdateWebhook")
+	defer func() { endSpan(span, err) }()
+
+	if err := validate.Struct(input); err != nil {
+		return nil, fmt.Errorf("service/webhook: invalid update: %w", err)
+	}
+	if input.EventTypes != nil {
+		types := uniqueEventTypes(*input.EventTypes)
+		input.EventTypes = &types
+	}
+
+	updated, err := s.webhookRepository.UpdateWebhook(ctx, webhookID, userID, input)
+	if err != nil {
+		return nil, fmt.Errorf("service/webhook: can't update webhook: %w", err)
+	}
+	updated.Secret = ""
+	return updated, nil
+}
+
+// DeleteWebhook deletes a webhook with its deliveries.
+func (s *WebhookService) DeleteWebhook(ctx context.Context, userID, webhookID int) (err error) {
+	ctx, span := startSpan(ctx, "WebhookService.DeleteWebhook")
+	defer func() { endSpan(span, err) }()
+
+	if err := s.webhookRepository.DeleteWebhook(ctx, webhookID, userID); err != nil {
+		return fmt.Errorf("service/webhook: can't delete webhook: %w", err)
+	}
+	lib.Logger(ctx).Info("webhook deleted", "webhook_id", webhookID)
+	return nil
+}
+
+// ListDeliveries retrieves the latest deliveries of a webhook, newest first.
+// A limit outside 1..MaxDeliveryLimit is replaced with the default or maximum.
+func (s *WebhookService) ListDeliveries(ctx context.Context, userID, webhookID, limit int) (_ []model.WebhookDelivery, err error) {
+	ctx, span := startSpan(ctx, "WebhookService.ListDeliveries")
+	defer func() { endSpan(span, err) }()
+
+	// The repository returns no deliveries for webhooks of other users, so
+	// existence is checked first to answer 404 for them.
+	if _, err := s.webhookRepository.GetWebhook(ctx, webhookID, userID); err != nil {
+		return nil, fmt.Errorf("service/webhook: can't get webhook: %w", err)
+	}
+	if limit <= 0 {
+		limit = DefaultDeliveryLimit
+	}
+	limit = min(limit, MaxDeliveryLimit)
+
+	deliveries, err := s.webhookRepository.ListDeliveries(ctx, webhookID, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("service/webhook: can't list deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// GetDelivery retrieves a delivery of a webhook with the log of its attempts.
+func (s *WebhookService) GetDelivery(ctx context.Context, userID, webhookID int, deliveryID int64) (_ *model.WebhookDelivery, err error) {
+	ctx, span := startSpan(ctx, "WebhookService.GetDelivery")
+	defer func() { endSpan(span, err) }()
+
+	delivery, err := s.webhookRepository.GetDelivery(ctx, deliveryID, webhookID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("service/webhook: can't get delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// Redeliver enqueues a new delivery with the payload of an earlier one. The
+// webhook must be active.
+func (s *WebhookService) Redeliver(ctx context.Context, userID, webhookID int, deliveryID int64) (_ *model.WebhookDelivery, err error) {
+	ctx, span := startSpan(ctx, "WebhookService.Redeliver")
+	defer func() { endSpan(span, err) }()
+
+	webhook, err := s.webhookRepository.GetWebhook(ctx, webhookID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("service/webhook: can't get webhook: %w", err)
+	}
+	if !webhook.Active {
+		return nil, fmt.Errorf("service/webhook: %w", lib.Conflict("webhook is disabled; activate it before redelivering"))
+	}
+
+	delivery, err := s.webhookRepository.Redeliver(ctx, deliveryID, webhookID, userID, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("service/webhook: can't redeliver: %w", err)
+	}
+	lib.Logger(ctx).Info("webhook delivery redelivered", "delivery_id", deliveryID, "redelivery_id", delivery.ID)
+	return delivery, nil
+}
+
+// uniqueEventTypes returns types without duplicates, in their first order.
+func uniqueEventTypes(types []model.EventType) []model.EventType {
+	unique := make([]model.EventType, 0, len(types))
+	for _, t := range types {
+		if !slices.Contains(unique, t) {
+			unique = append(unique, t)
+		}
+	}
+	return unique
+}
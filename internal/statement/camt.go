@@ -0,0 +1,246 @@
+package statement
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/ianaindex"
+)
+
+// camtDocument is the part of a camt.053 document that is read. The element
+// names are matched regardless of their namespace, so every version of the
+// message, from camt.053.001.02 on, can be read.
+type camtDocument struct {
+	Report *struct {
+		Statements []struct {
+			Account struct {
+				IBAN     string `xml:"Id>IBAN"`
+				Other    string `xml:"Id>Othr>Id"`
+				Currency string `xml:"Ccy"`
+			} `xml:"Acct"`
+			Entries []camtEntry `xml:"Ntry"`
+		} `xml:"Stmt"`
+	} `xml:"BkToCstmrStmt"`
+}
+
+type camtEntry struct {
+	Ref         string     `xml:"NtryRef"`
+	Amount      camtAmount `xml:"Amt"`
+	CreditDebit string     `xml:"CdtDbtInd"`
+	// Status is a code up to camt.053.001.07 and a Cd element afterwards.
+	Status struct {
+		Text string `xml:",chardata"`
+		Code string `xml:"Cd"`
+	} `xml:"Sts"`
+	BookingDate camtDate        `xml:"BookgDt"`
+	ValueDate   camtDate        `xml:"ValDt"`
+	BankRef     string          `xml:"AcctSvcrRef"`
+	Details     []camtTxDetails `xml:"NtryDtls>TxDtls"`
+	Info        string          `xml:"AddtlNtryInf"`
+}
+
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+// camtTxDetails describes one of the transactions booked together as an entry.
+type camtTxDetails struct {
+	BankRef     string      `xml:"Refs>AcctSvcrRef"`
+	Amount      *camtAmount `xml:"Amt"`
+	TxAmount    *camtAmount `xml:"AmtDtls>TxAmt>Amt"`
+	CreditDebit string      `xml:"CdtDbtInd"`
+	Debtor      camtParty   `xml:"RltdPties>Dbtr"`
+	Creditor    camtParty   `xml:"RltdPties>Cdtr"`
+	Remittance  []string    `xml:"RmtInf>Ustrd"`
+	Info        string      `xml:"AddtlTxInf"`
+}
+
+// camtParty is a related party; its name moved into Pty in camt.053.001.08.
+type camtParty struct {
+	Name      string `xml:"Nm"`
+	PartyName string `xml:"Pty>Nm"`
+}
+
+func (p camtParty) name() string {
+	return strings.TrimSpace(p.Name + p.PartyName)
+}
+
+// ParseCAMT053 reads the statements of an ISO 20022 camt.053 document. Only
+// booked entries are read: pending ones may still change. An entry batching
+// several transactions with their own amounts yields one transaction each.
+//
+// The ID of a transaction is the reference the bank gave it (AcctSvcrRef) or,
+// without one, the entry reference. Entries without either get an ID derived
+// from their content.
+func ParseCAMT053(r io.Reader) ([]Statement, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("statement: can't read file: %w", err)
+	}
+	statements, err := parseCAMT053(data)
+	if err != nil {
+		return nil, &ParseError{Format: FormatCAMT053, Err: err}
+	}
+	return statements, nil
+}
+
+func parseCAMT053(data []byte) ([]Statement, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.CharsetReader = charsetReader
+	var doc camtDocument
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc.Report == nil {
+		return nil, errors.New("no BkToCstmrStmt element")
+	}
+
+	statements := make([]Statement, 0, len(doc.Report.Statements))
+	for _, s := range doc.Report.Statements {
+		st := Statement{Account: s.Account.IBAN, Currency: strings.ToUpper(s.Account.Currency)}
+		if st.Account == "" {
+			st.Account = s.Account.Other
+		}
+		for _, e := range s.Entries {
+			if status := strings.TrimSpace(e.Status.Text + e.Status.Code); status != "" && status != "BOOK" {
+				continue
+			}
+			transactions, err := camtTransactions(e, st.Currency)
+			if err != nil {
+				return nil, fmt.Errorf("account %q: %w", st.Account, err)
+			}
+			st.Transactions = append(st.Transactions, transactions...)
+		}
+		contentIDs(&st)
+		statements = append(statements, st)
+	}
+	return statements, nil
+}
+
+// charsetReader decodes documents declaring an encoding other than UTF-8.
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	enc, err := ianaindex.IANA.Encoding(label)
+	if err != nil || enc == nil {
+		return nil, fmt.Errorf("unsupported encoding %q", label)
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+// camtTransactions converts an entry into its transactions.
+func camtTransactions(e camtEntry, currency string) ([]Transaction, error) {
+	ref := e.BankRef
+	if ref == "" {
+		ref = e.Ref
+	}
+	date := e.BookingDate
+	if date.Date == "" && date.DateTime == "" {
+		date = e.ValueDate
+	}
+	day, err := date.day()
+	if err != nil {
+		return nil, fmt.Errorf("entry %q: %w", ref, err)
+	}
+
+	// An entry batching several transactions is split when each has an amount.
+	split := len(e.Details) > 1
+	for _, d := range e.Details {
+		split = split && d.amount() != nil
+	}
+	if !split {
+		var details camtTxDetails
+		if len(e.Details) > 0 {
+			details = e.Details[0]
+		}
+		if ref == "" {
+			ref = details.BankRef
+		}
+		t, err := camtTransaction(ref, day, e.Amount, e.CreditDebit, details, e.Info, currency)
+		if err != nil {
+			return nil, fmt.Errorf("entry %q: %w", ref, err)
+		}
+		return []Transaction{t}, nil
+	}
+
+	transactions := make([]Transaction, 0, len(e.Details))
+	for i, d := range e.Details {
+		id := d.BankRef
+		if id == "" && ref != "" {
+			id = fmt.Sprintf("%s/%d", ref, i+1)
+		}
+		creditDebit := d.CreditDebit
+		if creditDebit == "" {
+			creditDebit = e.CreditDebit
+		}
+		t, err := camtTransaction(id, day, *d.amount(), creditDebit, d, e.Info, currency)
+		if err != nil {
+			return nil, fmt.Errorf("entry %q: %w", ref, err)
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, nil
+}
+
+// camtTransaction builds a transaction. The counterparty of a debit is the
+// creditor and the one of a credit the debtor.
+func camtTransaction(id string, day time.Time, amt camtAmount, creditDebit string, d camtTxDetails, entryInfo, currency string) (Transaction, error) {
+	amount, err := parseAmount(amt.Value)
+	if err != nil {
+		return Transaction{}, err
+	}
+	t := Transaction{ID: id, Type: creditDebit, Date: day, Currency: currency}
+	if amt.Currency != "" {
+		t.Currency = strings.ToUpper(amt.Currency)
+	}
+
+	switch creditDebit {
+	case "DBIT":
+		t.Amount = -amount
+		t.Counterparty = d.Creditor.name()
+	case "CRDT":
+		t.Amount = amount
+		t.Counterparty = d.Debtor.name()
+	default:
+		return Transaction{}, fmt.Errorf("invalid credit debit indicator %q", creditDebit)
+	}
+
+	t.Memo = strings.Join(strings.Fields(strings.Join(d.Remittance, " ")), " ")
+	for _, info := range []string{d.Info, entryInfo} {
+		if t.Memo == "" {
+			t.Memo = strings.TrimSpace(info)
+		}
+	}
+	return t, nil
+}
+
+// amount returns the amount of the transaction, or nil if it has none.
+func (d camtTxDetails) amount() *camtAmount {
+	if d.Amount != nil {
+		return d.Amount
+	}
+	return d.TxAmount
+}
+
+// day returns the date, or the day of the date and time.
+func (d camtDate) day() (time.Time, error) {
+	s := d.Date
+	if s == "" && len(d.DateTime) >= len(time.DateOnly) {
+		s = d.DateTime[:len(time.DateOnly)]
+	}
+	t, err := time.Parse(time.DateOnly, strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", cmp.Or(d.Date, d.DateTime))
+	}
+	return t, nil
+}
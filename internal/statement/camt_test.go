@@ -0,0 +1,203 @@
+package statement
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// camt02 is a camt.053.001.02 statement, whose status is a code.
+const camt02 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+<BkToCstmrStmt>
+<Stmt>
+<Acct><Id><IBAN>DE89370400440532013000</IBAN></Id><Ccy>eur</Ccy></Acct>
+<Ntry>
+	<Amt Ccy="EUR">12.34</Amt>
+	<CdtDbtInd>DBIT</CdtDbtInd>
+	<Sts>BOOK</Sts>
+	<BookgDt><Dt>2024-03-15</Dt></BookgDt>
+	<AcctSvcrRef>R1</AcctSvcrRef>
+	<NtryDtls><TxDtls>
+		<RltdPties><Dbtr><Nm>Me</Nm></Dbtr><Cdtr><Nm>ACME GmbH</Nm></Cdtr></RltdPties>
+		<RmtInf><Ustrd>Invoice</Ustrd><Ustrd>  12</Ustrd></RmtInf>
+	</TxDtls></NtryDtls>
+</Ntry>
+<Ntry>
+	<NtryRef>N2</NtryRef>
+	<Amt Ccy="EUR">1000,00</Amt>
+	<CdtDbtInd>CRDT</CdtDbtInd>
+	<Sts>BOOK</Sts>
+	<BookgDt><DtTm>2024-03-16T10:00:00+01:00</DtTm></BookgDt>
+	<NtryDtls><TxDtls>
+		<RltdPties><Dbtr><Nm>Employer</Nm></Dbtr><Cdtr><Nm>Me</Nm></Cdtr></RltdPties>
+	</TxDtls></NtryDtls>
+	<AddtlNtryInf>Salary</AddtlNtryInf>
+</Ntry>
+<Ntry>
+	<Amt Ccy="EUR">99.00</Amt>
+	<CdtDbtInd>DBIT</CdtDbtInd>
+	<Sts>PDNG</Sts>
+	<BookgDt><Dt>2024-03-17</Dt></BookgDt>
+	<AcctSvcrRef>R3</AcctSvcrRef>
+</Ntry>
+<Ntry>
+	<NtryRef>B</NtryRef>
+	<Amt Ccy="EUR">20.00</Amt>
+	<CdtDbtInd>DBIT</CdtDbtInd>
+	<Sts>BOOK</Sts>
+	<BookgDt><Dt>2024-03-18</Dt></BookgDt>
+	<NtryDtls>
+		<TxDtls>
+			<Refs><AcctSvcrRef>B-1</AcctSvcrRef></Refs>
+			<Amt Ccy="EUR">25.00</Amt>
+			<RltdPties><Cdtr><Nm>Grocer</Nm></Cdtr></RltdPties>
+		</TxDtls>
+		<TxDtls>
+			<AmtDtls><TxAmt><Amt Ccy="usd">5.00</Amt></TxAmt></AmtDtls>
+			<CdtDbtInd>CRDT</CdtDbtInd>
+			<RltdPties><Dbtr><Nm>Grocer</Nm></Dbtr></RltdPties>
+			<AddtlTxInf>Deposit return</AddtlTxInf>
+		</TxDtls>
+	</NtryDtls>
+</Ntry>
+<Ntry>
+	<Amt Ccy="EUR">3.00</Amt>
+	<CdtDbtInd>DBIT</CdtDbtInd>
+	<Sts>BOOK</Sts>
+	<ValDt><Dt>2024-03-19</Dt></ValDt>
+	<AddtlNtryInf>Account fee</AddtlNtryInf>
+</Ntry>
+</Stmt>
+</BkToCstmrStmt>
+</Document>`
+
+// camt08 is a camt.053.001.08 statement, whose status has a code element
+// and whose parties have their name in Pty.
+const camt08 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.08">
+<BkToCstmrStmt>
+<Stmt>
+<Acct><Id><Othr><Id>0532013000</Id></Othr></Id><Ccy>CHF</Ccy></Acct>
+<Ntry>
+	<Amt Ccy="CHF">7.50</Amt>
+	<CdtDbtInd>DBIT</CdtDbtInd>
+	<Sts><Cd>BOOK</Cd></Sts>
+	<BookgDt><Dt>2024-04-01</Dt></BookgDt>
+	<AcctSvcrRef>Z1</AcctSvcrRef>
+	<NtryDtls><TxDtls>
+		<RltdPties><Cdtr><Pty><Nm> Bakery </Nm></Pty></Cdtr></RltdPties>
+	</TxDtls></NtryDtls>
+</Ntry>
+<Ntry>
+	<Amt Ccy="CHF">8.00</Amt>
+	<CdtDbtInd>DBIT</CdtDbtInd>
+	<Sts><Cd>PDNG</Cd></Sts>
+	<BookgDt><Dt>2024-04-02</Dt></BookgDt>
+	<AcctSvcrRef>Z2</AcctSvcrRef>
+</Ntry>
+</Stmt>
+</BkToCstmrStmt>
+</Document>`
+
+func TestParseCAMT053(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want []Statement
+	}{
+		{
+			name: "camt.053.001.02",
+			file: camt02,
+			want: []Statement{{
+				Account:  "DE89370400440532013000",
+				Currency: "EUR",
+				Transactions: []Transaction{
+					{ID: "R1", Type: "DBIT", Date: day(2024, 3, 15), Amount: -12.34, Currency: "EUR", Counterparty: "ACME GmbH", Memo: "Invoice 12"},
+					{ID: "N2", Type: "CRDT", Date: day(2024, 3, 16), Amount: 1000, Currency: "EUR", Counterparty: "Employer", Memo: "Salary"},
+					{ID: "B-1", Type: "DBIT", Date: day(2024, 3, 18), Amount: -25, Currency: "EUR", Counterparty: "Grocer"},
+					{ID: "B/2", Type: "CRDT", Date: day(2024, 3, 18), Amount: 5, Currency: "USD", Counterparty: "Grocer", Memo: "Deposit return"},
+					{ID: "content:", Type: "DBIT", Date: day(2024, 3, 19), Amount: -3, Currency: "EUR", Memo: "Account fee"},
+				},
+			}},
+		},
+		{
+			name: "camt.053.001.08",
+			file: camt08,
+			want: []Statement{{
+				Account:  "0532013000",
+				Currency: "CHF",
+				Transactions: []Transaction{
+					{ID: "Z1", Type: "DBIT", Date: day(2024, 4, 1), Amount: -7.5, Currency: "CHF", Counterparty: "Bakery"},
+				},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCAMT053(strings.NewReader(tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Content IDs are only compared by their prefix.
+			for _, st := range got {
+				for i := range st.Transactions {
+					if id := st.Transactions[i].ID; strings.HasPrefix(id, "content:") && strings.HasSuffix(id, ":1") {
+						st.Transactions[i].ID = "content:"
+					}
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCAMT053ContentIDsAreStable(t *testing.T) {
+	first, err := ParseCAMT053(strings.NewReader(camt02))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := ParseCAMT053(strings.NewReader(camt02))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := first[0].Transactions[4].ID; id != second[0].Transactions[4].ID || !strings.HasPrefix(id, "content:") {
+		t.Errorf("content IDs %q and %q", id, second[0].Transactions[4].ID)
+	}
+}
+
+func TestParseCAMT053Encoding(t *testing.T) {
+	file := strings.Replace(camt08, `encoding="UTF-8"`, `encoding="ISO-8859-1"`, 1)
+	file = strings.Replace(file, " Bakery ", "Caf\xe9", 1)
+	statements, err := ParseCAMT053(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := statements[0].Transactions[0].Counterparty; got != "Café" {
+		t.Errorf("counterparty %q", got)
+	}
+}
+
+func TestParseCAMT053Errors(t *testing.T) {
+	tests := []struct {
+		name, file, want string
+	}{
+		{"not a statement", `<Document><BkToCstmrDbtCdtNtfctn/></Document>`, "no BkToCstmrStmt element"},
+		{"unknown encoding", strings.Replace(camt08, `encoding="UTF-8"`, `encoding="x-unknown"`, 1), `unsupported encoding "x-unknown"`},
+		{"credit debit indicator", strings.Replace(camt08, "<CdtDbtInd>DBIT", "<CdtDbtInd>DEBIT", 1), `entry "Z1": invalid credit debit indicator "DEBIT"`},
+		{"date", strings.Replace(camt08, "2024-04-01", "01.04.2024", 1), `entry "Z1": invalid date "01.04.2024"`},
+		{"amount", strings.Replace(camt08, ">7.50<", ">7.50 CHF<", 1), `entry "Z1": invalid amount`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCAMT053(strings.NewReader(tt.file))
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) || parseErr.Format != FormatCAMT053 || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want a parse error containing %q", err, tt.want)
+			}
+		})
+	}
+}
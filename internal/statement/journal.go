@@ -0,0 +1,92 @@
+package statement
+
+import (
+	"errors"
+	"expense_tracker/internal/journal"
+	"io"
+	"slices"
+	"strings"
+)
+
+// journalSymbols are the currency symbols ledger journals commonly use as commodities.
+var journalSymbols = map[string]string{"$": "USD", "€": "EUR", "£": "GBP"}
+
+// ParseJournal reads a ledger or beancount journal as one statement per
+// expense account, the accounts whose first component is "Expenses" in any case. Every
+// posting to one with an amount is a transaction, so spending is a debit and
+// refunds are credits; the other postings are not read.
+//
+// The category of a transaction is its "category" metadata or the account
+// without its first component, so "Expenses:Food" books to "Food". Its tags
+// are the tags of the transaction and posting and the comma-separated "tags"
+// metadata. Journals have no transaction IDs, so every transaction gets one
+// derived from its content.
+func ParseJournal(format Format, r io.Reader) ([]Statement, error) {
+	transactions, err := journal.Parse(journal.Format(format), r)
+	var syntaxErr *journal.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return nil, &ParseError{Format: format, Err: err}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []Statement
+	index := map[string]int{}
+	for _, jt := range transactions {
+		for _, p := range jt.Postings {
+			root, category, _ := strings.Cut(p.Account, ":")
+			if p.Amount == nil || !strings.EqualFold(root, "Expenses") {
+				continue
+			}
+			t := Transaction{
+				Date:         jt.Date,
+				Amount:       -p.Amount.Number,
+				Currency:     strings.ToUpper(p.Amount.Commodity),
+				Counterparty: jt.Payee,
+				Memo:         jt.Narration,
+				Category:     category,
+				Tags:         journalTags(jt, p),
+			}
+			if code, ok := journalSymbols[p.Amount.Commodity]; ok {
+				t.Currency = code
+			}
+			for _, meta := range [][]journal.Meta{jt.Meta, p.Meta} {
+				if value, ok := journal.Lookup(meta, "category"); ok && value != "" {
+					t.Category = value
+				}
+			}
+
+			i, ok := index[p.Account]
+			if !ok {
+				i = len(statements)
+				index[p.Account] = i
+				statements = append(statements, Statement{Account: p.Account})
+			}
+			statements[i].Transactions = append(statements[i].Transactions, t)
+		}
+	}
+	for i := range statements {
+		contentIDs(&statements[i])
+	}
+	return statements, nil
+}
+
+// journalTags returns the tags of a posting and its transaction without duplicates.
+func journalTags(t journal.Transaction, p journal.Posting) []string {
+	var tags []string
+	for _, meta := range [][]journal.Meta{t.Meta, p.Meta} {
+		if value, ok := journal.Lookup(meta, "tags"); ok {
+			tags = append(tags, strings.Split(value, ",")...)
+		}
+	}
+	tags = append(append(tags, t.Tags...), p.Tags...)
+
+	unique := tags[:0]
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" && !slices.Contains(unique, tag) {
+			unique = append(unique, tag)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,94 @@
+package statement
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const ledgerStatement = `2024-03-15 * Lunch  ; :work:
+    ; tags: lunch, work
+    Expenses:Eating-Out           $12.50
+    Assets:Cash
+
+2024-03-16 * Refund
+    expenses:food                 -3,50 €  ; :refund:
+    ; category: groceries
+    Assets:Cash
+
+2024-03-17 * Transfer
+    Assets:Savings                100 EUR
+    Assets:Cash
+`
+
+func TestParseJournalLedger(t *testing.T) {
+	statements, err := ParseJournal(FormatLedger, strings.NewReader(ledgerStatement))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, st := range statements {
+		for i, tx := range st.Transactions {
+			if !strings.HasPrefix(tx.ID, "content:") {
+				t.Errorf("transaction %+v has no content ID", tx)
+			}
+			st.Transactions[i].ID = ""
+		}
+	}
+	want := []Statement{
+		{
+			Account: "Expenses:Eating-Out",
+			Transactions: []Transaction{{
+				Date: day(2024, 3, 15), Amount: -12.5, Currency: "USD", Memo: "Lunch",
+				Category: "Eating-Out", Tags: []string{"lunch", "work"},
+			}},
+		},
+		{
+			Account: "expenses:food",
+			Transactions: []Transaction{{
+				Date: day(2024, 3, 16), Amount: 3.5, Currency: "EUR", Memo: "Refund",
+				Category: "groceries", Tags: []string{"refund"},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(statements, want) {
+		t.Errorf("got %+v\nwant %+v", statements, want)
+	}
+}
+
+func TestParseJournalBeancount(t *testing.T) {
+	journal := `2024-03-15 * "Shop" "Lunch" #trip
+  category: "eating out"
+  Expenses:Food:Lunch  12.50 EUR
+    category: "lunch"
+  Expenses:Food:Drinks  2.00 EUR
+  Assets:Cash
+`
+	statements, err := ParseJournal(FormatBeancount, strings.NewReader(journal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statements) != 2 {
+		t.Fatalf("got %+v", statements)
+	}
+	lunch, drinks := statements[0].Transactions[0], statements[1].Transactions[0]
+	// Metadata of the posting overrides the one of the transaction.
+	if lunch.Category != "lunch" || lunch.Counterparty != "Shop" || lunch.Memo != "Lunch" || lunch.Currency != "EUR" ||
+		!reflect.DeepEqual(lunch.Tags, []string{"trip"}) {
+		t.Errorf("lunch %+v", lunch)
+	}
+	if drinks.Category != "eating out" || drinks.Amount != -2 {
+		t.Errorf("drinks %+v", drinks)
+	}
+	if lunch.ID == drinks.ID {
+		t.Errorf("transactions share the ID %q", lunch.ID)
+	}
+}
+
+func TestParseJournalErrors(t *testing.T) {
+	_, err := ParseJournal(FormatBeancount, strings.NewReader("2024-03-15 * \"unterminated\n"))
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Format != FormatBeancount || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("got %v, want a parse error on line 1", err)
+	}
+}
@@ -0,0 +1,253 @@
+package statement
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// mtField matches the first line of a field, e.g. ":61:2410011001D12,34NTRFNONREF//B4J01".
+	mtField = regexp.MustCompile(`^:(\d{2}[A-Z]?):(.*)$`)
+	// mtBalance matches the start of an opening balance, e.g. "C241001EUR".
+	mtBalance = regexp.MustCompile(`^[CD]\d{6}([A-Z]{3})`)
+	// mtLine matches a statement line: value date, optional entry date,
+	// debit/credit mark, optional funds code, amount, transaction type,
+	// customer reference, optional bank reference and supplementary details.
+	mtLine = regexp.MustCompile(`^(\d{6})(\d{4})?(R?[CD])([A-Z])?(\d[\d,]*)([NFS][A-Z0-9]{3})([^\n]*?)(?://([^\n]*))?(?:\n([\s\S]*))?$`)
+	// mtStructuredInfo matches the German structured :86: field, "NNN?00...".
+	mtStructuredInfo = regexp.MustCompile(`^\d{3}\?`)
+	// mtSEPAPurpose matches the purpose in remittance information made of
+	// SEPA keywords, e.g. "EREF+4711SVWZ+Invoice 12".
+	mtSEPAPurpose = regexp.MustCompile(`SVWZ\+(.*?)(?:(?:EREF|KREF|MREF|CRED|DEBT|ABWA|ABWE|IBAN|BIC)\+|$)`)
+	// mtSlashName and mtSlashRemittance read the /KEY/value/ form of :86:.
+	mtSlashName       = regexp.MustCompile(`/(?:NAME|CNTP/[^/]*/[^/]*)/([^/]+)`)
+	mtSlashRemittance = regexp.MustCompile(`/REMI/(?:USTD//)?([^/]+)`)
+)
+
+// mtNoReference is the placeholder of missing references.
+const mtNoReference = "NONREF"
+
+// ParseMT940 reads the statements of SWIFT MT940 messages, with or without
+// the SWIFT block envelope. Every :20: field starts a statement.
+//
+// The ID of a transaction is its bank reference, the part of :61: after
+// "//". Customer references are not used, since standing orders repeat
+// them, so lines without a bank reference get an ID derived from their
+// content. The counterparty and memo are read from the :86: field, both in
+// its German structured form (?20 to ?29 and ?32, ?33) and in the /NAME/,
+// /CNTP/ and /REMI/ form; unstructured information is the memo.
+func ParseMT940(r io.Reader) ([]Statement, error) {
+	data, err := readText(r)
+	if err != nil {
+		return nil, err
+	}
+	statements, err := parseMT940(string(data))
+	if err != nil {
+		return nil, &ParseError{Format: FormatMT940, Err: err}
+	}
+	return statements, nil
+}
+
+func parseMT940(data string) ([]Statement, error) {
+	var (
+		statements []Statement
+		st         *Statement
+		// last is the transaction an :86: field describes.
+		last *Transaction
+	)
+	finish := func() {
+		if st != nil {
+			contentIDs(st)
+			statements = append(statements, *st)
+		}
+	}
+
+	fields, err := mtFields(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		if f.tag != "20" && st == nil {
+			st = &Statement{}
+		}
+		switch f.tag {
+		case "20":
+			finish()
+			st = &Statement{}
+		case "25":
+			st.Account = strings.TrimSpace(f.value)
+		case "60F", "60M":
+			m := mtBalance.FindStringSubmatch(f.value)
+			if m == nil {
+				return nil, fmt.Errorf("invalid opening balance %q", f.value)
+			}
+			st.Currency = m[1]
+		case "61":
+			t, err := mtTransaction(f.value, st.Currency)
+			if err != nil {
+				return nil, fmt.Errorf("account %q: %w", st.Account, err)
+			}
+			st.Transactions = append(st.Transactions, t)
+			last = &st.Transactions[len(st.Transactions)-1]
+			continue
+		case "86":
+			if last != nil {
+				counterparty, memo := mtInformation(f.value)
+				last.Counterparty = counterparty
+				if memo != "" {
+					last.Memo = memo
+				}
+			}
+		}
+		last = nil
+	}
+	finish()
+	if len(statements) == 0 {
+		return nil, errors.New("no statement fields")
+	}
+	return statements, nil
+}
+
+// mtTransaction parses a :61: statement line.
+func mtTransaction(line, currency string) (Transaction, error) {
+	m := mtLine.FindStringSubmatch(line)
+	if m == nil {
+		return Transaction{}, fmt.Errorf("invalid statement line %q", line)
+	}
+	valueDate, err := time.Parse("060102", m[1])
+	if err != nil {
+		return Transaction{}, fmt.Errorf("invalid value date in statement line %q", line)
+	}
+	date := valueDate
+	if m[2] != "" {
+		if date, err = mtEntryDate(valueDate, m[2]); err != nil {
+			return Transaction{}, fmt.Errorf("invalid entry date in statement line %q", line)
+		}
+	}
+	amount, err := parseAmount(m[5])
+	if err != nil {
+		return Transaction{}, fmt.Errorf("statement line %q: %w", line, err)
+	}
+	// Reversals of credits take money out of the account, reversals of debits pay it back.
+	if m[3] == "D" || m[3] == "RC" {
+		amount = -amount
+	}
+
+	t := Transaction{
+		Type:     m[6],
+		Date:     date,
+		Amount:   amount,
+		Currency: currency,
+		Memo:     strings.Join(strings.Fields(m[9]), " "),
+	}
+	if ref := strings.TrimSpace(m[8]); ref != mtNoReference {
+		t.ID = ref
+	}
+	return t, nil
+}
+
+// mtEntryDate returns the entry date given as MMDD, in the year of the
+// value date or the next or previous one when the dates span New Year.
+func mtEntryDate(valueDate time.Time, mmdd string) (time.Time, error) {
+	d, err := time.Parse("0102", mmdd)
+	if err != nil {
+		return time.Time{}, err
+	}
+	date := time.Date(valueDate.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+	switch {
+	case date.Sub(valueDate) > 180*24*time.Hour:
+		date = date.AddDate(-1, 0, 0)
+	case valueDate.Sub(date) > 180*24*time.Hour:
+		date = date.AddDate(1, 0, 0)
+	}
+	return date, nil
+}
+
+// mtInformation returns the counterparty and memo of an :86: field.
+func mtInformation(info string) (counterparty, memo string) {
+	switch {
+	case mtStructuredInfo.MatchString(info):
+		// Subfields may be wrapped anywhere, so the lines are joined first.
+		subfields := strings.Split(strings.ReplaceAll(info, "\n", ""), "?")
+		var name, remittance []string
+		for _, sf := range subfields[1:] {
+			if len(sf) < 2 {
+				continue
+			}
+			switch key, value := sf[:2], sf[2:]; {
+			case key == "32" || key == "33":
+				name = append(name, value)
+			case key >= "20" && key <= "29" || key >= "60" && key <= "63":
+				remittance = append(remittance, value)
+			}
+		}
+		memo = strings.Join(remittance, "")
+		if m := mtSEPAPurpose.FindStringSubmatch(memo); m != nil {
+			memo = m[1]
+		}
+		return strings.TrimSpace(strings.Join(name, "")), strings.Join(strings.Fields(memo), " ")
+
+	case strings.HasPrefix(info, "/"):
+		joined := strings.ReplaceAll(info, "\n", "")
+		if m := mtSlashName.FindStringSubmatch(joined); m != nil {
+			counterparty = strings.TrimSpace(m[1])
+		}
+		if m := mtSlashRemittance.FindStringSubmatch(joined); m != nil {
+			memo = strings.Join(strings.Fields(m[1]), " ")
+		}
+		return counterparty, memo
+	}
+	return "", strings.Join(strings.Fields(info), " ")
+}
+
+// mtMaxFieldLines bounds the lines of a field. The longest field of the
+// standard, :86:, has 6 lines; banks exceeding it stay far below this.
+const mtMaxFieldLines = 100
+
+// mtFieldValue is a field of a message with its continuation lines.
+type mtFieldValue struct {
+	tag   string
+	value string
+}
+
+// mtFields splits messages into fields. The SWIFT envelope, "{1:...}" blocks
+// and the "-}" trailer, and empty lines are dropped. Fields longer than
+// mtMaxFieldLines are an error.
+func mtFields(data string) ([]mtFieldValue, error) {
+	var (
+		fields []mtFieldValue
+		// lines are the lines of the last field, joined once it ends.
+		lines []string
+	)
+	end := func() {
+		if len(lines) > 0 {
+			fields[len(fields)-1].value = strings.Join(lines, "\n")
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if i := strings.Index(line, "{4:"); i >= 0 {
+			line = line[i+len("{4:"):]
+		}
+		line = strings.TrimRight(line, " \r")
+		if line == "" || line == "-" || strings.HasPrefix(line, "{") || strings.HasPrefix(line, "-}") {
+			continue
+		}
+		if m := mtField.FindStringSubmatch(line); m != nil {
+			end()
+			fields = append(fields, mtFieldValue{tag: m[1]})
+			lines = append(lines[:0], m[2])
+		} else if len(fields) > 0 {
+			if len(lines) == mtMaxFieldLines {
+				return nil, fmt.Errorf("field :%s: has more than %d lines", fields[len(fields)-1].tag, mtMaxFieldLines)
+			}
+			lines = append(lines, line)
+		}
+	}
+	end()
+	return fields, nil
+}
@@ -0,0 +1,51 @@
+package statement
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const mt940Message = `{1:F01BANKDEFFXXXX0000000000}{2:O9400000000000BANKDEFFXXXX00000000000000000000N}{4:
+:20:STARTUMS
+:25:DE89370400440532013000
+:28C:00001/001
+:60F:C241001EUR1000,00
+:61:2410011001D12,34NTRFNONREF//B4J01
+:86:166?00SEPA-UEBERWEISUNG?20EREF+4711SVWZ+Invoice?21 12?32ACME
+?33 GmbH
+:62F:C241001EUR987,66
+-}`
+
+func TestParseMT940(t *testing.T) {
+	statements, err := ParseMT940(strings.NewReader(mt940Message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statements) != 1 || len(statements[0].Transactions) != 1 {
+		t.Fatalf("got %+v", statements)
+	}
+	st := statements[0]
+	if st.Account != "DE89370400440532013000" || st.Currency != "EUR" {
+		t.Errorf("statement %q in %q", st.Account, st.Currency)
+	}
+	tx := st.Transactions[0]
+	if tx.ID != "B4J01" || tx.Amount != -12.34 || tx.Counterparty != "ACME GmbH" || tx.Memo != "Invoice 12" {
+		t.Errorf("transaction %+v", tx)
+	}
+}
+
+func TestParseMT940LongField(t *testing.T) {
+	long := strings.Replace(mt940Message, "?33 GmbH", strings.Repeat("?34\n", mtMaxFieldLines-1)+"?33 GmbH", 1)
+	_, err := ParseMT940(strings.NewReader(long))
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || !strings.Contains(err.Error(), ":86:") {
+		t.Fatalf("got %v, want a parse error naming :86:", err)
+	}
+
+	// The limit counts the first line.
+	ok := strings.Replace(mt940Message, "?33 GmbH", strings.Repeat("?34\n", mtMaxFieldLines-2)+"?33 GmbH", 1)
+	if _, err := ParseMT940(strings.NewReader(ok)); err != nil {
+		t.Errorf("field of %d lines: %v", mtMaxFieldLines, err)
+	}
+}
@@ -0,0 +1,250 @@
+package statement
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ofxStart matches the root element, which follows the header.
+var ofxStart = regexp.MustCompile(`(?i)<OFX\s*>`)
+
+// ParseOFX reads the bank and credit card statements of an OFX file. Both
+// OFX 1.x, an SGML dialect whose leaf elements have no end tags, and the XML
+// of OFX 2.x are accepted, as is QFX, which only adds elements of its own.
+//
+// The header is skipped: files that are not valid UTF-8 are decoded as
+// Windows-1252, which is what banks declaring CHARSET:1252 or NONE send.
+func ParseOFX(r io.Reader) ([]Statement, error) {
+	data, err := readText(r)
+	if err != nil {
+		return nil, err
+	}
+	statements, err := parseOFX(data)
+	if err != nil {
+		return nil, &ParseError{Format: FormatOFX, Err: err}
+	}
+	return statements, nil
+}
+
+func parseOFX(data []byte) ([]Statement, error) {
+	loc := ofxStart.FindIndex(data)
+	if loc == nil {
+		return nil, errors.New("no <OFX> element")
+	}
+	root, err := parseElements(string(data[loc[0]:]))
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []Statement
+	for _, rs := range root.findAll("STMTRS", "CCSTMTRS") {
+		st, err := ofxStatement(rs)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, st)
+	}
+	return statements, nil
+}
+
+// ofxStatement converts a STMTRS or CCSTMTRS aggregate.
+func ofxStatement(rs *element) (Statement, error) {
+	st := Statement{Currency: strings.ToUpper(rs.text("CURDEF"))}
+	if acct := rs.child("BANKACCTFROM"); acct != nil {
+		st.Account = strings.Trim(acct.text("BANKID")+"/"+acct.text("ACCTID"), "/")
+	} else if acct := rs.child("CCACCTFROM"); acct != nil {
+		st.Account = acct.text("ACCTID")
+	}
+
+	list := rs.child("BANKTRANLIST")
+	if list == nil {
+		return st, nil
+	}
+	for _, trn := range list.children {
+		if trn.name != "STMTTRN" {
+			continue
+		}
+		t, err := ofxTransaction(trn, st.Currency)
+		if err != nil {
+			return Statement{}, fmt.Errorf("account %q: %w", st.Account, err)
+		}
+		st.Transactions = append(st.Transactions, t)
+	}
+	return st, nil
+}
+
+// ofxTransaction converts a STMTTRN aggregate. Amounts are in the statement
+// currency unless the transaction has a CURRENCY aggregate; ORIGCURRENCY only
+// names the currency the amount was converted from.
+func ofxTransaction(trn *element, currency string) (Transaction, error) {
+	t := Transaction{
+		ID:       trn.text("FITID"),
+		Type:     strings.ToUpper(trn.text("TRNTYPE")),
+		Currency: currency,
+		Memo:     trn.text("MEMO"),
+	}
+	t.Counterparty = trn.text("NAME")
+	if payee := trn.child("PAYEE"); t.Counterparty == "" && payee != nil {
+		t.Counterparty = payee.text("NAME")
+	}
+	if cur := trn.child("CURRENCY"); cur != nil && cur.text("CURSYM") != "" {
+		t.Currency = strings.ToUpper(cur.text("CURSYM"))
+	}
+
+	var err error
+	if t.Date, err = parseOFXDate(trn.text("DTPOSTED")); err != nil {
+		return Transaction{}, fmt.Errorf("transaction %q: %w", t.ID, err)
+	}
+	if t.Amount, err = parseAmount(trn.text("TRNAMT")); err != nil {
+		return Transaction{}, fmt.Errorf("transaction %q: %w", t.ID, err)
+	}
+	return t, nil
+}
+
+// parseOFXDate parses the day of an OFX datetime such as
+// "20240115120000.000[-5:EST]". The time and time zone are ignored: the day
+// is the one printed on the statement.
+func parseOFXDate(s string) (time.Time, error) {
+	if len(s) < 8 {
+		return time.Time{}, fmt.Errorf("invalid date %q", s)
+	}
+	d, err := time.Parse("20060102", s[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", s)
+	}
+	return d, nil
+}
+
+// element is a node of an OFX document. Leaf elements have a value,
+// aggregates have children.
+type element struct {
+	name     string
+	value    string
+	children []*element
+}
+
+// child returns the first child named name, or nil.
+func (e *element) child(name string) *element {
+	for _, c := range e.children {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// text returns the value of the first child named name, or "".
+func (e *element) text(name string) string {
+	if c := e.child(name); c != nil {
+		return c.value
+	}
+	return ""
+}
+
+// findAll returns the descendants of e with one of the given names in
+// document order, without looking inside the matches.
+func (e *element) findAll(names ...string) []*element {
+	var found []*element
+	for _, c := range e.children {
+		if slices.Contains(names, c.name) {
+			found = append(found, c)
+		} else {
+			found = append(found, c.findAll(names...)...)
+		}
+	}
+	return found
+}
+
+// parseElements builds the element tree of an OFX document, which is either
+// SGML or XML. A value ends its element, so the missing end tags of SGML leaf
+// elements are not needed, and the end tags XML has for them are skipped. An
+// end tag closes every element still open inside the one it names. Names are
+// upper-cased and values unescaped.
+//
+// An SGML leaf without a value, such as an empty <MEMO>, can't be told from
+// an aggregate until the end tag of its parent arrives: aggregates have end
+// tags, leaves don't. The elements that end tag closes are leaves, so the
+// elements parsed into them are moved up to follow them, as if each had been
+// closed by the next start tag.
+func parseElements(doc string) (*element, error) {
+	root := &element{}
+	stack := []*element{root}
+	// leaf is the element ended by its value, whose end tag may follow.
+	var leaf string
+
+	for doc != "" {
+		i := strings.IndexByte(doc, '<')
+		if i < 0 {
+			i = len(doc)
+		}
+		if text := strings.TrimSpace(doc[:i]); text != "" && len(stack) > 1 {
+			top := stack[len(stack)-1]
+			top.value = html.UnescapeString(text)
+			stack = stack[:len(stack)-1]
+			leaf = top.name
+		}
+		doc = doc[i:]
+		if doc == "" {
+			break
+		}
+
+		if strings.HasPrefix(doc, "<!--") {
+			end := strings.Index(doc, "-->")
+			if end < 0 {
+				return nil, errors.New("unterminated comment")
+			}
+			doc = doc[end+len("-->"):]
+			continue
+		}
+		end := strings.IndexByte(doc, '>')
+		if end < 0 {
+			return nil, errors.New("unterminated tag")
+		}
+		tag := strings.TrimSpace(doc[1:end])
+		doc = doc[end+1:]
+
+		switch {
+		case strings.HasPrefix(tag, "?"), strings.HasPrefix(tag, "!"):
+			// The OFX processing instruction of 2.x files and declarations.
+		case strings.HasPrefix(tag, "/"):
+			name := strings.ToUpper(strings.TrimSpace(tag[1:]))
+			if name == leaf {
+				leaf = ""
+				continue
+			}
+			leaf = ""
+			// Stray end tags are ignored.
+			for k := len(stack) - 1; k > 0; k-- {
+				if stack[k].name == name {
+					for j := len(stack) - 1; j > k; j-- {
+						parent := stack[j-1]
+						parent.children = append(parent.children, stack[j].children...)
+						stack[j].children = nil
+					}
+					stack = stack[:k]
+					break
+				}
+			}
+		default:
+			selfClosing := strings.HasSuffix(tag, "/")
+			fields := strings.Fields(strings.TrimSuffix(tag, "/"))
+			if len(fields) == 0 {
+				return nil, errors.New("empty tag")
+			}
+			el := &element{name: strings.ToUpper(fields[0])}
+			top := stack[len(stack)-1]
+			top.children = append(top.children, el)
+			leaf = ""
+			if !selfClosing {
+				stack = append(stack, el)
+			}
+		}
+	}
+	return root, nil
+}
@@ -0,0 +1,197 @@
+package statement
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ofxSGML is an OFX 1.x bank statement with empty leaves.
+const ofxSGML = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+CHARSET:1252
+
+<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0<SEVERITY>INFO</STATUS></SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1><STMTTRNRS><TRNUID>1<STMTRS>
+<CURDEF>eur
+<BANKACCTFROM><BANKID>10020030<ACCTID>12345<ACCTTYPE>CHECKING</BANKACCTFROM>
+<BANKTRANLIST>
+<DTSTART>20240301<DTEND>20240331
+<STMTTRN>
+<TRNTYPE>pos
+<DTPOSTED>20240315120000.000[-5:EST]
+<TRNAMT>-12.50
+<FITID>T1
+<MEMO>
+<NAME>Shop &amp; Co
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20240316
+<TRNAMT>+1000,00
+<FITID>T2
+<NAME>
+<MEMO>Salary
+<CHECKNUM>
+</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL><BALAMT>987.50<DTASOF>20240331</LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+// ofxXML is an OFX 2.x credit card statement.
+const ofxXML = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX>
+  <!-- comments are skipped -->
+  <CREDITCARDMSGSRSV1>
+    <CCSTMTTRNRS>
+      <CCSTMTRS>
+        <CURDEF>USD</CURDEF>
+        <CCACCTFROM><ACCTID>4111</ACCTID></CCACCTFROM>
+        <BANKTRANLIST>
+          <STMTTRN>
+            <TRNTYPE>DEBIT</TRNTYPE>
+            <DTPOSTED>20240102</DTPOSTED>
+            <TRNAMT>-40.00</TRNAMT>
+            <FITID>C1</FITID>
+            <PAYEE><NAME>Hotel</NAME><CITY>Paris</CITY></PAYEE>
+            <MEMO></MEMO>
+            <CURRENCY><CURRATE>1.1</CURRATE><CURSYM>eur</CURSYM></CURRENCY>
+          </STMTTRN>
+          <STMTTRN>
+            <TRNTYPE>DEBIT</TRNTYPE>
+            <DTPOSTED>20240103</DTPOSTED>
+            <TRNAMT>-5</TRNAMT>
+            <FITID>C2</FITID>
+            <NAME/>
+            <MEMO>Fee</MEMO>
+            <ORIGCURRENCY><CURRATE>0.9</CURRATE><CURSYM>GBP</CURSYM></ORIGCURRENCY>
+          </STMTTRN>
+          <STMTTRN>
+            <TRNTYPE>CREDIT</TRNTYPE>
+            <DTPOSTED>20240104</DTPOSTED>
+            <TRNAMT>40.00</TRNAMT>
+            <FITID>C3</FITID>
+            <NAME>Refund</NAME>
+          </STMTTRN>
+        </BANKTRANLIST>
+      </CCSTMTRS>
+    </CCSTMTTRNRS>
+  </CREDITCARDMSGSRSV1>
+</OFX>`
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseOFX(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want []Statement
+	}{
+		{
+			name: "SGML",
+			file: ofxSGML,
+			want: []Statement{{
+				Account:  "10020030/12345",
+				Currency: "EUR",
+				Transactions: []Transaction{
+					{ID: "T1", Type: "POS", Date: day(2024, 3, 15), Amount: -12.5, Currency: "EUR", Counterparty: "Shop & Co"},
+					{ID: "T2", Type: "CREDIT", Date: day(2024, 3, 16), Amount: 1000, Currency: "EUR", Memo: "Salary"},
+				},
+			}},
+		},
+		{
+			name: "XML",
+			file: ofxXML,
+			want: []Statement{{
+				Account:  "4111",
+				Currency: "USD",
+				Transactions: []Transaction{
+					{ID: "C1", Type: "DEBIT", Date: day(2024, 1, 2), Amount: -40, Currency: "EUR", Counterparty: "Hotel"},
+					{ID: "C2", Type: "DEBIT", Date: day(2024, 1, 3), Amount: -5, Currency: "USD", Memo: "Fee"},
+					{ID: "C3", Type: "CREDIT", Date: day(2024, 1, 4), Amount: 40, Currency: "USD", Counterparty: "Refund"},
+				},
+			}},
+		},
+		{
+			name: "no transactions",
+			file: `<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><CURDEF>EUR<BANKACCTFROM><ACCTID>1</BANKACCTFROM></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>`,
+			want: []Statement{{Account: "1", Currency: "EUR"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOFX(strings.NewReader(tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOFXWindows1252(t *testing.T) {
+	file := strings.Replace(ofxSGML, "Shop &amp; Co", "Caf\xe9", 1)
+	statements, err := ParseOFX(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := statements[0].Transactions[0].Counterparty; got != "Café" {
+		t.Errorf("counterparty %q", got)
+	}
+}
+
+func TestParseOFXErrors(t *testing.T) {
+	tests := []struct {
+		name, file, want string
+	}{
+		{"no root", "OFXHEADER:100\n", "no <OFX> element"},
+		{"unterminated tag", "<OFX><STMTRS", "unterminated tag"},
+		{"unterminated comment", "<OFX><!-- <STMTRS>", "unterminated comment"},
+		{"invalid amount", strings.Replace(ofxSGML, "-12.50", "-12.50.1", 1), `transaction "T1": invalid amount`},
+		{"invalid date", strings.Replace(ofxSGML, "20240316", "2024", 1), `transaction "T2": invalid date`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseOFX(strings.NewReader(tt.file))
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) || parseErr.Format != FormatOFX || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want a parse error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseElementsEmptyLeaves(t *testing.T) {
+	root, err := parseElements("<OFX><A><B><C>1<D></A><E>2</OFX>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names func(e *element) string
+	names = func(e *element) string {
+		s := e.name + e.value
+		if len(e.children) > 0 {
+			var children []string
+			for _, c := range e.children {
+				children = append(children, names(c))
+			}
+			s += "(" + strings.Join(children, " ") + ")"
+		}
+		return s
+	}
+	// B and D are empty leaves: their parent's end tag moves what was parsed
+	// into them up.
+	if got, want := names(root), "(OFX(A(B C1 D) E2))"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+// Package statement parses the statement files exported by banks into
+// normalized transactions, which service.ExpenseService imports as expenses.
+package statement
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Format names a statement file format.
+type Format string
+
+// Supported formats.
+const (
+	// FormatOFX is OFX 1.x (SGML) and 2.x (XML), including Quicken's QFX.
+	FormatOFX Format = "ofx"
+	// FormatCAMT053 is the ISO 20022 bank to customer statement (camt.053) XML.
+	FormatCAMT053 Format = "camt053"
+	// FormatMT940 is the SWIFT MT940 customer statement message.
+	FormatMT940 Format = "mt940"
+	// FormatLedger is the journal of ledger and hledger.
+	FormatLedger Format = "ledger"
+	// FormatBeancount is the input file of beancount.
+	FormatBeancount Format = "beancount"
+)
+
+// ErrUnknownFormat is returned by Parse for unsupported formats.
+var ErrUnknownFormat = errors.New("statement: unknown format")
+
+// ParseError reports a file that is not a valid statement of its format.
+type ParseError struct {
+	Format Format
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("statement: invalid %s file: %v", e.Format, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Statement is the list of transactions of one account.
+type Statement struct {
+	// Account identifies the account, e.g. "BANKID/ACCTID" for OFX bank
+	// accounts, the IBAN or the expense account of a journal.
+	Account string
+	// Currency is the ISO 4217 code of the account currency, if known.
+	Currency     string
+	Transactions []Transaction
+}
+
+// Transaction is one entry of a statement.
+type Transaction struct {
+	// ID is the reference the bank assigned to the transaction, unique
+	// within the account: FITID in OFX, AcctSvcrRef in camt.053 and the bank
+	// reference in MT940. Formats where it is optional get an ID derived from
+	// the transaction instead, see contentIDs; it is empty if an OFX file has none.
+	ID string
+	// Type is the transaction type given by the bank, e.g. "POS" in OFX,
+	// "DBIT" in camt.053 or "NTRF" in MT940.
+	Type string
+	// Date is the day the transaction was posted.
+	Date time.Time
+	// Amount is negative for debits (money leaving the account) and positive for credits.
+	Amount float64
+	// Currency is the ISO 4217 code of Amount.
+	Currency string
+	// Counterparty is the name of the payee of debits and the payer of credits.
+	Counterparty string
+	// Memo is the free text of the transaction, such as the remittance information.
+	Memo string
+	// Category and Tags are given by journals, which book expenses to
+	// categories themselves; they are empty for bank statements.
+	Category string
+	Tags     []string
+}
+
+// Parse reads the statements of a file in the given format. Files that are
+// not valid are reported with a *ParseError.
+func Parse(format Format, r io.Reader) ([]Statement, error) {
+	switch format {
+	case FormatOFX:
+		return ParseOFX(r)
+	case FormatCAMT053:
+		return ParseCAMT053(r)
+	case FormatMT940:
+		return ParseMT940(r)
+	case FormatLedger, FormatBeancount:
+		return ParseJournal(format, r)
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+}
+
+// parseAmount parses a signed decimal amount, accepting a comma as the
+// decimal separator. Exponents, NaN and infinities are rejected.
+func parseAmount(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	digits := strings.TrimLeft(s, "+-")
+	if len(s)-len(digits) > 1 || strings.Trim(digits, "0123456789.,") != "" {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	amount, err := strconv.ParseFloat(strings.Replace(strings.TrimPrefix(s, "+"), ",", ".", 1), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return amount, nil
+}
+
+// readText reads a text file, decoding it as Windows-1252 unless it is valid
+// UTF-8. Files declaring ISO-8859-1 are decoded the same way, since banks
+// sending them use the Windows superset.
+func readText(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("statement: can't read file: %w", err)
+	}
+	if utf8.Valid(data) {
+		return data, nil
+	}
+	decoded, err := charmap.Windows1252.NewDecoder().Bytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("statement: can't decode file: %w", err)
+	}
+	return decoded, nil
+}
+
+// contentIDs gives the transactions of st without an ID one derived from
+// their content. Identical transactions are numbered in file order, so that
+// importing the same file again yields the same IDs.
+func contentIDs(st *Statement) {
+	seen := map[string]int{}
+	for i := range st.Transactions {
+		t := &st.Transactions[i]
+		if t.ID != "" {
+			continue
+		}
+		sum := sha256.Sum256(fmt.Appendf(nil, "%s|%s|%s|%s|%s|%s",
+			t.Date.Format(time.DateOnly), strconv.FormatFloat(t.Amount, 'f', -1, 64), t.Currency, t.Type, t.Counterparty, t.Memo))
+		key := hex.EncodeToString(sum[:8])
+		seen[key]++
+		t.ID = fmt.Sprintf("content:%s:%d", key, seen[key])
+	}
+}
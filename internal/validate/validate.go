@@ -0,0 +1,348 @@
+// Package validate checks input structs against rules declared in their
+// `validate` struct tags and reports every invalid field at once.
+//
+// Rules are separated by commas and applied in order:
+//
+//	required      the value must not be empty (zero number, blank string, empty slice, zero date)
+//	omitempty     skip the remaining rules when the value is empty
+//	min=N, max=N  length of strings (in characters) and slices, value of numbers,
+//	              or a YYYY-MM-DD bound for model.Date values
+//	gt=N, lt=N    exclusive bounds for numbers
+//	oneof=a b c   the value must be one of the space-separated options
+//	url           the value must be an absolute http or https URL
+//	gtefield=F    the value must not be less than field F of the same struct
+//	dive          apply the remaining rules to every element of a slice
+//
+// Nil pointers are treated as absent and skip all rules, which makes every
+// pointer field optional; rules apply to the pointed-to value otherwise.
+// Nested structs are validated recursively; slices of structs only with dive.
+// Field names in reports are the JSON names, e.g. "operations[2].expense.amount".
+package validate
+
+import (
+	"expense_tracker/internal/model"
+	"expense_tracker/lib"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var dateType = reflect.TypeOf(model.Date{})
+
+// Struct validates v, which must be a struct or a pointer to one, and returns a
+// lib.ErrValidation error listing every invalid field, or nil. The error message
+// joins the field messages, so it stays informative where only the message is shown.
+func Struct(v any) error {
+	fields := Fields(v)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(fields))
+	for i, f := range fields {
+		messages[i] = f.Message
+	}
+	return lib.Validation(strings.Join(messages, "; "), fields...)
+}
+
+// Fields is like Struct but returns the field errors themselves.
+func Fields(v any) []lib.FieldError {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validate: %T is not a struct", v))
+	}
+
+	var c checker
+	c.walkStruct(rv, "")
+	return c.errs
+}
+
+type checker struct {
+	errs []lib.FieldError
+}
+
+func (c *checker) fail(field, code, format string, args ...any) {
+	c.errs = append(c.errs, lib.FieldError{
+		Field:   field,
+		Code:    code,
+		Message: field + " " + fmt.Sprintf(format, args...),
+	})
+}
+
+// walkStruct validates the fields of struct value rv whose path is prefix.
+func (c *checker) walkStruct(rv reflect.Value, prefix string) {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		// Embedded structs are flattened in JSON, so their fields keep the parent prefix.
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			c.walkStruct(rv.Field(i), prefix)
+			continue
+		}
+
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		c.check(rv, rv.Field(i), path, ParseTag(field.Tag.Get("validate")))
+	}
+}
+
+// check applies rules to value v of field path inside struct parent.
+func (c *checker) check(parent, v reflect.Value, path string, rules []Rule) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	for i, r := range rules {
+		switch r.Name {
+		case "omitempty":
+			if isEmpty(v) {
+				return
+			}
+		case "dive":
+			if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+				for j := 0; j < v.Len(); j++ {
+					c.check(parent, v.Index(j), fmt.Sprintf("%s[%d]", path, j), rules[i+1:])
+				}
+			}
+			return
+		default:
+			if !c.apply(parent, v, path, r) {
+				// Report only the first broken rule of a field.
+				return
+			}
+		}
+	}
+
+	if v.Kind() == reflect.Struct && v.Type() != dateType {
+		c.walkStruct(v, path)
+	}
+}
+
+// apply checks a single rule and reports whether the value passed it.
+func (c *checker) apply(parent, v reflect.Value, path string, r Rule) bool {
+	switch r.Name {
+	case "required":
+		if isEmpty(v) || v.Kind() == reflect.String && strings.TrimSpace(v.String()) == "" {
+			c.fail(path, "required", "is required")
+			return false
+		}
+
+	case "min", "max":
+		return c.bound(v, path, r)
+
+	case "gt", "lt":
+		n, ok := number(v)
+		limit, err := strconv.ParseFloat(r.Param, 64)
+		if !ok || err != nil {
+			panic(fmt.Sprintf("validate: %s=%s needs a number on %s", r.Name, r.Param, path))
+		}
+		switch {
+		case r.Name == "gt" && n <= limit && limit == 0:
+			c.fail(path, "positive", "must be positive")
+			return false
+		case r.Name == "gt" && n <= limit:
+			c.fail(path, "too_small", "must be greater than %s", r.Param)
+			return false
+		case r.Name == "lt" && n >= limit:
+			c.fail(path, "too_large", "must be less than %s", r.Param)
+			return false
+		}
+
+	case "oneof":
+		options := strings.Fields(r.Param)
+		if !slices.Contains(options, fmt.Sprint(v.Interface())) {
+			c.fail(path, "enum", "must be one of %s", strings.Join(options, ", "))
+			return false
+		}
+
+	case "url":
+		u, err := url.Parse(v.String())
+		if err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+			c.fail(path, "url", "must be an absolute http or https URL")
+			return false
+		}
+
+	case "gtefield":
+		other := parent.FieldByName(r.Param)
+		for other.IsValid() && other.Kind() == reflect.Pointer {
+			if other.IsNil() {
+				return true
+			}
+			other = other.Elem()
+		}
+		if !other.IsValid() {
+			panic(fmt.Sprintf("validate: unknown field %s in gtefield on %s", r.Param, path))
+		}
+		if v.Type() == dateType {
+			if v.Interface().(model.Date).Before(other.Interface().(model.Date).Time) {
+				c.fail(path, "date_range", "must not be before %s", sibling(path, jsonName(parent.Type(), r.Param)))
+				return false
+			}
+			return true
+		}
+		a, _ := number(v)
+		b, _ := number(other)
+		if a < b {
+			c.fail(path, "range", "must not be less than %s", sibling(path, jsonName(parent.Type(), r.Param)))
+			return false
+		}
+
+	default:
+		panic(fmt.Sprintf("validate: unknown rule %q on %s", r.Name, path))
+	}
+	return true
+}
+
+// bound checks a min or max rule against the length, value or date of v.
+func (c *checker) bound(v reflect.Value, path string, r Rule) bool {
+	isMin := r.Name == "min"
+
+	if v.Type() == dateType {
+		limit, err := time.Parse("2006-01-02", r.Param)
+		if err != nil {
+			panic(fmt.Sprintf("validate: %s=%s needs a YYYY-MM-DD date on %s", r.Name, r.Param, path))
+		}
+		d := v.Interface().(model.Date)
+		if isMin && d.Before(limit) {
+			c.fail(path, "date_range", "must not be before %s", r.Param)
+			return false
+		}
+		if !isMin && d.After(limit) {
+			c.fail(path, "date_range", "must not be after %s", r.Param)
+			return false
+		}
+		return true
+	}
+
+	limit, err := strconv.ParseFloat(r.Param, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validate: %s=%s needs a number on %s", r.Name, r.Param, path))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		n := float64(utf8.RuneCountInString(v.String()))
+		if isMin && n < limit {
+			c.fail(path, "min_length", "must be at least %s characters long", r.Param)
+			return false
+		}
+		if !isMin && n > limit {
+			c.fail(path, "max_length", "must be at most %s characters long", r.Param)
+			return false
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		n := float64(v.Len())
+		if isMin && n < limit {
+			c.fail(path, "min_items", "must have at least %s items", r.Param)
+			return false
+		}
+		if !isMin && n > limit {
+			c.fail(path, "max_items", "must have at most %s items", r.Param)
+			return false
+		}
+	default:
+		n, ok := number(v)
+		if !ok {
+			panic(fmt.Sprintf("validate: %s does not apply to %s", r.Name, path))
+		}
+		if isMin && n < limit {
+			c.fail(path, "too_small", "must be at least %s", r.Param)
+			return false
+		}
+		if !isMin && n > limit {
+			c.fail(path, "too_large", "must be at most %s", r.Param)
+			return false
+		}
+	}
+	return true
+}
+
+// Rule is one comma-separated element of a validate tag, such as max=40.
+type Rule struct {
+	Name, Param string
+}
+
+// ParseTag splits a validate tag into its rules.
+func ParseTag(tag string) []Rule {
+	if tag == "" {
+		return nil
+	}
+	var rules []Rule
+	for _, part := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(part, "=")
+		rules = append(rules, Rule{Name: name, Param: param})
+	}
+	return rules
+}
+
+// isEmpty reports whether v holds the zero value or an empty string, slice or map.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String, reflect.Array:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// number returns the value of a numeric v as a float64.
+func number(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// jsonName returns the JSON name of field name of struct type t.
+func jsonName(t reflect.Type, name string) string {
+	field, ok := t.FieldByName(name)
+	if !ok {
+		return name
+	}
+	if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" && tag != "-" {
+		return tag
+	}
+	return name
+}
+
+// sibling returns the path of the field named name next to the field at path.
+func sibling(path, name string) string {
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		return path[:i+1] + name
+	}
+	return name
+}
@@ -0,0 +1,288 @@
+package validate
+
+import (
+	"expense_tracker/internal/model"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// inputs holds a value of every model type with validate tags.
+var inputs = []any{
+	model.BatchOperation{},
+	model.BatchRequest{},
+	model.BulkUpdateInput{},
+	model.ExpenseFilter{},
+	model.Expense{},
+	model.UpdateExpenseInput{},
+	model.ExportOptions{},
+	model.GraphQLRequest{},
+	model.ImportOptions{},
+	model.LoginInput{},
+	model.UpdateUsernameInput{},
+	model.User{},
+	model.UpdateWebhookInput{},
+	model.WebhookInput{},
+}
+
+// TestModelTags checks the validate tags of the model, which would otherwise
+// only panic when a request reaches the broken rule.
+func TestModelTags(t *testing.T) {
+	checked := map[string]bool{}
+	for _, v := range inputs {
+		typ := reflect.TypeOf(v)
+		checked[typ.Name()] = true
+		for _, problem := range checkStruct(typ, typ.Name()) {
+			t.Error(problem)
+		}
+		// The zero value runs the rules that don't depend on a value.
+		Fields(v)
+	}
+
+	for _, name := range taggedModelTypes(t) {
+		if !checked[name] {
+			t.Errorf("model.%s has validate tags but is missing from inputs", name)
+		}
+	}
+}
+
+// taggedModelTypes returns the names of the model structs with validate tags.
+func taggedModelTypes(t *testing.T) []string {
+	t.Helper()
+	pkgs, err := parser.ParseDir(token.NewFileSet(), "../model", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, pkg := range pkgs {
+		ast.Inspect(pkg, func(n ast.Node) bool {
+			spec, ok := n.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+			if st, ok := spec.Type.(*ast.StructType); ok {
+				for _, field := range st.Fields.List {
+					if field.Tag != nil && strings.Contains(field.Tag.Value, `validate:"`) {
+						names = append(names, spec.Name.Name)
+						break
+					}
+				}
+			}
+			return false
+		})
+	}
+	return names
+}
+
+// checkStruct returns the rules of struct type typ and the structs it
+// contains that Fields would panic on.
+func checkStruct(typ reflect.Type, path string) []string {
+	var problems []string
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			problems = append(problems, checkStruct(field.Type, path)...)
+			continue
+		}
+		problems = append(problems, checkRules(typ, field.Type, path+"."+field.Name, ParseTag(field.Tag.Get("validate")))...)
+	}
+	return problems
+}
+
+// checkRules returns the problems of rules applied to a field of type typ in
+// struct parent.
+func checkRules(parent, typ reflect.Type, path string, rules []Rule) []string {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	_, isNumber := number(reflect.Zero(typ))
+
+	var problems []string
+	fail := func(r Rule, msg string) {
+		problems = append(problems, path+": "+r.Name+"="+r.Param+" "+msg)
+	}
+	for i, r := range rules {
+		switch r.Name {
+		case "required", "omitempty":
+		case "dive":
+			if typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+				fail(r, "needs a slice")
+				return problems
+			}
+			return append(problems, checkRules(parent, typ.Elem(), path+"[]", rules[i+1:])...)
+		case "min", "max":
+			if typ == dateType {
+				if _, err := time.Parse("2006-01-02", r.Param); err != nil {
+					fail(r, "needs a YYYY-MM-DD date")
+				}
+				continue
+			}
+			if _, err := strconv.ParseFloat(r.Param, 64); err != nil {
+				fail(r, "needs a number")
+			}
+			switch typ.Kind() {
+			case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+			default:
+				if !isNumber {
+					fail(r, "applies to "+typ.String())
+				}
+			}
+		case "gt", "lt":
+			if _, err := strconv.ParseFloat(r.Param, 64); err != nil || !isNumber {
+				fail(r, "needs a number and a numeric field")
+			}
+		case "oneof":
+			if len(strings.Fields(r.Param)) == 0 {
+				fail(r, "has no options")
+			}
+		case "url":
+			if typ.Kind() != reflect.String {
+				fail(r, "needs a string")
+			}
+		case "gtefield":
+			other, ok := parent.FieldByName(r.Param)
+			if !ok {
+				fail(r, "names an unknown field")
+				continue
+			}
+			for other.Type.Kind() == reflect.Pointer {
+				other.Type = other.Type.Elem()
+			}
+			if other.Type != typ {
+				fail(r, "compares "+typ.String()+" with "+other.Type.String())
+			}
+		default:
+			fail(r, "is unknown")
+		}
+	}
+	if typ.Kind() == reflect.Struct && typ != dateType {
+		problems = append(problems, checkStruct(typ, path)...)
+	}
+	return problems
+}
+
+func date(s string) model.Date {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return model.Date{Time: d}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestFields(t *testing.T) {
+	valid := model.Expense{Amount: 12.5, Category: "food", Date: date("2024-03-15"), Tags: []string{"lunch"}}
+
+	tests := []struct {
+		name  string
+		input any
+		// want lists the expected field and code pairs.
+		want []string
+	}{
+		{"valid", valid, nil},
+		{"nil pointer", (*model.Expense)(nil), nil},
+		{
+			name: "first broken rule only",
+			input: model.Expense{
+				Amount:   -1,
+				Category: "  ",
+				Date:     date("1899-12-31"),
+				Tags:     []string{"ok", "", strings.Repeat("x", 41)},
+			},
+			want: []string{"amount positive", "category required", "date date_range", "tags[1] required", "tags[2] max_length"},
+		},
+		{"date upper bound", model.Expense{Amount: 1, Category: "food", Date: date("2101-01-01")}, []string{"date date_range"}},
+		{"too many tags", model.Expense{Amount: 1, Category: "food", Date: date("2024-03-15"), Tags: make([]string, 21)}, []string{"tags max_items"}},
+		{"absent optional fields", model.UpdateExpenseInput{}, nil},
+		{
+			name:  "pointed-to values",
+			input: model.UpdateExpenseInput{Amount: ptr(0.0), Category: ptr(""), Tags: &[]string{""}},
+			want:  []string{"amount positive", "category required", "tags[0] required"},
+		},
+		{"dive into numbers", model.ExpenseFilter{IDs: []int{1, 0, -2}}, []string{"ids[1] positive", "ids[2] positive"}},
+		{
+			name:  "gtefield",
+			input: model.ExpenseFilter{Start: ptr(date("2024-03-15")), End: ptr(date("2024-03-14"))},
+			want:  []string{"end date_range"},
+		},
+		{"gtefield same day", model.ExpenseFilter{Start: ptr(date("2024-03-15")), End: ptr(date("2024-03-15"))}, nil},
+		{"gtefield without the other field", model.ExpenseFilter{End: ptr(date("2024-03-14"))}, nil},
+		{
+			name:  "nested struct",
+			input: model.BulkUpdateInput{Filter: model.ExpenseFilter{Category: ptr(strings.Repeat("x", 41))}, SetCategory: ptr("food")},
+			want:  []string{"filter.category max_length"},
+		},
+		{
+			name:  "dive into options",
+			input: model.WebhookInput{URL: "ftp://example.com", EventTypes: []model.EventType{model.EventExpenseCreated, "expense.viewed"}},
+			want:  []string{"url url", "event_types[1] enum"},
+		},
+		{"omitempty", model.ImportOptions{Format: "ofx", Category: "bank"}, nil},
+		{"omitempty with a value", model.ImportOptions{Format: "ofx", Category: "bank", Currency: "EURO"}, []string{"currency max_length"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, f := range Fields(tt.input) {
+				got = append(got, f.Field+" "+f.Code)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldsMessages(t *testing.T) {
+	fields := Fields(model.ExpenseFilter{Start: ptr(date("2024-03-15")), End: ptr(date("2024-03-14"))})
+	if len(fields) != 1 || fields[0].Message != "end must not be before start" {
+		t.Errorf("got %+v", fields)
+	}
+}
+
+func TestUnknownRulePanics(t *testing.T) {
+	for name, input := range map[string]any{
+		"unknown rule": struct {
+			A string `validate:"requird"`
+		}{},
+		"malformed number": struct {
+			A int `validate:"max=ten"`
+		}{1},
+		"malformed date": struct {
+			A model.Date `validate:"min=01/01/1900"`
+		}{date("2024-03-15")},
+		"unknown gtefield": struct {
+			A int `validate:"gtefield=B"`
+		}{},
+		"gt on a string": struct {
+			A string `validate:"gt=0"`
+		}{"a"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("no panic")
+				}
+			}()
+			Fields(input)
+		})
+	}
+	// The tag checker catches what the validator panics on.
+	if problems := checkStruct(reflect.TypeOf(struct {
+		A string `validate:"requird"`
+	}{}), "T"); len(problems) != 1 {
+		t.Errorf("checkStruct reported %q", problems)
+	}
+}
@@ -1,7 +1,6 @@
 package lib
 
 import (
-	"fmt"
 	"net/http"
 )
 
@@ -25,7 +24,7 @@ const UserIDkey = "userID"
 func GetUserIDFromContext(r *http.Request) (int, error) {
 	userID, ok := r.Context().Value(UserIDkey).(int)
 	if !ok {
-		return 0, fmt.Errorf("user ID not found in context: unauthorized")
+		return 0, Unauthorized("user ID not found in context: unauthorized")
 	}
 	return userID, nil
 }
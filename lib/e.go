@@ -3,23 +3,84 @@ package lib
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
 )
 
-// WriteJSONError sends a JSON-formatted error response to the client.
-// It sets the HTTP status code and returns a JSON object with an "error" field containing the message.
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// WriteJSONError sends an RFC 7807 application/problem+json response to the client.
+// It sets the HTTP status code and uses message as the problem detail.
 //
 // Parameters:
 // - w: the http.ResponseWriter to write the response.
 // - status: the HTTP status code to set in the response.
-// - message: the error message to include in the JSON response.
+// - message: the error message to include in the response.
 //
-// Example JSON response:
+// Example response:
 //
 //	{
-//	  "error": "description of the error"
+//	  "type": "about:blank",
+//	  "title": "Bad Request",
+//	  "status": 400,
+//	  "detail": "description of the error"
 //	}
 func WriteJSONError(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
+	writeProblem(w, Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: message,
+	})
+}
+
+// WriteError maps err to an HTTP status and sends it as an RFC 7807 problem.
+// Typed errors (see Error) expose only their client-safe message and field details;
+// any other error is logged and reported as a generic 500 Internal Server Error.
+//
+// Parameters:
+// - w: the http.ResponseWriter to write the response.
+// - r: the request being answered, used for the problem instance.
+// - err: the error returned by a service.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	e := AsError(err)
+	status := e.Kind.Status()
+
+	if e.Kind == KindInternal {
+		Logger(r.Context()).Error("internal error", "method", r.Method, "path", r.URL.Path, "error", err)
+	}
+
+	if e.RetryAfter > 0 {
+		SetRetryAfter(w, e.RetryAfter)
+	}
+
+	writeProblem(w, Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   e.Message,
+		Instance: r.URL.Path,
+		Code:     e.Kind.String(),
+		Errors:   e.Fields,
+	})
+}
+
+// SetRetryAfter sets the Retry-After header to d, rounded up to whole seconds.
+func SetRetryAfter(w http.ResponseWriter, d time.Duration) {
+	w.Header().Set("Retry-After", strconv.FormatInt(int64((d+time.Second-1)/time.Second), 10))
+}
+
+func writeProblem(w http.ResponseWriter, p Problem) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
 }
@@ -0,0 +1,159 @@
+package lib
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Kind classifies an application error and determines the HTTP status it maps to.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindValidation
+	KindNotFound
+	KindConflict
+	KindUnauthorized
+	KindTooLarge
+	KindTooManyRequests
+)
+
+// String returns the machine-readable code of the kind used in problem responses.
+func (k Kind) String() string {
+	switch k {
+	case KindValidation:
+		return "validation_failed"
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindUnauthorized:
+		return "unauthorized"
+	case KindTooLarge:
+		return "payload_too_large"
+	case KindTooManyRequests:
+		return "too_many_requests"
+	default:
+		return "internal"
+	}
+}
+
+// Status returns the HTTP status code for the kind.
+func (k Kind) Status() int {
+	switch k {
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case KindTooManyRequests:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// FieldError describes why a single input field is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error is a typed application error. Message is safe to show to clients,
+// while Err keeps the underlying cause for logs and is never sent over the wire.
+type Error struct {
+	Kind    Kind
+	Message string
+	Fields  []FieldError
+	// RetryAfter tells the client when to retry a throttled request.
+	RetryAfter time.Duration
+	Err        error
+}
+
+// Sentinel errors for use with errors.Is. Any *Error of the same kind matches them.
+var (
+	ErrInternal     = &Error{Kind: KindInternal, Message: "internal server error"}
+	ErrValidation   = &Error{Kind: KindValidation, Message: "validation failed"}
+	ErrNotFound     = &Error{Kind: KindNotFound, Message: "resource not found"}
+	ErrConflict     = &Error{Kind: KindConflict, Message: "resource already exists"}
+	ErrUnauthorized = &Error{Kind: KindUnauthorized, Message: "unauthorized"}
+	ErrTooLarge     = &Error{Kind: KindTooLarge, Message: "request body too large"}
+	ErrTooMany      = &Error{Kind: KindTooManyRequests, Message: "too many requests"}
+)
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the underlying cause.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an *Error of the same kind.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind
+}
+
+// NotFound returns an error reporting that the requested resource does not exist.
+func NotFound(message string) error {
+	return &Error{Kind: KindNotFound, Message: message}
+}
+
+// Conflict returns an error reporting that the request conflicts with existing data.
+func Conflict(message string) error {
+	return &Error{Kind: KindConflict, Message: message}
+}
+
+// Unauthorized returns an error reporting missing or invalid credentials.
+func Unauthorized(message string) error {
+	return &Error{Kind: KindUnauthorized, Message: message}
+}
+
+// TooLarge returns an error reporting that the request body exceeds the size limit.
+func TooLarge(message string) error {
+	return &Error{Kind: KindTooLarge, Message: message}
+}
+
+// TooManyRequests returns an error reporting that the client is throttled
+// and may retry after the given duration.
+func TooManyRequests(message string, retryAfter time.Duration) error {
+	return &Error{Kind: KindTooManyRequests, Message: message, RetryAfter: retryAfter}
+}
+
+// Validation returns an error reporting invalid input, optionally with per-field details.
+func Validation(message string, fields ...FieldError) error {
+	return &Error{Kind: KindValidation, Message: message, Fields: fields}
+}
+
+// Internal wraps an unexpected error. Its text is kept for logs only.
+func Internal(err error) error {
+	return &Error{Kind: KindInternal, Message: ErrInternal.Message, Err: err}
+}
+
+// AsError returns the first *Error in err's chain. Errors that are not typed
+// are treated as internal errors.
+func AsError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return &Error{Kind: KindInternal, Message: ErrInternal.Message, Err: err}
+}
+
+// PublicMessage returns the client-safe message of err.
+func PublicMessage(err error) string {
+	return AsError(err).Message
+}
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_expenses_tags;
+DROP INDEX IF EXISTS idx_expenses_user_date;
+
+ALTER TABLE expenses DROP COLUMN IF EXISTS tags;
@@ -0,0 +1,4 @@
+ALTER TABLE expenses ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_expenses_user_date ON expenses (user_id, date);
+CREATE INDEX idx_expenses_tags ON expenses USING GIN (tags);
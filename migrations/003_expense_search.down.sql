@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_expenses_search;
+
+ALTER TABLE expenses DROP COLUMN IF EXISTS search_vector;
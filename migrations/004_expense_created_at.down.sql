@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_expenses_created_at;
+
+ALTER TABLE expenses DROP COLUMN IF EXISTS created_at;
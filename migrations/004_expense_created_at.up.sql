@@ -0,0 +1,3 @@
+ALTER TABLE expenses ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX idx_expenses_created_at ON expenses (created_at);
@@ -0,0 +1,10 @@
+-- Transactions imported from bank statements, by the ID the bank gave them,
+-- so that importing a statement again skips them. Rows outlive the expenses
+-- they created: a deleted expense is not imported again.
+CREATE TABLE imported_transactions (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    account VARCHAR(100) NOT NULL,
+    external_id VARCHAR(255) NOT NULL,
+    imported_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, account, external_id)
+);
@@ -0,0 +1,15 @@
+// Package migrations embeds the schema migrations so the server binary can
+// apply them without access to the source tree.
+package migrations
+
+import "embed"
+
+// FS contains the PostgreSQL *.up.sql and *.down.sql files in golang-migrate naming format.
+//
+//go:embed *.sql
+var FS embed.FS
+
+// SQLiteFS contains the SQLite migrations in its "sqlite" directory.
+//
+//go:embed sqlite/*.sql
+var SQLiteFS embed.FS
@@ -0,0 +1,22 @@
+-- SQLite has no DECIMAL, DATE or array types: amounts are stored in cents,
+-- dates as YYYY-MM-DD text and tags as a JSON array. The CHECK constraints
+-- mirror the column limits of the PostgreSQL schema.
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username TEXT NOT NULL UNIQUE CHECK (length(username) <= 40),
+    password TEXT NOT NULL CHECK (length(password) <= 60)
+);
+
+CREATE TABLE expenses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount_cents INTEGER NOT NULL CHECK (amount_cents > 0 AND amount_cents < 10000000000),
+    category TEXT NOT NULL CHECK (length(category) <= 40),
+    description TEXT NOT NULL DEFAULT '',
+    date TEXT NOT NULL DEFAULT (date('now')),
+    tags TEXT NOT NULL DEFAULT '[]' CHECK (json_type(tags) = 'array'),
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+CREATE INDEX idx_expenses_user_date ON expenses (user_id, date);
+CREATE INDEX idx_expenses_created_at ON expenses (created_at);
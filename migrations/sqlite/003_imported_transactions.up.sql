@@ -0,0 +1,8 @@
+-- See the PostgreSQL migration.
+CREATE TABLE imported_transactions (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    account TEXT NOT NULL CHECK (length(account) <= 100),
+    external_id TEXT NOT NULL CHECK (length(external_id) <= 255),
+    imported_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    PRIMARY KEY (user_id, account, external_id)
+);
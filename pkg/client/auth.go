@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"net/http"
+	"time"
+)
+
+// Register creates a user account and returns its profile. It does not log in.
+func (c *Client) Register(ctx context.Context, username, password string) (*Profile, error) {
+	var profile Profile
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/auth/register",
+		body:   model.User{Username: username, Password: password},
+		out:    &profile,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// Login authenticates the user, stores the access token for later calls and
+// returns it. The credentials are kept so the token can be renewed when it expires.
+func (c *Client) Login(ctx context.Context, username, password string) (string, error) {
+	var resp struct {
+		Token string `json:"token"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/auth/login",
+		body:   model.LoginInput{Username: username, Password: password},
+		out:    &resp,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	c.setToken(resp.Token)
+	c.mu.Lock()
+	c.username, c.password = username, password
+	c.mu.Unlock()
+	return resp.Token, nil
+}
+
+// Logout forgets the access token and the stored credentials.
+func (c *Client) Logout() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token, c.expires = "", time.Time{}
+	c.username, c.password = "", ""
+}
@@ -0,0 +1,334 @@
+// Package client is a typed Go client for the expense tracker HTTP API.
+//
+// A Client covers the auth, user and expense endpoints. Every call takes a
+// context, failed idempotent calls are retried with exponential backoff, and
+// error responses are returned as *Error values that match the lib sentinel
+// errors with errors.Is:
+//
+//	c := client.New("http://localhost:8080", client.WithCredentials("alice", "secret"))
+//	expense, err := c.GetExpense(ctx, 42)
+//	if errors.Is(err, lib.ErrNotFound) {
+//		...
+//	}
+//
+// With WithCredentials the client logs in on the first call and logs in again
+// shortly before the access token expires or when the server rejects it.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Defaults used by New.
+const (
+	DefaultTimeout    = 30 * time.Second
+	DefaultMaxRetries = 3
+	DefaultMinBackoff = 200 * time.Millisecond
+	DefaultMaxBackoff = 5 * time.Second
+
+	// refreshBefore is how long before its expiry a token is renewed.
+	refreshBefore = 30 * time.Second
+	// maxRetryAfter caps how long a Retry-After header makes the client wait.
+	maxRetryAfter = time.Minute
+)
+
+// Client calls the expense tracker API. It is safe for concurrent use.
+type Client struct {
+	base       string
+	http       *http.Client
+	userAgent  string
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	mu       sync.Mutex
+	token    string
+	expires  time.Time
+	username string
+	password string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithToken sets the access token sent with authenticated requests.
+func WithToken(token string) Option {
+	return func(c *Client) { c.setToken(token) }
+}
+
+// WithCredentials makes the client log in with username and password when
+// it has no valid token and whenever the token expires.
+func WithCredentials(username, password string) Option {
+	return func(c *Client) { c.username, c.password = username, password }
+}
+
+// WithRetry sets how many times a failed idempotent call is retried and the
+// bounds of the exponential backoff between attempts. maxRetries 0 disables retries.
+func WithRetry(maxRetries int, minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries, c.minBackoff, c.maxBackoff = maxRetries, minBackoff, maxBackoff
+	}
+}
+
+// WithUserAgent sets the User-Agent header of every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.userAgent = userAgent }
+}
+
+// New returns a client for the API served at baseURL, e.g. "https://expenses.example.com".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		base:       strings.TrimRight(baseURL, "/"),
+		http:       &http.Client{Timeout: DefaultTimeout},
+		userAgent:  "expense-tracker-go-client",
+		maxRetries: DefaultMaxRetries,
+		minBackoff: DefaultMinBackoff,
+		maxBackoff: DefaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Token returns the current access token, or "" if the client has none.
+func (c *Client) Token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+// setToken stores token and its expiry. The expiry is read from the
+// unverified "exp" claim; the server remains the one validating tokens.
+func (c *Client) setToken(token string) {
+	var expires time.Time
+	if t, _, err := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{}); err == nil {
+		if exp, ok := t.Claims.(jwt.MapClaims)["exp"].(float64); ok {
+			expires = time.Unix(int64(exp), 0)
+		}
+	}
+
+	c.mu.Lock()
+	c.token, c.expires = token, expires
+	c.mu.Unlock()
+}
+
+// authToken returns a token for an authenticated request, logging in first if
+// the client has credentials and no token or one that is about to expire.
+func (c *Client) authToken(ctx context.Context, force bool) (string, error) {
+	c.mu.Lock()
+	token, expires, username, password := c.token, c.expires, c.username, c.password
+	c.mu.Unlock()
+
+	stale := token == "" || (!expires.IsZero() && time.Until(expires) < refreshBefore)
+	if username == "" || (!force && !stale) {
+		return token, nil
+	}
+	if _, err := c.Login(ctx, username, password); err != nil {
+		return "", &refreshError{err: err}
+	}
+	return c.Token(), nil
+}
+
+// request describes a single API call.
+type request struct {
+	method string
+	path   string
+	body   any
+	// auth sends the access token and enables token refresh.
+	auth bool
+	// out receives the decoded JSON response; nil discards it.
+	out any
+	// accept lists non-2xx statuses whose JSON body is decoded into out
+	// instead of being reported as an error.
+	accept []int
+}
+
+// do sends req, retrying it as described in the package documentation.
+func (c *Client) do(ctx context.Context, req request) error {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return fmt.Errorf("client: can't encode request: %w", err)
+		}
+	}
+
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := c.send(ctx, req, body, refreshed)
+
+		// A failed login is final, a rejected token is renewed once if the
+		// client can log in again.
+		var rErr *refreshError
+		if errors.As(err, &rErr) {
+			return err
+		}
+		var apiErr *Error
+		if req.auth && !refreshed && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized && c.hasCredentials() {
+			refreshed = true
+			attempt--
+			continue
+		}
+
+		if err == nil || attempt >= c.maxRetries || !c.retryable(req.method, err) {
+			return err
+		}
+
+		delay := c.backoff(attempt)
+		if retryAfter > 0 {
+			delay = min(retryAfter, maxRetryAfter)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// send performs one attempt of req. forceRefresh renews the token before sending.
+func (c *Client) send(ctx context.Context, req request, body []byte, forceRefresh bool) (retryAfter time.Duration, err error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, c.base+req.path, reader)
+	if err != nil {
+		return 0, fmt.Errorf("client: can't create request: %w", err)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if req.auth {
+		token, err := c.authToken(ctx, forceRefresh)
+		if err != nil {
+			return 0, err
+		}
+		if token != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return 0, &transportError{err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 && !accepted(resp.StatusCode, req.accept) {
+		apiErr := decodeError(resp)
+		return apiErr.RetryAfter, apiErr
+	}
+
+	if req.out == nil || resp.StatusCode == http.StatusNoContent {
+		io.Copy(io.Discard, resp.Body)
+		return 0, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(req.out); err != nil {
+		return 0, fmt.Errorf("client: can't decode response: %w", err)
+	}
+	return 0, nil
+}
+
+func (c *Client) hasCredentials() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.username != ""
+}
+
+// retryable reports whether a failed attempt may be repeated. Throttled
+// requests were not processed and are always retried; network errors and
+// gateway failures only for idempotent methods.
+func (c *Client) retryable(method string, err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests:
+			return true
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return idempotent(method)
+		}
+		return false
+	}
+	var tErr *transportError
+	return errors.As(err, &tErr) && idempotent(method)
+}
+
+// backoff returns the delay before retry attempt+1: exponential with full jitter.
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.maxBackoff
+	if attempt < 30 {
+		d = min(c.minBackoff<<attempt, c.maxBackoff)
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func accepted(status int, statuses []int) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// transportError wraps a failure to get any response from the server.
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string { return "client: " + e.err.Error() }
+
+func (e *transportError) Unwrap() error { return e.err }
+
+// refreshError wraps a failure to log in again with the stored credentials.
+type refreshError struct {
+	err error
+}
+
+func (e *refreshError) Error() string { return "client: can't refresh token: " + e.err.Error() }
+
+func (e *refreshError) Unwrap() error { return e.err }
+
+// parseRetryAfter reads a Retry-After header given in seconds.
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
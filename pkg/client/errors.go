@@ -0,0 +1,83 @@
+package client
+
+import (
+	"encoding/json"
+	"expense_tracker/lib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Error is an error response of the API, decoded from the RFC 7807 problem
+// written by lib.WriteError and lib.WriteJSONError.
+//
+// errors.Is matches an *Error against the lib sentinel errors of its kind,
+// e.g. errors.Is(err, lib.ErrNotFound) for a 404.
+type Error struct {
+	StatusCode int
+	lib.Problem
+	// RetryAfter is the delay requested by a 429 response.
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	msg := e.Detail
+	if msg == "" {
+		msg = e.Title
+	}
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("client: %s (HTTP %d)", msg, e.StatusCode)
+}
+
+// Kind classifies the error like the server does. Responses without a problem
+// code, such as those of lib.WriteJSONError, are classified by status.
+func (e *Error) Kind() lib.Kind {
+	for _, k := range []lib.Kind{
+		lib.KindValidation, lib.KindNotFound, lib.KindConflict, lib.KindUnauthorized,
+		lib.KindTooLarge, lib.KindTooManyRequests, lib.KindInternal,
+	} {
+		if e.Code == k.String() || (e.Code == "" && e.StatusCode == k.Status()) {
+			return k
+		}
+	}
+	if e.StatusCode >= 400 && e.StatusCode < 500 {
+		return lib.KindValidation
+	}
+	return lib.KindInternal
+}
+
+// Is reports whether target is a lib.Error of the same kind.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*lib.Error)
+	return ok && t.Kind == e.Kind()
+}
+
+// decodeError builds an *Error from a failed response. A body that is not a
+// problem document is kept as the detail.
+func decodeError(resp *http.Response) *Error {
+	e := &Error{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	contentType := resp.Header.Get("Content-Type")
+	if strings.HasPrefix(contentType, "application/problem+json") || strings.HasPrefix(contentType, "application/json") {
+		if json.Unmarshal(data, &e.Problem) == nil && e.Status != 0 {
+			return e
+		}
+	}
+
+	e.Problem = lib.Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(resp.StatusCode),
+		Status: resp.StatusCode,
+		Detail: strings.TrimSpace(string(data)),
+	}
+	return e
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// CreateExpense creates an expense for the authenticated user and returns it with its ID.
+func (c *Client) CreateExpense(ctx context.Context, expense Expense) (*Expense, error) {
+	var created Expense
+	err := c.do(ctx, request{method: http.MethodPost, path: "/expenses", body: expense, auth: true, out: &created})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetExpense returns the expense with the given ID.
+func (c *Client) GetExpense(ctx context.Context, id int) (*Expense, error) {
+	var expense Expense
+	err := c.do(ctx, request{method: http.MethodGet, path: expensePath(id), auth: true, out: &expense})
+	if err != nil {
+		return nil, err
+	}
+	return &expense, nil
+}
+
+// UpdateExpense changes the fields set in input and returns the updated expense.
+func (c *Client) UpdateExpense(ctx context.Context, id int, input UpdateExpenseInput) (*Expense, error) {
+	var updated Expense
+	err := c.do(ctx, request{method: http.MethodPut, path: expensePath(id), body: input, auth: true, out: &updated})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteExpense deletes the expense with the given ID.
+func (c *Client) DeleteExpense(ctx context.Context, id int) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: expensePath(id), auth: true})
+}
+
+// ListExpenses returns all expenses of the authenticated user.
+func (c *Client) ListExpenses(ctx context.Context) ([]Expense, error) {
+	return c.listExpenses(ctx, "/expenses")
+}
+
+// ListExpensesByPeriod returns the expenses dated from start to end inclusive.
+func (c *Client) ListExpensesByPeriod(ctx context.Context, start, end time.Time) ([]Expense, error) {
+	q := url.Values{"start": {start.Format(time.DateOnly)}, "end": {end.Format(time.DateOnly)}}
+	return c.listExpenses(ctx, "/expenses/period?"+q.Encode())
+}
+
+// ListExpensesByCategory returns the expenses of a category.
+func (c *Client) ListExpensesByCategory(ctx context.Context, category string) ([]Expense, error) {
+	return c.listExpenses(ctx, "/expenses/category?"+url.Values{"category": {category}}.Encode())
+}
+
+func (c *Client) listExpenses(ctx context.Context, path string) ([]Expense, error) {
+	var expenses []Expense
+	if err := c.do(ctx, request{method: http.MethodGet, path: path, auth: true, out: &expenses}); err != nil {
+		return nil, err
+	}
+	return expenses, nil
+}
+
+// SearchExpenses full-text searches the authenticated user's expenses and
+// returns the matches ordered by relevance.
+func (c *Client) SearchExpenses(ctx context.Context, query string, opts SearchOptions) ([]SearchResult, error) {
+	q := url.Values{"q": {query}}
+	if opts.Category != "" {
+		q.Set("category", opts.Category)
+	}
+	if opts.Start != nil {
+		q.Set("start", opts.Start.Format(time.DateOnly))
+	}
+	if opts.End != nil {
+		q.Set("end", opts.End.Format(time.DateOnly))
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		q.Set("offset", strconv.Itoa(opts.Offset))
+	}
+
+	var results []SearchResult
+	err := c.do(ctx, request{method: http.MethodGet, path: "/expenses/search?" + q.Encode(), auth: true, out: &results})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Batch runs create, update and delete operations in one request. A batch in
+// which some operations failed is not an error: inspect the Failed count and
+// the per-operation results of the response.
+func (c *Client) Batch(ctx context.Context, req BatchRequest) (*BatchResponse, error) {
+	var resp BatchResponse
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/expenses/batch",
+		body:   req,
+		auth:   true,
+		out:    &resp,
+		accept: []int{http.StatusMultiStatus, http.StatusUnprocessableEntity},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// BulkUpdate recategorizes and/or retags every expense matching input.Filter
+// and returns the number of changed expenses.
+func (c *Client) BulkUpdate(ctx context.Context, input BulkUpdateInput) (int64, error) {
+	var result BulkUpdateResult
+	err := c.do(ctx, request{method: http.MethodPost, path: "/expenses/bulk-update", body: input, auth: true, out: &result})
+	if err != nil {
+		return 0, err
+	}
+	return result.Updated, nil
+}
+
+func expensePath(id int) string {
+	return "/expenses/" + strconv.Itoa(id)
+}
@@ -0,0 +1,46 @@
+package client
+
+import "expense_tracker/internal/model"
+
+// The client exchanges the server's own model types, so requests and
+// responses always match the API.
+type (
+	Expense            = model.Expense
+	UpdateExpenseInput = model.UpdateExpenseInput
+	Date               = model.Date
+	User               = model.User
+	SearchResult       = model.SearchResult
+	BatchMode          = model.BatchMode
+	BatchOp            = model.BatchOp
+	BatchOperation     = model.BatchOperation
+	BatchRequest       = model.BatchRequest
+	BatchResult        = model.BatchResult
+	BatchResponse      = model.BatchResponse
+	ExpenseFilter      = model.ExpenseFilter
+	BulkUpdateInput    = model.BulkUpdateInput
+	BulkUpdateResult   = model.BulkUpdateResult
+)
+
+// Batch modes and operations, see BatchRequest.
+const (
+	BatchAtomic     = model.BatchAtomic
+	BatchBestEffort = model.BatchBestEffort
+	BatchCreate     = model.BatchCreate
+	BatchUpdate     = model.BatchUpdate
+	BatchDelete     = model.BatchDelete
+)
+
+// Profile is the public part of a user account.
+type Profile struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+// SearchOptions narrows a full-text search. Zero fields are ignored.
+type SearchOptions struct {
+	Category string
+	Start    *Date
+	End      *Date
+	Limit    int
+	Offset   int
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"expense_tracker/internal/model"
+	"net/http"
+)
+
+// Profile returns the authenticated user's profile.
+func (c *Client) Profile(ctx context.Context) (*Profile, error) {
+	var profile Profile
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/user", auth: true, out: &profile}); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// UpdateUsername renames the authenticated user. Stored credentials follow
+// the new name so the token can still be renewed.
+func (c *Client) UpdateUsername(ctx context.Context, username string) (*Profile, error) {
+	var user User
+	err := c.do(ctx, request{
+		method: http.MethodPut,
+		path:   "/user/username",
+		body:   model.UpdateUsernameInput{Username: username},
+		auth:   true,
+		out:    &user,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.username != "" {
+		c.username = user.Username
+	}
+	c.mu.Unlock()
+	return &Profile{ID: user.ID, Username: user.Username}, nil
+}
+
+// DeleteUser deletes the authenticated user and all their expenses, then logs out.
+func (c *Client) DeleteUser(ctx context.Context) error {
+	if err := c.do(ctx, request{method: http.MethodDelete, path: "/user", auth: true}); err != nil {
+		return err
+	}
+	c.Logout()
+	return nil
+}
@@ -0,0 +1,88 @@
+// Package webhook signs and verifies the webhook deliveries of the expense
+// tracker.
+//
+// Every delivery is a POST whose body is an Event as JSON. The body is signed
+// with the secret of the webhook together with the time it was sent, and
+// receivers should check both with Verify before trusting it:
+//
+//	func receive(w http.ResponseWriter, r *http.Request) {
+//		body, _ := io.ReadAll(r.Body)
+//		if err := webhook.Verify(secret, r.Header, body, 5*time.Minute); err != nil {
+//			http.Error(w, err.Error(), http.StatusUnauthorized)
+//			return
+//		}
+//		var event webhook.Event
+//		json.Unmarshal(body, &event)
+//		...
+//	}
+//
+// Deliveries answered with a status other than 2xx are retried, so receivers
+// should use the delivery ID to ignore the ones they have processed already.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"expense_tracker/internal/model"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is the body of a delivery.
+type Event = model.ExpenseEvent
+
+// Headers of a delivery.
+const (
+	// HeaderEvent is the event type, e.g. "expense.created".
+	HeaderEvent = "X-Expense-Event"
+	// HeaderDelivery is the ID of the delivery; redeliveries get a new one.
+	HeaderDelivery = "X-Expense-Delivery"
+	// HeaderTimestamp is the time the delivery was sent, in Unix seconds.
+	HeaderTimestamp = "X-Expense-Timestamp"
+	// HeaderSignature is the signature computed by Sign.
+	HeaderSignature = "X-Expense-Signature"
+)
+
+// signaturePrefix names the algorithm of a signature.
+const signaturePrefix = "sha256="
+
+// Errors returned by Verify.
+var (
+	ErrMissingSignature = errors.New("webhook: missing signature or timestamp")
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+	ErrExpired          = errors.New("webhook: timestamp outside the tolerance")
+)
+
+// Sign returns the signature of a payload sent at timestamp: "sha256=" and the
+// hex-encoded HMAC-SHA256 of the timestamp, a dot and the payload, keyed with
+// secret.
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of a delivery with the given headers and body.
+// It also rejects deliveries sent more than tolerance before or after now,
+// which keeps captured deliveries from being replayed later.
+func Verify(secret string, header http.Header, payload []byte, tolerance time.Duration) error {
+	signature := header.Get(HeaderSignature)
+	timestamp, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if signature == "" || err != nil {
+		return ErrMissingSignature
+	}
+	if !strings.HasPrefix(signature, signaturePrefix) ||
+		!hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, payload))) {
+		return ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age > tolerance || age < -tolerance {
+		return ErrExpired
+	}
+	return nil
+}